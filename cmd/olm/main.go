@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"net/http"
@@ -45,11 +46,57 @@ var (
 	wakeupInterval = flag.Duration(
 		"interval", defaultWakeupInterval, "wake up interval")
 
+	requeueJitter = flag.Float64(
+		"requeueJitter", olm.DefaultRequeueJitter, "maximum fraction of a CSV requeue delay to add as random jitter")
+
 	watchedNamespaces = flag.String(
 		"watchedNamespaces", "", "comma separated list of namespaces for alm operator to watch. "+
 			"If not set, or set to the empty string (e.g. `-watchedNamespaces=\"\"`), "+
 			"alm operator will watch all namespaces in the cluster.")
 
+	requirementsQPS = flag.Float64(
+		"requirementsQPS", 0, "maximum queries per second made by CSV requirement checks, which use their own client. "+
+			"If not set, requirement checks share the operator's client.")
+
+	requirementsBurst = flag.Int(
+		"requirementsBurst", 0, "maximum burst of queries made by CSV requirement checks above requirementsQPS. "+
+			"If not set, requirement checks share the operator's client.")
+
+	requirementUpdateTimes = flag.Bool(
+		"requirementUpdateTimes", false, "record the time of every requirement check in CSV requirement statuses, "+
+			"rather than only the time each requirement's status last changed.")
+
+	patchRequirementStatus = flag.Bool(
+		"patchRequirementStatus", false, "write requirement rechecks that don't change a CSV's phase as a JSON patch "+
+			"of the changed requirement statuses, rather than updating the whole status, to avoid conflicts with concurrent updates.")
+
+	apiServiceAvailabilityAttempts = flag.Int(
+		"apiServiceAvailabilityAttempts", 1, "number of times a requirement check looks at an unavailable APIService "+
+			"before reporting it NotPresent, to ride out APIService rollouts.")
+
+	apiServiceAvailabilityInterval = flag.Duration(
+		"apiServiceAvailabilityInterval", time.Second, "time a requirement check waits between looks at an unavailable APIService.")
+
+	subjectAccessReviewFallback = flag.Bool(
+		"subjectAccessReviewFallback", false, "recheck permissions that OLM's RBAC caches find missing with a "+
+			"SubjectAccessReview, so that newly granted permissions are seen at once at the cost of more API requests.")
+
+	requirementSweepInterval = flag.Duration(
+		"requirementSweepInterval", 0, "interval at which every CSV is re-evaluated, regardless of events, to catch "+
+			"changes OLM's informers missed. If not set, CSVs are only re-evaluated on events and informer resyncs.")
+
+	requirementSweepJitter = flag.Float64(
+		"requirementSweepJitter", olm.DefaultRequirementSweepJitter, "maximum fraction of requirementSweepInterval to add as random jitter before each sweep")
+
+	namespaceApproval = flag.String(
+		"namespaceApproval", "", "label or annotation, as key or key=value, that a CSV's namespace must carry for its "+
+			"requirements to be met. If not set, CSVs may be installed into any namespace.")
+
+	debugEndpoints = flag.Bool(
+		"debugEndpoints", false, "serve requirement traces and on-demand requirement reports under /debug on the "+
+			"metrics port. The endpoints are unauthenticated, expose requirement details, and evaluate requirements "+
+			"against the cluster, so only enable them where the port isn't reachable by untrusted clients.")
+
 	debug = flag.Bool(
 		"debug", false, "use debug log level")
 
@@ -104,22 +151,53 @@ func main() {
 	opClient := operatorclient.NewClientFromConfig(*kubeConfigPath)
 
 	// Create a new instance of the operator.
-	operator, err := olm.NewOperator(crClient, opClient, &install.StrategyResolver{}, *wakeupInterval, annotation, namespaces)
+	operator, err := olm.NewOperator(crClient, opClient, &install.StrategyResolver{}, *wakeupInterval, *requeueJitter, annotation, namespaces)
 
 	if err != nil {
 		log.Fatalf("error configuring operator: %s", err.Error())
 	}
 	defer operator.Cleanup()
 
+	// Give requirement checks, which are discovery heavy, their own client-side rate limit if one is configured.
+	if *requirementsQPS > 0 || *requirementsBurst > 0 {
+		operator.SetRequirementsClient(operatorclient.NewClientFromConfigWithRateLimit(*kubeConfigPath, float32(*requirementsQPS), *requirementsBurst))
+	}
+	operator.SetRecordRequirementUpdateTimes(*requirementUpdateTimes)
+	operator.SetPatchRequirementStatus(*patchRequirementStatus)
+	operator.SetAPIServiceAvailabilityPoll(*apiServiceAvailabilityAttempts, *apiServiceAvailabilityInterval)
+	operator.SetSubjectAccessReviewFallback(*subjectAccessReviewFallback)
+	operator.SetRequirementSweep(*requirementSweepInterval, *requirementSweepJitter)
+	// Hold CSVs in unapproved namespaces at Pending if namespace approval is configured.
+	if *namespaceApproval != "" {
+		key, value := *namespaceApproval, ""
+		if i := strings.Index(key, "="); i >= 0 {
+			key, value = key[:i], key[i+1:]
+		}
+		operator.RegisterRequirementCheck(olm.NewNamespaceApprovalCheck(opClient, key, value))
+	}
+
 	// Serve a health check.
 	http.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	})
+	if *debugEndpoints {
+		// Serve the recorded requirement checks of CSVs that have opted in to tracing.
+		http.HandleFunc("/debug/requirements-trace", func(w http.ResponseWriter, r *http.Request) {
+			trace := operator.RequirementsTrace(r.URL.Query().Get("namespace"), r.URL.Query().Get("name"))
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(trace); err != nil {
+				log.Warnf("error writing requirements trace: %s", err)
+			}
+		})
+		// Serve the current requirement status of a CSV, evaluated on demand.
+		http.Handle("/debug/requirements", operator.RequirementsHandler())
+	}
 	// TODO: both of the following require vendor updates (add k8s.io/apiserver and update prometheus)
 	//healthz.InstallHandler(mux) //(less code)
 	//mux.Handle("/metrics", promhttp.Handler()) //other form is deprecated
 	http.Handle("/metrics", prometheus.Handler())
 	go http.ListenAndServe(":8080", nil)
 
+	go operator.RunRequirementSweep(stopCh)
 	operator.Run(stopCh)
 }
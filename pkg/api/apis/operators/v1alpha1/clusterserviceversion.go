@@ -45,6 +45,33 @@ func (c *ClusterServiceVersion) SetRequirementStatus(statuses []RequirementStatu
 	c.Status.RequirementStatus = statuses
 }
 
+// SetStatusCondition adds or updates a status condition, using Type as the merge key. The condition's
+// LastTransitionTime is set to now if it's new or its status changed, and kept otherwise.
+func (c *ClusterServiceVersion) SetStatusCondition(cond ClusterServiceVersionStatusCondition, now metav1.Time) {
+	cond.LastTransitionTime = now
+	for i, existing := range c.Status.StatusConditions {
+		if existing.Type != cond.Type {
+			continue
+		}
+		if existing.Status == cond.Status {
+			cond.LastTransitionTime = existing.LastTransitionTime
+		}
+		c.Status.StatusConditions[i] = cond
+		return
+	}
+	c.Status.StatusConditions = append(c.Status.StatusConditions, cond)
+}
+
+// GetStatusCondition returns the status condition of the given type, or nil if the CSV has none
+func (c *ClusterServiceVersion) GetStatusCondition(condType ClusterServiceVersionConditionType) *ClusterServiceVersionStatusCondition {
+	for i := range c.Status.StatusConditions {
+		if c.Status.StatusConditions[i].Type == condType {
+			return &c.Status.StatusConditions[i]
+		}
+	}
+	return nil
+}
+
 // IsObsolete returns if this CSV is being replaced or is marked for deletion
 func (c *ClusterServiceVersion) IsObsolete() bool {
 	for _, condition := range c.Status.Conditions {
@@ -2,8 +2,11 @@ package v1alpha1
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestSetRequirementStatus(t *testing.T) {
@@ -101,3 +104,67 @@ func TestIsObsolete(t *testing.T) {
 		})
 	}
 }
+
+func TestSetStatusCondition(t *testing.T) {
+	times := []metav1.Time{metav1.NewTime(time.Unix(100, 0)), metav1.NewTime(time.Unix(200, 0)), metav1.NewTime(time.Unix(300, 0))}
+
+	requirementsMet := func(status corev1.ConditionStatus, message string) ClusterServiceVersionStatusCondition {
+		return ClusterServiceVersionStatusCondition{Type: CSVConditionRequirementsMet, Status: status, Reason: CSVReasonRequirementsMet, Message: message}
+	}
+
+	tests := []struct {
+		description string
+		existing    []ClusterServiceVersionStatusCondition
+		in          ClusterServiceVersionStatusCondition
+		expected    []ClusterServiceVersionStatusCondition
+	}{
+		{
+			description: "New",
+			in:          requirementsMet(corev1.ConditionFalse, "unmet"),
+			expected: []ClusterServiceVersionStatusCondition{
+				{Type: CSVConditionRequirementsMet, Status: corev1.ConditionFalse, Reason: CSVReasonRequirementsMet, Message: "unmet", LastTransitionTime: times[2]},
+			},
+		},
+		{
+			description: "SameStatus",
+			existing: []ClusterServiceVersionStatusCondition{
+				{Type: CSVConditionRequirementsMet, Status: corev1.ConditionFalse, Message: "unmet", LastTransitionTime: times[0]},
+			},
+			in: requirementsMet(corev1.ConditionFalse, "still unmet"),
+			expected: []ClusterServiceVersionStatusCondition{
+				{Type: CSVConditionRequirementsMet, Status: corev1.ConditionFalse, Reason: CSVReasonRequirementsMet, Message: "still unmet", LastTransitionTime: times[0]},
+			},
+		},
+		{
+			description: "ChangedStatus",
+			existing: []ClusterServiceVersionStatusCondition{
+				{Type: CSVConditionRequirementsMet, Status: corev1.ConditionFalse, Message: "unmet", LastTransitionTime: times[0]},
+			},
+			in: requirementsMet(corev1.ConditionTrue, "met"),
+			expected: []ClusterServiceVersionStatusCondition{
+				{Type: CSVConditionRequirementsMet, Status: corev1.ConditionTrue, Reason: CSVReasonRequirementsMet, Message: "met", LastTransitionTime: times[2]},
+			},
+		},
+		{
+			description: "OtherType",
+			existing: []ClusterServiceVersionStatusCondition{
+				{Type: "Other", Status: corev1.ConditionTrue, LastTransitionTime: times[1]},
+			},
+			in: requirementsMet(corev1.ConditionTrue, "met"),
+			expected: []ClusterServiceVersionStatusCondition{
+				{Type: "Other", Status: corev1.ConditionTrue, LastTransitionTime: times[1]},
+				{Type: CSVConditionRequirementsMet, Status: corev1.ConditionTrue, Reason: CSVReasonRequirementsMet, Message: "met", LastTransitionTime: times[2]},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			csv := ClusterServiceVersion{Status: ClusterServiceVersionStatus{StatusConditions: tt.existing}}
+			csv.SetStatusCondition(tt.in, times[2])
+			require.Equal(t, tt.expected, csv.Status.StatusConditions)
+			require.Equal(t, &csv.Status.StatusConditions[len(tt.expected)-1], csv.GetStatusCondition(CSVConditionRequirementsMet))
+		})
+	}
+
+	require.Nil(t, (&ClusterServiceVersion{}).GetStatusCondition(CSVConditionRequirementsMet))
+}
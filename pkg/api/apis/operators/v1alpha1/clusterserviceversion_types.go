@@ -5,10 +5,13 @@ package v1alpha1
 import (
 	"encoding/json"
 	"sort"
+	"sync"
 
 	"github.com/coreos/go-semver/semver"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 const (
@@ -54,26 +57,56 @@ type ActionDescriptor struct {
 type CRDDescription struct {
 	Name              string                 `json:"name"`
 	Version           string                 `json:"version"`
+	VersionRange      string                 `json:"versionRange,omitempty"`
 	Kind              string                 `json:"kind"`
+	Scope             string                 `json:"scope,omitempty"`
+	StatusSubresource bool                   `json:"statusSubresource,omitempty"`
 	DisplayName       string                 `json:"displayName,omitempty"`
 	Description       string                 `json:"description,omitempty"`
 	Resources         []APIResourceReference `json:"resources,omitempty"`
 	StatusDescriptors []StatusDescriptor     `json:"statusDescriptors,omitempty"`
 	SpecDescriptors   []SpecDescriptor       `json:"specDescriptors,omitempty"`
 	ActionDescriptor  []ActionDescriptor     `json:"actionDescriptors,omitempty"`
+	// When, if set, makes the CRD a requirement only while its condition holds
+	When *RequirementCondition `json:"when,omitempty"`
+	// PrinterColumns, if set, names the additionalPrinterColumns the CRD is expected to have. A CRD lacking any of
+	// them is flagged, but still meets the requirement.
+	PrinterColumns []string `json:"printerColumns,omitempty"`
+	// RequiresSchema, if set, means the CSV expects the CRD to define an OpenAPI v3 validation schema for Version. A
+	// CRD without one is flagged, but still meets the requirement.
+	RequiresSchema bool `json:"requiresSchema,omitempty"`
+	// ServedVersions, if set, names versions an owned CRD must all serve at once, such as both the old and new versions
+	// during a migration. A CRD that doesn't serve every one of them doesn't meet the requirement.
+	ServedVersions []string `json:"servedVersions,omitempty"`
 }
 
-// APIServiceDescription provides details to OLM about apis provided via aggregation
+// APIServiceDescription provides details to OLM about apis provided via aggregation.
+// DeploymentName names the deployment in the install strategy that serves an owned APIService.
 type APIServiceDescription struct {
 	Name              string                 `json:"name"`
 	Version           string                 `json:"version"`
 	Kind              string                 `json:"kind"`
+	DeploymentName    string                 `json:"deploymentName,omitempty"`
 	DisplayName       string                 `json:"displayName,omitempty"`
 	Description       string                 `json:"description,omitempty"`
 	Resources         []APIResourceReference `json:"resources,omitempty"`
 	StatusDescriptors []StatusDescriptor     `json:"statusDescriptors,omitempty"`
 	SpecDescriptors   []SpecDescriptor       `json:"specDescriptors,omitempty"`
 	ActionDescriptor  []ActionDescriptor     `json:"actionDescriptors,omitempty"`
+	// When, if set, makes the APIService a requirement only while its condition holds
+	When *RequirementCondition `json:"when,omitempty"`
+	// AcceptableVersions, if set on a required APIService, are other versions of its group the CSV can use. If the
+	// cluster doesn't serve Version, the requirement is met by the newest of these that's newer than Version and
+	// served instead.
+	AcceptableVersions []string `json:"acceptableVersions,omitempty"`
+}
+
+// RequirementCondition is a precondition of a requirement: the requirement is checked only while the cluster serves
+// the given API, and is skipped otherwise. If Kind is empty, the cluster only has to serve the group and version.
+type RequirementCondition struct {
+	Group   string `json:"group"`
+	Version string `json:"version"`
+	Kind    string `json:"kind,omitempty"`
 }
 
 // APIResourceReference is a Kubernetes resource type used by a custom resource
@@ -99,12 +132,51 @@ type APIServiceDefinitions struct {
 	Required []APIServiceDescription `json:"required,omitempty"`
 }
 
+// DeploymentRequirement references a Deployment that a CSV requires to be ready, such as one shared by several
+// operators
+type DeploymentRequirement struct {
+	Name string `json:"name"`
+	// Namespace is the namespace of the Deployment, or the CSV's namespace if empty
+	Namespace string `json:"namespace,omitempty"`
+	// When, if set, makes the Deployment a requirement only while its condition holds
+	When *RequirementCondition `json:"when,omitempty"`
+}
+
+// InstallModeType is a way a CSV can be installed, given by the namespaces its operator watches
+type InstallModeType string
+
+const (
+	// InstallModeTypeOwnNamespace means the operator watches the namespace it's installed in
+	InstallModeTypeOwnNamespace InstallModeType = "OwnNamespace"
+	// InstallModeTypeSingleNamespace means the operator watches a single namespace
+	InstallModeTypeSingleNamespace InstallModeType = "SingleNamespace"
+	// InstallModeTypeMultiNamespace means the operator watches several namespaces
+	InstallModeTypeMultiNamespace InstallModeType = "MultiNamespace"
+	// InstallModeTypeAllNamespaces means the operator watches every namespace
+	InstallModeTypeAllNamespaces InstallModeType = "AllNamespaces"
+)
+
+// InstallModeTypes are the known install mode types
+var InstallModeTypes = []InstallModeType{
+	InstallModeTypeOwnNamespace,
+	InstallModeTypeSingleNamespace,
+	InstallModeTypeMultiNamespace,
+	InstallModeTypeAllNamespaces,
+}
+
+// InstallMode declares whether a CSV supports an install mode
+type InstallMode struct {
+	Type      InstallModeType `json:"type"`
+	Supported bool            `json:"supported"`
+}
+
 // ClusterServiceVersionSpec declarations tell the OLM how to install an operator
 // that can manage apps for given version and AppType.
 type ClusterServiceVersionSpec struct {
 	InstallStrategy           NamedInstallStrategy      `json:"install"`
 	Version                   semver.Version            `json:"version,omitempty"`
 	Maturity                  string                    `json:"maturity,omitempty"`
+	MinKubeVersion            string                    `json:"minKubeVersion,omitempty"`
 	CustomResourceDefinitions CustomResourceDefinitions `json:"customresourcedefinitions,omitempty"`
 	APIServiceDefinitions     APIServiceDefinitions     `json:"apiservicedefinitions,omitempty"`
 	DisplayName               string                    `json:"displayName"`
@@ -115,6 +187,14 @@ type ClusterServiceVersionSpec struct {
 	Links                     []AppLink                 `json:"links,omitempty"`
 	Icon                      []Icon                    `json:"icon,omitempty"`
 
+	// RequiredDeployments are existing Deployments, not installed by the CSV, that must be ready before it's installed
+	// +optional
+	RequiredDeployments []DeploymentRequirement `json:"requiredDeployments,omitempty"`
+
+	// InstallModes declare which install modes the CSV supports. Modes that aren't declared aren't supported.
+	// +optional
+	InstallModes []InstallMode `json:"installModes,omitempty"`
+
 	// The name of a CSV this one replaces. Should match the `metadata.Name` field of the old CSV.
 	// +optional
 	Replaces string `json:"replaces,omitempty"`
@@ -226,13 +306,111 @@ func (csv ClusterServiceVersion) OwnsCRD(name string) bool {
 type StatusReason string
 
 const (
-	RequirementStatusReasonPresent             StatusReason = "Present"
-	RequirementStatusReasonNotPresent          StatusReason = "NotPresent"
-	RequirementStatusReasonPresentNotSatisfied StatusReason = "PresentNotSatisfied"
-	DependentStatusReasonSatisfied             StatusReason = "Satisfied"
-	DependentStatusReasonNotSatisfied          StatusReason = "NotSatisfied"
+	RequirementStatusReasonPresent                StatusReason = "Present"
+	RequirementStatusReasonNotPresent             StatusReason = "NotPresent"
+	RequirementStatusReasonPresentNotSatisfied    StatusReason = "PresentNotSatisfied"
+	RequirementStatusReasonPresentSchemaMismatch  StatusReason = "PresentSchemaMismatch"
+	RequirementStatusReasonAccessDenied           StatusReason = "AccessDenied"
+	RequirementStatusReasonNamespaceNotApproved   StatusReason = "NamespaceNotApproved"
+	DependentStatusReasonSatisfied                StatusReason = "Satisfied"
+	DependentStatusReasonNotSatisfied             StatusReason = "NotSatisfied"
+	DependentStatusReasonOverlyBroadPermissions   StatusReason = "OverlyBroadPermissions"
+	DependentStatusReasonMissingStatusSubresource StatusReason = "PresentMissingStatusSubresource"
+	DependentStatusReasonStorageVersionMismatch   StatusReason = "PresentStorageVersionMismatch"
+	DependentStatusReasonMissingPrinterColumns    StatusReason = "PresentMissingPrinterColumns"
+	DependentStatusReasonUndeclaredServiceAccount StatusReason = "UndeclaredServiceAccount"
+	DependentStatusReasonInconsistentScope        StatusReason = "PresentInconsistentScope"
+	DependentStatusReasonMissingSchema            StatusReason = "PresentMissingSchema"
+	DependentStatusReasonOrphaned                 StatusReason = "PresentOrphaned"
+)
+
+// RequirementMet reports whether the requirement with the given GVK and name is met according to statuses,
+// along with the recorded reason.
+//
+// A requirement is met only if its status isn't a blocking reason. Of the built-in requirement reasons, only Present
+// (or Satisfied) isn't; PresentNotSatisfied, PresentSchemaMismatch, NotPresent, AccessDenied, NamespaceNotApproved,
+// NotSatisfied, and unrecognized reasons are unmet. If no status has been recorded for the requirement, it is unmet
+// and the returned reason is empty.
+func RequirementMet(statuses []RequirementStatus, gvk schema.GroupVersionKind, name string) (bool, StatusReason) {
+	for _, status := range statuses {
+		if status.Group != gvk.Group || status.Version != gvk.Version || status.Kind != gvk.Kind || status.Name != name {
+			continue
+		}
+
+		return ReasonSeverity(status.Status) != SeverityBlocking, status.Status
+	}
+
+	return false, ""
+}
+
+// Severity is how much a StatusReason matters operationally
+type Severity string
+
+const (
+	// SeverityBlocking reasons make a requirement unmet
+	SeverityBlocking Severity = "Blocking"
+	// SeverityWarning reasons flag something worth reviewing, but don't make a requirement unmet
+	SeverityWarning Severity = "Warning"
+	// SeverityInfo reasons report that a requirement is met
+	SeverityInfo Severity = "Info"
+)
+
+var (
+	reasonSeveritiesMu sync.RWMutex
+	reasonSeverities   = map[StatusReason]Severity{
+		RequirementStatusReasonPresent:                SeverityInfo,
+		RequirementStatusReasonNotPresent:             SeverityBlocking,
+		RequirementStatusReasonPresentNotSatisfied:    SeverityBlocking,
+		RequirementStatusReasonPresentSchemaMismatch:  SeverityBlocking,
+		RequirementStatusReasonAccessDenied:           SeverityBlocking,
+		RequirementStatusReasonNamespaceNotApproved:   SeverityBlocking,
+		DependentStatusReasonSatisfied:                SeverityInfo,
+		DependentStatusReasonNotSatisfied:             SeverityBlocking,
+		DependentStatusReasonOverlyBroadPermissions:   SeverityWarning,
+		DependentStatusReasonMissingStatusSubresource: SeverityWarning,
+		DependentStatusReasonStorageVersionMismatch:   SeverityWarning,
+		DependentStatusReasonMissingPrinterColumns:    SeverityWarning,
+		DependentStatusReasonUndeclaredServiceAccount: SeverityWarning,
+		DependentStatusReasonInconsistentScope:        SeverityWarning,
+		DependentStatusReasonMissingSchema:            SeverityWarning,
+		DependentStatusReasonOrphaned:                 SeverityWarning,
+	}
 )
 
+// ReasonSeverity returns the severity of a reason. Reasons that are neither built in nor registered with
+// RegisterReasonSeverity are blocking.
+func ReasonSeverity(reason StatusReason) Severity {
+	reasonSeveritiesMu.RLock()
+	defer reasonSeveritiesMu.RUnlock()
+	if severity, ok := reasonSeverities[reason]; ok {
+		return severity
+	}
+	return SeverityBlocking
+}
+
+// RegisterReasonSeverity sets the severity of a reason, so that reasons reported by custom requirement checks can be
+// classified like the built-in ones
+func RegisterReasonSeverity(reason StatusReason, severity Severity) {
+	reasonSeveritiesMu.Lock()
+	defer reasonSeveritiesMu.Unlock()
+	reasonSeverities[reason] = severity
+}
+
+// RequirementsMet returns true if no status or dependent status has a blocking reason
+func RequirementsMet(statuses []RequirementStatus) bool {
+	for _, status := range statuses {
+		if ReasonSeverity(status.Status) == SeverityBlocking {
+			return false
+		}
+		for _, dependent := range status.Dependents {
+			if ReasonSeverity(dependent.Status) == SeverityBlocking {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 // DependentStatus is the status for a dependent requirement (to prevent infinite nesting)
 type DependentStatus struct {
 	Group   string       `json:"group"`
@@ -249,10 +427,41 @@ type RequirementStatus struct {
 	Kind       string            `json:"kind"`
 	Name       string            `json:"name"`
 	Status     StatusReason      `json:"status"`
+	Message    string            `json:"message,omitempty"`
 	UUID       string            `json:"uuid,omitempty"`
 	Dependents []DependentStatus `json:"dependents,omitempty"`
+	// Details holds additional references recorded for the requirement, keyed by the RequirementDetail constants
+	Details map[string]string `json:"details,omitempty"`
+	// Annotations holds metadata that other controllers attach to the requirement. OLM doesn't set annotations, and
+	// keeps them when it rechecks the requirement.
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// LastTransitionTime is when Status last changed
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// LastUpdateTime is when the requirement was last checked, if OLM is configured to record it
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
 }
 
+// Keys of RequirementStatus Details recorded for APIService, CustomResourceDefinition, and ServiceAccount requirements
+const (
+	// RequirementDetailService is the namespace/name of the Service backing the APIService
+	RequirementDetailService = "service"
+	// RequirementDetailEndpointsReady is "true" if the backing Service has at least one ready endpoint
+	RequirementDetailEndpointsReady = "endpointsReady"
+	// RequirementDetailCABundleSHA256 is the hex encoded SHA-256 fingerprint of the APIService's CABundle
+	RequirementDetailCABundleSHA256 = "caBundleSHA256"
+	// RequirementDetailStorageVersion is the version an owned CustomResourceDefinition stores its resources as
+	RequirementDetailStorageVersion = "storageVersion"
+	// RequirementDetailRulesSatisfied is the number of the ServiceAccount's permission rules that are satisfied, out of
+	// RequirementDetailRules
+	RequirementDetailRulesSatisfied = "rulesSatisfied"
+	// RequirementDetailRules is the number of permission rules checked for the ServiceAccount. A rule checked in
+	// several namespaces counts once per namespace.
+	RequirementDetailRules = "rules"
+	// RequirementDetailServedVersion is the newer acceptable version a required APIService was found at, if its own
+	// version isn't served
+	RequirementDetailServedVersion = "servedVersion"
+)
+
 // ClusterServiceVersionStatus represents information about the status of a pod. Status may trail the actual
 // state of a system.
 type ClusterServiceVersionStatus struct {
@@ -275,6 +484,32 @@ type ClusterServiceVersionStatus struct {
 	Conditions []ClusterServiceVersionCondition `json:"conditions,omitempty"`
 	// The status of each requirement for this CSV
 	RequirementStatus []RequirementStatus `json:"requirementStatus,omitempty"`
+	// StatusConditions summarize the CSV's current state, with at most one condition of each type
+	// +optional
+	StatusConditions []ClusterServiceVersionStatusCondition `json:"statusConditions,omitempty"`
+}
+
+// ClusterServiceVersionConditionType is the type of a ClusterServiceVersionStatusCondition
+type ClusterServiceVersionConditionType string
+
+const (
+	// CSVConditionRequirementsMet is True if every requirement of the CSV was met when last checked
+	CSVConditionRequirementsMet ClusterServiceVersionConditionType = "RequirementsMet"
+)
+
+// ClusterServiceVersionStatusCondition summarizes one aspect of a CSV's state in the shape of a standard Kubernetes
+// condition. Unlike ClusterServiceVersionConditions, which record the history of phase transitions, it only
+// describes the current state.
+type ClusterServiceVersionStatusCondition struct {
+	Type   ClusterServiceVersionConditionType `json:"type"`
+	Status corev1.ConditionStatus             `json:"status"` // True, False, or Unknown
+	// ObservedGeneration is the generation of the CSV the condition was set for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// LastTransitionTime is when the condition last changed status
+	LastTransitionTime metav1.Time     `json:"lastTransitionTime"`
+	Reason             ConditionReason `json:"reason"`
+	Message            string          `json:"message,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
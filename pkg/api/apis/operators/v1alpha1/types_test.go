@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 func TestGetAllCRDDescriptions(t *testing.T) {
@@ -102,3 +103,82 @@ func TestOwnsCRD(t *testing.T) {
 		require.Equal(t, tt.expected, csv.OwnsCRD(tt.crdName))
 	}
 }
+
+func TestRequirementMet(t *testing.T) {
+	crdGVK := schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1beta1", Kind: "CustomResourceDefinition"}
+	saGVK := schema.GroupVersionKind{Group: "", Version: "v1", Kind: "ServiceAccount"}
+
+	var table = []struct {
+		description    string
+		statuses       []RequirementStatus
+		gvk            schema.GroupVersionKind
+		name           string
+		expectedMet    bool
+		expectedReason StatusReason
+	}{
+		{"NoStatuses", nil, crdGVK, "c1", false, ""},
+		{"Present", []RequirementStatus{{Group: crdGVK.Group, Version: crdGVK.Version, Kind: crdGVK.Kind, Name: "c1", Status: RequirementStatusReasonPresent}}, crdGVK, "c1", true, RequirementStatusReasonPresent},
+		{"NotPresent", []RequirementStatus{{Group: crdGVK.Group, Version: crdGVK.Version, Kind: crdGVK.Kind, Name: "c1", Status: RequirementStatusReasonNotPresent}}, crdGVK, "c1", false, RequirementStatusReasonNotPresent},
+		{"PresentNotSatisfied", []RequirementStatus{{Group: saGVK.Group, Version: saGVK.Version, Kind: saGVK.Kind, Name: "sa", Status: RequirementStatusReasonPresentNotSatisfied}}, saGVK, "sa", false, RequirementStatusReasonPresentNotSatisfied},
+		{"PresentSchemaMismatch", []RequirementStatus{{Group: crdGVK.Group, Version: crdGVK.Version, Kind: crdGVK.Kind, Name: "c1", Status: RequirementStatusReasonPresentSchemaMismatch}}, crdGVK, "c1", false, RequirementStatusReasonPresentSchemaMismatch},
+		{"Satisfied", []RequirementStatus{{Group: saGVK.Group, Version: saGVK.Version, Kind: saGVK.Kind, Name: "sa", Status: DependentStatusReasonSatisfied}}, saGVK, "sa", true, DependentStatusReasonSatisfied},
+		{"NotSatisfied", []RequirementStatus{{Group: saGVK.Group, Version: saGVK.Version, Kind: saGVK.Kind, Name: "sa", Status: DependentStatusReasonNotSatisfied}}, saGVK, "sa", false, DependentStatusReasonNotSatisfied},
+		{"UnknownReason", []RequirementStatus{{Group: crdGVK.Group, Version: crdGVK.Version, Kind: crdGVK.Kind, Name: "c1", Status: "Bogus"}}, crdGVK, "c1", false, "Bogus"},
+		{"DifferentName", []RequirementStatus{{Group: crdGVK.Group, Version: crdGVK.Version, Kind: crdGVK.Kind, Name: "c2", Status: RequirementStatusReasonPresent}}, crdGVK, "c1", false, ""},
+		{"DifferentKind", []RequirementStatus{{Group: saGVK.Group, Version: saGVK.Version, Kind: saGVK.Kind, Name: "c1", Status: RequirementStatusReasonPresent}}, crdGVK, "c1", false, ""},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.description, func(t *testing.T) {
+			met, reason := RequirementMet(tt.statuses, tt.gvk, tt.name)
+			require.Equal(t, tt.expectedMet, met)
+			require.Equal(t, tt.expectedReason, reason)
+		})
+	}
+}
+
+func TestReasonSeverity(t *testing.T) {
+	RegisterReasonSeverity("TestCustomWarning", SeverityWarning)
+
+	var table = []struct {
+		reason   StatusReason
+		expected Severity
+	}{
+		{RequirementStatusReasonPresent, SeverityInfo},
+		{DependentStatusReasonSatisfied, SeverityInfo},
+		{RequirementStatusReasonNotPresent, SeverityBlocking},
+		{RequirementStatusReasonAccessDenied, SeverityBlocking},
+		{DependentStatusReasonNotSatisfied, SeverityBlocking},
+		{DependentStatusReasonOverlyBroadPermissions, SeverityWarning},
+		{DependentStatusReasonStorageVersionMismatch, SeverityWarning},
+		{"TestCustomWarning", SeverityWarning},
+		{"Bogus", SeverityBlocking},
+	}
+
+	for _, tt := range table {
+		t.Run(string(tt.reason), func(t *testing.T) {
+			require.Equal(t, tt.expected, ReasonSeverity(tt.reason))
+		})
+	}
+}
+
+func TestRequirementsMet(t *testing.T) {
+	var table = []struct {
+		description string
+		statuses    []RequirementStatus
+		expectedMet bool
+	}{
+		{"NoStatuses", nil, true},
+		{"Present", []RequirementStatus{{Status: RequirementStatusReasonPresent}}, true},
+		{"Blocking", []RequirementStatus{{Status: RequirementStatusReasonPresent}, {Status: RequirementStatusReasonNotPresent}}, false},
+		{"WarningDependent", []RequirementStatus{{Status: RequirementStatusReasonPresent, Dependents: []DependentStatus{{Status: DependentStatusReasonMissingStatusSubresource}}}}, true},
+		{"BlockingDependent", []RequirementStatus{{Status: RequirementStatusReasonPresent, Dependents: []DependentStatus{{Status: DependentStatusReasonNotSatisfied}}}}, false},
+		{"UnknownReason", []RequirementStatus{{Status: "Bogus"}}, false},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.description, func(t *testing.T) {
+			require.Equal(t, tt.expectedMet, RequirementsMet(tt.statuses))
+		})
+	}
+}
@@ -76,6 +76,11 @@ func (in *APIServiceDefinitions) DeepCopy() *APIServiceDefinitions {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *APIServiceDescription) DeepCopyInto(out *APIServiceDescription) {
 	*out = *in
+	if in.AcceptableVersions != nil {
+		in, out := &in.AcceptableVersions, &out.AcceptableVersions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Resources != nil {
 		in, out := &in.Resources, &out.Resources
 		*out = make([]APIResourceReference, len(*in))
@@ -102,6 +107,15 @@ func (in *APIServiceDescription) DeepCopyInto(out *APIServiceDescription) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.When != nil {
+		in, out := &in.When, &out.When
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(RequirementCondition)
+			**out = **in
+		}
+	}
 	return
 }
 
@@ -194,6 +208,25 @@ func (in *CRDDescription) DeepCopyInto(out *CRDDescription) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.When != nil {
+		in, out := &in.When, &out.When
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(RequirementCondition)
+			**out = **in
+		}
+	}
+	if in.PrinterColumns != nil {
+		in, out := &in.PrinterColumns, &out.PrinterColumns
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ServedVersions != nil {
+		in, out := &in.ServedVersions, &out.ServedVersions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -423,6 +456,18 @@ func (in *ClusterServiceVersionSpec) DeepCopyInto(out *ClusterServiceVersionSpec
 		*out = make([]Icon, len(*in))
 		copy(*out, *in)
 	}
+	if in.RequiredDeployments != nil {
+		in, out := &in.RequiredDeployments, &out.RequiredDeployments
+		*out = make([]DeploymentRequirement, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.InstallModes != nil {
+		in, out := &in.InstallModes, &out.InstallModes
+		*out = make([]InstallMode, len(*in))
+		copy(*out, *in)
+	}
 	if in.Labels != nil {
 		in, out := &in.Labels, &out.Labels
 		*out = make(map[string]string, len(*in))
@@ -478,6 +523,13 @@ func (in *ClusterServiceVersionStatus) DeepCopyInto(out *ClusterServiceVersionSt
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.StatusConditions != nil {
+		in, out := &in.StatusConditions, &out.StatusConditions
+		*out = make([]ClusterServiceVersionStatusCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -491,6 +543,23 @@ func (in *ClusterServiceVersionStatus) DeepCopy() *ClusterServiceVersionStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterServiceVersionStatusCondition) DeepCopyInto(out *ClusterServiceVersionStatusCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterServiceVersionStatusCondition.
+func (in *ClusterServiceVersionStatusCondition) DeepCopy() *ClusterServiceVersionStatusCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterServiceVersionStatusCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ConfigMapResourceReference) DeepCopyInto(out *ConfigMapResourceReference) {
 	*out = *in
@@ -553,6 +622,31 @@ func (in *DependentStatus) DeepCopy() *DependentStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeploymentRequirement) DeepCopyInto(out *DeploymentRequirement) {
+	*out = *in
+	if in.When != nil {
+		in, out := &in.When, &out.When
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(RequirementCondition)
+			**out = **in
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeploymentRequirement.
+func (in *DeploymentRequirement) DeepCopy() *DeploymentRequirement {
+	if in == nil {
+		return nil
+	}
+	out := new(DeploymentRequirement)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Icon) DeepCopyInto(out *Icon) {
 	*out = *in
@@ -569,6 +663,22 @@ func (in *Icon) DeepCopy() *Icon {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InstallMode) DeepCopyInto(out *InstallMode) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstallMode.
+func (in *InstallMode) DeepCopy() *InstallMode {
+	if in == nil {
+		return nil
+	}
+	out := new(InstallMode)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *InstallPlan) DeepCopyInto(out *InstallPlan) {
 	*out = *in
@@ -755,6 +865,22 @@ func (in *NamedInstallStrategy) DeepCopy() *NamedInstallStrategy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RequirementCondition) DeepCopyInto(out *RequirementCondition) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RequirementCondition.
+func (in *RequirementCondition) DeepCopy() *RequirementCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(RequirementCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RequirementStatus) DeepCopyInto(out *RequirementStatus) {
 	*out = *in
@@ -763,6 +889,22 @@ func (in *RequirementStatus) DeepCopyInto(out *RequirementStatus) {
 		*out = make([]DependentStatus, len(*in))
 		copy(*out, *in)
 	}
+	if in.Details != nil {
+		in, out := &in.Details, &out.Details
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
 	return
 }
 
@@ -31,13 +31,59 @@ func IsMultipleExistingCRDOwnersError(err error) bool {
 	return false
 }
 
-// GroupVersionKindNotFoundError occurs when we can't find an API via discovery
+// GroupVersionKindNotFoundError occurs when we can't find an API via discovery.
+// An empty Kind means nothing was served under the Group and Version.
 type GroupVersionKindNotFoundError struct {
 	Group   string
 	Version string
 	Kind    string
 }
 
+func NewGroupVersionKindNotFoundError(group, version, kind string) GroupVersionKindNotFoundError {
+	return GroupVersionKindNotFoundError{
+		Group:   group,
+		Version: version,
+		Kind:    kind,
+	}
+}
+
 func (g GroupVersionKindNotFoundError) Error() string {
-	return fmt.Sprintf("Unable to find GVK in discovery: %s %s %s", g.Group, g.Version, g.Kind)
+	groupVersion := g.Version
+	if g.Group != "" {
+		groupVersion = g.Group + "/" + g.Version
+	}
+	if g.Kind == "" {
+		return fmt.Sprintf("GroupVersion %s not found in discovery", groupVersion)
+	}
+	return fmt.Sprintf("GroupVersionKind %s, Kind=%s not found in discovery", groupVersion, g.Kind)
+}
+
+// Is reports whether target is a GroupVersionKindNotFoundError for the same GVK, so that it can be matched with
+// errors.Is. Empty fields of target match any value, so GroupVersionKindNotFoundError{} matches every such error.
+func (g GroupVersionKindNotFoundError) Is(target error) bool {
+	var t GroupVersionKindNotFoundError
+	switch target := target.(type) {
+	case GroupVersionKindNotFoundError:
+		t = target
+	case *GroupVersionKindNotFoundError:
+		if target == nil {
+			return false
+		}
+		t = *target
+	default:
+		return false
+	}
+
+	return (t.Group == "" || t.Group == g.Group) &&
+		(t.Version == "" || t.Version == g.Version) &&
+		(t.Kind == "" || t.Kind == g.Kind)
+}
+
+func IsGroupVersionKindNotFoundError(err error) bool {
+	switch err.(type) {
+	case GroupVersionKindNotFoundError:
+		return true
+	}
+
+	return false
 }
@@ -0,0 +1,86 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupVersionKindNotFoundErrorMessage(t *testing.T) {
+	tests := []struct {
+		description string
+		err         GroupVersionKindNotFoundError
+		expected    string
+	}{
+		{
+			description: "GroupVersionKind",
+			err:         NewGroupVersionKindNotFoundError("etcd.database.coreos.com", "v1beta2", "EtcdCluster"),
+			expected:    "GroupVersionKind etcd.database.coreos.com/v1beta2, Kind=EtcdCluster not found in discovery",
+		},
+		{
+			description: "GroupVersion",
+			err:         NewGroupVersionKindNotFoundError("etcd.database.coreos.com", "v1beta2", ""),
+			expected:    "GroupVersion etcd.database.coreos.com/v1beta2 not found in discovery",
+		},
+		{
+			description: "CoreGroup",
+			err:         NewGroupVersionKindNotFoundError("", "v1", "Pod"),
+			expected:    "GroupVersionKind v1, Kind=Pod not found in discovery",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			require.EqualError(t, tt.err, tt.expected)
+		})
+	}
+}
+
+func TestGroupVersionKindNotFoundErrorIs(t *testing.T) {
+	err := fmt.Errorf("checking requirements: %w", NewGroupVersionKindNotFoundError("g", "v1", "K"))
+
+	tests := []struct {
+		description string
+		target      error
+		expected    bool
+	}{
+		{
+			description: "Any",
+			target:      GroupVersionKindNotFoundError{},
+			expected:    true,
+		},
+		{
+			description: "Same",
+			target:      NewGroupVersionKindNotFoundError("g", "v1", "K"),
+			expected:    true,
+		},
+		{
+			description: "Pointer",
+			target:      &GroupVersionKindNotFoundError{Group: "g"},
+			expected:    true,
+		},
+		{
+			description: "DifferentKind",
+			target:      NewGroupVersionKindNotFoundError("g", "v1", "Other"),
+			expected:    false,
+		},
+		{
+			description: "DifferentVersion",
+			target:      GroupVersionKindNotFoundError{Version: "v2"},
+			expected:    false,
+		},
+		{
+			description: "OtherError",
+			target:      MultipleExistingCRDOwnersError{},
+			expected:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			require.Equal(t, tt.expected, errors.Is(err, tt.target))
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package install
 
 import (
+	"fmt"
+
 	log "github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
@@ -68,3 +70,23 @@ func toDefaultInfo(sa *corev1.ServiceAccount) *user.DefaultInfo {
 		UID:  string(sa.GetUID()),
 	}
 }
+
+// subjectInfo returns the user info the RBAC authorizer matches against bindings to the given subject
+func subjectInfo(subject rbacv1.Subject) (*user.DefaultInfo, error) {
+	switch subject.Kind {
+	case rbacv1.ServiceAccountKind:
+		return &user.DefaultInfo{
+			Name: serviceaccount.MakeUsername(subject.Namespace, subject.Name),
+		}, nil
+	case rbacv1.UserKind:
+		return &user.DefaultInfo{
+			Name: subject.Name,
+		}, nil
+	case rbacv1.GroupKind:
+		return &user.DefaultInfo{
+			Groups: []string{subject.Name},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported subject kind %q", subject.Kind)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	rbac "k8s.io/api/rbac/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/wrappers"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/ownerutil"
 )
@@ -21,6 +22,9 @@ const (
 type StrategyDeploymentPermissions struct {
 	ServiceAccountName string            `json:"serviceAccountName"`
 	Rules              []rbac.PolicyRule `json:"rules"`
+	// When, if set, makes the permissions a requirement only while its condition holds. The permissions are
+	// installed regardless.
+	When *v1alpha1.RequirementCondition `json:"when,omitempty"`
 }
 
 // StrategyDeploymentSpec contains the name and spec for the deployment ALM should create
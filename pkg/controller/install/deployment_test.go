@@ -319,7 +319,7 @@ func TestInstallStrategyDeploymentInstallPermissions(t *testing.T) {
 			description: "creates roles, SAs, and rolebindings for multiple permissions",
 			inputs: inputs{
 				[]StrategyDeploymentPermissions{
-					{serviceAccountName1, testRules1}, {serviceAccountName2, testRules2},
+					{ServiceAccountName: serviceAccountName1, Rules: testRules1}, {ServiceAccountName: serviceAccountName2, Rules: testRules2},
 				},
 			},
 			mocks: []mock{
@@ -414,7 +414,7 @@ func TestInstallStrategyDeploymentInstallPermissions(t *testing.T) {
 			description: "handles errors creating roles",
 			inputs: inputs{
 				[]StrategyDeploymentPermissions{
-					{serviceAccountName1, testRules1}, {serviceAccountName2, testRules2},
+					{ServiceAccountName: serviceAccountName1, Rules: testRules1}, {ServiceAccountName: serviceAccountName2, Rules: testRules2},
 				},
 			},
 			mocks: []mock{
@@ -1,10 +1,15 @@
 package install
 
 import (
+	"sort"
+	"strings"
+
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/apiserver/pkg/authorization/authorizer"
 	crbacv1 "k8s.io/client-go/listers/rbac/v1"
 	rbacauthorizer "k8s.io/kubernetes/plugin/pkg/auth/authorizer/rbac"
@@ -17,6 +22,18 @@ type RuleChecker interface {
 	// RuleSatisfied determines whether a PolicyRule is satisfied for a ServiceAccount
 	// by existing Roles and ClusterRoles
 	RuleSatisfied(sa *corev1.ServiceAccount, namespace string, rule rbacv1.PolicyRule) (bool, error)
+
+	// RuleSatisfiedFor determines whether a PolicyRule is satisfied for a ServiceAccount, User, or Group subject
+	// by existing Roles and ClusterRoles
+	RuleSatisfiedFor(subject rbacv1.Subject, namespace string, rule rbacv1.PolicyRule) (bool, error)
+}
+
+// ClusterAdminChecker is implemented by RuleCheckers that can tell up front whether a subject is granted every action
+// on every resource cluster-wide, so that checking its resource rules one by one can be skipped
+type ClusterAdminChecker interface {
+	// ClusterAdminBindingFor returns the name of a ClusterRoleBinding that grants the subject every action on every
+	// resource, or "" if none is found
+	ClusterAdminBindingFor(subject rbacv1.Subject) (string, error)
 }
 
 // CSVRuleChecker determines whether a PolicyRule is satisfied for a ServiceAccount
@@ -29,6 +46,8 @@ type CSVRuleChecker struct {
 	csv                      *v1alpha1.ClusterServiceVersion
 }
 
+var _ ClusterAdminChecker = &CSVRuleChecker{}
+
 // NewCSVRuleChecker returns a pointer to a new CSVRuleChecker
 func NewCSVRuleChecker(roleLister crbacv1.RoleLister, roleBindingLister crbacv1.RoleBindingLister, clusterRoleLister crbacv1.ClusterRoleLister, clusterRoleBindingLister crbacv1.ClusterRoleBindingLister, csv *v1alpha1.ClusterServiceVersion) *CSVRuleChecker {
 	return &CSVRuleChecker{
@@ -42,8 +61,21 @@ func NewCSVRuleChecker(roleLister crbacv1.RoleLister, roleBindingLister crbacv1.
 
 // RuleSatisfied returns true if a ServiceAccount is authorized to perform all actions described by a PolicyRule in a namespace
 func (c *CSVRuleChecker) RuleSatisfied(sa *corev1.ServiceAccount, namespace string, rule rbacv1.PolicyRule) (bool, error) {
-	// get attributes set for the given Role and ServiceAccount
-	user := toDefaultInfo(sa)
+	return c.ruleSatisfied(toDefaultInfo(sa), namespace, rule)
+}
+
+// RuleSatisfiedFor returns true if a subject is authorized to perform all actions described by a PolicyRule in a namespace
+func (c *CSVRuleChecker) RuleSatisfiedFor(subject rbacv1.Subject, namespace string, rule rbacv1.PolicyRule) (bool, error) {
+	user, err := subjectInfo(subject)
+	if err != nil {
+		return false, err
+	}
+
+	return c.ruleSatisfied(user, namespace, rule)
+}
+
+func (c *CSVRuleChecker) ruleSatisfied(user user.Info, namespace string, rule rbacv1.PolicyRule) (bool, error) {
+	// get attributes set for the given Role and user
 	attributesSet := toAttributesSet(user, namespace, rule)
 
 	// create a new RBACAuthorizer
@@ -65,6 +97,129 @@ func (c *CSVRuleChecker) RuleSatisfied(sa *corev1.ServiceAccount, namespace stri
 	return true, nil
 }
 
+// ClusterAdminBindingFor returns the name of the first ClusterRoleBinding, by name, that binds the subject to a
+// ClusterRole with a rule granting every verb on every resource in every API group, or "" if there's none.
+// The check is conservative: only bindings naming the subject itself count, not those of groups it belongs to, and
+// only rules whose verbs, API groups, and resources include "*" and that aren't limited to resource names. A subject
+// it doesn't find may still be granted everything some other way.
+func (c *CSVRuleChecker) ClusterAdminBindingFor(subject rbacv1.Subject) (string, error) {
+	bindings, err := c.ListClusterRoleBindings()
+	if err != nil {
+		return "", err
+	}
+	sort.Slice(bindings, func(i, j int) bool {
+		return bindings[i].GetName() < bindings[j].GetName()
+	})
+
+	for _, binding := range bindings {
+		if binding.RoleRef.Kind != "ClusterRole" || !bindsSubject(binding.Subjects, subject) {
+			continue
+		}
+		clusterRole, err := c.GetClusterRole(binding.RoleRef.Name)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		for _, rule := range clusterRole.Rules {
+			if grantsEverything(rule) {
+				return binding.GetName(), nil
+			}
+		}
+	}
+
+	return "", nil
+}
+
+// bindsSubject returns true if subjects names the given subject itself
+func bindsSubject(subjects []rbacv1.Subject, subject rbacv1.Subject) bool {
+	for _, s := range subjects {
+		if s.Kind != subject.Kind || s.Name != subject.Name {
+			continue
+		}
+		if s.Kind == rbacv1.ServiceAccountKind && s.Namespace != subject.Namespace {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// grantsEverything returns true if a rule grants every verb on every resource in every API group
+func grantsEverything(rule rbacv1.PolicyRule) bool {
+	return len(rule.ResourceNames) == 0 &&
+		hasWildcard(rule.Verbs) &&
+		hasWildcard(rule.APIGroups) &&
+		hasWildcard(rule.Resources)
+}
+
+func hasWildcard(values []string) bool {
+	for _, value := range values {
+		if value == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// ActionDecision is whether a subject is authorized to perform a single action described by a PolicyRule
+type ActionDecision struct {
+	Verb     string `json:"verb"`
+	APIGroup string `json:"apiGroup"`
+	Resource string `json:"resource"`
+	Name     string `json:"name,omitempty"`
+	Allowed  bool   `json:"allowed"`
+	// GrantedBy describes the binding and role that allow the action, if it's allowed
+	GrantedBy string `json:"grantedBy,omitempty"`
+}
+
+// RuleActionsFor returns a decision for each action described by a PolicyRule, sorted by verb, API group, resource, and
+// name, recording which binding grants each allowed action
+func (c *CSVRuleChecker) RuleActionsFor(subject rbacv1.Subject, namespace string, rule rbacv1.PolicyRule) ([]ActionDecision, error) {
+	user, err := subjectInfo(subject)
+	if err != nil {
+		return nil, err
+	}
+
+	rbacAuthorizer := rbacauthorizer.New(c, c, c, c)
+	decisions := []ActionDecision{}
+	for _, attributes := range toAttributesSet(user, namespace, rule) {
+		decision, reason, err := rbacAuthorizer.Authorize(attributes)
+		if err != nil {
+			return nil, err
+		}
+
+		action := ActionDecision{
+			Verb:     attributes.GetVerb(),
+			APIGroup: attributes.GetAPIGroup(),
+			Resource: attributes.GetResource(),
+			Name:     attributes.GetName(),
+			Allowed:  decision == authorizer.DecisionAllow,
+		}
+		if action.Allowed {
+			action.GrantedBy = strings.TrimPrefix(reason, "RBAC: allowed by ")
+		}
+		decisions = append(decisions, action)
+	}
+
+	sort.Slice(decisions, func(i, j int) bool {
+		a, b := decisions[i], decisions[j]
+		if a.Verb != b.Verb {
+			return a.Verb < b.Verb
+		}
+		if a.APIGroup != b.APIGroup {
+			return a.APIGroup < b.APIGroup
+		}
+		if a.Resource != b.Resource {
+			return a.Resource < b.Resource
+		}
+		return a.Name < b.Name
+	})
+
+	return decisions, nil
+}
+
 func (c *CSVRuleChecker) GetRole(namespace, name string) (*rbacv1.Role, error) {
 	// get the Role
 	role, err := c.roleLister.Roles(namespace).Get(name)
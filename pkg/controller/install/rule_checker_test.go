@@ -14,6 +14,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/informers"
 	k8sfake "k8s.io/client-go/kubernetes/fake"
+	crbacv1 "k8s.io/client-go/listers/rbac/v1"
 	"k8s.io/client-go/tools/cache"
 	apiregistrationfake "k8s.io/kube-aggregator/pkg/client/clientset_generated/clientset/fake"
 
@@ -574,6 +575,204 @@ func TestRuleSatisfied(t *testing.T) {
 	}
 }
 
+func TestRuleSatisfiedFor(t *testing.T) {
+	csv := &v1alpha1.ClusterServiceVersion{}
+	csv.SetName("barista-operator")
+	csv.SetUID(types.UID("barista-operator"))
+
+	namespace := "coffee-shop"
+	rule := rbacv1.PolicyRule{
+		APIGroups: []string{""},
+		Verbs:     []string{"*"},
+		Resources: []string{"donuts"},
+	}
+	clusterRole := &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "coffee",
+		},
+		Rules: []rbacv1.PolicyRule{rule},
+	}
+	clusterRoleBinding := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "coffee",
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				Kind:     rbacv1.GroupKind,
+				APIGroup: rbacv1.GroupName,
+				Name:     "baristas",
+			},
+			{
+				Kind:     rbacv1.UserKind,
+				APIGroup: rbacv1.GroupName,
+				Name:     "head-barista",
+			},
+			{
+				Kind:      rbacv1.ServiceAccountKind,
+				Name:      "barista-operator",
+				Namespace: namespace,
+			},
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: "rbac.authorization.k8s.io",
+			Kind:     "ClusterRole",
+			Name:     "coffee",
+		},
+	}
+
+	stopCh := make(chan struct{})
+	defer func() {
+		stopCh <- struct{}{}
+	}()
+	ruleChecker, err := NewFakeCSVRuleChecker(Objs(nil, nil, []*rbacv1.ClusterRole{clusterRole}, []*rbacv1.ClusterRoleBinding{clusterRoleBinding}), csv, namespace, stopCh)
+	require.NoError(t, err)
+	time.Sleep(1 * time.Second)
+
+	tests := []struct {
+		description   string
+		subject       rbacv1.Subject
+		expectedError string
+		satisfied     bool
+	}{
+		{
+			description: "GroupSatisfied",
+			subject:     rbacv1.Subject{Kind: rbacv1.GroupKind, Name: "baristas"},
+			satisfied:   true,
+		},
+		{
+			description: "GroupNotSatisfied",
+			subject:     rbacv1.Subject{Kind: rbacv1.GroupKind, Name: "customers"},
+			satisfied:   false,
+		},
+		{
+			description: "UserSatisfied",
+			subject:     rbacv1.Subject{Kind: rbacv1.UserKind, Name: "head-barista"},
+			satisfied:   true,
+		},
+		{
+			description: "ServiceAccountSatisfied",
+			subject:     rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: "barista-operator", Namespace: namespace},
+			satisfied:   true,
+		},
+		{
+			description: "ServiceAccountOtherNamespaceNotSatisfied",
+			subject:     rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: "barista-operator", Namespace: "tea-shop"},
+			satisfied:   false,
+		},
+		{
+			description:   "UnsupportedKind",
+			subject:       rbacv1.Subject{Kind: "Robot", Name: "espresso-machine"},
+			expectedError: `unsupported subject kind "Robot"`,
+			satisfied:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			satisfied, err := ruleChecker.RuleSatisfiedFor(tt.subject, namespace, rule)
+			if tt.expectedError != "" {
+				require.EqualError(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.satisfied, satisfied)
+		})
+	}
+}
+
+func TestClusterAdminBindingFor(t *testing.T) {
+	csv := &v1alpha1.ClusterServiceVersion{}
+	csv.SetName("barista-operator")
+	csv.SetUID(types.UID("barista-operator"))
+
+	namespace := "coffee-shop"
+	subject := rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: "barista-operator", Namespace: namespace}
+	clusterRole := func(name string, rules ...rbacv1.PolicyRule) *rbacv1.ClusterRole {
+		return &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: name}, Rules: rules}
+	}
+	clusterRoleBinding := func(name, role string, subjects ...rbacv1.Subject) *rbacv1.ClusterRoleBinding {
+		return &rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Subjects:   subjects,
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: role},
+		}
+	}
+	everything := rbacv1.PolicyRule{APIGroups: []string{"*"}, Verbs: []string{"*"}, Resources: []string{"*"}}
+
+	tests := []struct {
+		description         string
+		clusterRoles        []*rbacv1.ClusterRole
+		clusterRoleBindings []*rbacv1.ClusterRoleBinding
+		expectedBinding     string
+	}{
+		{
+			description:         "ClusterAdmin",
+			clusterRoles:        []*rbacv1.ClusterRole{clusterRole("cluster-admin", everything)},
+			clusterRoleBindings: []*rbacv1.ClusterRoleBinding{clusterRoleBinding("barista-admin", "cluster-admin", subject)},
+			expectedBinding:     "barista-admin",
+		},
+		{
+			description: "FirstBindingByName",
+			clusterRoles: []*rbacv1.ClusterRole{
+				clusterRole("cluster-admin", everything),
+				clusterRole("admin", rbacv1.PolicyRule{APIGroups: []string{""}, Verbs: []string{"get"}, Resources: []string{"donuts"}}, everything),
+			},
+			clusterRoleBindings: []*rbacv1.ClusterRoleBinding{
+				clusterRoleBinding("z-admin", "cluster-admin", subject),
+				clusterRoleBinding("a-admin", "admin", subject),
+			},
+			expectedBinding: "a-admin",
+		},
+		{
+			description:         "NotBound",
+			clusterRoles:        []*rbacv1.ClusterRole{clusterRole("cluster-admin", everything)},
+			clusterRoleBindings: []*rbacv1.ClusterRoleBinding{clusterRoleBinding("tea-admin", "cluster-admin", rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: "barista-operator", Namespace: "tea-shop"})},
+		},
+		{
+			description:         "BoundThroughGroup",
+			clusterRoles:        []*rbacv1.ClusterRole{clusterRole("cluster-admin", everything)},
+			clusterRoleBindings: []*rbacv1.ClusterRoleBinding{clusterRoleBinding("all-admin", "cluster-admin", rbacv1.Subject{Kind: rbacv1.GroupKind, Name: "system:serviceaccounts"})},
+		},
+		{
+			description:         "CoreGroupOnly",
+			clusterRoles:        []*rbacv1.ClusterRole{clusterRole("core-admin", rbacv1.PolicyRule{APIGroups: []string{""}, Verbs: []string{"*"}, Resources: []string{"*"}})},
+			clusterRoleBindings: []*rbacv1.ClusterRoleBinding{clusterRoleBinding("barista-core-admin", "core-admin", subject)},
+		},
+		{
+			description:         "SomeVerbs",
+			clusterRoles:        []*rbacv1.ClusterRole{clusterRole("reader", rbacv1.PolicyRule{APIGroups: []string{"*"}, Verbs: []string{"get", "list", "watch"}, Resources: []string{"*"}})},
+			clusterRoleBindings: []*rbacv1.ClusterRoleBinding{clusterRoleBinding("barista-reader", "reader", subject)},
+		},
+		{
+			description:         "ResourceNames",
+			clusterRoles:        []*rbacv1.ClusterRole{clusterRole("named", rbacv1.PolicyRule{APIGroups: []string{"*"}, Verbs: []string{"*"}, Resources: []string{"*"}, ResourceNames: []string{"espresso"}})},
+			clusterRoleBindings: []*rbacv1.ClusterRoleBinding{clusterRoleBinding("barista-named", "named", subject)},
+		},
+		{
+			description:         "MissingClusterRole",
+			clusterRoleBindings: []*rbacv1.ClusterRoleBinding{clusterRoleBinding("barista-admin", "cluster-admin", subject)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			clusterRoles := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			for _, role := range tt.clusterRoles {
+				require.NoError(t, clusterRoles.Add(role))
+			}
+			clusterRoleBindings := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			for _, binding := range tt.clusterRoleBindings {
+				require.NoError(t, clusterRoleBindings.Add(binding))
+			}
+			ruleChecker := NewCSVRuleChecker(nil, nil, crbacv1.NewClusterRoleLister(clusterRoles), crbacv1.NewClusterRoleBindingLister(clusterRoleBindings), csv)
+
+			binding, err := ruleChecker.ClusterAdminBindingFor(subject)
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedBinding, binding)
+		})
+	}
+}
+
 func NewFakeCSVRuleChecker(k8sObjs []runtime.Object, csv *v1alpha1.ClusterServiceVersion, namespace string, stopCh <-chan struct{}) (*CSVRuleChecker, error) {
 	// create client fakes
 	opClientFake := operatorclient.NewClient(k8sfake.NewSimpleClientset(k8sObjs...), apiextensionsfake.NewSimpleClientset(), apiregistrationfake.NewSimpleClientset())
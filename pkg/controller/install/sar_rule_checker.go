@@ -0,0 +1,70 @@
+package install
+
+import (
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/client-go/kubernetes"
+)
+
+// SARRuleChecker determines whether a PolicyRule is satisfied by asking the API server with SubjectAccessReviews.
+// Unlike CSVRuleChecker it sees RBAC changes as soon as they're made, rather than once informer caches catch up, but it
+// makes a request for every action a rule describes. It also consults every authorizer the API server is configured
+// with, and doesn't exclude RBAC resources owned by other CSVs.
+type SARRuleChecker struct {
+	client kubernetes.Interface
+}
+
+var _ RuleChecker = &SARRuleChecker{}
+
+// NewSARRuleChecker returns a pointer to a new SARRuleChecker
+func NewSARRuleChecker(client kubernetes.Interface) *SARRuleChecker {
+	return &SARRuleChecker{
+		client: client,
+	}
+}
+
+// RuleSatisfied returns true if a ServiceAccount is authorized to perform all actions described by a PolicyRule in a namespace
+func (c *SARRuleChecker) RuleSatisfied(sa *corev1.ServiceAccount, namespace string, rule rbacv1.PolicyRule) (bool, error) {
+	return c.ruleSatisfied(toDefaultInfo(sa), namespace, rule)
+}
+
+// RuleSatisfiedFor returns true if a subject is authorized to perform all actions described by a PolicyRule in a namespace
+func (c *SARRuleChecker) RuleSatisfiedFor(subject rbacv1.Subject, namespace string, rule rbacv1.PolicyRule) (bool, error) {
+	user, err := subjectInfo(subject)
+	if err != nil {
+		return false, err
+	}
+
+	return c.ruleSatisfied(user, namespace, rule)
+}
+
+func (c *SARRuleChecker) ruleSatisfied(user user.Info, namespace string, rule rbacv1.PolicyRule) (bool, error) {
+	for _, attributes := range toAttributesSet(user, namespace, rule) {
+		review := &authorizationv1.SubjectAccessReview{
+			Spec: authorizationv1.SubjectAccessReviewSpec{
+				User:   user.GetName(),
+				Groups: user.GetGroups(),
+				UID:    user.GetUID(),
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Namespace: attributes.GetNamespace(),
+					Verb:      attributes.GetVerb(),
+					Group:     attributes.GetAPIGroup(),
+					Resource:  attributes.GetResource(),
+					Name:      attributes.GetName(),
+				},
+			},
+		}
+
+		result, err := c.client.AuthorizationV1().SubjectAccessReviews().Create(review)
+		if err != nil {
+			return false, err
+		}
+		if !result.Status.Allowed {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,62 @@
+package install
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestSARRuleCheckerRuleSatisfiedFor(t *testing.T) {
+	rule := rbacv1.PolicyRule{Verbs: []string{"get", "list"}, APIGroups: []string{"apps"}, Resources: []string{"deployments"}}
+
+	tests := []struct {
+		description     string
+		allowedVerbs    map[string]bool
+		expected        bool
+		expectedReviews int
+	}{
+		{
+			description:     "AllAllowed",
+			allowedVerbs:    map[string]bool{"get": true, "list": true},
+			expected:        true,
+			expectedReviews: 2,
+		},
+		{
+			description:  "OneDenied",
+			allowedVerbs: map[string]bool{"get": true},
+			expected:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			client := k8sfake.NewSimpleClientset()
+			reviewed := []authorizationv1.ResourceAttributes{}
+			client.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+				require.Equal(t, "system:serviceaccount:coffee-shop:barista-operator", review.Spec.User)
+				reviewed = append(reviewed, *review.Spec.ResourceAttributes)
+				review.Status.Allowed = tt.allowedVerbs[review.Spec.ResourceAttributes.Verb]
+				return true, review, nil
+			})
+
+			checker := NewSARRuleChecker(client)
+			satisfied, err := checker.RuleSatisfiedFor(rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: "barista-operator", Namespace: "coffee-shop"}, "coffee-shop", rule)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, satisfied)
+			if tt.expectedReviews > 0 {
+				require.Len(t, reviewed, tt.expectedReviews)
+			}
+			for _, attributes := range reviewed {
+				require.Equal(t, "coffee-shop", attributes.Namespace)
+				require.Equal(t, "apps", attributes.Group)
+				require.Equal(t, "deployments", attributes.Resource)
+			}
+		})
+	}
+}
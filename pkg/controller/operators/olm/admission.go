@@ -0,0 +1,162 @@
+package olm
+
+import (
+	"fmt"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+)
+
+// clusterScopedResources are the built-in resources that only exist at cluster scope, keyed by API group and then
+// resource. A namespaced permission can never grant access to them.
+var clusterScopedResources = map[string]map[string]struct{}{
+	"": {
+		"componentstatuses": {},
+		"namespaces":        {},
+		"nodes":             {},
+		"persistentvolumes": {},
+	},
+	"admissionregistration.k8s.io": {
+		"mutatingwebhookconfigurations":   {},
+		"validatingwebhookconfigurations": {},
+	},
+	"apiextensions.k8s.io": {
+		"customresourcedefinitions": {},
+	},
+	"apiregistration.k8s.io": {
+		"apiservices": {},
+	},
+	"certificates.k8s.io": {
+		"certificatesigningrequests": {},
+	},
+	"rbac.authorization.k8s.io": {
+		"clusterrolebindings": {},
+		"clusterroles":        {},
+	},
+	"scheduling.k8s.io": {
+		"priorityclasses": {},
+	},
+	"storage.k8s.io": {
+		"storageclasses":    {},
+		"volumeattachments": {},
+	},
+}
+
+// AdmitClusterServiceVersion returns an Invalid error listing the requirements of a CSV that can never be met, or nil
+// if there are none, so that admission can reject such CSVs rather than let them sit Pending forever
+func AdmitClusterServiceVersion(csv *v1alpha1.ClusterServiceVersion) error {
+	errs := UnsatisfiableRequirements(csv)
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return k8serrors.NewInvalid(schema.GroupKind{Group: v1alpha1.GroupName, Kind: v1alpha1.ClusterServiceVersionKind}, csv.GetName(), errs)
+}
+
+// UnsatisfiableRequirements statically analyzes a CSV's requirements, returning an error for each one that can never
+// be met whatever the cluster serves. Requirements that could be met by installing or changing something on the
+// cluster aren't reported.
+func UnsatisfiableRequirements(csv *v1alpha1.ClusterServiceVersion) field.ErrorList {
+	errs := field.ErrorList{}
+	specPath := field.NewPath("spec")
+
+	clusterScopedCRDs := map[schema.GroupResource]struct{}{}
+	crdPath := specPath.Child("customresourcedefinitions")
+	for _, descs := range []struct {
+		path  *field.Path
+		descs []v1alpha1.CRDDescription
+	}{
+		{crdPath.Child("owned"), csv.Spec.CustomResourceDefinitions.Owned},
+		{crdPath.Child("required"), csv.Spec.CustomResourceDefinitions.Required},
+	} {
+		for i, desc := range descs.descs {
+			path := descs.path.Index(i)
+			// CRD names are always <plural>.<group>, so any other name is never found
+			plural, group := desc.Name, ""
+			if i := strings.Index(desc.Name, "."); i >= 0 {
+				plural, group = desc.Name[:i], desc.Name[i+1:]
+			}
+			if plural == "" || group == "" {
+				errs = append(errs, field.Invalid(path.Child("name"), desc.Name, "must be <plural>.<group>"))
+			}
+			switch v1beta1.ResourceScope(desc.Scope) {
+			case "", v1beta1.NamespaceScoped:
+			case v1beta1.ClusterScoped:
+				clusterScopedCRDs[schema.GroupResource{Group: group, Resource: plural}] = struct{}{}
+			default:
+				errs = append(errs, field.NotSupported(path.Child("scope"), desc.Scope, []string{string(v1beta1.NamespaceScoped), string(v1beta1.ClusterScoped)}))
+			}
+		}
+	}
+
+	deployments, deploymentsKnown := strategyDeploymentNames(csv)
+	apiServicePath := specPath.Child("apiservicedefinitions")
+	for _, descs := range []struct {
+		path  *field.Path
+		owned bool
+		descs []v1alpha1.APIServiceDescription
+	}{
+		{apiServicePath.Child("owned"), true, csv.Spec.APIServiceDefinitions.Owned},
+		{apiServicePath.Child("required"), false, csv.Spec.APIServiceDefinitions.Required},
+	} {
+		for i, desc := range descs.descs {
+			path := descs.path.Index(i)
+			// a name that includes its version is tolerated, but one that includes it twice names a group no
+			// APIService can serve
+			if group, _ := apiServiceGroupAndName(desc); desc.Version != "" && strings.HasPrefix(group, desc.Version+".") {
+				errs = append(errs, field.Invalid(path.Child("name"), desc.Name, fmt.Sprintf("includes version %s more than once; it should be the API group", desc.Version)))
+			}
+			if !descs.owned || desc.DeploymentName == "" || !deploymentsKnown {
+				continue
+			}
+			if _, ok := deployments[desc.DeploymentName]; !ok {
+				errs = append(errs, field.NotFound(path.Child("deploymentName"), desc.DeploymentName))
+			}
+		}
+	}
+
+	// an invalid install strategy fails the CSV on its own, so there are no permissions to analyze
+	details, ok := strategyDeploymentDetails(csv)
+	if !ok {
+		return errs
+	}
+	permissionsPath := specPath.Child("install", "spec", "permissions")
+	for i, perm := range details.Permissions {
+		for j, rule := range perm.Rules {
+			rulePath := permissionsPath.Index(i).Child("rules").Index(j)
+			if len(rule.NonResourceURLs) > 0 {
+				errs = append(errs, field.Invalid(rulePath.Child("nonResourceURLs"), rule.NonResourceURLs, "non-resource URLs can only be granted by clusterPermissions"))
+			}
+			for _, resource := range clusterScopedRuleResources(rule, clusterScopedCRDs) {
+				errs = append(errs, field.Invalid(rulePath.Child("resources"), resource, "cluster-scoped resources can only be granted by clusterPermissions"))
+			}
+		}
+	}
+
+	return errs
+}
+
+// clusterScopedRuleResources returns the resources a rule names explicitly that are cluster-scoped, either built-in or
+// one of the given CRDs. Wildcards aren't reported, since they also match namespaced resources.
+func clusterScopedRuleResources(rule rbacv1.PolicyRule, clusterScopedCRDs map[schema.GroupResource]struct{}) []string {
+	scoped := []string{}
+	for _, resource := range rule.Resources {
+		// subresources share the scope of their resource
+		name := strings.SplitN(resource, "/", 2)[0]
+		for _, group := range rule.APIGroups {
+			_, builtin := clusterScopedResources[group][name]
+			_, crd := clusterScopedCRDs[schema.GroupResource{Group: group, Resource: name}]
+			if builtin || crd {
+				scoped = append(scoped, resource)
+				break
+			}
+		}
+	}
+	return scoped
+}
@@ -0,0 +1,107 @@
+package olm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/install"
+)
+
+func TestUnsatisfiableRequirements(t *testing.T) {
+	namespace := "ns"
+	permissions := func(rules ...rbacv1.PolicyRule) []install.StrategyDeploymentPermissions {
+		return []install.StrategyDeploymentPermissions{{ServiceAccountName: "sa", Rules: rules}}
+	}
+
+	tests := []struct {
+		description string
+		crds        []v1alpha1.CRDDescription
+		owned       []v1alpha1.APIServiceDescription
+		required    []v1alpha1.APIServiceDescription
+		permissions []install.StrategyDeploymentPermissions
+		// clusterPermissions are never reported, whatever they grant
+		clusterPermissions []install.StrategyDeploymentPermissions
+		expectedFields     []string
+	}{
+		{
+			description: "Satisfiable",
+			crds:        []v1alpha1.CRDDescription{{Name: "c1s.c1group", Version: "v1", Kind: "C1", Scope: "Cluster"}},
+			owned:       []v1alpha1.APIServiceDescription{{Name: "a1group", Version: "v1", Kind: "A1", DeploymentName: "csv1-dep1"}},
+			required:    []v1alpha1.APIServiceDescription{{Name: "v1.a2group", Version: "v1", Kind: "A2"}},
+			permissions: permissions(rbacv1.PolicyRule{APIGroups: []string{"", "*"}, Resources: []string{"pods", "*"}, Verbs: []string{"get"}}),
+			clusterPermissions: permissions(
+				rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"nodes"}, Verbs: []string{"get"}},
+				rbacv1.PolicyRule{NonResourceURLs: []string{"/healthz"}, Verbs: []string{"get"}},
+			),
+		},
+		{
+			description:    "CRDNameWithoutGroup",
+			crds:           []v1alpha1.CRDDescription{{Name: "c1s", Version: "v1", Kind: "C1"}},
+			expectedFields: []string{"spec.customresourcedefinitions.required[0].name"},
+		},
+		{
+			description:    "CRDUnknownScope",
+			crds:           []v1alpha1.CRDDescription{{Name: "c1s.c1group", Version: "v1", Kind: "C1", Scope: "Global"}},
+			expectedFields: []string{"spec.customresourcedefinitions.required[0].scope"},
+		},
+		{
+			description:    "APIServiceNameDoublePrefixed",
+			required:       []v1alpha1.APIServiceDescription{{Name: "v1.v1.a1group", Version: "v1", Kind: "A1"}},
+			expectedFields: []string{"spec.apiservicedefinitions.required[0].name"},
+		},
+		{
+			description:    "OwnedAPIServiceDeploymentNotInStrategy",
+			owned:          []v1alpha1.APIServiceDescription{{Name: "a1group", Version: "v1", Kind: "A1", DeploymentName: "other"}},
+			expectedFields: []string{"spec.apiservicedefinitions.owned[0].deploymentName"},
+		},
+		{
+			description:    "NamespacedPermissionOnClusterScopedResource",
+			permissions:    permissions(rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"pods", "nodes/status"}, Verbs: []string{"get"}}),
+			expectedFields: []string{"spec.install.spec.permissions[0].rules[0].resources"},
+		},
+		{
+			description:    "NamespacedPermissionOnClusterScopedCRD",
+			crds:           []v1alpha1.CRDDescription{{Name: "c1s.c1group", Version: "v1", Kind: "C1", Scope: "Cluster"}},
+			permissions:    permissions(rbacv1.PolicyRule{APIGroups: []string{"c1group"}, Resources: []string{"c1s"}, Verbs: []string{"get"}}),
+			expectedFields: []string{"spec.install.spec.permissions[0].rules[0].resources"},
+		},
+		{
+			description:    "NamespacedPermissionOnNonResourceURL",
+			permissions:    permissions(rbacv1.PolicyRule{NonResourceURLs: []string{"/metrics"}, Verbs: []string{"get"}}),
+			expectedFields: []string{"spec.install.spec.permissions[0].rules[0].nonResourceURLs"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			csv := withAPIServices(csv("csv1",
+				namespace,
+				"",
+				withPermissions(installStrategy("csv1-dep1"), tt.permissions, tt.clusterPermissions),
+				[]*v1beta1.CustomResourceDefinition{},
+				[]*v1beta1.CustomResourceDefinition{},
+				v1alpha1.CSVPhaseNone,
+			), tt.owned, tt.required)
+			csv.Spec.CustomResourceDefinitions.Required = tt.crds
+
+			errs := UnsatisfiableRequirements(csv)
+			fields := []string{}
+			for _, err := range errs {
+				fields = append(fields, err.Field)
+			}
+			require.ElementsMatch(t, tt.expectedFields, fields)
+
+			err := AdmitClusterServiceVersion(csv)
+			if len(tt.expectedFields) == 0 {
+				require.NoError(t, err)
+			} else {
+				require.True(t, k8serrors.IsInvalid(err))
+			}
+		})
+	}
+}
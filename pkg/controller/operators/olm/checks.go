@@ -0,0 +1,90 @@
+package olm
+
+import (
+	"fmt"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/operatorclient"
+)
+
+// RequirementCheck is an additional requirement evaluated for every CSV after its built-in requirements.
+// Its statuses are recorded alongside the built-in ones, and, like them, make the CSV's requirements unmet only if
+// their reasons are blocking (see v1alpha1.ReasonSeverity). A check that reports unmet without any status also makes
+// them unmet.
+type RequirementCheck interface {
+	CheckRequirements(csv *v1alpha1.ClusterServiceVersion) (met bool, statuses []v1alpha1.RequirementStatus)
+}
+
+// RegisterRequirementCheck adds a check to the requirements evaluated for every CSV
+func (a *Operator) RegisterRequirementCheck(check RequirementCheck) {
+	a.requirementChecks = append(a.requirementChecks, check)
+}
+
+// NamespaceApprovalCheck requires a CSV's namespace to carry an approval label or annotation, so that cluster admins
+// can gate which namespaces operators are installed into.
+// CSVs are rechecked on the operator's wakeup interval, so approving a namespace takes effect on the next recheck.
+type NamespaceApprovalCheck struct {
+	client operatorclient.ClientInterface
+	key    string
+	value  string
+}
+
+var _ RequirementCheck = &NamespaceApprovalCheck{}
+
+// NewNamespaceApprovalCheck returns a NamespaceApprovalCheck that approves namespaces with a label or annotation with
+// the given key. If value is empty any value approves the namespace, otherwise only the given value does.
+func NewNamespaceApprovalCheck(client operatorclient.ClientInterface, key, value string) *NamespaceApprovalCheck {
+	return &NamespaceApprovalCheck{
+		client: client,
+		key:    key,
+		value:  value,
+	}
+}
+
+// CheckRequirements reports a single Namespace status for the CSV's namespace, NamespaceNotApproved if it doesn't
+// carry the approval label or annotation
+func (c *NamespaceApprovalCheck) CheckRequirements(csv *v1alpha1.ClusterServiceVersion) (bool, []v1alpha1.RequirementStatus) {
+	status := v1alpha1.RequirementStatus{
+		Group:   "",
+		Version: "v1",
+		Kind:    "Namespace",
+		Name:    csv.GetNamespace(),
+	}
+
+	namespace, err := c.client.KubernetesInterface().CoreV1().Namespaces().Get(csv.GetNamespace(), metav1.GetOptions{})
+	if k8serrors.IsForbidden(err) {
+		status.Status = v1alpha1.RequirementStatusReasonAccessDenied
+		status.Message = fmt.Sprintf("OLM is not permitted to get Namespace %s; ensure OLM's ServiceAccount can read Namespaces: %s", csv.GetNamespace(), err)
+		return false, []v1alpha1.RequirementStatus{status}
+	} else if err != nil {
+		status.Status = v1alpha1.RequirementStatusReasonNotPresent
+		status.Message = err.Error()
+		return false, []v1alpha1.RequirementStatus{status}
+	}
+	status.UUID = string(namespace.GetUID())
+
+	if !c.approved(namespace.GetLabels()) && !c.approved(namespace.GetAnnotations()) {
+		status.Status = v1alpha1.RequirementStatusReasonNamespaceNotApproved
+		status.Message = fmt.Sprintf("Namespace %s has no %s label or annotation approving operator installs", csv.GetNamespace(), c.approval())
+		return false, []v1alpha1.RequirementStatus{status}
+	}
+
+	status.Status = v1alpha1.RequirementStatusReasonPresent
+	return true, []v1alpha1.RequirementStatus{status}
+}
+
+func (c *NamespaceApprovalCheck) approved(values map[string]string) bool {
+	value, ok := values[c.key]
+	return ok && (c.value == "" || value == c.value)
+}
+
+// approval returns the label or annotation the check looks for, as key or key=value
+func (c *NamespaceApprovalCheck) approval() string {
+	if c.value == "" {
+		return c.key
+	}
+	return fmt.Sprintf("%s=%s", c.key, c.value)
+}
@@ -0,0 +1,173 @@
+package olm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/install"
+)
+
+func TestNamespaceApprovalCheck(t *testing.T) {
+	// the fake operator creates its own namespace, so the CSVs are in another one
+	namespace := "tenant"
+
+	tests := []struct {
+		description    string
+		namespace      *corev1.Namespace
+		value          string
+		expectedMet    bool
+		expectedStatus v1alpha1.StatusReason
+		expectedMsg    string
+	}{
+		{
+			description:    "ApprovedByAnnotation",
+			namespace:      &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace, Annotations: map[string]string{"olm.approved": "true"}}},
+			expectedMet:    true,
+			expectedStatus: v1alpha1.RequirementStatusReasonPresent,
+		},
+		{
+			description:    "ApprovedByLabel",
+			namespace:      &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace, Labels: map[string]string{"olm.approved": "true"}}},
+			expectedMet:    true,
+			expectedStatus: v1alpha1.RequirementStatusReasonPresent,
+		},
+		{
+			description:    "ApprovedByValue",
+			namespace:      &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace, Annotations: map[string]string{"olm.approved": "true"}}},
+			value:          "true",
+			expectedMet:    true,
+			expectedStatus: v1alpha1.RequirementStatusReasonPresent,
+		},
+		{
+			description:    "WrongValue",
+			namespace:      &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace, Annotations: map[string]string{"olm.approved": "false"}}},
+			value:          "true",
+			expectedMet:    false,
+			expectedStatus: v1alpha1.RequirementStatusReasonNamespaceNotApproved,
+			expectedMsg:    "Namespace tenant has no olm.approved=true label or annotation approving operator installs",
+		},
+		{
+			description:    "Unapproved",
+			namespace:      &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace, Annotations: map[string]string{"other": "true"}}},
+			expectedMet:    false,
+			expectedStatus: v1alpha1.RequirementStatusReasonNamespaceNotApproved,
+			expectedMsg:    "Namespace tenant has no olm.approved label or annotation approving operator installs",
+		},
+		{
+			description:    "NamespaceNotFound",
+			expectedMet:    false,
+			expectedStatus: v1alpha1.RequirementStatusReasonNotPresent,
+			expectedMsg:    `namespaces "tenant" not found`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			k8sObjs := []runtime.Object{}
+			if tt.namespace != nil {
+				k8sObjs = append(k8sObjs, tt.namespace)
+			}
+			op, err := NewFakeOperator(nil, k8sObjs, nil, nil, &install.StrategyResolver{}, "ns")
+			require.NoError(t, err)
+
+			csv := csv("csv1",
+				namespace,
+				"",
+				installStrategy("csv1-dep1"),
+				[]*v1beta1.CustomResourceDefinition{},
+				[]*v1beta1.CustomResourceDefinition{},
+				v1alpha1.CSVPhasePending,
+			)
+
+			// without the check, the CSV has no unmet requirements
+			met, _ := op.requirementStatus(csv)
+			require.True(t, met)
+
+			op.RegisterRequirementCheck(NewNamespaceApprovalCheck(op.OpClient, "olm.approved", tt.value))
+			met, statuses := op.requirementStatus(csv)
+			require.Equal(t, tt.expectedMet, met)
+
+			status := requirementStatusFor(statuses, "Namespace", namespace)
+			require.NotNil(t, status)
+			require.Equal(t, "v1", status.Version)
+			require.Equal(t, tt.expectedStatus, status.Status)
+			require.Equal(t, tt.expectedMsg, status.Message)
+		})
+	}
+}
+
+// fixedCheck reports the same result for every CSV
+type fixedCheck struct {
+	met      bool
+	statuses []v1alpha1.RequirementStatus
+}
+
+func (c fixedCheck) CheckRequirements(csv *v1alpha1.ClusterServiceVersion) (bool, []v1alpha1.RequirementStatus) {
+	return c.met, c.statuses
+}
+
+func TestRequirementStatusSeverity(t *testing.T) {
+	namespace := "ns"
+	v1alpha1.RegisterReasonSeverity("TestLicenseExpiring", v1alpha1.SeverityWarning)
+	status := func(reason v1alpha1.StatusReason) []v1alpha1.RequirementStatus {
+		return []v1alpha1.RequirementStatus{{Version: "v1", Kind: "License", Name: "license", Status: reason}}
+	}
+
+	tests := []struct {
+		description string
+		check       fixedCheck
+		expectedMet bool
+	}{
+		{
+			description: "Info",
+			check:       fixedCheck{met: true, statuses: status(v1alpha1.RequirementStatusReasonPresent)},
+			expectedMet: true,
+		},
+		{
+			description: "Warning",
+			check:       fixedCheck{met: false, statuses: status("TestLicenseExpiring")},
+			expectedMet: true,
+		},
+		{
+			description: "Blocking",
+			check:       fixedCheck{met: true, statuses: status(v1alpha1.RequirementStatusReasonNotPresent)},
+			expectedMet: false,
+		},
+		{
+			description: "Unregistered",
+			check:       fixedCheck{met: true, statuses: status("TestLicenseRevoked")},
+			expectedMet: false,
+		},
+		{
+			description: "UnmetWithoutStatus",
+			check:       fixedCheck{met: false},
+			expectedMet: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			op, err := NewFakeOperator(nil, nil, nil, nil, &install.StrategyResolver{}, namespace)
+			require.NoError(t, err)
+			op.RegisterRequirementCheck(tt.check)
+
+			csv := csv("csv1",
+				namespace,
+				"",
+				installStrategy("csv1-dep1"),
+				[]*v1beta1.CustomResourceDefinition{},
+				[]*v1beta1.CustomResourceDefinition{},
+				v1alpha1.CSVPhasePending,
+			)
+			met, statuses := op.requirementStatus(csv)
+			require.Equal(t, tt.expectedMet, met)
+			require.Len(t, statuses, len(tt.check.statuses))
+		})
+	}
+}
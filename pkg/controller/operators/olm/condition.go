@@ -0,0 +1,47 @@
+package olm
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+)
+
+// maxConditionUnmet caps the number of unmet requirements named in the message of a RequirementsMet condition
+const maxConditionUnmet = 5
+
+// requirementsCondition returns the RequirementsMet condition of a CSV whose requirement checks returned the given
+// results. Its message names the requirements with blocking statuses, if any.
+func requirementsCondition(csv *v1alpha1.ClusterServiceVersion, met bool, statuses []v1alpha1.RequirementStatus) v1alpha1.ClusterServiceVersionStatusCondition {
+	condition := v1alpha1.ClusterServiceVersionStatusCondition{
+		Type:               v1alpha1.CSVConditionRequirementsMet,
+		Status:             corev1.ConditionTrue,
+		ObservedGeneration: csv.GetGeneration(),
+		Reason:             v1alpha1.CSVReasonRequirementsMet,
+		Message:            fmt.Sprintf("all %d requirements met", len(statuses)),
+	}
+	if met {
+		return condition
+	}
+
+	condition.Status = corev1.ConditionFalse
+	condition.Reason = v1alpha1.CSVReasonRequirementsNotMet
+	unmet := []string{}
+	for _, status := range statuses {
+		if !v1alpha1.RequirementsMet([]v1alpha1.RequirementStatus{status}) {
+			unmet = append(unmet, fmt.Sprintf("%s %s (%s)", status.Kind, status.Name, status.Status))
+		}
+	}
+	switch {
+	case len(unmet) == 0:
+		// a custom check can leave the requirements unmet without a blocking status
+		condition.Message = "one or more requirements couldn't be checked"
+	case len(unmet) > maxConditionUnmet:
+		condition.Message = fmt.Sprintf("%d of %d requirements not met: %s, and %d more", len(unmet), len(statuses), strings.Join(unmet[:maxConditionUnmet], ", "), len(unmet)-maxConditionUnmet)
+	default:
+		condition.Message = fmt.Sprintf("%d of %d requirements not met: %s", len(unmet), len(statuses), strings.Join(unmet, ", "))
+	}
+	return condition
+}
@@ -0,0 +1,138 @@
+package olm
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/install"
+)
+
+func TestRequirementsConditionTransitions(t *testing.T) {
+	namespace := "ns"
+	pending := csv("csv1",
+		namespace,
+		"",
+		installStrategy("csv1-dep1"),
+		[]*v1beta1.CustomResourceDefinition{},
+		[]*v1beta1.CustomResourceDefinition{crd("c1", "v1")},
+		v1alpha1.CSVPhasePending,
+	)
+
+	// unmet checks requirements without the required CRD, and met with it
+	unmet, err := NewFakeOperator([]runtime.Object{pending}, nil, nil, nil, &install.StrategyResolver{}, namespace)
+	require.NoError(t, err)
+	met, err := NewFakeOperator([]runtime.Object{pending}, nil, []runtime.Object{crd("c1", "v1")}, nil, &install.StrategyResolver{}, namespace)
+	require.NoError(t, err)
+
+	// the condition's transition times come from the operators' clock
+	now := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	unmet.clock = clock.NewFakeClock(now)
+	met.clock = clock.NewFakeClock(now)
+	longAgo := metav1.NewTime(time.Unix(0, 0))
+	check := func(op *Operator, in *v1alpha1.ClusterServiceVersion) *v1alpha1.ClusterServiceVersion {
+		in = in.DeepCopy()
+		in.Status.Phase = v1alpha1.CSVPhasePending
+		out, _ := op.transitionCSVState(*in)
+		require.Len(t, out.Status.StatusConditions, 1)
+		return out
+	}
+
+	// the condition is added when requirements are first checked
+	out := check(unmet, pending)
+	condition := out.GetStatusCondition(v1alpha1.CSVConditionRequirementsMet)
+	require.NotNil(t, condition)
+	require.Equal(t, corev1.ConditionFalse, condition.Status)
+	require.Equal(t, v1alpha1.CSVReasonRequirementsNotMet, condition.Reason)
+	require.Equal(t, "1 of 1 requirements not met: CustomResourceDefinition c1group (NotPresent)", condition.Message)
+	require.True(t, condition.LastTransitionTime.Equal(&metav1.Time{Time: now}))
+	condition.LastTransitionTime = longAgo
+
+	// rechecking unmet requirements keeps the transition time
+	out = check(unmet, out)
+	condition = out.GetStatusCondition(v1alpha1.CSVConditionRequirementsMet)
+	require.Equal(t, corev1.ConditionFalse, condition.Status)
+	require.Equal(t, longAgo, condition.LastTransitionTime)
+
+	// meeting the requirements transitions the condition
+	out = check(met, out)
+	condition = out.GetStatusCondition(v1alpha1.CSVConditionRequirementsMet)
+	require.Equal(t, corev1.ConditionTrue, condition.Status)
+	require.Equal(t, v1alpha1.CSVReasonRequirementsMet, condition.Reason)
+	require.Equal(t, "all 1 requirements met", condition.Message)
+	require.True(t, condition.LastTransitionTime.Equal(&metav1.Time{Time: now}))
+	condition.LastTransitionTime = longAgo
+
+	out = check(met, out)
+	condition = out.GetStatusCondition(v1alpha1.CSVConditionRequirementsMet)
+	require.Equal(t, corev1.ConditionTrue, condition.Status)
+	require.Equal(t, longAgo, condition.LastTransitionTime)
+
+	// and losing them transitions it back
+	out = check(unmet, out)
+	condition = out.GetStatusCondition(v1alpha1.CSVConditionRequirementsMet)
+	require.Equal(t, corev1.ConditionFalse, condition.Status)
+	require.Equal(t, v1alpha1.CSVReasonRequirementsNotMet, condition.Reason)
+	require.True(t, condition.LastTransitionTime.Equal(&metav1.Time{Time: now}))
+}
+
+func TestRequirementsConditionMessage(t *testing.T) {
+	crdStatus := func(name string, reason v1alpha1.StatusReason) v1alpha1.RequirementStatus {
+		return v1alpha1.RequirementStatus{Kind: "CustomResourceDefinition", Name: name, Status: reason}
+	}
+	manyUnmet := []v1alpha1.RequirementStatus{crdStatus("present", v1alpha1.RequirementStatusReasonPresent)}
+	for i := 0; i < maxConditionUnmet+2; i++ {
+		manyUnmet = append(manyUnmet, crdStatus(fmt.Sprintf("c%d", i), v1alpha1.RequirementStatusReasonNotPresent))
+	}
+
+	tests := []struct {
+		description     string
+		met             bool
+		statuses        []v1alpha1.RequirementStatus
+		expectedMessage string
+	}{
+		{
+			description:     "Met",
+			met:             true,
+			statuses:        []v1alpha1.RequirementStatus{crdStatus("c0", v1alpha1.RequirementStatusReasonPresent)},
+			expectedMessage: "all 1 requirements met",
+		},
+		{
+			description: "DependentNotSatisfied",
+			statuses: []v1alpha1.RequirementStatus{
+				crdStatus("c0", v1alpha1.RequirementStatusReasonPresent),
+				{Kind: "ServiceAccount", Name: "sa", Status: v1alpha1.RequirementStatusReasonPresent, Dependents: []v1alpha1.DependentStatus{{Status: v1alpha1.DependentStatusReasonNotSatisfied}}},
+			},
+			expectedMessage: "1 of 2 requirements not met: ServiceAccount sa (Present)",
+		},
+		{
+			description:     "Capped",
+			statuses:        manyUnmet,
+			expectedMessage: "7 of 8 requirements not met: CustomResourceDefinition c0 (NotPresent), CustomResourceDefinition c1 (NotPresent), CustomResourceDefinition c2 (NotPresent), CustomResourceDefinition c3 (NotPresent), CustomResourceDefinition c4 (NotPresent), and 2 more",
+		},
+		{
+			description:     "NoBlockingStatus",
+			statuses:        []v1alpha1.RequirementStatus{crdStatus("c0", v1alpha1.RequirementStatusReasonPresent)},
+			expectedMessage: "one or more requirements couldn't be checked",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			csv := &v1alpha1.ClusterServiceVersion{}
+			csv.SetGeneration(3)
+			condition := requirementsCondition(csv, tt.met, tt.statuses)
+			require.Equal(t, v1alpha1.CSVConditionRequirementsMet, condition.Type)
+			require.Equal(t, int64(3), condition.ObservedGeneration)
+			require.Equal(t, tt.expectedMessage, condition.Message)
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package olm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/ghodss/yaml"
+	log "github.com/sirupsen/logrus"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+)
+
+// RequirementsReport is the fully evaluated requirement status of a CSV, grouped by kind of requirement.
+// Requirements of any other kind, such as PriorityClasses and Deployments, are listed in Other.
+type RequirementsReport struct {
+	Namespace                 string                       `json:"namespace"`
+	Name                      string                       `json:"name"`
+	Met                       bool                         `json:"met"`
+	CustomResourceDefinitions []v1alpha1.RequirementStatus `json:"customResourceDefinitions"`
+	APIServices               []v1alpha1.RequirementStatus `json:"apiServices"`
+	Permissions               []v1alpha1.RequirementStatus `json:"permissions"`
+	Other                     []v1alpha1.RequirementStatus `json:"other"`
+}
+
+// RequirementsReportFor evaluates the requirements of the CSV with the given namespace and name.
+// The CSV is not modified, and no requirement traces are recorded.
+func (a *Operator) RequirementsReportFor(namespace, name string) (*RequirementsReport, error) {
+	csv, err := a.client.OperatorsV1alpha1().ClusterServiceVersions(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := a.requirementsSnapshot()
+	snapshot.untraced = true
+	met, statuses := a.requirementStatusFromSnapshot(csv.DeepCopy(), snapshot)
+
+	return newRequirementsReport(namespace, name, met, statuses), nil
+}
+
+// newRequirementsReport groups statuses by kind of requirement
+func newRequirementsReport(namespace, name string, met bool, statuses []v1alpha1.RequirementStatus) *RequirementsReport {
+	report := &RequirementsReport{
+		Namespace:                 namespace,
+		Name:                      name,
+		Met:                       met,
+		CustomResourceDefinitions: []v1alpha1.RequirementStatus{},
+		APIServices:               []v1alpha1.RequirementStatus{},
+		Permissions:               []v1alpha1.RequirementStatus{},
+		Other:                     []v1alpha1.RequirementStatus{},
+	}
+	for _, status := range statuses {
+		switch status.Kind {
+		case "CustomResourceDefinition":
+			report.CustomResourceDefinitions = append(report.CustomResourceDefinitions, status)
+		case "APIService":
+			report.APIServices = append(report.APIServices, status)
+		case "ServiceAccount":
+			report.Permissions = append(report.Permissions, status)
+		default:
+			report.Other = append(report.Other, status)
+		}
+	}
+
+	return report
+}
+
+// RequirementsHandler serves the RequirementsReport of the CSV named by the namespace and name query parameters.
+// The report is JSON unless the output query parameter is "yaml". Requirements are evaluated on each request.
+func (a *Operator) RequirementsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, fmt.Sprintf("method %s not allowed", r.Method), http.StatusMethodNotAllowed)
+			return
+		}
+
+		namespace, name := r.URL.Query().Get("namespace"), r.URL.Query().Get("name")
+		if namespace == "" || name == "" {
+			http.Error(w, "namespace and name are required", http.StatusBadRequest)
+			return
+		}
+
+		report, err := a.RequirementsReportFor(namespace, name)
+		if k8serrors.IsNotFound(err) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		out, err := json.Marshal(report)
+		contentType := "application/json"
+		if err == nil && r.URL.Query().Get("output") == "yaml" {
+			out, err = yaml.JSONToYAML(out)
+			contentType = "application/yaml"
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		if _, err := w.Write(out); err != nil {
+			log.Warnf("error writing requirements report: %s", err)
+		}
+	})
+}
@@ -0,0 +1,129 @@
+package olm
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+	apiregistrationv1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/client/clientset/versioned/fake"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/install"
+)
+
+func TestRequirementsHandler(t *testing.T) {
+	namespace := "ns"
+	permissions := []install.StrategyDeploymentPermissions{
+		{
+			ServiceAccountName: "sa",
+			Rules: []rbacv1.PolicyRule{
+				{
+					Verbs:     []string{"get", "list"},
+					APIGroups: []string{""},
+					Resources: []string{"pods"},
+				},
+			},
+		},
+	}
+	csv := withAPIServices(csv("csv1",
+		namespace,
+		"",
+		withPermissions(installStrategy("csv1-dep1"), permissions, nil),
+		[]*v1beta1.CustomResourceDefinition{},
+		[]*v1beta1.CustomResourceDefinition{crd("c1", "v1")},
+		v1alpha1.CSVPhasePending,
+	), nil, apis("a1.v1.a1Kind"))
+	csv.SetAnnotations(map[string]string{RequirementsTraceAnnotationKey: "true"})
+
+	op, err := NewFakeOperator(
+		[]runtime.Object{csv},
+		[]runtime.Object{serviceAccount("sa", namespace)},
+		[]runtime.Object{crd("c1", "v1")},
+		[]runtime.Object{apiService("a1", "v1", apiregistrationv1.ConditionTrue)},
+		&install.StrategyResolver{},
+		namespace,
+	)
+	require.NoError(t, err)
+	handler := op.RequirementsHandler()
+
+	tests := []struct {
+		description  string
+		method       string
+		query        string
+		expectedCode int
+	}{
+		{description: "JSON", method: http.MethodGet, query: "?namespace=ns&name=csv1", expectedCode: http.StatusOK},
+		{description: "YAML", method: http.MethodGet, query: "?namespace=ns&name=csv1&output=yaml", expectedCode: http.StatusOK},
+		{description: "NotFound", method: http.MethodGet, query: "?namespace=ns&name=missing", expectedCode: http.StatusNotFound},
+		{description: "MissingName", method: http.MethodGet, query: "?namespace=ns", expectedCode: http.StatusBadRequest},
+		{description: "NotGet", method: http.MethodPost, query: "?namespace=ns&name=csv1", expectedCode: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(tt.method, "/debug/requirements"+tt.query, nil))
+			require.Equal(t, tt.expectedCode, recorder.Code, recorder.Body.String())
+			if tt.expectedCode != http.StatusOK {
+				return
+			}
+
+			body := recorder.Body.Bytes()
+			if recorder.Header().Get("Content-Type") == "application/yaml" {
+				body, err = yaml.YAMLToJSON(body)
+				require.NoError(t, err)
+			}
+
+			sections := map[string]json.RawMessage{}
+			require.NoError(t, json.Unmarshal(body, &sections))
+			for _, section := range []string{"customResourceDefinitions", "apiServices", "permissions", "other"} {
+				require.Contains(t, sections, section)
+			}
+
+			report := RequirementsReport{}
+			require.NoError(t, json.Unmarshal(body, &report))
+			require.False(t, report.Met)
+			require.Len(t, report.CustomResourceDefinitions, 1)
+			require.Equal(t, v1alpha1.RequirementStatusReasonPresent, report.CustomResourceDefinitions[0].Status)
+			require.Len(t, report.APIServices, 1)
+			require.Equal(t, v1alpha1.RequirementStatusReasonPresent, report.APIServices[0].Status)
+
+			sa := requirementStatusFor(report.Permissions, "ServiceAccount", "sa")
+			require.NotNil(t, sa)
+			require.Equal(t, v1alpha1.RequirementStatusReasonPresentNotSatisfied, sa.Status)
+			require.Len(t, sa.Dependents, 1)
+			require.Contains(t, sa.Dependents[0].Message, `"verbs":["get","list"]`)
+		})
+	}
+
+	// the handler only reads the CSV, and doesn't record to its trace
+	for _, action := range op.client.(*fake.Clientset).Actions() {
+		require.Equal(t, "get", action.GetVerb())
+	}
+	require.Empty(t, op.RequirementsTrace(namespace, "csv1"))
+}
+
+func TestNewRequirementsReport(t *testing.T) {
+	statuses := []v1alpha1.RequirementStatus{
+		{Kind: "CustomResourceDefinition", Name: "c1", Status: v1alpha1.RequirementStatusReasonPresent},
+		{Kind: "APIService", Name: "v1.a1", Status: v1alpha1.RequirementStatusReasonPresent},
+		{Kind: "ServiceAccount", Name: "sa", Status: v1alpha1.RequirementStatusReasonPresent},
+		{Kind: "PriorityClass", Name: "high", Status: v1alpha1.RequirementStatusReasonNotPresent},
+		{Kind: "Deployment", Name: "dep", Status: v1alpha1.RequirementStatusReasonPresentNotSatisfied},
+		{Kind: v1alpha1.ClusterServiceVersionKind, Name: "csv1", Status: v1alpha1.RequirementStatusReasonNotPresent},
+		{Kind: "Namespace", Name: "ns", Status: v1alpha1.RequirementStatusReasonNamespaceNotApproved},
+	}
+
+	report := newRequirementsReport("ns", "csv1", false, statuses)
+	require.Equal(t, statuses[:1], report.CustomResourceDefinitions)
+	require.Equal(t, statuses[1:2], report.APIServices)
+	require.Equal(t, statuses[2:3], report.Permissions)
+	require.Equal(t, statuses[3:], report.Other)
+}
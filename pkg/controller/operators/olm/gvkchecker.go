@@ -0,0 +1,28 @@
+package olm
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/gvkcache"
+)
+
+// GVKChecker reports whether the cluster serves a group, version, and kind. An empty kind matches any resource served
+// under the group and version.
+// By default, requirement checks use a *gvkcache.Cache over the requirements client's discovery client.
+type GVKChecker interface {
+	Has(gvk schema.GroupVersionKind) (bool, error)
+}
+
+// gvkInvalidator is implemented by GVKCheckers that cache discovery information and need to be told when the served
+// APIs change
+type gvkInvalidator interface {
+	Invalidate()
+}
+
+var _ GVKChecker = &gvkcache.Cache{}
+var _ gvkInvalidator = &gvkcache.Cache{}
+
+// newDiscoveryGVKChecker returns the default GVKChecker for the given client
+func newDiscoveryGVKChecker(discovery gvkcache.ServerResourcesGetter) GVKChecker {
+	return gvkcache.New(discovery, gvkcache.DefaultTTL)
+}
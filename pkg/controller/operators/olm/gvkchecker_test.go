@@ -0,0 +1,310 @@
+package olm
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	apiregistrationv1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	olmErrors "github.com/operator-framework/operator-lifecycle-manager/pkg/controller/errors"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/install"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/metrics"
+)
+
+// fakeGVKChecker serves a fixed set of GVKs, or fails every check with err if it's set.
+// GVKs in registered are served only once the checker has been invalidated, as if they were registered after
+// discovery was cached.
+type fakeGVKChecker struct {
+	gvks        []schema.GroupVersionKind
+	registered  []schema.GroupVersionKind
+	err         error
+	checks      int
+	invalidated int
+}
+
+func (f *fakeGVKChecker) Has(gvk schema.GroupVersionKind) (bool, error) {
+	f.checks++
+	if f.err != nil {
+		return false, f.err
+	}
+	for _, served := range f.gvks {
+		if served.GroupVersion() != gvk.GroupVersion() {
+			continue
+		}
+		if gvk.Kind == "" || served.Kind == gvk.Kind {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *fakeGVKChecker) Invalidate() {
+	f.invalidated++
+	f.gvks = append(f.gvks, f.registered...)
+	f.registered = nil
+}
+
+func recheckCount(t *testing.T, found bool) float64 {
+	metric := &dto.Metric{}
+	require.NoError(t, metrics.GVKCacheRechecks.WithLabelValues(strconv.FormatBool(found)).Write(metric))
+	return metric.GetCounter().GetValue()
+}
+
+func TestIsGVKRegistered(t *testing.T) {
+	served := []schema.GroupVersionKind{{Group: "a1", Version: "v1", Kind: "a1Kind"}}
+	discoveryErr := errors.New("discovery unavailable")
+
+	tests := []struct {
+		description string
+		checker     *fakeGVKChecker
+		gvk         schema.GroupVersionKind
+		expectedErr error
+		// expectedRecheck is whether a miss is expected to invalidate the checker and check again
+		expectedRecheck bool
+		expectedFound   bool
+	}{
+		{
+			description: "Served",
+			checker:     &fakeGVKChecker{gvks: served},
+			gvk:         schema.GroupVersionKind{Group: "a1", Version: "v1", Kind: "a1Kind"},
+		},
+		{
+			description: "ServedGroupVersion",
+			checker:     &fakeGVKChecker{gvks: served},
+			gvk:         schema.GroupVersionKind{Group: "a1", Version: "v1"},
+		},
+		{
+			description:     "KindNotServed",
+			checker:         &fakeGVKChecker{gvks: served},
+			gvk:             schema.GroupVersionKind{Group: "a1", Version: "v1", Kind: "b1Kind"},
+			expectedErr:     olmErrors.NewGroupVersionKindNotFoundError("a1", "v1", "b1Kind"),
+			expectedRecheck: true,
+		},
+		{
+			description:     "VersionNotServed",
+			checker:         &fakeGVKChecker{gvks: served},
+			gvk:             schema.GroupVersionKind{Group: "a1", Version: "v2", Kind: "a1Kind"},
+			expectedErr:     olmErrors.NewGroupVersionKindNotFoundError("a1", "v2", "a1Kind"),
+			expectedRecheck: true,
+		},
+		{
+			description:     "RegisteredSinceCached",
+			checker:         &fakeGVKChecker{registered: served},
+			gvk:             schema.GroupVersionKind{Group: "a1", Version: "v1", Kind: "a1Kind"},
+			expectedRecheck: true,
+			expectedFound:   true,
+		},
+		{
+			description: "DiscoveryError",
+			checker:     &fakeGVKChecker{err: discoveryErr},
+			gvk:         schema.GroupVersionKind{Group: "a1", Version: "v1", Kind: "a1Kind"},
+			expectedErr: discoveryErr,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			op, err := NewFakeOperator(nil, nil, nil, nil, &install.StrategyResolver{}, "ns")
+			require.NoError(t, err)
+			op.SetGVKChecker(tt.checker)
+
+			rechecks := recheckCount(t, tt.expectedFound)
+			err = op.requirementsSnapshot().isGVKRegistered(tt.gvk.Group, tt.gvk.Version, tt.gvk.Kind, op.logger)
+			require.Equal(t, tt.expectedErr, err)
+			if tt.expectedRecheck {
+				require.Equal(t, 2, tt.checker.checks)
+				require.Equal(t, 1, tt.checker.invalidated)
+				require.Equal(t, rechecks+1, recheckCount(t, tt.expectedFound))
+			} else {
+				require.Equal(t, 1, tt.checker.checks)
+				require.Equal(t, 0, tt.checker.invalidated)
+				require.Equal(t, rechecks, recheckCount(t, tt.expectedFound))
+			}
+		})
+	}
+}
+
+func TestRequirementStatusUsesGVKChecker(t *testing.T) {
+	namespace := "ns"
+
+	tests := []struct {
+		description    string
+		checker        *fakeGVKChecker
+		expectedMet    bool
+		expectedStatus v1alpha1.StatusReason
+	}{
+		{
+			description:    "Served",
+			checker:        &fakeGVKChecker{gvks: []schema.GroupVersionKind{{Group: "a1", Version: "v1", Kind: "a1Kind"}}},
+			expectedMet:    true,
+			expectedStatus: v1alpha1.RequirementStatusReasonPresent,
+		},
+		{
+			description:    "NotServed",
+			checker:        &fakeGVKChecker{},
+			expectedMet:    false,
+			expectedStatus: v1alpha1.RequirementStatusReasonNotPresent,
+		},
+		{
+			description:    "RegisteredSinceCached",
+			checker:        &fakeGVKChecker{registered: []schema.GroupVersionKind{{Group: "a1", Version: "v1", Kind: "a1Kind"}}},
+			expectedMet:    true,
+			expectedStatus: v1alpha1.RequirementStatusReasonPresent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			// the fake client's discovery serves nothing, so the requirement is met only if the checker is used
+			op, err := NewFakeOperator(nil, nil, nil, []runtime.Object{apiService("a1", "v1", apiregistrationv1.ConditionTrue)}, &install.StrategyResolver{}, namespace)
+			require.NoError(t, err)
+			op.SetGVKChecker(tt.checker)
+
+			csv := withAPIServices(csv("csv1",
+				namespace,
+				"",
+				installStrategy("csv1-dep1"),
+				[]*v1beta1.CustomResourceDefinition{},
+				[]*v1beta1.CustomResourceDefinition{},
+				v1alpha1.CSVPhasePending,
+			), nil, []v1alpha1.APIServiceDescription{{Name: "a1", Version: "v1", Kind: "a1Kind"}})
+
+			met, statuses := op.requirementStatus(csv)
+			require.Equal(t, tt.expectedMet, met)
+
+			status := requirementStatusFor(statuses, "APIService", "v1.a1")
+			require.NotNil(t, status)
+			require.Equal(t, tt.expectedStatus, status.Status)
+		})
+	}
+}
+
+func TestIsGVKRegisteredRechecksOncePerSnapshot(t *testing.T) {
+	op, err := NewFakeOperator(nil, nil, nil, nil, &install.StrategyResolver{}, "ns")
+	require.NoError(t, err)
+	checker := &fakeGVKChecker{}
+	op.SetGVKChecker(checker)
+
+	snapshot := op.requirementsSnapshot()
+	require.Error(t, snapshot.isGVKRegistered("a1", "v1", "a1Kind", op.logger))
+	require.Error(t, snapshot.isGVKRegistered("b1", "v1", "b1Kind", op.logger))
+	require.Equal(t, 1, checker.invalidated)
+	require.Equal(t, 3, checker.checks)
+
+	// a new snapshot may recheck again
+	require.Error(t, op.requirementsSnapshot().isGVKRegistered("a1", "v1", "a1Kind", op.logger))
+	require.Equal(t, 2, checker.invalidated)
+}
+
+func TestSyncRequirementInvalidatesGVKChecker(t *testing.T) {
+	op, err := NewFakeOperator(nil, nil, nil, nil, &install.StrategyResolver{}, "ns")
+	require.NoError(t, err)
+	checker := &fakeGVKChecker{}
+	op.SetGVKChecker(checker)
+
+	require.NoError(t, op.syncRequirement(apiService("a1", "v1", apiregistrationv1.ConditionTrue)))
+	require.Equal(t, 1, checker.invalidated)
+}
+
+func TestRequirementStatusConditions(t *testing.T) {
+	namespace := "ns"
+	snapshots := schema.GroupVersionKind{Group: "snapshot.storage.k8s.io", Version: "v1alpha1", Kind: "VolumeSnapshot"}
+	discoveryErr := errors.New("discovery unavailable")
+
+	tests := []struct {
+		description string
+		when        v1alpha1.RequirementCondition
+		checker     *fakeGVKChecker
+		expectedMet bool
+		// expectedChecked is true if the conditional requirements are expected to be checked, rather than skipped
+		expectedChecked bool
+	}{
+		{
+			description:     "Served",
+			when:            v1alpha1.RequirementCondition{Group: snapshots.Group, Version: snapshots.Version, Kind: snapshots.Kind},
+			checker:         &fakeGVKChecker{gvks: []schema.GroupVersionKind{snapshots}},
+			expectedMet:     false,
+			expectedChecked: true,
+		},
+		{
+			description:     "NotServed",
+			when:            v1alpha1.RequirementCondition{Group: snapshots.Group, Version: snapshots.Version, Kind: snapshots.Kind},
+			checker:         &fakeGVKChecker{},
+			expectedMet:     true,
+			expectedChecked: false,
+		},
+		{
+			description:     "OtherKindServed",
+			when:            v1alpha1.RequirementCondition{Group: snapshots.Group, Version: snapshots.Version, Kind: "VolumeSnapshotClass"},
+			checker:         &fakeGVKChecker{gvks: []schema.GroupVersionKind{snapshots}},
+			expectedMet:     true,
+			expectedChecked: false,
+		},
+		{
+			description:     "GroupVersionServed",
+			when:            v1alpha1.RequirementCondition{Group: snapshots.Group, Version: snapshots.Version},
+			checker:         &fakeGVKChecker{gvks: []schema.GroupVersionKind{snapshots}},
+			expectedMet:     false,
+			expectedChecked: true,
+		},
+		{
+			description:     "DiscoveryError",
+			when:            v1alpha1.RequirementCondition{Group: snapshots.Group, Version: snapshots.Version, Kind: snapshots.Kind},
+			checker:         &fakeGVKChecker{err: discoveryErr},
+			expectedMet:     false,
+			expectedChecked: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			op, err := NewFakeOperator(nil, nil, nil, nil, &install.StrategyResolver{}, namespace)
+			require.NoError(t, err)
+			op.SetGVKChecker(tt.checker)
+
+			// none of the conditional requirements are present
+			when := tt.when
+			strategy := withPermissions(installStrategy("csv1-dep1"), []install.StrategyDeploymentPermissions{{
+				ServiceAccountName: "snapshotter",
+				Rules:              []rbacv1.PolicyRule{{Verbs: []string{"get"}, APIGroups: []string{snapshots.Group}, Resources: []string{"volumesnapshots"}}},
+				When:               &when,
+			}}, nil)
+			csv := withAPIServices(csv("csv1",
+				namespace,
+				"",
+				strategy,
+				[]*v1beta1.CustomResourceDefinition{},
+				[]*v1beta1.CustomResourceDefinition{},
+				v1alpha1.CSVPhasePending,
+			), nil, []v1alpha1.APIServiceDescription{{Name: "a1", Version: "v1", Kind: "a1Kind", When: &when}})
+			csv.Spec.CustomResourceDefinitions.Required = []v1alpha1.CRDDescription{{Name: "c1group", Version: "v1", Kind: "c1", When: &when}}
+			csv.Spec.RequiredDeployments = []v1alpha1.DeploymentRequirement{{Name: "snapshot-controller", When: &when}}
+
+			met, statuses := op.requirementStatus(csv)
+			require.Equal(t, tt.expectedMet, met)
+
+			for kind, name := range map[string]string{
+				"CustomResourceDefinition": "c1group",
+				"APIService":               "v1.a1",
+				"Deployment":               "ns/snapshot-controller",
+				"ServiceAccount":           "snapshotter",
+			} {
+				status := requirementStatusFor(statuses, kind, name)
+				if !tt.expectedChecked {
+					require.Nil(t, status, "%s %s should have been skipped", kind, name)
+					continue
+				}
+				require.NotNil(t, status, "%s %s should have been checked", kind, name)
+				require.Equal(t, v1alpha1.RequirementStatusReasonNotPresent, status.Status)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package olm
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	apiextensionsfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/clock"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	crbacv1 "k8s.io/client-go/listers/rbac/v1"
+	"k8s.io/client-go/tools/cache"
+	apiregistrationv1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
+	apiregistrationfake "k8s.io/kube-aggregator/pkg/client/clientset_generated/clientset/fake"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/operatorclient"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/queueinformer"
+)
+
+// CheckRequirementsOffline evaluates a CSV's requirements against the given cluster objects rather than a live
+// cluster, using the same checks as the operator.
+//
+// objs may contain CRDs, APIServices, ServiceAccounts, RBAC resources, Namespaces, and any other core Kubernetes
+// objects. Served CRD versions are added to discovery automatically. Since an APIService doesn't declare the kinds it
+// serves, the discovery information for APIService requirements that name a kind must be given as
+// *metav1.APIResourceLists.
+func CheckRequirementsOffline(csv *v1alpha1.ClusterServiceVersion, objs []runtime.Object) (bool, []v1alpha1.RequirementStatus, error) {
+	var k8sObjs, extObjs, regObjs []runtime.Object
+	resources := []*metav1.APIResourceList{}
+	roles := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	roleBindings := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	clusterRoles := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	clusterRoleBindings := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	namespaces := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+
+	for _, obj := range objs {
+		var err error
+		switch o := obj.(type) {
+		case *metav1.APIResourceList:
+			resources = append(resources, o)
+		case *v1beta1.CustomResourceDefinition:
+			extObjs = append(extObjs, o)
+			resources = append(resources, crdAPIResources(o)...)
+		case *apiregistrationv1.APIService:
+			regObjs = append(regObjs, o)
+		case *rbacv1.Role:
+			k8sObjs = append(k8sObjs, o)
+			err = roles.Add(o)
+		case *rbacv1.RoleBinding:
+			k8sObjs = append(k8sObjs, o)
+			err = roleBindings.Add(o)
+		case *rbacv1.ClusterRole:
+			k8sObjs = append(k8sObjs, o)
+			err = clusterRoles.Add(o)
+		case *rbacv1.ClusterRoleBinding:
+			k8sObjs = append(k8sObjs, o)
+			err = clusterRoleBindings.Add(o)
+		case *corev1.Namespace:
+			k8sObjs = append(k8sObjs, o)
+			err = namespaces.Add(o)
+		default:
+			k8sObjs = append(k8sObjs, o)
+		}
+		if err != nil {
+			return false, nil, fmt.Errorf("error adding %T to RBAC cache: %s", obj, err)
+		}
+	}
+
+	k8sClient := k8sfake.NewSimpleClientset(k8sObjs...)
+	k8sClient.Resources = resources
+	client := operatorclient.NewClient(k8sClient, apiextensionsfake.NewSimpleClientset(extObjs...), apiregistrationfake.NewSimpleClientset(regObjs...))
+
+	op := &Operator{
+		Operator:                 &queueinformer.Operator{OpClient: client},
+		roleLister:               crbacv1.NewRoleLister(roles),
+		roleBindingLister:        crbacv1.NewRoleBindingLister(roleBindings),
+		clusterRoleLister:        crbacv1.NewClusterRoleLister(clusterRoles),
+		clusterRoleBindingLister: crbacv1.NewClusterRoleBindingLister(clusterRoleBindings),
+		namespaceLister:          corev1listers.NewNamespaceLister(namespaces),
+		traces:                   map[string]*requirementsTrace{},
+		logger:                   log.StandardLogger(),
+		clock:                    clock.RealClock{},
+	}
+	met, statuses := op.requirementStatus(csv)
+
+	return met, statuses, nil
+}
+
+// crdAPIResources returns the discovery information a cluster serves for a CRD
+func crdAPIResources(crd *v1beta1.CustomResourceDefinition) []*metav1.APIResourceList {
+	lists := []*metav1.APIResourceList{}
+	for _, version := range servedVersions(crd) {
+		lists = append(lists, &metav1.APIResourceList{
+			GroupVersion: metav1.GroupVersion{Group: crd.Spec.Group, Version: version}.String(),
+			APIResources: []metav1.APIResource{
+				{
+					Name:         crd.Spec.Names.Plural,
+					SingularName: crd.Spec.Names.Singular,
+					Namespaced:   crd.Spec.Scope == v1beta1.NamespaceScoped,
+					Group:        crd.Spec.Group,
+					Version:      version,
+					Kind:         crd.Spec.Names.Kind,
+				},
+			},
+		})
+	}
+
+	return lists
+}
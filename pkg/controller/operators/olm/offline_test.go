@@ -0,0 +1,126 @@
+package olm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	apiregistrationv1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/install"
+)
+
+func TestCheckRequirementsOffline(t *testing.T) {
+	namespace := "ns"
+	rule := rbacv1.PolicyRule{
+		Verbs:     []string{"get"},
+		APIGroups: []string{""},
+		Resources: []string{"pods"},
+	}
+	permissions := []install.StrategyDeploymentPermissions{
+		{
+			ServiceAccountName: "sa",
+			Rules:              []rbacv1.PolicyRule{rule},
+		},
+	}
+	role := &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{Name: "role", Namespace: namespace},
+		Rules:      []rbacv1.PolicyRule{rule},
+	}
+	roleBinding := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "role-binding", Namespace: namespace},
+		Subjects: []rbacv1.Subject{
+			{Kind: rbacv1.ServiceAccountKind, Name: "sa", Namespace: namespace},
+		},
+		RoleRef: rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: "role"},
+	}
+	a1Resources := &metav1.APIResourceList{
+		GroupVersion: "a1/v1",
+		APIResources: []metav1.APIResource{{Name: "a1s", Kind: "a1Kind"}},
+	}
+
+	tests := []struct {
+		description      string
+		objs             []runtime.Object
+		expectedMet      bool
+		expectedStatuses map[string]v1alpha1.StatusReason
+	}{
+		{
+			description: "AllPresent",
+			objs: []runtime.Object{
+				crd("c1", "v1"),
+				apiService("a1", "v1", apiregistrationv1.ConditionTrue),
+				a1Resources,
+				serviceAccount("sa", namespace),
+				role,
+				roleBinding,
+			},
+			expectedMet: true,
+			expectedStatuses: map[string]v1alpha1.StatusReason{
+				"CustomResourceDefinition/c1group": v1alpha1.RequirementStatusReasonPresent,
+				"APIService/v1.a1":                 v1alpha1.RequirementStatusReasonPresent,
+				"ServiceAccount/sa":                v1alpha1.RequirementStatusReasonPresent,
+			},
+		},
+		{
+			description: "MissingRoleBinding",
+			objs: []runtime.Object{
+				crd("c1", "v1"),
+				apiService("a1", "v1", apiregistrationv1.ConditionTrue),
+				a1Resources,
+				serviceAccount("sa", namespace),
+				role,
+			},
+			expectedMet: false,
+			expectedStatuses: map[string]v1alpha1.StatusReason{
+				"CustomResourceDefinition/c1group": v1alpha1.RequirementStatusReasonPresent,
+				"APIService/v1.a1":                 v1alpha1.RequirementStatusReasonPresent,
+				"ServiceAccount/sa":                v1alpha1.RequirementStatusReasonPresentNotSatisfied,
+			},
+		},
+		{
+			description: "MissingDiscovery",
+			objs: []runtime.Object{
+				apiService("a1", "v1", apiregistrationv1.ConditionTrue),
+				serviceAccount("sa", namespace),
+				role,
+				roleBinding,
+			},
+			expectedMet: false,
+			expectedStatuses: map[string]v1alpha1.StatusReason{
+				"CustomResourceDefinition/c1group": v1alpha1.RequirementStatusReasonNotPresent,
+				"APIService/v1.a1":                 v1alpha1.RequirementStatusReasonNotPresent,
+				"ServiceAccount/sa":                v1alpha1.RequirementStatusReasonPresent,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			csv := withAPIServices(csv("csv1",
+				namespace,
+				"",
+				withPermissions(installStrategy("csv1-dep1"), permissions, nil),
+				[]*v1beta1.CustomResourceDefinition{},
+				[]*v1beta1.CustomResourceDefinition{crd("c1", "v1")},
+				v1alpha1.CSVPhasePending,
+			), nil, apis("a1.v1.a1Kind"))
+
+			met, statuses, err := CheckRequirementsOffline(csv, tt.objs)
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedMet, met)
+
+			for key, expected := range tt.expectedStatuses {
+				kindName := strings.SplitN(key, "/", 2)
+				status := requirementStatusFor(statuses, kindName[0], kindName[1])
+				require.NotNil(t, status, "missing status for %s", key)
+				require.Equal(t, expected, status.Status, "status for %s", key)
+			}
+		})
+	}
+}
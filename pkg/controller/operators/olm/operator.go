@@ -3,14 +3,25 @@ package olm
 import (
 	"errors"
 	"fmt"
+	"math/rand"
+	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 	"k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	schedulingv1beta1 "k8s.io/api/scheduling/v1beta1"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/informers"
+	corev1listers "k8s.io/client-go/listers/core/v1"
 	crbacv1 "k8s.io/client-go/listers/rbac/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
@@ -32,6 +43,12 @@ var ErrCRDOwnerConflict = errors.New("CRD owned by another ClusterServiceVersion
 
 const (
 	FallbackWakeupInterval = 30 * time.Second
+
+	// DefaultRequeueJitter is the default maximum fraction of a CSV requeue delay that is added as random jitter
+	DefaultRequeueJitter = 0.5
+
+	// requeueSpreadBase is the window, scaled by the requeue jitter, that CSVs requeued together are spread over
+	requeueSpreadBase = 2 * time.Second
 )
 
 type Operator struct {
@@ -43,14 +60,47 @@ type Operator struct {
 	roleBindingLister        crbacv1.RoleBindingLister
 	clusterRoleLister        crbacv1.ClusterRoleLister
 	clusterRoleBindingLister crbacv1.ClusterRoleBindingLister
+	namespaceLister          corev1listers.NamespaceLister
 	annotator                *annotator.Annotator
 	cleanupFunc              func()
+	tracesMu                 sync.Mutex
+	traces                   map[string]*requirementsTrace
+	unmetRequirements        *unmetRequirementsTracker
+	csvIndexers              []cache.Indexer
+	requirementsClient       operatorclient.ClientInterface
+	gvks                     GVKChecker
+	// apiServiceAvailabilityAttempts is how many times requirement checks look at an unavailable APIService, waiting
+	// apiServiceAvailabilityInterval between each, before reporting it NotPresent
+	apiServiceAvailabilityAttempts int
+	apiServiceAvailabilityInterval time.Duration
+	requirementChecks              []RequirementCheck
+	// subjectAccessReviewFallback rechecks rules the RBAC listers find unsatisfied with SubjectAccessReviews
+	subjectAccessReviewFallback bool
+	// recordRequirementUpdateTimes stamps requirement statuses with the time of every check, not just when they change
+	recordRequirementUpdateTimes bool
+	// requirementStatusMerge carries data over from previously recorded requirement statuses, or is nil to keep their
+	// annotations
+	requirementStatusMerge RequirementStatusMergeFunc
+	// patchRequirementStatus writes changes to only requirement statuses and status conditions with a JSON patch
+	patchRequirementStatus bool
+	// requirementSweepInterval is how often RunRequirementSweep re-evaluates CSVs, or <= 0 if it doesn't
+	requirementSweepInterval time.Duration
+	requirementSweepJitter   float64
+	csvSyncTimes             *syncTimes
+	// requeueSpread is the most that CSVs requeued together, such as by a requirement change or a sweep, are delayed
+	// by, so that they aren't all rechecked at once. 0 enqueues them immediately.
+	requeueSpread time.Duration
+	clock         clock.Clock
+	logger        log.FieldLogger
 }
 
-func NewOperator(crClient versioned.Interface, opClient operatorclient.ClientInterface, resolver install.StrategyResolverInterface, wakeupInterval time.Duration, annotations map[string]string, namespaces []string) (*Operator, error) {
+func NewOperator(crClient versioned.Interface, opClient operatorclient.ClientInterface, resolver install.StrategyResolverInterface, wakeupInterval time.Duration, requeueJitter float64, annotations map[string]string, namespaces []string) (*Operator, error) {
 	if wakeupInterval < 0 {
 		wakeupInterval = FallbackWakeupInterval
 	}
+	if requeueJitter < 0 {
+		requeueJitter = DefaultRequeueJitter
+	}
 	if len(namespaces) < 1 {
 		namespaces = []string{metav1.NamespaceAll}
 	}
@@ -69,6 +119,13 @@ func NewOperator(crClient versioned.Interface, opClient operatorclient.ClientInt
 		cleanupFunc: func() {
 			namespaceAnnotator.CleanNamespaceAnnotations(namespaces)
 		},
+		traces:            map[string]*requirementsTrace{},
+		unmetRequirements: newUnmetRequirementsTracker(metrics.CSVUnmetRequirements),
+		gvks:              newDiscoveryGVKChecker(queueOperator.OpClient.KubernetesInterface().Discovery()),
+		csvSyncTimes:      newSyncTimes(),
+		requeueSpread:     time.Duration(requeueJitter * float64(requeueSpreadBase)),
+		clock:             clock.RealClock{},
+		logger:            log.StandardLogger(),
 	}
 
 	// if watching all namespaces, set up a watch to annotate new namespaces
@@ -98,12 +155,13 @@ func NewOperator(crClient versioned.Interface, opClient operatorclient.ClientInt
 	clusterRoleInformer := informerFactory.Rbac().V1().ClusterRoles()
 	clusterRoleBindingInformer := informerFactory.Rbac().V1().ClusterRoleBindings()
 
-	// register RBAC QueueInformers
+	// register RBAC QueueInformers, along with ServiceAccounts since their permissions can't be met until they exist
 	rbacInformers := []cache.SharedIndexInformer{
 		roleInformer.Informer(),
 		roleBindingInformer.Informer(),
 		clusterRoleInformer.Informer(),
 		clusterRoleBindingInformer.Informer(),
+		informerFactory.Core().V1().ServiceAccounts().Informer(),
 	}
 
 	rbacQueueInformers := queueinformer.New(
@@ -130,12 +188,19 @@ func NewOperator(crClient versioned.Interface, opClient operatorclient.ClientInt
 		log.Debugf("watching for CSVs in namespace %s", namespace)
 		sharedInformerFactory := externalversions.NewSharedInformerFactoryWithOptions(crClient, wakeupInterval, externalversions.WithNamespace(namespace))
 		informer := sharedInformerFactory.Operators().V1alpha1().ClusterServiceVersions().Informer()
+		informer.AddEventHandler(cache.ResourceEventHandlerFuncs{DeleteFunc: op.handleDeletedCSV})
+		if err := informer.AddIndexers(cache.Indexers{csvRequirementsIndex: csvRequirementsIndexFunc}); err != nil {
+			return nil, err
+		}
 		csvInformers = append(csvInformers, informer)
+		op.csvIndexers = append(op.csvIndexers, informer.GetIndexer())
 	}
 
 	// csvInformers for each namespace all use the same backing queue
 	// queue keys are namespaced
-	csvQueue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "clusterserviceversions")
+	// requeues are jittered so that CSVs waiting on a shared requirement don't all re-check it at once
+	csvRateLimiter := queueinformer.NewJitteredRateLimiter(workqueue.DefaultControllerRateLimiter(), requeueJitter)
+	csvQueue := workqueue.NewNamedRateLimitingQueue(csvRateLimiter, "clusterserviceversions")
 	queueInformers := queueinformer.New(
 		csvQueue,
 		csvInformers,
@@ -149,6 +214,49 @@ func NewOperator(crClient versioned.Interface, opClient operatorclient.ClientInt
 	}
 	op.csvQueue = csvQueue
 
+	// set up watches on CRDs, APIServices, and PriorityClasses so CSVs waiting on them are rechecked as soon as they
+	// appear, and on namespaces so CSVs with a target namespace selector are rechecked when the namespaces it matches
+	// change
+	crdInformer := cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return opClient.ApiextensionsV1beta1Interface().ApiextensionsV1beta1().CustomResourceDefinitions().List(options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return opClient.ApiextensionsV1beta1Interface().ApiextensionsV1beta1().CustomResourceDefinitions().Watch(options)
+			},
+		},
+		&v1beta1.CustomResourceDefinition{},
+		wakeupInterval,
+		cache.Indexers{},
+	)
+	apiServiceInformer := cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return opClient.ApiregistrationV1Interface().ApiregistrationV1().APIServices().List(options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return opClient.ApiregistrationV1Interface().ApiregistrationV1().APIServices().Watch(options)
+			},
+		},
+		&apiregistrationv1.APIService{},
+		wakeupInterval,
+		cache.Indexers{},
+	)
+	namespaceInformer := informerFactory.Core().V1().Namespaces()
+	op.namespaceLister = namespaceInformer.Lister()
+	requirementQueueInformers := queueinformer.New(
+		workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "requirements"),
+		[]cache.SharedIndexInformer{crdInformer, apiServiceInformer, informerFactory.Scheduling().V1beta1().PriorityClasses().Informer(), namespaceInformer.Informer()},
+		op.syncRequirement,
+		nil,
+		"requirement",
+		metrics.NewMetricsNil(),
+	)
+	for _, informer := range requirementQueueInformers {
+		op.RegisterQueueInformer(informer)
+	}
+
 	// set up watch on deployments
 	depInformers := []cache.SharedIndexInformer{}
 	for _, namespace := range namespaces {
@@ -176,6 +284,19 @@ func (a *Operator) Cleanup() {
 	a.cleanupFunc()
 }
 
+// handleDeletedCSV stops counting a deleted CSV's unmet requirements, tracking when it was synced, and keeping its
+// requirements trace
+func (a *Operator) handleDeletedCSV(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		log.Debugf("couldn't get key for deleted CSV: %s", err)
+		return
+	}
+	a.unmetRequirements.forget(key)
+	a.csvSyncTimes.forget(key)
+	a.forgetTrace(key)
+}
+
 func (a *Operator) requeueCSV(name, namespace string) {
 	// we can build the key directly, will need to change if queue uses different key scheme
 	key := fmt.Sprintf("%s/%s", namespace, name)
@@ -198,9 +319,186 @@ func (a *Operator) syncDeployment(obj interface{}) (syncError error) {
 	return nil
 }
 
+// SetRequirementsClient sets the client used for the discovery queries and lookups made by requirement checks, so that
+// it can be given a rate limit independent of the operator's other requests. By default, the operator's client is used.
+func (a *Operator) SetRequirementsClient(client operatorclient.ClientInterface) {
+	a.requirementsClient = client
+	a.gvks = newDiscoveryGVKChecker(client.KubernetesInterface().Discovery())
+}
+
+// SetGVKChecker sets the GVKChecker that requirement checks query to find out whether the cluster serves a GVK,
+// replacing the discovery-backed default. It must be called after SetRequirementsClient, which resets the checker.
+func (a *Operator) SetGVKChecker(checker GVKChecker) {
+	a.gvks = checker
+}
+
+// SetAPIServiceAvailabilityPoll sets how many times requirement checks look at an unavailable APIService, waiting
+// interval between each, before reporting it NotPresent. Each wait holds up the check, so by default an APIService is
+// looked at once.
+func (a *Operator) SetAPIServiceAvailabilityPoll(attempts int, interval time.Duration) {
+	a.apiServiceAvailabilityAttempts = attempts
+	a.apiServiceAvailabilityInterval = interval
+}
+
+// SetSubjectAccessReviewFallback sets whether permission checks ask the API server, with a SubjectAccessReview, about
+// rules that the RBAC listers find unsatisfied. This avoids reporting permissions granted moments ago as missing while
+// the listers catch up, at the cost of a request for each action of each unsatisfied rule, so it's off by default.
+func (a *Operator) SetSubjectAccessReviewFallback(enabled bool) {
+	a.subjectAccessReviewFallback = enabled
+}
+
+// SetRecordRequirementUpdateTimes sets whether requirement statuses record the time of every check in their
+// LastUpdateTime. This updates a pending CSV's status on every check, so it's off by default.
+func (a *Operator) SetRecordRequirementUpdateTimes(record bool) {
+	a.recordRequirementUpdateTimes = record
+}
+
+// SetPatchRequirementStatus sets whether a recheck of a CSV's requirements that leaves its phase, reason, and message
+// unchanged is written with a JSON patch of the changed requirement statuses and status conditions, rather than by
+// updating the whole status. Patches don't conflict with concurrent updates to other parts of the status, but are only
+// checked against the entries they change, so it's off by default.
+func (a *Operator) SetPatchRequirementStatus(patch bool) {
+	a.patchRequirementStatus = patch
+}
+
+// SetRequirementStatusMerge sets the function that carries data over from a CSV's previously recorded requirement
+// statuses into the recomputed ones. By default, MergeRequirementStatusAnnotations keeps their annotations.
+func (a *Operator) SetRequirementStatusMerge(merge RequirementStatusMergeFunc) {
+	a.requirementStatusMerge = merge
+}
+
+// syncRequirement enqueues the CSVs that require a CRD, APIService, or PriorityClass that has been created or updated,
+// or whose target namespace selector may match a namespace that has been created or updated
+func (a *Operator) syncRequirement(obj interface{}) (syncError error) {
+	var indexKey string
+	switch v := obj.(type) {
+	case *v1beta1.CustomResourceDefinition:
+		indexKey = requirementIndexKey("CustomResourceDefinition", v.GetName())
+	case *apiregistrationv1.APIService:
+		indexKey = requirementIndexKey("APIService", v.GetName())
+	case *schedulingv1beta1.PriorityClass:
+		// PriorityClasses don't serve APIs, so there's no discovery information to invalidate
+		return a.requeueCSVsRequiring(requirementIndexKey("PriorityClass", v.GetName()))
+	case *corev1.Namespace:
+		return a.requeueCSVsRequiring(namespaceSelectorIndexKey)
+	default:
+		syncError = errors.New("attempted to sync non requirement resource with requirement sync handler")
+		log.Debug(syncError)
+		return
+	}
+
+	// the change may add or remove served APIs, which the cached discovery information wouldn't reflect
+	if invalidator, ok := a.gvks.(gvkInvalidator); ok {
+		invalidator.Invalidate()
+	}
+
+	return a.requeueCSVsRequiring(indexKey)
+}
+
+// requeueCSVsRequiring enqueues the CSVs with the given requirements index key, spread over requeueSpread
+func (a *Operator) requeueCSVsRequiring(indexKey string) error {
+	for _, indexer := range a.csvIndexers {
+		csvs, err := indexer.ByIndex(csvRequirementsIndex, indexKey)
+		if err != nil {
+			return err
+		}
+		for _, obj := range csvs {
+			csv, ok := obj.(*v1alpha1.ClusterServiceVersion)
+			if !ok {
+				continue
+			}
+			log.Debugf("%s changed, requeueing CSV %s in namespace %s", indexKey, csv.GetName(), csv.GetNamespace())
+			// skip the rate limiter so the CSV isn't delayed by backoff from earlier failures
+			a.addSpread(fmt.Sprintf("%s/%s", csv.GetNamespace(), csv.GetName()))
+		}
+	}
+
+	return nil
+}
+
+// addSpread enqueues the CSV with the given key after a random delay of less than requeueSpread, bypassing the rate
+// limiter
+func (a *Operator) addSpread(key string) {
+	if a.requeueSpread <= 0 {
+		a.csvQueue.Add(key)
+		return
+	}
+	a.csvQueue.AddAfter(key, time.Duration(rand.Int63n(int64(a.requeueSpread))))
+}
+
+// serviceAccountsBoundBy returns the namespace/name of each ServiceAccount whose permissions may have changed along
+// with the given RBAC object or ServiceAccount
+func (a *Operator) serviceAccountsBoundBy(obj interface{}) ([]string, error) {
+	var bindings []rbacv1.RoleBinding
+	var clusterBindings []rbacv1.ClusterRoleBinding
+	switch v := obj.(type) {
+	case *corev1.ServiceAccount:
+		return []string{fmt.Sprintf("%s/%s", v.GetNamespace(), v.GetName())}, nil
+	case *rbacv1.RoleBinding:
+		bindings = append(bindings, *v)
+	case *rbacv1.ClusterRoleBinding:
+		clusterBindings = append(clusterBindings, *v)
+	case *rbacv1.Role:
+		roleBindings, err := a.roleBindingLister.RoleBindings(v.GetNamespace()).List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for _, binding := range roleBindings {
+			if binding.RoleRef.Kind == "Role" && binding.RoleRef.Name == v.GetName() {
+				bindings = append(bindings, *binding)
+			}
+		}
+	case *rbacv1.ClusterRole:
+		roleBindings, err := a.roleBindingLister.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for _, binding := range roleBindings {
+			if binding.RoleRef.Kind == "ClusterRole" && binding.RoleRef.Name == v.GetName() {
+				bindings = append(bindings, *binding)
+			}
+		}
+		clusterRoleBindings, err := a.clusterRoleBindingLister.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for _, binding := range clusterRoleBindings {
+			if binding.RoleRef.Kind == "ClusterRole" && binding.RoleRef.Name == v.GetName() {
+				clusterBindings = append(clusterBindings, *binding)
+			}
+		}
+	}
+
+	serviceAccounts := []string{}
+	for _, binding := range bindings {
+		for _, subject := range binding.Subjects {
+			if subject.Kind != rbacv1.ServiceAccountKind {
+				continue
+			}
+			// ServiceAccount subjects of a RoleBinding default to the RoleBinding's namespace
+			namespace := subject.Namespace
+			if namespace == "" {
+				namespace = binding.GetNamespace()
+			}
+			serviceAccounts = append(serviceAccounts, fmt.Sprintf("%s/%s", namespace, subject.Name))
+		}
+	}
+	for _, binding := range clusterBindings {
+		for _, subject := range binding.Subjects {
+			if subject.Kind == rbacv1.ServiceAccountKind {
+				serviceAccounts = append(serviceAccounts, fmt.Sprintf("%s/%s", subject.Namespace, subject.Name))
+			}
+		}
+	}
+
+	return serviceAccounts, nil
+}
+
 func (a *Operator) syncRBAC(obj interface{}) (syncError error) {
 	clusterLevel := false
 	switch v := obj.(type) {
+	case *corev1.ServiceAccount:
+		log.Debugf("sync ServiceAccount %s in namespace %s", v.GetName(), v.GetNamespace())
 	case *rbacv1.Role:
 		log.Debugf("sync Role %s in namespace %s", v.GetName(), v.GetNamespace())
 	case *rbacv1.RoleBinding:
@@ -217,6 +515,19 @@ func (a *Operator) syncRBAC(obj interface{}) (syncError error) {
 		return
 	}
 
+	// Requeue CSVs requesting permissions for the ServiceAccounts affected by the change, so that newly granted
+	// permissions are noticed right away
+	serviceAccounts, err := a.serviceAccountsBoundBy(obj)
+	if err != nil {
+		return err
+	}
+	for _, sa := range serviceAccounts {
+		namespace, name, _ := cache.SplitMetaNamespaceKey(sa)
+		if err := a.requeueCSVsRequiring(serviceAccountIndexKey(namespace, name)); err != nil {
+			return err
+		}
+	}
+
 	if clusterLevel {
 		// Cannot requeue namespaced owner CSVs if cluster-scoped
 		return nil
@@ -254,16 +565,19 @@ func (a *Operator) syncClusterServiceVersion(obj interface{}) (syncError error)
 		"phase":     clusterServiceVersion.Status.Phase,
 	})
 	logger.Info("syncing")
+	a.csvSyncTimes.set(fmt.Sprintf("%s/%s", clusterServiceVersion.GetNamespace(), clusterServiceVersion.GetName()), a.clock.Now())
 
 	outCSV, syncError := a.transitionCSVState(*clusterServiceVersion)
 
 	// no changes in status, don't update
-	if outCSV.Status.Phase == clusterServiceVersion.Status.Phase && outCSV.Status.Reason == clusterServiceVersion.Status.Reason && outCSV.Status.Message == clusterServiceVersion.Status.Message {
+	if outCSV.Status.Phase == clusterServiceVersion.Status.Phase && outCSV.Status.Reason == clusterServiceVersion.Status.Reason && outCSV.Status.Message == clusterServiceVersion.Status.Message &&
+		!needsRequirementStatusUpdate(clusterServiceVersion.Status.RequirementStatus, outCSV.Status.RequirementStatus) &&
+		equality.Semantic.DeepEqual(clusterServiceVersion.Status.StatusConditions, outCSV.Status.StatusConditions) {
 		return
 	}
 
 	// Update CSV with status of transition. Log errors if we can't write them to the status.
-	_, err := a.client.OperatorsV1alpha1().ClusterServiceVersions(clusterServiceVersion.GetNamespace()).UpdateStatus(outCSV)
+	err := a.writeStatus(clusterServiceVersion, outCSV)
 	if err != nil {
 		updateErr := errors.New("error updating ClusterServiceVersion status: " + err.Error())
 		if syncError == nil {
@@ -275,6 +589,34 @@ func (a *Operator) syncClusterServiceVersion(obj interface{}) (syncError error)
 	return
 }
 
+// writeStatus writes the status of out, a transition of in. If the transition didn't change the phase and the operator
+// is configured to patch requirement statuses, only the changed requirement statuses and status conditions are
+// written.
+func (a *Operator) writeStatus(in, out *v1alpha1.ClusterServiceVersion) error {
+	csvs := a.client.OperatorsV1alpha1().ClusterServiceVersions(in.GetNamespace())
+	if a.patchRequirementStatus && requirementStatusOnlyChange(&in.Status, &out.Status) {
+		patch, err := requirementStatusPatch(in, out)
+		if err != nil {
+			return err
+		}
+		if patch == nil {
+			return nil
+		}
+		_, err = csvs.Patch(in.GetName(), types.JSONPatchType, patch, "status")
+		return err
+	}
+
+	_, err := csvs.UpdateStatus(out)
+	return err
+}
+
+// needsRequirementStatusUpdate returns true if newly computed requirement statuses differ from those recorded in a CSV's
+// status. Requirement checks order their statuses deterministically and keep the transition times of unchanged
+// statuses, so recomputing unchanged requirements produces identical statuses that don't need to be written.
+func needsRequirementStatusUpdate(old, new []v1alpha1.RequirementStatus) bool {
+	return !equality.Semantic.DeepEqual(old, new)
+}
+
 // transitionCSVState moves the CSV status state machine along based on the current value and the current cluster
 // state.
 func (a *Operator) transitionCSVState(in v1alpha1.ClusterServiceVersion) (out *v1alpha1.ClusterServiceVersion, syncError error) {
@@ -299,6 +641,8 @@ func (a *Operator) transitionCSVState(in v1alpha1.ClusterServiceVersion) (out *v
 	case v1alpha1.CSVPhasePending:
 		met, statuses := a.requirementStatus(out)
 		out.SetRequirementStatus(statuses)
+		out.SetStatusCondition(requirementsCondition(out, met, statuses), metav1.NewTime(a.clock.Now()))
+		a.unmetRequirements.set(fmt.Sprintf("%s/%s", out.GetNamespace(), out.GetName()), statuses)
 
 		if !met {
 			logger.Info("requirements were not met")
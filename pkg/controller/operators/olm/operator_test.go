@@ -17,6 +17,7 @@ import (
 	apiextensionsfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/clock"
 	k8sfake "k8s.io/client-go/kubernetes/fake"
 	apiregistrationv1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
 	apiregistrationfake "k8s.io/kube-aggregator/pkg/client/clientset_generated/clientset/fake"
@@ -108,7 +109,13 @@ func NewFakeOperator(clientObjs []runtime.Object, k8sObjs []runtime.Object, extO
 	if err != nil {
 		return nil, err
 	}
-	return NewOperator(clientFake, opClientFake, resolver, 5*time.Second, annotations, []string{namespace})
+	op, err := NewOperator(clientFake, opClientFake, resolver, 5*time.Second, DefaultRequeueJitter, annotations, []string{namespace})
+	if err != nil {
+		return nil, err
+	}
+	// enqueue requeued CSVs immediately, so that tests can check the queue right after requeueing
+	op.requeueSpread = 0
+	return op, nil
 }
 
 func (o *Operator) GetClient() versioned.Interface {
@@ -925,6 +932,148 @@ func TestTransitionCSV(t *testing.T) {
 	}
 }
 
+func TestNeedsRequirementStatusUpdate(t *testing.T) {
+	transitioned := metav1.NewTime(time.Date(2018, 10, 1, 0, 0, 0, 0, time.UTC))
+	statuses := func() []v1alpha1.RequirementStatus {
+		return []v1alpha1.RequirementStatus{
+			{Group: "apiextensions.k8s.io", Version: "v1beta1", Kind: "CustomResourceDefinition", Name: "c1.g1", Status: v1alpha1.RequirementStatusReasonNotPresent, LastTransitionTime: transitioned},
+			{Group: "", Version: "v1", Kind: "ServiceAccount", Name: "sa", Status: v1alpha1.RequirementStatusReasonPresent, LastTransitionTime: transitioned, Dependents: []v1alpha1.DependentStatus{
+				{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "PolicyRule", Status: v1alpha1.DependentStatusReasonSatisfied, Message: "rule"},
+			}},
+		}
+	}
+
+	tests := []struct {
+		description string
+		old         []v1alpha1.RequirementStatus
+		new         func() []v1alpha1.RequirementStatus
+		expected    bool
+	}{
+		{
+			description: "Identical",
+			old:         statuses(),
+			new:         statuses,
+			expected:    false,
+		},
+		{
+			description: "NoneRecordedAndNoneComputed",
+			old:         nil,
+			new:         func() []v1alpha1.RequirementStatus { return []v1alpha1.RequirementStatus{} },
+			expected:    false,
+		},
+		{
+			description: "NoneRecorded",
+			old:         nil,
+			new:         statuses,
+			expected:    true,
+		},
+		{
+			description: "StatusChanged",
+			old:         statuses(),
+			new: func() []v1alpha1.RequirementStatus {
+				changed := statuses()
+				changed[0].Status = v1alpha1.RequirementStatusReasonPresent
+				return changed
+			},
+			expected: true,
+		},
+		{
+			description: "DependentChanged",
+			old:         statuses(),
+			new: func() []v1alpha1.RequirementStatus {
+				changed := statuses()
+				changed[1].Dependents[0].Status = v1alpha1.DependentStatusReasonNotSatisfied
+				return changed
+			},
+			expected: true,
+		},
+		{
+			description: "Reordered",
+			old:         statuses(),
+			new: func() []v1alpha1.RequirementStatus {
+				reordered := statuses()
+				reordered[0], reordered[1] = reordered[1], reordered[0]
+				return reordered
+			},
+			expected: true,
+		},
+		{
+			description: "Removed",
+			old:         statuses(),
+			new:         func() []v1alpha1.RequirementStatus { return statuses()[:1] },
+			expected:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			require.Equal(t, tt.expected, needsRequirementStatusUpdate(tt.old, tt.new()))
+		})
+	}
+}
+
+func TestSyncClusterServiceVersionSkipsUnchangedRequirementStatus(t *testing.T) {
+	namespace := "ns"
+
+	tests := []struct {
+		description          string
+		recordUpdateTimes    bool
+		expectedResyncWrites int
+	}{
+		{
+			description:          "Unchanged",
+			expectedResyncWrites: 0,
+		},
+		{
+			description:          "UpdateTimesRecorded",
+			recordUpdateTimes:    true,
+			expectedResyncWrites: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			pending := csv("csv1",
+				namespace,
+				"",
+				installStrategy("csv1-dep1"),
+				[]*v1beta1.CustomResourceDefinition{},
+				[]*v1beta1.CustomResourceDefinition{crd("c1", "v1")},
+				v1alpha1.CSVPhasePending,
+			)
+			op, err := NewFakeOperator([]runtime.Object{pending}, nil, nil, nil, &install.StrategyResolver{}, namespace)
+			require.NoError(t, err)
+			op.SetRecordRequirementUpdateTimes(tt.recordUpdateTimes)
+			fakeClock := clock.NewFakeClock(time.Date(2018, 10, 1, 0, 0, 0, 0, time.UTC))
+			op.clock = fakeClock
+			client := op.client.(*fake.Clientset)
+
+			statusWrites := func() int {
+				writes := 0
+				for _, action := range client.Actions() {
+					if action.GetVerb() == "update" && action.GetSubresource() == "status" {
+						writes++
+					}
+				}
+				return writes
+			}
+
+			// the first check records the requirement statuses
+			require.Equal(t, ErrRequirementsNotMet, op.syncClusterServiceVersion(pending))
+			require.Equal(t, 1, statusWrites())
+
+			synced, err := client.OperatorsV1alpha1().ClusterServiceVersions(namespace).Get("csv1", metav1.GetOptions{})
+			require.NoError(t, err)
+			require.NotEmpty(t, synced.Status.RequirementStatus)
+
+			// recomputing the same requirements only writes if the statuses record the time of every check
+			fakeClock.Step(time.Second)
+			require.Equal(t, ErrRequirementsNotMet, op.syncClusterServiceVersion(synced))
+			require.Equal(t, 1+tt.expectedResyncWrites, statusWrites())
+		})
+	}
+}
+
 func TestIsReplacing(t *testing.T) {
 	log.SetLevel(log.DebugLevel)
 	namespace := "ns"
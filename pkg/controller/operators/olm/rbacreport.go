@@ -0,0 +1,118 @@
+package olm
+
+import (
+	"encoding/json"
+	"fmt"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/install"
+)
+
+// PermissionReport is the resolved RBAC picture of a CSV: every rule its install strategy requests, and for each
+// action a rule describes, whether it's granted and by which binding
+type PermissionReport struct {
+	CSV       string `json:"csv"`
+	Namespace string `json:"namespace"`
+	// Error is set if the CSV's install strategy couldn't be read, in which case there are no rules
+	Error string           `json:"error,omitempty"`
+	Rules []RulePermission `json:"rules"`
+}
+
+// RulePermission is a rule requested for a ServiceAccount in a single namespace, or cluster-wide
+type RulePermission struct {
+	ServiceAccount string `json:"serviceAccount"`
+	// Namespace is the namespace the rule is checked in, or empty for cluster permissions
+	Namespace string            `json:"namespace,omitempty"`
+	Cluster   bool              `json:"cluster"`
+	Rule      rbacv1.PolicyRule `json:"rule"`
+	// Satisfied is true if every action the rule describes is granted
+	Satisfied bool                     `json:"satisfied"`
+	Actions   []install.ActionDecision `json:"actions"`
+	// Error is set if the rule couldn't be checked
+	Error string `json:"error,omitempty"`
+}
+
+// Missing returns the rules that aren't satisfied
+func (r PermissionReport) Missing() []RulePermission {
+	missing := []RulePermission{}
+	for _, rule := range r.Rules {
+		if !rule.Satisfied {
+			missing = append(missing, rule)
+		}
+	}
+	return missing
+}
+
+// String returns the report as indented JSON
+func (r PermissionReport) String() string {
+	out, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("error marshalling permission report: %s", err)
+	}
+	return string(out)
+}
+
+// AnalyzePermissions checks the permissions and cluster permissions requested by a CSV's install strategy against the
+// operator's RBAC listers, as the permission requirement check does, and reports the result of every action each rule
+// describes. Namespaced permissions are reported for each of the CSV's target namespaces and each namespace its target
+// namespace selector matches.
+func (a *Operator) AnalyzePermissions(csv *v1alpha1.ClusterServiceVersion) PermissionReport {
+	report := PermissionReport{
+		CSV:       csv.GetName(),
+		Namespace: csv.GetNamespace(),
+		Rules:     []RulePermission{},
+	}
+
+	strategy, err := (&install.StrategyResolver{}).UnmarshalStrategy(csv.Spec.InstallStrategy)
+	if err != nil {
+		report.Error = fmt.Sprintf("couldn't unmarshal install strategy: %s", err)
+		return report
+	}
+	details, ok := strategy.(*install.StrategyDetailsDeployment)
+	if !ok {
+		report.Error = fmt.Sprintf("install strategy %s isn't a deployment strategy", csv.Spec.InstallStrategy.StrategyName)
+		return report
+	}
+
+	ruleChecker := install.NewCSVRuleChecker(a.roleLister, a.roleBindingLister, a.clusterRoleLister, a.clusterRoleBindingLister, csv)
+	analyze := func(permissions []install.StrategyDeploymentPermissions, namespaces []string, cluster bool) {
+		for _, perm := range permissions {
+			subject := rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: perm.ServiceAccountName, Namespace: csv.GetNamespace()}
+			for _, rule := range perm.Rules {
+				for _, namespace := range namespaces {
+					permission := RulePermission{
+						ServiceAccount: perm.ServiceAccountName,
+						Namespace:      namespace,
+						Cluster:        cluster,
+						Rule:           rule,
+					}
+
+					actions, err := ruleChecker.RuleActionsFor(subject, namespace, rule)
+					if err != nil {
+						permission.Error = err.Error()
+						report.Rules = append(report.Rules, permission)
+						continue
+					}
+					permission.Actions = actions
+					permission.Satisfied = true
+					for _, action := range actions {
+						permission.Satisfied = permission.Satisfied && action.Allowed
+					}
+					report.Rules = append(report.Rules, permission)
+				}
+			}
+		}
+	}
+
+	namespaces, err := a.permissionNamespaces(csv)
+	if err != nil {
+		report.Error = err.Error()
+	}
+	analyze(details.Permissions, namespaces, false)
+	analyze(details.ClusterPermissions, []string{metav1.NamespaceAll}, true)
+
+	return report
+}
@@ -0,0 +1,117 @@
+package olm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	crbacv1 "k8s.io/client-go/listers/rbac/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/install"
+)
+
+func TestAnalyzePermissions(t *testing.T) {
+	namespace := "ns"
+	op, err := NewFakeOperator(nil, nil, nil, nil, &install.StrategyResolver{}, namespace)
+	require.NoError(t, err)
+
+	subjects := []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "sa", Namespace: namespace}}
+	roles := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	roleBindings := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	clusterRoles := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	clusterRoleBindings := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	op.roleLister = crbacv1.NewRoleLister(roles)
+	op.roleBindingLister = crbacv1.NewRoleBindingLister(roleBindings)
+	op.clusterRoleLister = crbacv1.NewClusterRoleLister(clusterRoles)
+	op.clusterRoleBindingLister = crbacv1.NewClusterRoleBindingLister(clusterRoleBindings)
+
+	require.NoError(t, roles.Add(&rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-reader", Namespace: namespace},
+		Rules:      []rbacv1.PolicyRule{{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"pods"}}},
+	}))
+	require.NoError(t, roleBindings.Add(&rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-reader-binding", Namespace: namespace},
+		RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "pod-reader"},
+		Subjects:   subjects,
+	}))
+	require.NoError(t, clusterRoles.Add(&rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-reader"},
+		Rules:      []rbacv1.PolicyRule{{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"nodes"}}},
+	}))
+	require.NoError(t, clusterRoleBindings.Add(&rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-reader-binding"},
+		RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "node-reader"},
+		Subjects:   subjects,
+	}))
+
+	permissions := []install.StrategyDeploymentPermissions{{
+		ServiceAccountName: "sa",
+		Rules: []rbacv1.PolicyRule{
+			{Verbs: []string{"get", "list"}, APIGroups: []string{""}, Resources: []string{"pods"}},
+		},
+	}}
+	clusterPermissions := []install.StrategyDeploymentPermissions{{
+		ServiceAccountName: "sa",
+		Rules: []rbacv1.PolicyRule{
+			{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"nodes"}},
+		},
+	}}
+	csv := csv("csv1",
+		namespace,
+		"",
+		withPermissions(installStrategy("csv1-dep1"), permissions, clusterPermissions),
+		[]*v1beta1.CustomResourceDefinition{},
+		[]*v1beta1.CustomResourceDefinition{},
+		v1alpha1.CSVPhasePending,
+	)
+
+	report := op.AnalyzePermissions(csv)
+	require.Empty(t, report.Error)
+	require.Equal(t, "csv1", report.CSV)
+	require.Len(t, report.Rules, 2)
+
+	// the namespaced rule is only partly granted: get by the RoleBinding, list by nothing
+	namespaced := report.Rules[0]
+	require.False(t, namespaced.Cluster)
+	require.Equal(t, namespace, namespaced.Namespace)
+	require.Equal(t, "sa", namespaced.ServiceAccount)
+	require.False(t, namespaced.Satisfied)
+	require.Len(t, namespaced.Actions, 2)
+	require.Equal(t, "get", namespaced.Actions[0].Verb)
+	require.True(t, namespaced.Actions[0].Allowed)
+	require.Contains(t, namespaced.Actions[0].GrantedBy, "pod-reader-binding")
+	require.Equal(t, "list", namespaced.Actions[1].Verb)
+	require.False(t, namespaced.Actions[1].Allowed)
+	require.Empty(t, namespaced.Actions[1].GrantedBy)
+
+	cluster := report.Rules[1]
+	require.True(t, cluster.Cluster)
+	require.Empty(t, cluster.Namespace)
+	require.True(t, cluster.Satisfied)
+	require.Len(t, cluster.Actions, 1)
+	require.Contains(t, cluster.Actions[0].GrantedBy, "node-reader-binding")
+
+	require.Equal(t, []RulePermission{namespaced}, report.Missing())
+}
+
+func TestAnalyzePermissionsInvalidStrategy(t *testing.T) {
+	op, err := NewFakeOperator(nil, nil, nil, nil, &install.StrategyResolver{}, "ns")
+	require.NoError(t, err)
+
+	csv := csv("csv1",
+		"ns",
+		"",
+		v1alpha1.NamedInstallStrategy{StrategyName: "unknown"},
+		[]*v1beta1.CustomResourceDefinition{},
+		[]*v1beta1.CustomResourceDefinition{},
+		v1alpha1.CSVPhasePending,
+	)
+
+	report := op.AnalyzePermissions(csv)
+	require.NotEmpty(t, report.Error)
+	require.Empty(t, report.Rules)
+}
@@ -0,0 +1,115 @@
+package olm
+
+import (
+	"context"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+)
+
+// RequirementReportRow is a single requirement of a single CSV
+type RequirementReportRow struct {
+	Namespace string
+	CSV       string
+	Kind      string
+	Name      string
+	Reason    v1alpha1.StatusReason
+}
+
+// RequirementReportFilter selects the rows included in a requirements report. The zero value includes every row.
+type RequirementReportFilter struct {
+	// UnmetOnly excludes requirements that are met
+	UnmetOnly bool
+	// Reasons, if not empty, excludes requirements whose reason isn't listed
+	Reasons []v1alpha1.StatusReason
+}
+
+func (f RequirementReportFilter) includes(reason v1alpha1.StatusReason) bool {
+	if f.UnmetOnly && requirementReasonMet(reason) {
+		return false
+	}
+	if len(f.Reasons) == 0 {
+		return true
+	}
+	for _, r := range f.Reasons {
+		if r == reason {
+			return true
+		}
+	}
+
+	return false
+}
+
+// requirementReasonMet returns true if a requirement with the given reason is met, including with a warning
+func requirementReasonMet(reason v1alpha1.StatusReason) bool {
+	return v1alpha1.ReasonSeverity(reason) != v1alpha1.SeverityBlocking
+}
+
+// RequirementsTable evaluates the requirements of every CSV in a namespace, or in all namespaces if namespace is
+// metav1.NamespaceAll, and returns a row for each requirement the filter includes, sorted by namespace, CSV, kind,
+// name, and reason.
+// As with RequirementsForNamespace, all CSVs are checked against the same discovery and lookup results, evaluation
+// stops early if ctx is done, and nil is returned if the CSVs can't be listed.
+func (a *Operator) RequirementsTable(ctx context.Context, namespace string, filter RequirementReportFilter) []RequirementReportRow {
+	csvs, err := a.client.OperatorsV1alpha1().ClusterServiceVersions(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		a.baseLogger().WithField("namespace", namespace).Warnf("couldn't list CSVs: %s", err)
+		return nil
+	}
+
+	snapshot := a.requirementsSnapshot()
+	snapshot.ctx = ctx
+	rows := []RequirementReportRow{}
+	for i := range csvs.Items {
+		if ctx.Err() != nil {
+			break
+		}
+		csv := &csvs.Items[i]
+		_, statuses := a.requirementStatusFromSnapshot(csv, snapshot)
+		rows = append(rows, requirementReportRows(csv, statuses, filter)...)
+	}
+	SortRequirementReport(rows)
+
+	return rows
+}
+
+// requirementReportRows returns the rows for a CSV's requirement statuses that the filter includes
+func requirementReportRows(csv *v1alpha1.ClusterServiceVersion, statuses []v1alpha1.RequirementStatus, filter RequirementReportFilter) []RequirementReportRow {
+	rows := []RequirementReportRow{}
+	for _, status := range statuses {
+		if !filter.includes(status.Status) {
+			continue
+		}
+		rows = append(rows, RequirementReportRow{
+			Namespace: csv.GetNamespace(),
+			CSV:       csv.GetName(),
+			Kind:      status.Kind,
+			Name:      status.Name,
+			Reason:    status.Status,
+		})
+	}
+
+	return rows
+}
+
+// SortRequirementReport sorts rows by namespace, CSV, kind, name, and reason
+func SortRequirementReport(rows []RequirementReportRow) {
+	sort.Slice(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.CSV != b.CSV {
+			return a.CSV < b.CSV
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Reason < b.Reason
+	})
+}
@@ -0,0 +1,152 @@
+package olm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	apiregistrationv1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/install"
+)
+
+func TestRequirementsTable(t *testing.T) {
+	namespace := "ns"
+	permissions := []install.StrategyDeploymentPermissions{
+		{
+			ServiceAccountName: "sa",
+			Rules:              []rbacv1.PolicyRule{{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"pods"}}},
+		},
+	}
+
+	// listed out of order, so that the report has to sort them
+	clientObjs := []runtime.Object{
+		csv("other",
+			"other-ns",
+			"",
+			installStrategy("other-dep1"),
+			[]*v1beta1.CustomResourceDefinition{},
+			[]*v1beta1.CustomResourceDefinition{crd("c2", "v1")},
+			v1alpha1.CSVPhasePending,
+		),
+		withAPIServices(csv("csv3",
+			namespace,
+			"",
+			installStrategy("csv3-dep1"),
+			[]*v1beta1.CustomResourceDefinition{},
+			[]*v1beta1.CustomResourceDefinition{},
+			v1alpha1.CSVPhasePending,
+		), nil, apis("a2.v1.a2Kind", "a1.v1.a1Kind")),
+		csv("csv2",
+			namespace,
+			"",
+			withPermissions(installStrategy("csv2-dep1"), permissions, nil),
+			[]*v1beta1.CustomResourceDefinition{},
+			[]*v1beta1.CustomResourceDefinition{crd("c2", "v1"), crd("c1", "v1")},
+			v1alpha1.CSVPhasePending,
+		),
+		csv("csv1",
+			namespace,
+			"",
+			installStrategy("csv1-dep1"),
+			[]*v1beta1.CustomResourceDefinition{crd("c1", "v1")},
+			[]*v1beta1.CustomResourceDefinition{},
+			v1alpha1.CSVPhasePending,
+		),
+	}
+	k8sObjs := []runtime.Object{serviceAccount("sa", namespace)}
+	extObjs := []runtime.Object{crd("c1", "v1")}
+	regObjs := []runtime.Object{apiService("a1", "v1", apiregistrationv1.ConditionTrue)}
+
+	op, err := NewFakeOperator(clientObjs, k8sObjs, extObjs, regObjs, &install.StrategyResolver{}, namespace)
+	require.NoError(t, err)
+
+	tests := []struct {
+		namespace    string
+		filter       RequirementReportFilter
+		expectedRows []RequirementReportRow
+		description  string
+	}{
+		{
+			namespace: namespace,
+			filter:    RequirementReportFilter{},
+			expectedRows: []RequirementReportRow{
+				{Namespace: "ns", CSV: "csv1", Kind: "CustomResourceDefinition", Name: "c1group", Reason: v1alpha1.RequirementStatusReasonPresent},
+				{Namespace: "ns", CSV: "csv2", Kind: "CustomResourceDefinition", Name: "c1group", Reason: v1alpha1.RequirementStatusReasonPresent},
+				{Namespace: "ns", CSV: "csv2", Kind: "CustomResourceDefinition", Name: "c2group", Reason: v1alpha1.RequirementStatusReasonNotPresent},
+				{Namespace: "ns", CSV: "csv2", Kind: "ServiceAccount", Name: "sa", Reason: v1alpha1.RequirementStatusReasonPresentNotSatisfied},
+				{Namespace: "ns", CSV: "csv3", Kind: "APIService", Name: "v1.a1", Reason: v1alpha1.RequirementStatusReasonPresent},
+				{Namespace: "ns", CSV: "csv3", Kind: "APIService", Name: "v1.a2", Reason: v1alpha1.RequirementStatusReasonNotPresent},
+			},
+			description: "Namespace/All",
+		},
+		{
+			namespace: metav1.NamespaceAll,
+			filter:    RequirementReportFilter{UnmetOnly: true},
+			expectedRows: []RequirementReportRow{
+				{Namespace: "ns", CSV: "csv2", Kind: "CustomResourceDefinition", Name: "c2group", Reason: v1alpha1.RequirementStatusReasonNotPresent},
+				{Namespace: "ns", CSV: "csv2", Kind: "ServiceAccount", Name: "sa", Reason: v1alpha1.RequirementStatusReasonPresentNotSatisfied},
+				{Namespace: "ns", CSV: "csv3", Kind: "APIService", Name: "v1.a2", Reason: v1alpha1.RequirementStatusReasonNotPresent},
+				{Namespace: "other-ns", CSV: "other", Kind: "CustomResourceDefinition", Name: "c2group", Reason: v1alpha1.RequirementStatusReasonNotPresent},
+			},
+			description: "AllNamespaces/Unmet",
+		},
+		{
+			namespace: metav1.NamespaceAll,
+			filter:    RequirementReportFilter{Reasons: []v1alpha1.StatusReason{v1alpha1.RequirementStatusReasonPresentNotSatisfied}},
+			expectedRows: []RequirementReportRow{
+				{Namespace: "ns", CSV: "csv2", Kind: "ServiceAccount", Name: "sa", Reason: v1alpha1.RequirementStatusReasonPresentNotSatisfied},
+			},
+			description: "AllNamespaces/Reason",
+		},
+		{
+			namespace:    metav1.NamespaceAll,
+			filter:       RequirementReportFilter{UnmetOnly: true, Reasons: []v1alpha1.StatusReason{v1alpha1.RequirementStatusReasonPresent}},
+			expectedRows: []RequirementReportRow{},
+			description:  "AllNamespaces/UnmetAndMetReason",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			rows := op.RequirementsTable(context.Background(), tt.namespace, tt.filter)
+			require.Equal(t, tt.expectedRows, rows)
+
+			// the order doesn't depend on the order CSVs are evaluated in
+			for i := 0; i < 5; i++ {
+				require.Equal(t, rows, op.RequirementsTable(context.Background(), tt.namespace, tt.filter))
+			}
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.Empty(t, op.RequirementsTable(ctx, namespace, RequirementReportFilter{}))
+}
+
+func TestRequirementReportFilterUnmetOnly(t *testing.T) {
+	v1alpha1.RegisterReasonSeverity("TestReportWarning", v1alpha1.SeverityWarning)
+	filter := RequirementReportFilter{UnmetOnly: true}
+
+	tests := []struct {
+		reason   v1alpha1.StatusReason
+		expected bool
+	}{
+		{v1alpha1.RequirementStatusReasonPresent, false},
+		{v1alpha1.DependentStatusReasonSatisfied, false},
+		{v1alpha1.DependentStatusReasonMissingSchema, false},
+		{"TestReportWarning", false},
+		{v1alpha1.RequirementStatusReasonNotPresent, true},
+		{v1alpha1.DependentStatusReasonNotSatisfied, true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.reason), func(t *testing.T) {
+			require.Equal(t, tt.expected, filter.includes(tt.reason))
+		})
+	}
+}
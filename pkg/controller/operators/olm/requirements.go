@@ -1,19 +1,413 @@
 package olm
 
 import (
+	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 
 	log "github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	schedulingv1beta1 "k8s.io/api/scheduling/v1beta1"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	apiregistrationv1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
 
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
 	olmErrors "github.com/operator-framework/operator-lifecycle-manager/pkg/controller/errors"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/install"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/operatorclient"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/ownerutil"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/metrics"
 )
 
+// TargetNamespacesAnnotationKey lists, comma separated, the namespaces targeted by the OperatorGroup a CSV is installed
+// by. The CSV's namespaced permissions are checked in each of them as well as in the CSV's own namespace.
+const TargetNamespacesAnnotationKey = "olm.targetNamespaces"
+
+// TargetNamespaceSelectorAnnotationKey is a label selector, in the form accepted by kubectl, for the namespaces a CSV's
+// operator acts on. The CSV's namespaced permissions are also checked in each namespace that matches it, so that RBAC
+// gaps in the namespaces actually watched are caught even when the operator is installed for all namespaces.
+const TargetNamespaceSelectorAnnotationKey = "olm.targetNamespaceSelector"
+
+// CABundleConfigMapAnnotationKey names, on an APIService, the ConfigMap in its Service's namespace that a CA bundle
+// is injected into, for instance by annotating the ConfigMap with service.beta.openshift.io/inject-cabundle.
+// Such an APIService's requirement is met only once the ConfigMap holds a CA bundle under InjectedCABundleKey.
+const CABundleConfigMapAnnotationKey = "olm.caBundleConfigMap"
+
+// InjectedCABundleKey is the ConfigMap data key an injected CA bundle is written to
+const InjectedCABundleKey = "service-ca.crt"
+
+// csvRequirementsIndex indexes CSVs by the CRDs, APIServices, ServiceAccounts, and PriorityClasses they require
+const csvRequirementsIndex = "requirements"
+
+func requirementIndexKey(kind, name string) string {
+	return fmt.Sprintf("%s/%s", kind, name)
+}
+
+// namespaceSelectorIndexKey is the index key of CSVs with a target namespace selector. Any namespace change may change
+// the namespaces it matches.
+var namespaceSelectorIndexKey = requirementIndexKey("Namespace", "")
+
+// serviceAccountIndexKey returns the index key of CSVs whose install strategy requests permissions for a ServiceAccount
+func serviceAccountIndexKey(namespace, name string) string {
+	return requirementIndexKey("ServiceAccount", fmt.Sprintf("%s/%s", namespace, name))
+}
+
+// csvRequirementsIndexFunc returns an index key for each CRD and APIService a CSV requires, for each ServiceAccount its
+// install strategy requests permissions for or its deployments run as, and for each PriorityClass its install
+// strategy's deployments request
+func csvRequirementsIndexFunc(obj interface{}) ([]string, error) {
+	csv, ok := obj.(*v1alpha1.ClusterServiceVersion)
+	if !ok {
+		return nil, fmt.Errorf("can't index %T by requirements", obj)
+	}
+
+	keys := []string{}
+	for _, desc := range csv.GetAllCRDDescriptions() {
+		keys = append(keys, requirementIndexKey("CustomResourceDefinition", desc.Name))
+	}
+	for _, desc := range csv.GetAllAPIServiceDescriptions() {
+		group, apiName := apiServiceGroupAndName(desc)
+		keys = append(keys, requirementIndexKey("APIService", apiName))
+		for _, version := range desc.AcceptableVersions {
+			keys = append(keys, requirementIndexKey("APIService", version+"."+group))
+		}
+	}
+
+	if _, ok := csv.GetAnnotations()[TargetNamespaceSelectorAnnotationKey]; ok {
+		keys = append(keys, namespaceSelectorIndexKey)
+	}
+
+	// an invalid install strategy fails the CSV on its own, so there are no permissions or PriorityClasses to recheck
+	details, ok := strategyDeploymentDetails(csv)
+	if !ok {
+		return keys, nil
+	}
+	seen := map[string]struct{}{}
+	for _, perm := range append(details.Permissions, details.ClusterPermissions...) {
+		key := serviceAccountIndexKey(csv.GetNamespace(), perm.ServiceAccountName)
+		if _, ok := seen[key]; !ok {
+			seen[key] = struct{}{}
+			keys = append(keys, key)
+		}
+	}
+	for name := range strategyDeploymentServiceAccounts(details) {
+		key := serviceAccountIndexKey(csv.GetNamespace(), name)
+		if _, ok := seen[key]; !ok {
+			seen[key] = struct{}{}
+			keys = append(keys, key)
+		}
+	}
+	for name := range strategyPriorityClasses(csv) {
+		keys = append(keys, requirementIndexKey("PriorityClass", name))
+	}
+
+	return keys, nil
+}
+
+// requirementsSnapshot caches the cluster reads made while checking requirements, so that checks for several CSVs
+// can share a single discovery query and a single lookup of each CRD, APIService, ServiceAccount, PriorityClass, and
+// ConfigMap.
+// A snapshot is not safe for concurrent use.
+type requirementsSnapshot struct {
+	client operatorclient.ClientInterface
+	// ctx bounds the waits made by the checks, such as polling an APIService until it's available
+	ctx context.Context
+	// gvks answers discovery queries. Snapshots made by the operator share its GVKChecker; otherwise the snapshot
+	// caches a single discovery query.
+	gvks GVKChecker
+
+	crds            map[string]crdLookup
+	apiServices     map[string]apiServiceLookup
+	serviceAccounts map[string]serviceAccountLookup
+	endpoints       map[string]endpointsLookup
+	priorityClasses map[string]priorityClassLookup
+	configMaps      map[string]configMapLookup
+
+	// rechecked is true once a GVK miss has invalidated gvks, so that each snapshot forces at most one live recheck
+	rechecked bool
+	// untraced is true if checks made with the snapshot must not touch requirement traces, such as when evaluating a
+	// CSV that isn't the one on the cluster
+	untraced bool
+}
+
+type crdLookup struct {
+	crd *v1beta1.CustomResourceDefinition
+	err error
+}
+
+type apiServiceLookup struct {
+	apiService *apiregistrationv1.APIService
+	err        error
+}
+
+type serviceAccountLookup struct {
+	serviceAccount *corev1.ServiceAccount
+	err            error
+}
+
+type configMapLookup struct {
+	configMap *corev1.ConfigMap
+	err       error
+}
+
+type priorityClassLookup struct {
+	priorityClass *schedulingv1beta1.PriorityClass
+	err           error
+}
+
+type endpointsLookup struct {
+	endpoints *corev1.Endpoints
+	err       error
+}
+
+func newRequirementsSnapshot(client operatorclient.ClientInterface) *requirementsSnapshot {
+	return &requirementsSnapshot{
+		client:          client,
+		ctx:             context.Background(),
+		gvks:            newDiscoveryGVKChecker(client.KubernetesInterface().Discovery()),
+		crds:            map[string]crdLookup{},
+		apiServices:     map[string]apiServiceLookup{},
+		serviceAccounts: map[string]serviceAccountLookup{},
+		endpoints:       map[string]endpointsLookup{},
+		priorityClasses: map[string]priorityClassLookup{},
+		configMaps:      map[string]configMapLookup{},
+	}
+}
+
+// requirementsSnapshot returns a new snapshot that reads through the requirements client and shares the operator's GVK
+// cache
+func (a *Operator) requirementsSnapshot() *requirementsSnapshot {
+	client := a.requirementsClient
+	if client == nil {
+		client = a.OpClient
+	}
+	snapshot := newRequirementsSnapshot(client)
+	if a.gvks != nil {
+		snapshot.gvks = a.gvks
+	}
+	return snapshot
+}
+
+func (s *requirementsSnapshot) getCRD(name string) (*v1beta1.CustomResourceDefinition, error) {
+	lookup, ok := s.crds[name]
+	if !ok {
+		lookup.crd, lookup.err = s.client.ApiextensionsV1beta1Interface().ApiextensionsV1beta1().CustomResourceDefinitions().Get(name, metav1.GetOptions{})
+		s.crds[name] = lookup
+	}
+	return lookup.crd, lookup.err
+}
+
+func (s *requirementsSnapshot) getAPIService(name string) (*apiregistrationv1.APIService, error) {
+	lookup, ok := s.apiServices[name]
+	if !ok {
+		lookup.apiService, lookup.err = s.client.ApiregistrationV1Interface().ApiregistrationV1().APIServices().Get(name, metav1.GetOptions{})
+		s.apiServices[name] = lookup
+	}
+	return lookup.apiService, lookup.err
+}
+
+// refreshAPIService gets the named APIService again, replacing the result cached by getAPIService
+func (s *requirementsSnapshot) refreshAPIService(name string) (*apiregistrationv1.APIService, error) {
+	delete(s.apiServices, name)
+	return s.getAPIService(name)
+}
+
+// awaitAPIServiceAvailable returns the given APIService, refreshed until it's available or the operator's configured
+// number of attempts is used up, and whether it's available.
+// An APIService is briefly unavailable whenever its backing deployment rolls out, so polling keeps CSVs requiring it
+// from flapping to Pending. Polling stops early if the snapshot's context is done.
+func (a *Operator) awaitAPIServiceAvailable(snapshot *requirementsSnapshot, apiService *apiregistrationv1.APIService) (*apiregistrationv1.APIService, bool) {
+	for attempt := 1; ; attempt++ {
+		if a.isAPIServiceAvailable(apiService) {
+			return apiService, true
+		}
+		if attempt >= a.apiServiceAvailabilityAttempts {
+			return apiService, false
+		}
+
+		select {
+		case <-snapshot.ctx.Done():
+			return apiService, false
+		case <-a.clock.After(a.apiServiceAvailabilityInterval):
+		}
+
+		refreshed, err := snapshot.refreshAPIService(apiService.GetName())
+		if err != nil {
+			return apiService, false
+		}
+		apiService = refreshed
+	}
+}
+
+func (s *requirementsSnapshot) getServiceAccount(namespace, name string) (*corev1.ServiceAccount, error) {
+	key := fmt.Sprintf("%s/%s", namespace, name)
+	lookup, ok := s.serviceAccounts[key]
+	if !ok {
+		lookup.serviceAccount, lookup.err = s.client.GetServiceAccount(namespace, name)
+		s.serviceAccounts[key] = lookup
+	}
+	return lookup.serviceAccount, lookup.err
+}
+
+func (s *requirementsSnapshot) getPriorityClass(name string) (*schedulingv1beta1.PriorityClass, error) {
+	lookup, ok := s.priorityClasses[name]
+	if !ok {
+		lookup.priorityClass, lookup.err = s.client.KubernetesInterface().SchedulingV1beta1().PriorityClasses().Get(name, metav1.GetOptions{})
+		s.priorityClasses[name] = lookup
+	}
+	return lookup.priorityClass, lookup.err
+}
+
+func (s *requirementsSnapshot) getEndpoints(namespace, name string) (*corev1.Endpoints, error) {
+	key := fmt.Sprintf("%s/%s", namespace, name)
+	lookup, ok := s.endpoints[key]
+	if !ok {
+		lookup.endpoints, lookup.err = s.client.KubernetesInterface().CoreV1().Endpoints(namespace).Get(name, metav1.GetOptions{})
+		s.endpoints[key] = lookup
+	}
+	return lookup.endpoints, lookup.err
+}
+
+func (s *requirementsSnapshot) getConfigMap(namespace, name string) (*corev1.ConfigMap, error) {
+	key := fmt.Sprintf("%s/%s", namespace, name)
+	lookup, ok := s.configMaps[key]
+	if !ok {
+		lookup.configMap, lookup.err = s.client.KubernetesInterface().CoreV1().ConfigMaps(namespace).Get(name, metav1.GetOptions{})
+		s.configMaps[key] = lookup
+	}
+	return lookup.configMap, lookup.err
+}
+
+// caBundleDependent returns the status of the ConfigMap an APIService's CA bundle is injected into, or nil if the
+// APIService doesn't name one with CABundleConfigMapAnnotationKey
+func (s *requirementsSnapshot) caBundleDependent(apiService *apiregistrationv1.APIService) *v1alpha1.DependentStatus {
+	name, ok := apiService.GetAnnotations()[CABundleConfigMapAnnotationKey]
+	if !ok {
+		return nil
+	}
+
+	dependent := &v1alpha1.DependentStatus{
+		Version: "v1",
+		Kind:    "ConfigMap",
+		Status:  v1alpha1.DependentStatusReasonNotSatisfied,
+	}
+	service := apiService.Spec.Service
+	if service == nil {
+		dependent.Message = fmt.Sprintf("APIService %s names CA bundle ConfigMap %s but has no Service to find it in", apiService.GetName(), name)
+		return dependent
+	}
+
+	configMap, err := s.getConfigMap(service.Namespace, name)
+	switch {
+	case k8serrors.IsForbidden(err):
+		dependent.Message = fmt.Sprintf("OLM is not permitted to get CA bundle ConfigMap %s/%s: %s", service.Namespace, name, err)
+	case err != nil:
+		dependent.Message = fmt.Sprintf("CA bundle ConfigMap %s/%s not found: %s", service.Namespace, name, err)
+	case configMap.Data[InjectedCABundleKey] == "":
+		dependent.Message = fmt.Sprintf("CA bundle hasn't been injected into ConfigMap %s/%s yet; %s is empty", service.Namespace, name, InjectedCABundleKey)
+	default:
+		dependent.Status = v1alpha1.DependentStatusReasonSatisfied
+		dependent.UUID = string(configMap.GetUID())
+		dependent.Message = fmt.Sprintf("CA bundle injected into ConfigMap %s/%s", service.Namespace, name)
+	}
+	return dependent
+}
+
+// apiServiceDetails returns the backing Service, whether it has ready endpoints, and the CABundle fingerprint of an
+// APIService, for recording in its RequirementStatus
+func (s *requirementsSnapshot) apiServiceDetails(apiService *apiregistrationv1.APIService) map[string]string {
+	details := map[string]string{}
+	if len(apiService.Spec.CABundle) > 0 {
+		details[v1alpha1.RequirementDetailCABundleSHA256] = fmt.Sprintf("%x", sha256.Sum256(apiService.Spec.CABundle))
+	}
+
+	// APIServices without a Service are served locally by the kube-apiserver
+	service := apiService.Spec.Service
+	if service == nil {
+		return details
+	}
+	details[v1alpha1.RequirementDetailService] = fmt.Sprintf("%s/%s", service.Namespace, service.Name)
+
+	ready := false
+	if endpoints, err := s.getEndpoints(service.Namespace, service.Name); err == nil {
+		for _, subset := range endpoints.Subsets {
+			if len(subset.Addresses) > 0 {
+				ready = true
+				break
+			}
+		}
+	}
+	details[v1alpha1.RequirementDetailEndpointsReady] = strconv.FormatBool(ready)
+
+	return details
+}
+
+// RequirementsForNamespace evaluates the requirements of every CSV in a namespace, keyed by CSV name.
+// All CSVs are checked against the same discovery and lookup results, which is much cheaper than checking each CSV
+// separately. Evaluation stops early if ctx is done, and nil is returned if the CSVs can't be listed.
+func (a *Operator) RequirementsForNamespace(ctx context.Context, namespace string) map[string][]v1alpha1.RequirementStatus {
+	csvs, err := a.client.OperatorsV1alpha1().ClusterServiceVersions(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		a.baseLogger().WithField("namespace", namespace).Warnf("couldn't list CSVs: %s", err)
+		return nil
+	}
+
+	snapshot := a.requirementsSnapshot()
+	snapshot.ctx = ctx
+	requirements := make(map[string][]v1alpha1.RequirementStatus, len(csvs.Items))
+	for i := range csvs.Items {
+		if ctx.Err() != nil {
+			break
+		}
+		csv := &csvs.Items[i]
+		_, statuses := a.requirementStatusFromSnapshot(csv, snapshot)
+		requirements[csv.GetName()] = statuses
+	}
+
+	return requirements
+}
+
+// baseLogger returns the Operator's logger, falling back to the standard logger if none was set
+func (a *Operator) baseLogger() log.FieldLogger {
+	if a.logger == nil {
+		return log.StandardLogger()
+	}
+	return a.logger
+}
+
+// requirementsLogger returns a logger for the requirement checks of the given CSV
+func (a *Operator) requirementsLogger(csv *v1alpha1.ClusterServiceVersion) log.FieldLogger {
+	return a.baseLogger().WithFields(log.Fields{
+		"csv":       csv.GetName(),
+		"namespace": csv.GetNamespace(),
+	})
+}
+
+// requirementStatus checks every requirement of the given CSV. The returned statuses are complete, so they replace
+// rather than merge with those recorded by a previous check; a requirement that isn't present carries no UUID, and one
+// that was recreated carries the UUID of the current object.
 func (a *Operator) requirementStatus(csv *v1alpha1.ClusterServiceVersion) (met bool, statuses []v1alpha1.RequirementStatus) {
-	met = true
+	return a.requirementStatusFromSnapshot(csv, a.requirementsSnapshot())
+}
+
+func (a *Operator) requirementStatusFromSnapshot(csv *v1alpha1.ClusterServiceVersion, snapshot *requirementsSnapshot) (met bool, statuses []v1alpha1.RequirementStatus) {
+	trace := a.snapshotTraceFor(csv, snapshot)
+	logger := a.requirementsLogger(csv)
+	ownedCRDs := map[string]struct{}{}
+	for _, desc := range csv.Spec.CustomResourceDefinitions.Owned {
+		ownedCRDs[desc.Name] = struct{}{}
+	}
 	for _, r := range csv.GetAllCRDDescriptions() {
 		status := v1alpha1.RequirementStatus{
 			Group:   "apiextensions.k8s.io",
@@ -21,176 +415,1262 @@ func (a *Operator) requirementStatus(csv *v1alpha1.ClusterServiceVersion) (met b
 			Kind:    "CustomResourceDefinition",
 			Name:    r.Name,
 		}
+		if !snapshot.conditionHolds(r.When, logger) {
+			trace.record(status, "skipped, condition %s not served", conditionString(r.When))
+			continue
+		}
 
 		// check if CRD exists - this verifies group, version, and kind, so no need for GVK check via discovery
-		crd, err := a.OpClient.ApiextensionsV1beta1Interface().ApiextensionsV1beta1().CustomResourceDefinitions().Get(r.Name, metav1.GetOptions{})
-		if err != nil {
+		crd, err := snapshot.getCRD(r.Name)
+		if k8serrors.IsForbidden(err) {
+			// OLM can't tell whether the CRD exists, so point at OLM's RBAC rather than the CSV
+			status.Status = v1alpha1.RequirementStatusReasonAccessDenied
+			status.Message = fmt.Sprintf("OLM is not permitted to get CustomResourceDefinition %s; ensure OLM's ServiceAccount can read CustomResourceDefinitions: %s", r.Name, err)
+			trace.record(status, "get CustomResourceDefinition %s: %s", r.Name, err)
+		} else if err != nil {
 			status.Status = v1alpha1.RequirementStatusReasonNotPresent
-			met = false
+			trace.record(status, "get CustomResourceDefinition %s: %s", r.Name, err)
+		} else if message := crdSchemaMismatch(crd, r); message != "" {
+			status.Status = v1alpha1.RequirementStatusReasonPresentSchemaMismatch
+			status.UUID = string(crd.GetUID())
+			status.Message = message
+			trace.record(status, "get CustomResourceDefinition %s: found, %s", r.Name, message)
+		} else if missing := ownedCRDMissingServedVersions(crd, r, ownedCRDs); len(missing) > 0 {
+			status.Status = v1alpha1.RequirementStatusReasonPresentNotSatisfied
+			status.UUID = string(crd.GetUID())
+			status.Message = fmt.Sprintf("CustomResourceDefinition %s serves versions %v, missing required versions %v", r.Name, servedVersions(crd), missing)
+			trace.record(status, "get CustomResourceDefinition %s: found, missing served versions %v", r.Name, missing)
+		} else if r.VersionRange != "" {
+			status.UUID = string(crd.GetUID())
+			if satisfied, message := crdSatisfiesVersionRange(crd, r.VersionRange); satisfied {
+				status.Status = v1alpha1.RequirementStatusReasonPresent
+			} else {
+				status.Status = v1alpha1.RequirementStatusReasonPresentNotSatisfied
+				status.Message = message
+			}
+			trace.record(status, "get CustomResourceDefinition %s: found, check version range %q", r.Name, r.VersionRange)
 		} else {
 			status.Status = v1alpha1.RequirementStatusReasonPresent
 			status.UUID = string(crd.GetUID())
+			trace.record(status, "get CustomResourceDefinition %s: found", r.Name)
+		}
+
+		// a missing status subresource is only flagged; the CRD is still usable without it
+		if err == nil && r.StatusSubresource && (crd.Spec.Subresources == nil || crd.Spec.Subresources.Status == nil) {
+			status.Dependents = append(status.Dependents, v1alpha1.DependentStatus{
+				Group:   "apiextensions.k8s.io",
+				Version: "v1beta1",
+				Kind:    "CustomResourceDefinition",
+				Status:  v1alpha1.DependentStatusReasonMissingStatusSubresource,
+				Message: fmt.Sprintf("CustomResourceDefinition %s doesn't enable the status subresource expected for version %s", r.Name, r.Version),
+			})
+			trace.record(status, "CustomResourceDefinition %s: status subresource missing", r.Name)
+		}
+
+		// a missing schema only leaves the resources unvalidated, so it's only flagged
+		if err == nil && r.RequiresSchema && !crdHasSchema(crd, r.Version) {
+			status.Dependents = append(status.Dependents, v1alpha1.DependentStatus{
+				Group:   "apiextensions.k8s.io",
+				Version: "v1beta1",
+				Kind:    "CustomResourceDefinition",
+				Status:  v1alpha1.DependentStatusReasonMissingSchema,
+				Message: fmt.Sprintf("CustomResourceDefinition %s doesn't define the OpenAPI v3 validation schema expected for version %s", r.Name, r.Version),
+			})
+			trace.record(status, "CustomResourceDefinition %s: validation schema missing", r.Name)
+		}
+
+		// missing printer columns only affect how kubectl displays the resources, so they're only flagged
+		if err == nil && len(r.PrinterColumns) > 0 {
+			if missing := crdMissingPrinterColumns(crd, r.PrinterColumns); len(missing) > 0 {
+				status.Dependents = append(status.Dependents, v1alpha1.DependentStatus{
+					Group:   "apiextensions.k8s.io",
+					Version: "v1beta1",
+					Kind:    "CustomResourceDefinition",
+					Status:  v1alpha1.DependentStatusReasonMissingPrinterColumns,
+					Message: fmt.Sprintf("CustomResourceDefinition %s lacks the printer columns %s", r.Name, strings.Join(missing, ", ")),
+				})
+				trace.record(status, "CustomResourceDefinition %s: printer columns %s missing", r.Name, strings.Join(missing, ", "))
+			}
+		}
+
+		// a storage version lagging the version the CSV owns is only flagged, so that authors can sequence migrations
+		if _, ok := ownedCRDs[r.Name]; ok && err == nil {
+			if storageVersion := crdStorageVersion(crd); storageVersion != "" {
+				status.Details = map[string]string{v1alpha1.RequirementDetailStorageVersion: storageVersion}
+				if storageVersion != r.Version {
+					status.Dependents = append(status.Dependents, v1alpha1.DependentStatus{
+						Group:   "apiextensions.k8s.io",
+						Version: "v1beta1",
+						Kind:    "CustomResourceDefinition",
+						Status:  v1alpha1.DependentStatusReasonStorageVersionMismatch,
+						Message: fmt.Sprintf("CustomResourceDefinition %s stores version %s, not version %s owned by the CSV", r.Name, storageVersion, r.Version),
+					})
+					trace.record(status, "CustomResourceDefinition %s: storage version %s, expected %s", r.Name, storageVersion, r.Version)
+				}
+			}
+		}
+
+		// a scope at odds with the CSV's install modes may still work, so it's only flagged
+		if _, ok := ownedCRDs[r.Name]; ok && err == nil {
+			if message := crdScopeInconsistency(crd, csv); message != "" {
+				status.Dependents = append(status.Dependents, v1alpha1.DependentStatus{
+					Group:   "apiextensions.k8s.io",
+					Version: "v1beta1",
+					Kind:    "CustomResourceDefinition",
+					Status:  v1alpha1.DependentStatusReasonInconsistentScope,
+					Message: message,
+				})
+				trace.record(status, "CustomResourceDefinition %s: %s scope inconsistent with install modes", r.Name, crd.Spec.Scope)
+			}
 		}
 		statuses = append(statuses, status)
 	}
+	owned := map[string]struct{}{}
+	for _, desc := range csv.Spec.APIServiceDefinitions.Owned {
+		owned[desc.Name] = struct{}{}
+	}
+	deployments, deploymentsKnown := strategyDeploymentNames(csv)
 	for _, r := range csv.GetAllAPIServiceDescriptions() {
-		apiName := fmt.Sprintf("%s.%s", r.Version, r.Name)
+		group, apiName := apiServiceGroupAndName(r)
 		status := v1alpha1.RequirementStatus{
 			Group:   "apiregistration.k8s.io",
 			Version: "v1",
 			Kind:    "APIService",
 			Name:    apiName,
 		}
+		if !snapshot.conditionHolds(r.When, logger) {
+			trace.record(status, "skipped, condition %s not served", conditionString(r.When))
+			continue
+		}
+		if group != r.Name {
+			status.Message = fmt.Sprintf("APIServiceDescription name %s already includes its version %s; it should be the API group %s", r.Name, r.Version, group)
+		}
+
+		// an owned APIService is only ever available if a deployment in the install strategy serves it
+		deploymentMissing := false
+		if _, ok := owned[r.Name]; ok && r.DeploymentName != "" && deploymentsKnown {
+			dependent := v1alpha1.DependentStatus{
+				Group:   "apps",
+				Version: "v1",
+				Kind:    "Deployment",
+				Status:  v1alpha1.DependentStatusReasonSatisfied,
+				Message: fmt.Sprintf("deployment %s serves APIService %s", r.DeploymentName, apiName),
+			}
+			if _, ok := deployments[r.DeploymentName]; !ok {
+				deploymentMissing = true
+				dependent.Status = v1alpha1.DependentStatusReasonNotSatisfied
+				dependent.Message = fmt.Sprintf("deployment %s named to serve APIService %s isn't in the install strategy", r.DeploymentName, apiName)
+			}
+			status.Dependents = append(status.Dependents, dependent)
+			trace.record(status, "install strategy deployment %s: found %t", r.DeploymentName, !deploymentMissing)
+		}
 
-		// check if GVK exists
-		if err := a.isGVKRegistered(r.Name, r.Version, r.Kind); err != nil {
+		// check if GVK exists - descriptions without a kind only require the group version to be served. A required
+		// APIService whose version isn't served is also met by a newer version the CSV accepts.
+		servedVersion, servedName := r.Version, apiName
+		var err error
+		if _, ok := owned[r.Name]; ok {
+			err = snapshot.isGVKRegistered(group, r.Version, r.Kind, logger)
+		} else {
+			servedVersion, err = snapshot.acceptedAPIVersion(group, r, logger)
+		}
+		if err != nil {
 			status.Status = "NotPresent"
-			met = false
+			if olmErrors.IsGroupVersionKindNotFoundError(err) {
+				message := fmt.Sprintf("%s; ensure the APIService serving it is installed and available", err)
+				if status.Message != "" {
+					message = status.Message + "; " + message
+				}
+				status.Message = message
+			}
+			trace.record(status, "discover %s/%s %s: %s", group, r.Version, r.Kind, err)
 			statuses = append(statuses, status)
 			continue
 		}
 
+		if servedVersion != r.Version {
+			servedName = servedVersion + "." + group
+			trace.record(status, "discover %s/%s %s: not found, accepting newer version %s", group, r.Version, r.Kind, servedVersion)
+		}
+
 		// Check if APIService is registered
-		apiService, err := a.OpClient.ApiregistrationV1Interface().ApiregistrationV1().APIServices().Get(apiName, metav1.GetOptions{})
+		apiService, err := snapshot.getAPIService(servedName)
 		if err != nil {
 			status.Status = "NotPresent"
-			met = false
+			trace.record(status, "get APIService %s: %s", servedName, err)
 			statuses = append(statuses, status)
 			continue
 		}
 
 		// Check if API is available
-		if !a.isAPIServiceAvailable(apiService) {
+		apiService, available := a.awaitAPIServiceAvailable(snapshot, apiService)
+		if details := snapshot.apiServiceDetails(apiService); len(details) > 0 {
+			status.Details = details
+		}
+		if servedVersion != r.Version {
+			if status.Details == nil {
+				status.Details = map[string]string{}
+			}
+			status.Details[v1alpha1.RequirementDetailServedVersion] = servedVersion
+		}
+		// an APIService left behind by a deleted CSV may still serve the API, so it's only flagged for cleanup
+		if dependent := a.orphanedOwnerDependent(apiService, csv, logger); dependent != nil {
+			status.Dependents = append(status.Dependents, *dependent)
+			trace.record(status, "APIService %s: %s", servedName, dependent.Message)
+		}
+		caBundleMissing := false
+		if dependent := snapshot.caBundleDependent(apiService); dependent != nil {
+			caBundleMissing = dependent.Status != v1alpha1.DependentStatusReasonSatisfied
+			status.Dependents = append(status.Dependents, *dependent)
+			trace.record(status, "APIService %s CA bundle ConfigMap: %s", servedName, dependent.Message)
+		}
+		if !available {
 			status.Status = "NotPresent"
-			met = false
+			trace.record(status, "APIService %s available: false", servedName)
 		} else {
 			status.Status = "Present"
+			if deploymentMissing || caBundleMissing {
+				status.Status = v1alpha1.RequirementStatusReasonPresentNotSatisfied
+			}
 			status.UUID = string(apiService.GetUID())
+			trace.record(status, "APIService %s available: true", servedName)
+		}
+		statuses = append(statuses, status)
+	}
+
+	priorityClasses := strategyPriorityClasses(csv)
+	priorityClassNames := make([]string, 0, len(priorityClasses))
+	for name := range priorityClasses {
+		priorityClassNames = append(priorityClassNames, name)
+	}
+	sort.Strings(priorityClassNames)
+	for _, name := range priorityClassNames {
+		status := v1alpha1.RequirementStatus{
+			Group:   "scheduling.k8s.io",
+			Version: "v1beta1",
+			Kind:    "PriorityClass",
+			Name:    name,
+		}
+
+		// pods requesting a PriorityClass that doesn't exist are rejected, so the deployment never becomes available
+		priorityClass, err := snapshot.getPriorityClass(name)
+		if k8serrors.IsForbidden(err) {
+			status.Status = v1alpha1.RequirementStatusReasonAccessDenied
+			status.Message = fmt.Sprintf("OLM is not permitted to get PriorityClass %s; ensure OLM's ServiceAccount can read PriorityClasses: %s", name, err)
+			trace.record(status, "get PriorityClass %s: %s", name, err)
+		} else if err != nil {
+			status.Status = v1alpha1.RequirementStatusReasonNotPresent
+			status.Message = fmt.Sprintf("PriorityClass %s requested by deployment %s not found", name, strings.Join(priorityClasses[name], ", "))
+			trace.record(status, "get PriorityClass %s: %s", name, err)
+		} else {
+			status.Status = v1alpha1.RequirementStatusReasonPresent
+			status.UUID = string(priorityClass.GetUID())
+			trace.record(status, "get PriorityClass %s: found", name)
+		}
+		statuses = append(statuses, status)
+	}
+
+	// required Deployments aren't watched, so their readiness is rechecked on the operator's wakeup interval
+	for _, required := range csv.Spec.RequiredDeployments {
+		namespace := required.Namespace
+		if namespace == "" {
+			namespace = csv.GetNamespace()
+		}
+		status := v1alpha1.RequirementStatus{
+			Group:   "apps",
+			Version: "v1",
+			Kind:    "Deployment",
+			Name:    fmt.Sprintf("%s/%s", namespace, required.Name),
+		}
+		if !snapshot.conditionHolds(required.When, logger) {
+			trace.record(status, "skipped, condition %s not served", conditionString(required.When))
+			continue
+		}
+
+		deployment, err := snapshot.client.KubernetesInterface().AppsV1().Deployments(namespace).Get(required.Name, metav1.GetOptions{})
+		if k8serrors.IsForbidden(err) {
+			status.Status = v1alpha1.RequirementStatusReasonAccessDenied
+			status.Message = fmt.Sprintf("OLM is not permitted to get Deployment %s; ensure OLM's ServiceAccount can read Deployments in namespace %s: %s", status.Name, namespace, err)
+			trace.record(status, "get Deployment %s: %s", status.Name, err)
+		} else if err != nil {
+			status.Status = v1alpha1.RequirementStatusReasonNotPresent
+			status.Message = err.Error()
+			trace.record(status, "get Deployment %s: %s", status.Name, err)
+		} else if ready, message := deploymentReady(deployment); !ready {
+			status.Status = v1alpha1.RequirementStatusReasonPresentNotSatisfied
+			status.UUID = string(deployment.GetUID())
+			status.Message = message
+			trace.record(status, "get Deployment %s: found, %s", status.Name, message)
+		} else {
+			status.Status = v1alpha1.RequirementStatusReasonPresent
+			status.UUID = string(deployment.GetUID())
+			trace.record(status, "get Deployment %s: found, ready", status.Name)
 		}
 		statuses = append(statuses, status)
 	}
 
 	// Get permission status
-	permissionsMet, permissionStatuses := a.permissionStatus(csv)
-	log.Infof("CSV %s permission met: %t", csv.GetName(), permissionsMet)
+	permissionsMet, permissionStatuses := a.permissionStatus(csv, snapshot, logger)
+	logger.Infof("permission met: %t", permissionsMet)
 	statuses = append(statuses, permissionStatuses...)
-	met = met && permissionsMet
+	// an install strategy that can't be unmarshalled is unmet without a status to classify
+	unclassified := !permissionsMet && len(permissionStatuses) == 0
+
+	for _, check := range a.requirementChecks {
+		checkMet, checkStatuses := check.CheckRequirements(csv)
+		for _, status := range checkStatuses {
+			trace.record(status, "%T: %s", check, status.Status)
+		}
+		statuses = append(statuses, checkStatuses...)
+		unclassified = unclassified || (!checkMet && len(checkStatuses) == 0)
+	}
+
+	// only blocking reasons make the requirements unmet, so that warnings are reported without holding up the CSV
+	met = !unclassified && v1alpha1.RequirementsMet(statuses)
+	logger.WithFields(log.Fields{
+		"met":          met,
+		"requirements": len(statuses),
+		"inputsHash":   requirementInputsHash(csv),
+	}).Info("requirements checked")
 
+	merge := a.requirementStatusMerge
+	if merge == nil {
+		merge = MergeRequirementStatusAnnotations
+	}
+	stampRequirementStatuses(csv.Status.RequirementStatus, statuses, metav1.NewTime(a.clock.Now().UTC()), a.recordRequirementUpdateTimes, merge)
 	return
 }
 
-func (a *Operator) isGVKRegistered(group, version, kind string) error {
-	logger := log.WithFields(log.Fields{
+// requirementInputsHash returns a hash of the parts of a CSV that determine which requirements are checked: its CRD and
+// APIService descriptions, the permissions its install strategy requests, and the namespaces those are checked in. The
+// order of descriptions, permissions, and rules doesn't affect the hash, so a CSV that is rechecked with an unchanged
+// hash was rechecked because of a change on the cluster, or for no reason at all.
+func requirementInputsHash(csv *v1alpha1.ClusterServiceVersion) string {
+	hash := sha256.New()
+	// each section's items are written in a canonical order, preceded by a header so that an item can't move between
+	// sections without changing the hash
+	write := func(section string, n int, item func(i int) interface{}) {
+		marshalled := make([]string, 0, n)
+		for i := 0; i < n; i++ {
+			out, _ := json.Marshal(item(i))
+			marshalled = append(marshalled, string(out))
+		}
+		sort.Strings(marshalled)
+		fmt.Fprintf(hash, "%s %d\n", section, n)
+		for _, out := range marshalled {
+			fmt.Fprintf(hash, "%s\n", out)
+		}
+	}
+
+	crds := csv.Spec.CustomResourceDefinitions
+	write("ownedCRDs", len(crds.Owned), func(i int) interface{} { return crds.Owned[i] })
+	write("requiredCRDs", len(crds.Required), func(i int) interface{} { return crds.Required[i] })
+	apiServices := csv.Spec.APIServiceDefinitions
+	write("ownedAPIServices", len(apiServices.Owned), func(i int) interface{} { return apiServices.Owned[i] })
+	write("requiredAPIServices", len(apiServices.Required), func(i int) interface{} { return apiServices.Required[i] })
+
+	permissions := func(perms []install.StrategyDeploymentPermissions) func(i int) interface{} {
+		return func(i int) interface{} {
+			perm := perms[i]
+			rules := make([]string, 0, len(perm.Rules))
+			for _, rule := range perm.Rules {
+				out, _ := json.Marshal(rule)
+				rules = append(rules, string(out))
+			}
+			sort.Strings(rules)
+			return struct {
+				ServiceAccountName string                         `json:"serviceAccountName"`
+				Rules              []string                       `json:"rules"`
+				When               *v1alpha1.RequirementCondition `json:"when,omitempty"`
+			}{perm.ServiceAccountName, rules, perm.When}
+		}
+	}
+	if details, ok := strategyDeploymentDetails(csv); ok {
+		write("permissions", len(details.Permissions), permissions(details.Permissions))
+		write("clusterPermissions", len(details.ClusterPermissions), permissions(details.ClusterPermissions))
+	}
+
+	annotations := csv.GetAnnotations()
+	namespaces := []string{csv.GetNamespace(), annotations[TargetNamespacesAnnotationKey], annotations[TargetNamespaceSelectorAnnotationKey]}
+	fmt.Fprintf(hash, "namespaces %q\n", namespaces)
+
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
+
+// RequirementStatusMergeFunc carries over into a recomputed requirement status the data that the requirement's
+// previously recorded status holds beyond what requirement checks compute, such as fields set by other controllers.
+// It's only called for requirements that were recorded by the previous check.
+type RequirementStatusMergeFunc func(previous v1alpha1.RequirementStatus, current *v1alpha1.RequirementStatus)
+
+// MergeRequirementStatusAnnotations is the default RequirementStatusMergeFunc. It keeps the previous status's
+// Annotations, except for any the recomputed status sets itself.
+func MergeRequirementStatusAnnotations(previous v1alpha1.RequirementStatus, current *v1alpha1.RequirementStatus) {
+	if len(previous.Annotations) == 0 {
+		return
+	}
+	if current.Annotations == nil {
+		current.Annotations = make(map[string]string, len(previous.Annotations))
+	}
+	for key, value := range previous.Annotations {
+		if _, ok := current.Annotations[key]; !ok {
+			current.Annotations[key] = value
+		}
+	}
+}
+
+// stampRequirementStatuses sets when each status last transitioned, keeping the time recorded by the previous check if
+// its Status is unchanged, so that unchanged requirements don't cause status updates. Statuses are also stamped with
+// the time of this check if updateTimes is set, and merge is called with each status's previous record.
+func stampRequirementStatuses(previous, statuses []v1alpha1.RequirementStatus, now metav1.Time, updateTimes bool, merge RequirementStatusMergeFunc) {
+	type requirementKey struct {
+		group, version, kind, name string
+	}
+	prior := make(map[requirementKey]v1alpha1.RequirementStatus, len(previous))
+	for _, status := range previous {
+		prior[requirementKey{status.Group, status.Version, status.Kind, status.Name}] = status
+	}
+
+	for i := range statuses {
+		status := &statuses[i]
+		status.LastTransitionTime = now
+		p, ok := prior[requirementKey{status.Group, status.Version, status.Kind, status.Name}]
+		if ok {
+			merge(p, status)
+		}
+		if ok && p.Status == status.Status && !p.LastTransitionTime.IsZero() {
+			status.LastTransitionTime = p.LastTransitionTime
+		}
+		if updateTimes {
+			status.LastUpdateTime = now
+		}
+	}
+}
+
+// strategyDeploymentDetails returns a CSV's deployment install strategy, or false if the strategy isn't a valid
+// deployment strategy, which is reported by the permission check
+func strategyDeploymentDetails(csv *v1alpha1.ClusterServiceVersion) (*install.StrategyDetailsDeployment, bool) {
+	strategyResolver := install.StrategyResolver{}
+	strategy, err := strategyResolver.UnmarshalStrategy(csv.Spec.InstallStrategy)
+	if err != nil {
+		return nil, false
+	}
+	details, ok := strategy.(*install.StrategyDetailsDeployment)
+	return details, ok
+}
+
+// strategyDeploymentNames returns the names of the deployments in a CSV's install strategy, or false if the strategy
+// isn't a valid deployment strategy
+func strategyDeploymentNames(csv *v1alpha1.ClusterServiceVersion) (map[string]struct{}, bool) {
+	details, ok := strategyDeploymentDetails(csv)
+	if !ok {
+		return nil, false
+	}
+
+	names := map[string]struct{}{}
+	for _, spec := range details.DeploymentSpecs {
+		names[spec.Name] = struct{}{}
+	}
+	return names, true
+}
+
+// strategyPriorityClasses returns the PriorityClasses requested by the pods of a CSV's install strategy deployments,
+// mapped to the deployments requesting them
+func strategyPriorityClasses(csv *v1alpha1.ClusterServiceVersion) map[string][]string {
+	classes := map[string][]string{}
+	details, ok := strategyDeploymentDetails(csv)
+	if !ok {
+		return classes
+	}
+
+	for _, spec := range details.DeploymentSpecs {
+		if name := spec.Spec.Template.Spec.PriorityClassName; name != "" {
+			classes[name] = append(classes[name], spec.Name)
+		}
+	}
+	return classes
+}
+
+// strategyDeploymentServiceAccounts returns the names of the deployments in an install strategy that set the
+// ServiceAccount their pods run as, keyed by ServiceAccount name. Deployments that don't set one run as their
+// namespace's default ServiceAccount and aren't included.
+func strategyDeploymentServiceAccounts(details *install.StrategyDetailsDeployment) map[string][]string {
+	serviceAccounts := map[string][]string{}
+	for _, spec := range details.DeploymentSpecs {
+		name := spec.Spec.Template.Spec.ServiceAccountName
+		if name == "" {
+			name = spec.Spec.Template.Spec.DeprecatedServiceAccount
+		}
+		if name != "" {
+			serviceAccounts[name] = append(serviceAccounts[name], spec.Name)
+		}
+	}
+	return serviceAccounts
+}
+
+// apiServiceGroupAndName returns the API group an APIServiceDescription describes and the name of the APIService that
+// serves it, which is <version>.<group>.
+// Descriptions are meant to be named after the group, but since a description named after the APIService is a common
+// mistake, a name that already starts with the version is treated as the APIService name rather than prefixed again.
+func apiServiceGroupAndName(desc v1alpha1.APIServiceDescription) (group, name string) {
+	prefix := desc.Version + "."
+	if desc.Version != "" && strings.HasPrefix(desc.Name, prefix) {
+		return strings.TrimPrefix(desc.Name, prefix), desc.Name
+	}
+
+	return desc.Name, prefix + desc.Name
+}
+
+// deploymentReady returns true if all of a Deployment's desired replicas are available, or false and why not
+func deploymentReady(deployment *appsv1.Deployment) (bool, string) {
+	desired := int32(1)
+	if deployment.Spec.Replicas != nil {
+		desired = *deployment.Spec.Replicas
+	}
+	if deployment.Status.AvailableReplicas < desired {
+		return false, fmt.Sprintf("Deployment %s/%s has %d of %d replicas available", deployment.GetNamespace(), deployment.GetName(), deployment.Status.AvailableReplicas, desired)
+	}
+	return true, ""
+}
+
+// crdStorageVersion returns the version a CRD stores its resources as, or "" if it doesn't flag one
+func crdStorageVersion(crd *v1beta1.CustomResourceDefinition) string {
+	for _, version := range crd.Spec.Versions {
+		if version.Storage {
+			return version.Name
+		}
+	}
+	// CRDs that don't list their versions serve and store a single version
+	if len(crd.Spec.Versions) == 0 {
+		return crd.Spec.Version
+	}
+	return ""
+}
+
+// ownedCRDMissingServedVersions returns the ServedVersions of an owned CRD's description that the CRD doesn't serve.
+// Only the CSV that owns a CRD decides which of its versions must be served, so required CRDs are never checked.
+func ownedCRDMissingServedVersions(crd *v1beta1.CustomResourceDefinition, desc v1alpha1.CRDDescription, owned map[string]struct{}) []string {
+	if _, ok := owned[desc.Name]; !ok || len(desc.ServedVersions) == 0 {
+		return nil
+	}
+	return crdMissingServedVersions(crd, desc.ServedVersions)
+}
+
+// crdHasSchema returns true if a CRD serves the given version and defines an OpenAPI v3 validation schema for it.
+// v1beta1 CRDs define a single schema, spec.validation, that applies to every version they serve.
+func crdHasSchema(crd *v1beta1.CustomResourceDefinition, version string) bool {
+	if crd.Spec.Validation == nil || crd.Spec.Validation.OpenAPIV3Schema == nil {
+		return false
+	}
+	// CRDs that don't list their versions serve a single version
+	if len(crd.Spec.Versions) == 0 {
+		return crd.Spec.Version == version
+	}
+	for _, v := range crd.Spec.Versions {
+		if v.Name == version {
+			return v.Served
+		}
+	}
+	return false
+}
+
+// crdMissingPrinterColumns returns the names of the given printer columns that a CRD doesn't define, in order
+func crdMissingPrinterColumns(crd *v1beta1.CustomResourceDefinition, names []string) []string {
+	defined := make(map[string]struct{}, len(crd.Spec.AdditionalPrinterColumns))
+	for _, column := range crd.Spec.AdditionalPrinterColumns {
+		defined[column.Name] = struct{}{}
+	}
+
+	missing := []string{}
+	for _, name := range names {
+		if _, ok := defined[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// orphanedOwnerDependent returns a dependent status flagging an APIService whose owner labels name a
+// ClusterServiceVersion, other than the given CSV, that no longer exists, or nil if it isn't orphaned. Owners that
+// can't be looked up are assumed to exist.
+func (a *Operator) orphanedOwnerDependent(apiService *apiregistrationv1.APIService, csv *v1alpha1.ClusterServiceVersion, logger log.FieldLogger) *v1alpha1.DependentStatus {
+	kind, namespace, name, ok := ownerutil.GetOwnerLabels(apiService)
+	if !ok || kind != v1alpha1.ClusterServiceVersionKind || a.client == nil {
+		return nil
+	}
+	if namespace == csv.GetNamespace() && name == csv.GetName() {
+		return nil
+	}
+
+	_, err := a.client.OperatorsV1alpha1().ClusterServiceVersions(namespace).Get(name, metav1.GetOptions{})
+	if err == nil {
+		return nil
+	}
+	if !k8serrors.IsNotFound(err) {
+		logger.WithField("apiService", apiService.GetName()).Debugf("couldn't look up owner ClusterServiceVersion %s/%s: %s", namespace, name, err)
+		return nil
+	}
+
+	return &v1alpha1.DependentStatus{
+		Group:   "operators.coreos.com",
+		Version: "v1alpha1",
+		Kind:    v1alpha1.ClusterServiceVersionKind,
+		Status:  v1alpha1.DependentStatusReasonOrphaned,
+		Message: fmt.Sprintf("APIService %s is labeled as owned by ClusterServiceVersion %s/%s, which no longer exists; delete the APIService or update its %s labels", apiService.GetName(), namespace, name, ownerutil.OwnerKey),
+	}
+}
+
+// crdScopeInconsistency returns a message describing how a CRD's scope is at odds with the install modes a CSV
+// supports, or "" if it isn't: a Cluster scoped CRD is inconsistent with an operator that can't watch every namespace,
+// and a Namespaced CRD with one that can only watch every namespace. CSVs that don't declare install modes and CRDs
+// that don't set a scope are never inconsistent.
+func crdScopeInconsistency(crd *v1beta1.CustomResourceDefinition, csv *v1alpha1.ClusterServiceVersion) string {
+	if len(csv.Spec.InstallModes) == 0 {
+		return ""
+	}
+
+	allNamespaces, namespaced := false, false
+	for _, mode := range csv.Spec.InstallModes {
+		if !mode.Supported {
+			continue
+		}
+		if mode.Type == v1alpha1.InstallModeTypeAllNamespaces {
+			allNamespaces = true
+		} else {
+			namespaced = true
+		}
+	}
+
+	switch crd.Spec.Scope {
+	case v1beta1.ClusterScoped:
+		if !allNamespaces {
+			return fmt.Sprintf("CustomResourceDefinition %s is Cluster scoped, but the CSV doesn't support the %s install mode", crd.GetName(), v1alpha1.InstallModeTypeAllNamespaces)
+		}
+	case v1beta1.NamespaceScoped:
+		if allNamespaces && !namespaced {
+			return fmt.Sprintf("CustomResourceDefinition %s is Namespaced, but the CSV only supports the %s install mode", crd.GetName(), v1alpha1.InstallModeTypeAllNamespaces)
+		}
+	}
+	return ""
+}
+
+// crdSchemaMismatch compares the names and scope a CRDDescription implies with those of the installed CRD, returning a
+// message describing any differences, or "" if there are none.
+// The plural is implied by the description's name, which is <plural>.<group>. The kind and scope are only compared if
+// the description sets them.
+func crdSchemaMismatch(crd *v1beta1.CustomResourceDefinition, desc v1alpha1.CRDDescription) string {
+	mismatches := []string{}
+	if i := strings.Index(desc.Name, "."); i > 0 && desc.Name[:i] != crd.Spec.Names.Plural {
+		mismatches = append(mismatches, fmt.Sprintf("plural %q, expected %q", crd.Spec.Names.Plural, desc.Name[:i]))
+	}
+	if desc.Kind != "" && desc.Kind != crd.Spec.Names.Kind {
+		mismatches = append(mismatches, fmt.Sprintf("kind %q, expected %q", crd.Spec.Names.Kind, desc.Kind))
+	}
+	if desc.Scope != "" && desc.Scope != string(crd.Spec.Scope) {
+		mismatches = append(mismatches, fmt.Sprintf("scope %q, expected %q", crd.Spec.Scope, desc.Scope))
+	}
+	if len(mismatches) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("CustomResourceDefinition %s has %s", crd.GetName(), strings.Join(mismatches, ", "))
+}
+
+// isGVKRegistered checks the snapshot's GVKChecker for the given group, version, and kind.
+// An empty kind matches any resource served under the group and version.
+func (s *requirementsSnapshot) isGVKRegistered(group, version, kind string, logger log.FieldLogger) error {
+	logger = logger.WithFields(log.Fields{
 		"group":   group,
 		"version": version,
 		"kind":    kind,
 	})
-	groups, err := a.OpClient.KubernetesInterface().Discovery().ServerResources()
+	gvk := schema.GroupVersionKind{Group: group, Version: version, Kind: kind}
+	registered, err := s.gvks.Has(gvk)
+	if err == nil && !registered {
+		registered, err = s.recheckGVK(gvk, logger)
+	}
 	if err != nil {
 		logger.WithField("err", err).Info("couldn't query for GVK in api discovery")
 		return err
 	}
-	gv := metav1.GroupVersion{Group: group, Version: version}
-	for _, g := range groups {
-		if g.GroupVersion == gv.String() {
-			for _, r := range g.APIResources {
-				if r.Kind == kind {
-					return nil
-				}
-			}
-		}
+	if registered {
+		return nil
 	}
 	logger.Info("couldn't find GVK in api discovery")
-	return olmErrors.GroupVersionKindNotFoundError{group, version, kind}
+	return olmErrors.NewGroupVersionKindNotFoundError(group, version, kind)
+}
+
+// acceptedAPIVersion returns the version a required APIService is served at: its own version if the snapshot's
+// GVKChecker finds it, otherwise the newest of its acceptable versions newer than its own that's found. If none is
+// found, the error for its own version is returned.
+func (s *requirementsSnapshot) acceptedAPIVersion(group string, desc v1alpha1.APIServiceDescription, logger log.FieldLogger) (string, error) {
+	err := s.isGVKRegistered(group, desc.Version, desc.Kind, logger)
+	if err == nil || !olmErrors.IsGroupVersionKindNotFoundError(err) {
+		return desc.Version, err
+	}
+
+	for _, version := range newerAcceptableVersions(desc) {
+		if s.isGVKRegistered(group, version, desc.Kind, logger) == nil {
+			return version, nil
+		}
+	}
+	return desc.Version, err
+}
+
+// recheckGVK invalidates a caching GVKChecker and checks for gvk again, so that a GVK registered since discovery was
+// cached isn't reported missing until the cache expires. Only the first miss in a snapshot forces a recheck; later
+// misses are answered by the discovery information it fetched.
+func (s *requirementsSnapshot) recheckGVK(gvk schema.GroupVersionKind, logger log.FieldLogger) (bool, error) {
+	invalidator, ok := s.gvks.(gvkInvalidator)
+	if !ok || s.rechecked {
+		return false, nil
+	}
+	s.rechecked = true
+
+	invalidator.Invalidate()
+	registered, err := s.gvks.Has(gvk)
+	if err != nil {
+		return false, err
+	}
+	metrics.GVKCacheRechecks.WithLabelValues(strconv.FormatBool(registered)).Inc()
+	if registered {
+		logger.Info("found GVK in api discovery only after invalidating stale cache")
+	}
+	return registered, nil
+}
+
+// conditionHolds returns true if a requirement with the given condition applies. Requirements without a condition
+// always apply, and so do those whose condition can't be evaluated, so that a discovery failure never skips one.
+func (s *requirementsSnapshot) conditionHolds(when *v1alpha1.RequirementCondition, logger log.FieldLogger) bool {
+	if when == nil {
+		return true
+	}
+
+	served, err := s.gvks.Has(schema.GroupVersionKind{Group: when.Group, Version: when.Version, Kind: when.Kind})
+	if err != nil {
+		logger.WithField("err", err).Infof("couldn't query for requirement condition %s in api discovery, checking requirement", conditionString(when))
+		return true
+	}
+	return served
+}
+
+// conditionString formats a requirement condition as group/version/kind, or group/version if it has no kind
+func conditionString(when *v1alpha1.RequirementCondition) string {
+	if when.Kind == "" {
+		return fmt.Sprintf("%s/%s", when.Group, when.Version)
+	}
+	return fmt.Sprintf("%s/%s/%s", when.Group, when.Version, when.Kind)
 }
 
 // permissionStatus checks whether the given CSV's RBAC requirements are met in its namespace
-func (a *Operator) permissionStatus(csv *v1alpha1.ClusterServiceVersion) (bool, []v1alpha1.RequirementStatus) {
+func (a *Operator) permissionStatus(csv *v1alpha1.ClusterServiceVersion, snapshot *requirementsSnapshot, logger log.FieldLogger) (bool, []v1alpha1.RequirementStatus) {
+	// A CSV without an install strategy can never be met, so report it against the CSV rather than failing silently
+	if csv.Spec.InstallStrategy.StrategyName == "" && len(csv.Spec.InstallStrategy.StrategySpecRaw) == 0 {
+		status := v1alpha1.RequirementStatus{
+			Group:   v1alpha1.GroupName,
+			Version: v1alpha1.GroupVersion,
+			Kind:    v1alpha1.ClusterServiceVersionKind,
+			Name:    csv.GetName(),
+			Status:  v1alpha1.RequirementStatusReasonNotPresent,
+			Message: "no install strategy defined",
+		}
+		a.snapshotTraceFor(csv, snapshot).record(status, "unmarshal install strategy: empty")
+		logger.Info("no install strategy defined")
+		return false, []v1alpha1.RequirementStatus{status}
+	}
+
 	// Use a StrategyResolver to unmarshal
 	strategyResolver := install.StrategyResolver{}
 	strategy, err := strategyResolver.UnmarshalStrategy(csv.Spec.InstallStrategy)
 	if err != nil {
+		logger.WithField("err", err).Info("couldn't unmarshal install strategy")
 		return false, nil
 	}
 
 	// Assume the strategy is for a deployment
 	strategyDetailsDeployment, ok := strategy.(*install.StrategyDetailsDeployment)
 	if !ok {
+		logger.Infof("install strategy %s isn't a deployment strategy", csv.Spec.InstallStrategy.StrategyName)
 		return false, nil
 	}
 
+	trace := a.snapshotTraceFor(csv, snapshot)
 	statusesSet := map[string]v1alpha1.RequirementStatus{}
 	ruleChecker := install.NewCSVRuleChecker(a.roleLister, a.roleBindingLister, a.clusterRoleLister, a.clusterRoleBindingLister, csv)
+	var fallbackRuleChecker install.RuleChecker
+	if a.subjectAccessReviewFallback {
+		fallbackRuleChecker = install.NewSARRuleChecker(snapshot.client.KubernetesInterface())
+	}
 	met := true
 
-	checkPermissions := func(permissions []install.StrategyDeploymentPermissions, namespace string) {
+	namespaces, err := a.permissionNamespaces(csv)
+	var selectorStatus *v1alpha1.RequirementStatus
+	if err != nil {
+		// the namespaces that could be found are still checked, so that the CSV's other permission gaps are reported
+		met = false
+		selectorStatus = &v1alpha1.RequirementStatus{
+			Group:   v1alpha1.GroupName,
+			Version: v1alpha1.GroupVersion,
+			Kind:    v1alpha1.ClusterServiceVersionKind,
+			Name:    csv.GetName(),
+			Status:  v1alpha1.RequirementStatusReasonNotPresent,
+			Message: err.Error(),
+		}
+		trace.record(*selectorStatus, "target namespace selector: %s", err)
+		logger.WithField("err", err).Info("couldn't find namespaces matching target namespace selector")
+	}
+
+	// group the permissions whose conditions hold by ServiceAccount, in the order the install strategy declares them
+	var checks []*permissionChecks
+	checksBySA := map[string]*permissionChecks{}
+	collect := func(permissions []install.StrategyDeploymentPermissions, namespaces []string) {
 		for _, perm := range permissions {
 			saName := perm.ServiceAccountName
+			if !snapshot.conditionHolds(perm.When, logger) {
+				trace.record(v1alpha1.RequirementStatus{Version: "v1", Kind: "ServiceAccount", Name: saName}, "skipped permissions, condition %s not served", conditionString(perm.When))
+				continue
+			}
+			check, ok := checksBySA[saName]
+			if !ok {
+				check = &permissionChecks{serviceAccount: saName}
+				checksBySA[saName] = check
+				checks = append(checks, check)
+			}
+			check.permissions = append(check.permissions, perm)
+			check.namespaces = append(check.namespaces, namespaces)
+		}
+	}
+	collect(strategyDetailsDeployment.Permissions, namespaces)
+	collect(strategyDetailsDeployment.ClusterPermissions, []string{metav1.NamespaceAll})
 
-			var status v1alpha1.RequirementStatus
-			if stored, ok := statusesSet[saName]; !ok {
-				status = v1alpha1.RequirementStatus{
-					Group:      "",
-					Version:    "v1",
-					Kind:       "ServiceAccount",
-					Name:       saName,
-					Status:     v1alpha1.RequirementStatusReasonPresent,
-					Dependents: []v1alpha1.DependentStatus{},
-				}
-			} else {
-				status = stored
+	// the snapshot isn't safe for concurrent use, so every ServiceAccount is looked up before any rules are checked
+	accounts := make([]*corev1.ServiceAccount, len(checks))
+	for i, check := range checks {
+		sa, err := snapshot.getServiceAccount(csv.GetNamespace(), check.serviceAccount)
+		if err != nil {
+			met = false
+			status := v1alpha1.RequirementStatus{
+				Group:      "",
+				Version:    "v1",
+				Kind:       "ServiceAccount",
+				Name:       check.serviceAccount,
+				Status:     v1alpha1.RequirementStatusReasonNotPresent,
+				Message:    fmt.Sprintf("ServiceAccount %s referenced by install strategy not found in namespace %s; ensure your CSV's deployment spec or permissions create it", check.serviceAccount, csv.GetNamespace()),
+				Dependents: []v1alpha1.DependentStatus{},
 			}
+			trace.record(status, "get ServiceAccount %s/%s: %s", csv.GetNamespace(), check.serviceAccount, err)
+			logger.WithField("serviceaccount", check.serviceAccount).Debugf("couldn't get ServiceAccount: %s", err)
+			statusesSet[check.serviceAccount] = status
+			continue
+		}
+		accounts[i] = sa
+	}
+
+	// each ServiceAccount's rules are checked by a single worker, which writes only its own result, so the results
+	// are combined in the same order however the checks are scheduled
+	results := make([]permissionResult, len(checks))
+	forEachBounded(len(checks), maxPermissionCheckWorkers, func(i int) {
+		if accounts[i] == nil {
+			return
+		}
+		results[i] = a.serviceAccountPermissionStatus(checks[i], accounts[i], ruleChecker, fallbackRuleChecker, trace, logger)
+	})
+	for i, result := range results {
+		if accounts[i] == nil {
+			continue
+		}
+		met = met && result.met
+		statusesSet[checks[i].serviceAccount] = result.status
+	}
+
+	// every container of a deployment's pods, including init containers and sidecars, runs as the deployment's
+	// ServiceAccount, so one without declared permissions is flagged: none of the declared rules apply to its token
+	declared := map[string]struct{}{}
+	for _, perm := range append(strategyDetailsDeployment.Permissions, strategyDetailsDeployment.ClusterPermissions...) {
+		declared[perm.ServiceAccountName] = struct{}{}
+	}
+	serviceAccounts := strategyDeploymentServiceAccounts(strategyDetailsDeployment)
+	for saName, deployments := range serviceAccounts {
+		if _, ok := declared[saName]; ok {
+			continue
+		}
+
+		status := v1alpha1.RequirementStatus{
+			Group:   "",
+			Version: "v1",
+			Kind:    "ServiceAccount",
+			Name:    saName,
+			Status:  v1alpha1.RequirementStatusReasonPresent,
+		}
+		sa, err := snapshot.getServiceAccount(csv.GetNamespace(), saName)
+		if err != nil {
+			met = false
+			status.Status = v1alpha1.RequirementStatusReasonNotPresent
+			status.Message = fmt.Sprintf("ServiceAccount %s used by deployment %s not found in namespace %s", saName, strings.Join(deployments, ", "), csv.GetNamespace())
+			trace.record(status, "get ServiceAccount %s/%s: %s", csv.GetNamespace(), saName, err)
+		} else {
+			status.UUID = string(sa.GetUID())
+		}
+		for _, deployment := range deployments {
+			status.Dependents = append(status.Dependents, v1alpha1.DependentStatus{
+				Group:   "apps",
+				Version: "v1",
+				Kind:    "Deployment",
+				Status:  v1alpha1.DependentStatusReasonUndeclaredServiceAccount,
+				Message: fmt.Sprintf("deployment %s runs as ServiceAccount %s, which the install strategy declares no permissions for", deployment, saName),
+			})
+		}
+		trace.record(status, "ServiceAccount %s used by deployment %s has no declared permissions", saName, strings.Join(deployments, ", "))
+		statusesSet[saName] = status
+	}
+
+	statuses := sortedPermissionStatuses(statusesSet)
+	if selectorStatus != nil {
+		statuses = append([]v1alpha1.RequirementStatus{*selectorStatus}, statuses...)
+	}
+	return met, statuses
+}
+
+// maxPermissionCheckWorkers bounds the number of ServiceAccounts whose rules are checked at once for a CSV
+const maxPermissionCheckWorkers = 8
+
+// permissionChecks are the permissions a CSV requests for one ServiceAccount, in the order they're declared, along with
+// the namespaces the rules of each must be granted in
+type permissionChecks struct {
+	serviceAccount string
+	permissions    []install.StrategyDeploymentPermissions
+	namespaces     [][]string
+}
+
+// permissionResult is the outcome of checking the rules of one ServiceAccount
+type permissionResult struct {
+	met    bool
+	status v1alpha1.RequirementStatus
+}
+
+// forEachBounded calls f for each index below n, running at most workers calls at once, and returns once all calls are
+// done
+func forEachBounded(n, workers int, f func(i int)) {
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			f(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// serviceAccountPermissionStatus checks whether the given ServiceAccount is granted the rules of its permissions.
+// It's safe to call concurrently for different ServiceAccounts, since it only reads through the RBAC listers and rule
+// checkers.
+func (a *Operator) serviceAccountPermissionStatus(check *permissionChecks, sa *corev1.ServiceAccount, ruleChecker, fallbackRuleChecker install.RuleChecker, trace *requirementsTrace, logger log.FieldLogger) permissionResult {
+	saName := check.serviceAccount
+	met := true
+	status := v1alpha1.RequirementStatus{
+		Group:      "",
+		Version:    "v1",
+		Kind:       "ServiceAccount",
+		Name:       saName,
+		Status:     v1alpha1.RequirementStatusReasonPresent,
+		UUID:       string(sa.GetUID()),
+		Dependents: []v1alpha1.DependentStatus{},
+	}
+
+	// Check if the PolicyRules are satisfied
+	subject := rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: sa.GetName(), Namespace: sa.GetNamespace()}
+
+	// a ServiceAccount bound to a cluster-admin-equivalent ClusterRole is granted every resource rule, so they aren't
+	// checked one by one. Non-resource rules are still checked, since the ClusterRole may not grant them.
+	var clusterAdminBinding string
+	if checker, ok := ruleChecker.(install.ClusterAdminChecker); ok {
+		binding, err := checker.ClusterAdminBindingFor(subject)
+		if err != nil {
+			logger.WithFields(log.Fields{"serviceaccount": saName, "err": err}).Debug("couldn't check for cluster admin binding, checking each rule")
+		}
+		clusterAdminBinding = binding
+		if binding != "" {
+			trace.record(status, "ServiceAccount bound to cluster admin equivalent by ClusterRoleBinding %s: resource rules satisfied", binding)
+		}
+	}
 
-			// Ensure the ServiceAccount exists
-			sa, err := a.OpClient.GetServiceAccount(csv.GetNamespace(), perm.ServiceAccountName)
+	for i, perm := range check.permissions {
+		namespaces := check.namespaces[i]
+		for _, rule := range perm.Rules {
+			// TODO(Nick): decide what to do with dependent status here
+			dependent := v1alpha1.DependentStatus{
+				Group:   "rbac.authorization.k8s.io",
+				Kind:    "PolicyRule",
+				Version: "v1beta1",
+			}
+
+			marshalled, err := json.Marshal(rule)
 			if err != nil {
-				met = false
-				status.Status = v1alpha1.RequirementStatusReasonNotPresent
-				statusesSet[saName] = status
+				dependent.Status = v1alpha1.DependentStatusReasonNotSatisfied
+				dependent.Message = "rule unmarshallable"
+				status.Dependents = append(status.Dependents, dependent)
 				continue
 			}
 
-			// Check if the PolicyRules are satisfied
-			for _, rule := range perm.Rules {
-				// TODO(Nick): decide what to do with dependent status here
-				dependent := v1alpha1.DependentStatus{
-					Group:   "rbac.authorization.k8s.io",
-					Kind:    "PolicyRule",
-					Version: "v1beta1",
+			for _, namespace := range namespaces {
+				dependent := dependent
+				dependent.Message = fmt.Sprintf("rule raw:%s", ruleMessage(marshalled))
+				if len(namespaces) > 1 {
+					dependent.Message = fmt.Sprintf("namespace %s: rule raw:%s", namespace, ruleMessage(marshalled))
 				}
 
-				marshalled, err := json.Marshal(rule)
-				if err != nil {
-					dependent.Status = v1alpha1.DependentStatusReasonNotSatisfied
-					dependent.Message = "rule unmarshallable"
+				if clusterAdminBinding != "" && len(rule.NonResourceURLs) == 0 {
+					dependent.Status = v1alpha1.DependentStatusReasonSatisfied
 					status.Dependents = append(status.Dependents, dependent)
 					continue
 				}
-				dependent.Message = fmt.Sprintf("rule raw:%s", marshalled)
 
-				satisfied, err := ruleChecker.RuleSatisfied(sa, namespace, rule)
+				satisfied, err := ruleChecker.RuleSatisfiedFor(subject, namespace, rule)
+				if (err != nil || !satisfied) && fallbackRuleChecker != nil {
+					// the RBAC listers lag behind bindings made moments ago, so ask the API server before
+					// reporting the rule unsatisfied
+					trace.record(status, "rule satisfied by listers in namespace %q: %t (err: %v) %s", namespace, satisfied, err, dependent.Message)
+					satisfied, err = fallbackRuleChecker.RuleSatisfiedFor(subject, namespace, rule)
+				}
 				if err != nil || !satisfied {
+					logger.WithFields(log.Fields{
+						"serviceaccount": saName,
+						"namespace":      namespace,
+						"rule":           string(marshalled),
+						"err":            err,
+					}).Debug("rule not satisfied")
 					met = false
 					dependent.Status = v1alpha1.DependentStatusReasonNotSatisfied
 					status.Status = v1alpha1.RequirementStatusReasonPresentNotSatisfied
 				} else {
 					dependent.Status = v1alpha1.DependentStatusReasonSatisfied
 				}
+				trace.record(status, "rule satisfied in namespace %q: %t (err: %v) %s", namespace, satisfied, err, dependent.Message)
 
 				status.Dependents = append(status.Dependents, dependent)
 			}
 
-			statusesSet[saName] = status
+			// Overly broad rules are only flagged for review; they don't make the requirement unmet
+			if wildcards := ruleWildcards(rule); len(wildcards) > 1 {
+				broad := dependent
+				broad.Status = v1alpha1.DependentStatusReasonOverlyBroadPermissions
+				broad.Message = fmt.Sprintf("rule uses wildcards for %s; consider narrowing it to what the operator needs: %s", strings.Join(wildcards, ", "), ruleMessage(marshalled))
+				status.Dependents = append(status.Dependents, broad)
+			}
+		}
+	}
+
+	return permissionResult{met: met, status: status}
+}
+
+// summarizeRules records how many of a ServiceAccount status's PolicyRule dependents are satisfied, in its details
+// and as its message, so that a status with many rules shows how close it is to being met. The dependents must
+// already be deduplicated, so that each rule is counted once per namespace however many times it's declared.
+func summarizeRules(status *v1alpha1.RequirementStatus) {
+	var satisfied, total int
+	for _, dependent := range status.Dependents {
+		switch dependent.Status {
+		case v1alpha1.DependentStatusReasonSatisfied:
+			satisfied++
+			total++
+		case v1alpha1.DependentStatusReasonNotSatisfied:
+			total++
+		}
+	}
+	if total == 0 {
+		return
+	}
+
+	if status.Details == nil {
+		status.Details = map[string]string{}
+	}
+	status.Details[v1alpha1.RequirementDetailRulesSatisfied] = strconv.Itoa(satisfied)
+	status.Details[v1alpha1.RequirementDetailRules] = strconv.Itoa(total)
+	status.Message = fmt.Sprintf("%d/%d rules satisfied", satisfied, total)
+}
+
+const (
+	// maxRuleMessageBytes caps the rule JSON embedded in a permission dependent's message
+	maxRuleMessageBytes = 1024
+	// maxPermissionDependents caps the number of dependents of a permission status, including the summary of those
+	// left out
+	maxPermissionDependents = 100
+)
+
+// ruleMessage returns a rule's JSON for a dependent's message. JSON longer than maxRuleMessageBytes is truncated and
+// identified by its hash instead, so that operators requesting huge rules can't push their CSV's status past the
+// object size limit.
+func ruleMessage(marshalled []byte) string {
+	if len(marshalled) <= maxRuleMessageBytes {
+		return string(marshalled)
+	}
+	return fmt.Sprintf("%s... (truncated, sha256:%x)", marshalled[:maxRuleMessageBytes], sha256.Sum256(marshalled))
+}
+
+// capDependents returns at most maxPermissionDependents of the given dependents, in their original order. If some
+// must be left out, unsatisfied dependents are kept over others, and the last dependent summarizes those left out.
+func capDependents(dependents []v1alpha1.DependentStatus) []v1alpha1.DependentStatus {
+	if len(dependents) <= maxPermissionDependents {
+		return dependents
+	}
+
+	keep := make([]bool, len(dependents))
+	kept := 0
+	for _, unsatisfied := range []bool{true, false} {
+		for i, dependent := range dependents {
+			if kept == maxPermissionDependents-1 {
+				break
+			}
+			if (dependent.Status == v1alpha1.DependentStatusReasonNotSatisfied) == unsatisfied && !keep[i] {
+				keep[i] = true
+				kept++
+			}
+		}
+	}
+
+	capped := make([]v1alpha1.DependentStatus, 0, maxPermissionDependents)
+	omittedUnsatisfied := 0
+	for i, dependent := range dependents {
+		if keep[i] {
+			capped = append(capped, dependent)
+		} else if dependent.Status == v1alpha1.DependentStatusReasonNotSatisfied {
+			omittedUnsatisfied++
+		}
+	}
+
+	summary := v1alpha1.DependentStatus{
+		Group:   "rbac.authorization.k8s.io",
+		Kind:    "PolicyRule",
+		Version: "v1beta1",
+		Status:  v1alpha1.DependentStatusReasonSatisfied,
+		Message: fmt.Sprintf("%d more dependents omitted, %d of them not satisfied", len(dependents)-kept, omittedUnsatisfied),
+	}
+	if omittedUnsatisfied > 0 {
+		summary.Status = v1alpha1.DependentStatusReasonNotSatisfied
+	}
+	return append(capped, summary)
+}
+
+// targetNamespaces returns the namespaces a CSV's namespaced permissions must be granted in: its own namespace,
+// followed by any other namespaces its OperatorGroup targets, in order
+func targetNamespaces(csv *v1alpha1.ClusterServiceVersion) []string {
+	namespaces := []string{csv.GetNamespace()}
+	seen := map[string]struct{}{csv.GetNamespace(): {}}
+	targets := []string{}
+	for _, namespace := range strings.Split(csv.GetAnnotations()[TargetNamespacesAnnotationKey], ",") {
+		namespace = strings.TrimSpace(namespace)
+		if _, ok := seen[namespace]; ok || namespace == "" {
+			continue
+		}
+		seen[namespace] = struct{}{}
+		targets = append(targets, namespace)
+	}
+	sort.Strings(targets)
+
+	return append(namespaces, targets...)
+}
+
+// permissionNamespaces returns the namespaces a CSV's namespaced permissions must be granted in: its target namespaces,
+// along with, if it has a target namespace selector, every namespace the selector matches, in order. If the selector
+// can't be evaluated, its target namespaces are returned with an error.
+func (a *Operator) permissionNamespaces(csv *v1alpha1.ClusterServiceVersion) ([]string, error) {
+	namespaces := targetNamespaces(csv)
+	value, ok := csv.GetAnnotations()[TargetNamespaceSelectorAnnotationKey]
+	if !ok {
+		return namespaces, nil
+	}
+
+	selector, err := labels.Parse(value)
+	if err != nil {
+		return namespaces, fmt.Errorf("invalid %s annotation %q: %s", TargetNamespaceSelectorAnnotationKey, value, err)
+	}
+	matched, err := a.namespaceLister.List(selector)
+	if err != nil {
+		return namespaces, fmt.Errorf("couldn't list namespaces matching %s annotation %q: %s", TargetNamespaceSelectorAnnotationKey, value, err)
+	}
+
+	seen := map[string]struct{}{}
+	for _, namespace := range namespaces {
+		seen[namespace] = struct{}{}
+	}
+	others := append([]string{}, namespaces[1:]...)
+	for _, namespace := range matched {
+		if _, ok := seen[namespace.GetName()]; ok {
+			continue
+		}
+		seen[namespace.GetName()] = struct{}{}
+		others = append(others, namespace.GetName())
+	}
+	sort.Strings(others)
+
+	return append([]string{csv.GetNamespace()}, others...), nil
+}
+
+// ruleWildcards returns the parts of a rule that are granted by wildcard, ordered verbs, apiGroups, resources,
+// nonResourceURLs. A rule that wildcards more than one of them, such as all verbs on all resources, is likely broader
+// than needed.
+func ruleWildcards(rule rbacv1.PolicyRule) []string {
+	hasWildcard := func(values []string, wildcard string) bool {
+		for _, value := range values {
+			if value == wildcard {
+				return true
+			}
 		}
+		return false
+	}
+
+	wildcards := []string{}
+	if hasWildcard(rule.Verbs, rbacv1.VerbAll) {
+		wildcards = append(wildcards, "verbs")
+	}
+	if hasWildcard(rule.APIGroups, rbacv1.APIGroupAll) {
+		wildcards = append(wildcards, "apiGroups")
+	}
+	if hasWildcard(rule.Resources, rbacv1.ResourceAll) {
+		wildcards = append(wildcards, "resources")
+	}
+	if hasWildcard(rule.NonResourceURLs, rbacv1.NonResourceAll) {
+		wildcards = append(wildcards, "nonResourceURLs")
 	}
 
-	checkPermissions(strategyDetailsDeployment.Permissions, csv.GetNamespace())
-	checkPermissions(strategyDetailsDeployment.ClusterPermissions, metav1.NamespaceAll)
+	return wildcards
+}
 
-	statuses := make([]v1alpha1.RequirementStatus, len(statusesSet))
+// sortedPermissionStatuses flattens the per-ServiceAccount statuses in order of ServiceAccount name, with their
+// Dependents sorted by rule, deduplicated, summarized, and capped at maxPermissionDependents, so that the same
+// permissions always produce an identical status regardless of map iteration order or the order rules were declared in.
+// The summary counts every deduplicated rule, including those the cap leaves out.
+func sortedPermissionStatuses(statusesSet map[string]v1alpha1.RequirementStatus) []v1alpha1.RequirementStatus {
+	statuses := make([]v1alpha1.RequirementStatus, 0, len(statusesSet))
 	for _, status := range statusesSet {
+		sort.SliceStable(status.Dependents, func(i, j int) bool {
+			a, b := status.Dependents[i], status.Dependents[j]
+			if a.Message != b.Message {
+				return a.Message < b.Message
+			}
+			return a.Status < b.Status
+		})
+
+		dependents := status.Dependents[:0]
+		for i, dependent := range status.Dependents {
+			if i > 0 && dependent == status.Dependents[i-1] {
+				continue
+			}
+			dependents = append(dependents, dependent)
+		}
+		status.Dependents = dependents
+		summarizeRules(&status)
+		status.Dependents = capDependents(status.Dependents)
 		statuses = append(statuses, status)
 	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
 
-	return met, statuses
+	return statuses
 }
@@ -0,0 +1,3597 @@
+package olm
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	schedulingv1beta1 "k8s.io/api/scheduling/v1beta1"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	apiextensionsfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	crbacv1 "k8s.io/client-go/listers/rbac/v1"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	apiregistrationv1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
+	apiregistrationfake "k8s.io/kube-aggregator/pkg/client/clientset_generated/clientset/fake"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/install"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/operatorclient"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/ownerutil"
+)
+
+func withPermissions(strategy v1alpha1.NamedInstallStrategy, permissions, clusterPermissions []install.StrategyDeploymentPermissions) v1alpha1.NamedInstallStrategy {
+	details := install.StrategyDetailsDeployment{}
+	if err := json.Unmarshal(strategy.StrategySpecRaw, &details); err != nil {
+		panic(err)
+	}
+
+	details.Permissions = permissions
+	details.ClusterPermissions = clusterPermissions
+	raw, err := json.Marshal(details)
+	if err != nil {
+		panic(err)
+	}
+	strategy.StrategySpecRaw = raw
+
+	return strategy
+}
+
+func serviceAccount(name, namespace string) *v1.ServiceAccount {
+	return &v1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+}
+
+func requirementStatusFor(statuses []v1alpha1.RequirementStatus, kind, name string) *v1alpha1.RequirementStatus {
+	for _, status := range statuses {
+		if status.Kind == kind && status.Name == name {
+			return &status
+		}
+	}
+	return nil
+}
+
+func TestRequirementStatusServiceAccount(t *testing.T) {
+	namespace := "ns"
+	permissions := []install.StrategyDeploymentPermissions{
+		{
+			ServiceAccountName: "sa",
+			Rules: []rbacv1.PolicyRule{
+				{
+					Verbs:     []string{"get"},
+					APIGroups: []string{""},
+					Resources: []string{"pods"},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		description     string
+		objs            []runtime.Object
+		expectedStatus  v1alpha1.StatusReason
+		expectedMessage string
+	}{
+		{
+			description:     "Missing",
+			objs:            []runtime.Object{},
+			expectedStatus:  v1alpha1.RequirementStatusReasonNotPresent,
+			expectedMessage: "ServiceAccount sa referenced by install strategy not found in namespace ns; ensure your CSV's deployment spec or permissions create it",
+		},
+		{
+			description:     "PresentNotSatisfied",
+			objs:            []runtime.Object{serviceAccount("sa", namespace)},
+			expectedStatus:  v1alpha1.RequirementStatusReasonPresentNotSatisfied,
+			expectedMessage: "0/1 rules satisfied",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			op, err := NewFakeOperator(nil, tt.objs, nil, nil, &install.StrategyResolver{}, namespace)
+			require.NoError(t, err)
+
+			csv := csv("csv1",
+				namespace,
+				"",
+				withPermissions(installStrategy("csv1-dep1"), permissions, nil),
+				[]*v1beta1.CustomResourceDefinition{},
+				[]*v1beta1.CustomResourceDefinition{},
+				v1alpha1.CSVPhasePending,
+			)
+
+			met, statuses := op.requirementStatus(csv)
+			require.False(t, met)
+
+			status := requirementStatusFor(statuses, "ServiceAccount", "sa")
+			require.NotNil(t, status)
+			require.Equal(t, tt.expectedStatus, status.Status)
+			require.Equal(t, tt.expectedMessage, status.Message)
+		})
+	}
+}
+
+func TestRequirementStatusServiceAccountRuleSummary(t *testing.T) {
+	namespace := "ns"
+	getPods := rbacv1.PolicyRule{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"pods"}}
+	listConfigMaps := rbacv1.PolicyRule{Verbs: []string{"list"}, APIGroups: []string{""}, Resources: []string{"configmaps"}}
+	watchSecrets := rbacv1.PolicyRule{Verbs: []string{"watch"}, APIGroups: []string{""}, Resources: []string{"secrets"}}
+	everything := rbacv1.PolicyRule{Verbs: []string{"*"}, APIGroups: []string{"*"}, Resources: []string{"*"}}
+
+	tests := []struct {
+		description       string
+		rules             []rbacv1.PolicyRule
+		repeatedRules     []rbacv1.PolicyRule
+		granted           []rbacv1.PolicyRule
+		expectedSatisfied string
+		expectedTotal     string
+		expectedMessage   string
+	}{
+		{
+			description:       "Mixed",
+			rules:             []rbacv1.PolicyRule{getPods, listConfigMaps, watchSecrets},
+			granted:           []rbacv1.PolicyRule{getPods, watchSecrets},
+			expectedSatisfied: "2",
+			expectedTotal:     "3",
+			expectedMessage:   "2/3 rules satisfied",
+		},
+		{
+			description:       "AllSatisfied",
+			rules:             []rbacv1.PolicyRule{getPods, listConfigMaps},
+			granted:           []rbacv1.PolicyRule{getPods, listConfigMaps},
+			expectedSatisfied: "2",
+			expectedTotal:     "2",
+			expectedMessage:   "2/2 rules satisfied",
+		},
+		{
+			// the review flagged for the overly broad rule isn't counted as another rule
+			description:       "OverlyBroad",
+			rules:             []rbacv1.PolicyRule{getPods, everything},
+			granted:           []rbacv1.PolicyRule{getPods},
+			expectedSatisfied: "1",
+			expectedTotal:     "2",
+			expectedMessage:   "1/2 rules satisfied",
+		},
+		{
+			description:       "Duplicated",
+			rules:             []rbacv1.PolicyRule{getPods, listConfigMaps, getPods},
+			granted:           []rbacv1.PolicyRule{getPods},
+			expectedSatisfied: "1",
+			expectedTotal:     "2",
+			expectedMessage:   "1/2 rules satisfied",
+		},
+		{
+			// rules repeated by another permission block for the same ServiceAccount are counted once
+			description:       "RepeatedAcrossPermissions",
+			rules:             []rbacv1.PolicyRule{getPods, listConfigMaps},
+			repeatedRules:     []rbacv1.PolicyRule{listConfigMaps, watchSecrets},
+			granted:           []rbacv1.PolicyRule{getPods},
+			expectedSatisfied: "1",
+			expectedTotal:     "3",
+			expectedMessage:   "1/3 rules satisfied",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			op, err := NewFakeOperator(nil, []runtime.Object{serviceAccount("sa", namespace)}, nil, nil, &install.StrategyResolver{}, namespace)
+			require.NoError(t, err)
+
+			roles := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			roleBindings := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			require.NoError(t, roles.Add(&rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: "granted", Namespace: namespace}, Rules: tt.granted}))
+			require.NoError(t, roleBindings.Add(&rbacv1.RoleBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "granted-binding", Namespace: namespace},
+				RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "granted"},
+				Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "sa"}},
+			}))
+			op.roleLister = crbacv1.NewRoleLister(roles)
+			op.roleBindingLister = crbacv1.NewRoleBindingLister(roleBindings)
+
+			permissions := []install.StrategyDeploymentPermissions{{ServiceAccountName: "sa", Rules: tt.rules}}
+			if len(tt.repeatedRules) > 0 {
+				permissions = append(permissions, install.StrategyDeploymentPermissions{ServiceAccountName: "sa", Rules: tt.repeatedRules})
+			}
+			csv := csv("csv1",
+				namespace,
+				"",
+				withPermissions(installStrategy("csv1-dep1"), permissions, nil),
+				[]*v1beta1.CustomResourceDefinition{},
+				[]*v1beta1.CustomResourceDefinition{},
+				v1alpha1.CSVPhasePending,
+			)
+
+			_, statuses := op.requirementStatus(csv)
+			status := requirementStatusFor(statuses, "ServiceAccount", "sa")
+			require.NotNil(t, status)
+			require.Equal(t, tt.expectedSatisfied, status.Details[v1alpha1.RequirementDetailRulesSatisfied])
+			require.Equal(t, tt.expectedTotal, status.Details[v1alpha1.RequirementDetailRules])
+			require.Equal(t, tt.expectedMessage, status.Message)
+
+			// the summary counts the rules the status lists
+			rules := 0
+			for _, dependent := range status.Dependents {
+				if dependent.Status == v1alpha1.DependentStatusReasonSatisfied || dependent.Status == v1alpha1.DependentStatusReasonNotSatisfied {
+					rules++
+				}
+			}
+			require.Equal(t, tt.expectedTotal, strconv.Itoa(rules))
+		})
+	}
+}
+
+// countingRoleBindingLister counts the lookups of RoleBindings by namespace made by rule checks
+type countingRoleBindingLister struct {
+	crbacv1.RoleBindingLister
+	lookups int32
+}
+
+func (l *countingRoleBindingLister) RoleBindings(namespace string) crbacv1.RoleBindingNamespaceLister {
+	atomic.AddInt32(&l.lookups, 1)
+	return l.RoleBindingLister.RoleBindings(namespace)
+}
+
+func TestRequirementStatusServiceAccountClusterAdmin(t *testing.T) {
+	namespace := "ns"
+	getPods := rbacv1.PolicyRule{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"pods"}}
+	listConfigMaps := rbacv1.PolicyRule{Verbs: []string{"list"}, APIGroups: []string{""}, Resources: []string{"configmaps"}}
+	everything := rbacv1.PolicyRule{Verbs: []string{"*"}, APIGroups: []string{"*"}, Resources: []string{"*"}}
+
+	tests := []struct {
+		description       string
+		rules             []rbacv1.PolicyRule
+		clusterRoleRules  []rbacv1.PolicyRule
+		expectedMet       bool
+		expectedSatisfied string
+		expectedLookups   bool
+	}{
+		{
+			description:       "ClusterAdmin",
+			rules:             []rbacv1.PolicyRule{getPods, listConfigMaps},
+			clusterRoleRules:  []rbacv1.PolicyRule{everything},
+			expectedMet:       true,
+			expectedSatisfied: "2",
+			expectedLookups:   false,
+		},
+		{
+			description:       "NotClusterAdmin",
+			rules:             []rbacv1.PolicyRule{getPods, listConfigMaps},
+			clusterRoleRules:  []rbacv1.PolicyRule{getPods},
+			expectedMet:       false,
+			expectedSatisfied: "1",
+			expectedLookups:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			op, err := NewFakeOperator(nil, []runtime.Object{serviceAccount("sa", namespace)}, nil, nil, &install.StrategyResolver{}, namespace)
+			require.NoError(t, err)
+
+			clusterRoles := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			clusterRoleBindings := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			require.NoError(t, clusterRoles.Add(&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "granted"}, Rules: tt.clusterRoleRules}))
+			require.NoError(t, clusterRoleBindings.Add(&rbacv1.ClusterRoleBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "granted-binding"},
+				RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "granted"},
+				Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "sa", Namespace: namespace}},
+			}))
+			op.clusterRoleLister = crbacv1.NewClusterRoleLister(clusterRoles)
+			op.clusterRoleBindingLister = crbacv1.NewClusterRoleBindingLister(clusterRoleBindings)
+			roleBindings := &countingRoleBindingLister{RoleBindingLister: crbacv1.NewRoleBindingLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}))}
+			op.roleBindingLister = roleBindings
+
+			csv := csv("csv1",
+				namespace,
+				"",
+				withPermissions(installStrategy("csv1-dep1"), []install.StrategyDeploymentPermissions{{ServiceAccountName: "sa", Rules: tt.rules}}, nil),
+				[]*v1beta1.CustomResourceDefinition{},
+				[]*v1beta1.CustomResourceDefinition{},
+				v1alpha1.CSVPhasePending,
+			)
+
+			met, statuses := op.requirementStatus(csv)
+			require.Equal(t, tt.expectedMet, met)
+			status := requirementStatusFor(statuses, "ServiceAccount", "sa")
+			require.NotNil(t, status)
+			require.Equal(t, tt.expectedSatisfied, status.Details[v1alpha1.RequirementDetailRulesSatisfied])
+			require.Equal(t, tt.expectedLookups, atomic.LoadInt32(&roleBindings.lookups) > 0)
+		})
+	}
+}
+
+func TestRequirementStatusCRDErrors(t *testing.T) {
+	namespace := "ns"
+	crdResource := schema.GroupResource{Group: "apiextensions.k8s.io", Resource: "customresourcedefinitions"}
+
+	tests := []struct {
+		description     string
+		err             error
+		expectedStatus  v1alpha1.StatusReason
+		expectedMessage bool
+	}{
+		{
+			description:     "NotFound",
+			err:             k8serrors.NewNotFound(crdResource, "c1group"),
+			expectedStatus:  v1alpha1.RequirementStatusReasonNotPresent,
+			expectedMessage: false,
+		},
+		{
+			description:     "Forbidden",
+			err:             k8serrors.NewForbidden(crdResource, "c1group", fmt.Errorf("olm can't get crds")),
+			expectedStatus:  v1alpha1.RequirementStatusReasonAccessDenied,
+			expectedMessage: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			op, err := NewFakeOperator(nil, nil, nil, nil, &install.StrategyResolver{}, namespace)
+			require.NoError(t, err)
+
+			extClient, ok := op.OpClient.ApiextensionsV1beta1Interface().(*apiextensionsfake.Clientset)
+			require.True(t, ok)
+			extClient.PrependReactor("get", "customresourcedefinitions", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				return true, nil, tt.err
+			})
+
+			csv := csv("csv1",
+				namespace,
+				"",
+				installStrategy("csv1-dep1"),
+				[]*v1beta1.CustomResourceDefinition{},
+				[]*v1beta1.CustomResourceDefinition{crd("c1", "v1")},
+				v1alpha1.CSVPhasePending,
+			)
+
+			met, statuses := op.requirementStatus(csv)
+			require.False(t, met)
+
+			status := requirementStatusFor(statuses, "CustomResourceDefinition", "c1group")
+			require.NotNil(t, status)
+			require.Equal(t, tt.expectedStatus, status.Status)
+			if tt.expectedMessage {
+				require.Contains(t, status.Message, "OLM is not permitted")
+			} else {
+				require.Empty(t, status.Message)
+			}
+		})
+	}
+}
+
+func TestRequirementStatusAPIServiceKind(t *testing.T) {
+	namespace := "ns"
+
+	tests := []struct {
+		description string
+		apis        []v1alpha1.APIServiceDescription
+		regObjs     []runtime.Object
+		expectedMet bool
+	}{
+		{
+			description: "Kind/Registered",
+			apis:        apis("a1.v1.a1Kind"),
+			regObjs:     []runtime.Object{apiService("a1", "v1", apiregistrationv1.ConditionTrue)},
+			expectedMet: true,
+		},
+		{
+			description: "Kind/NotRegistered",
+			apis:        apis("a1.v1.otherKind"),
+			regObjs:     []runtime.Object{apiService("a1", "v1", apiregistrationv1.ConditionTrue)},
+			expectedMet: false,
+		},
+		{
+			description: "WildcardKind/Registered",
+			apis:        apis("a1.v1."),
+			regObjs:     []runtime.Object{apiService("a1", "v1", apiregistrationv1.ConditionTrue)},
+			expectedMet: true,
+		},
+		{
+			description: "WildcardKind/VersionNotRegistered",
+			apis:        apis("a1.v2."),
+			regObjs:     []runtime.Object{apiService("a1", "v1", apiregistrationv1.ConditionTrue)},
+			expectedMet: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			op, err := NewFakeOperator(nil, nil, nil, tt.regObjs, &install.StrategyResolver{}, namespace)
+			require.NoError(t, err)
+
+			csv := withAPIServices(csv("csv1",
+				namespace,
+				"",
+				installStrategy("csv1-dep1"),
+				[]*v1beta1.CustomResourceDefinition{},
+				[]*v1beta1.CustomResourceDefinition{},
+				v1alpha1.CSVPhasePending,
+			), nil, tt.apis)
+
+			met, statuses := op.requirementStatus(csv)
+			require.Equal(t, tt.expectedMet, met)
+
+			desc := tt.apis[0]
+			status := requirementStatusFor(statuses, "APIService", desc.Version+"."+desc.Name)
+			require.NotNil(t, status)
+			if tt.expectedMet {
+				require.Equal(t, v1alpha1.RequirementStatusReasonPresent, status.Status)
+			} else {
+				require.Equal(t, v1alpha1.RequirementStatusReasonNotPresent, status.Status)
+			}
+		})
+	}
+}
+
+func TestRequirementStatusAPIServiceAcceptableVersions(t *testing.T) {
+	namespace := "ns"
+
+	tests := []struct {
+		description           string
+		acceptableVersions    []string
+		regObjs               []runtime.Object
+		expectedMet           bool
+		expectedServedVersion string
+	}{
+		{
+			description:        "RequestedVersionServed",
+			acceptableVersions: []string{"v2"},
+			regObjs: []runtime.Object{
+				apiService("a1", "v1beta1", apiregistrationv1.ConditionTrue),
+				apiService("a1", "v2", apiregistrationv1.ConditionTrue),
+			},
+			expectedMet: true,
+		},
+		{
+			description:           "OnlyNewerVersionServed",
+			acceptableVersions:    []string{"v1", "v2"},
+			regObjs:               []runtime.Object{apiService("a1", "v1", apiregistrationv1.ConditionTrue)},
+			expectedMet:           true,
+			expectedServedVersion: "v1",
+		},
+		{
+			description:           "OnlyNewerVersionServed/Unavailable",
+			acceptableVersions:    []string{"v1"},
+			regObjs:               []runtime.Object{apiService("a1", "v1", apiregistrationv1.ConditionFalse)},
+			expectedMet:           false,
+			expectedServedVersion: "v1",
+		},
+		{
+			description:           "NewestServedVersionAccepted",
+			acceptableVersions:    []string{"v1", "v2", "v3"},
+			regObjs:               []runtime.Object{apiService("a1", "v1", apiregistrationv1.ConditionTrue), apiService("a1", "v2", apiregistrationv1.ConditionTrue)},
+			expectedMet:           true,
+			expectedServedVersion: "v2",
+		},
+		{
+			description:        "NewerVersionNotAcceptable",
+			acceptableVersions: nil,
+			regObjs:            []runtime.Object{apiService("a1", "v1", apiregistrationv1.ConditionTrue)},
+			expectedMet:        false,
+		},
+		{
+			description:        "OnlyOlderVersionServed",
+			acceptableVersions: []string{"v1alpha1"},
+			regObjs:            []runtime.Object{apiService("a1", "v1alpha1", apiregistrationv1.ConditionTrue)},
+			expectedMet:        false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			op, err := NewFakeOperator(nil, nil, nil, tt.regObjs, &install.StrategyResolver{}, namespace)
+			require.NoError(t, err)
+
+			required := apis("a1.v1beta1.a1Kind")
+			required[0].AcceptableVersions = tt.acceptableVersions
+			csv := withAPIServices(csv("csv1",
+				namespace,
+				"",
+				installStrategy("csv1-dep1"),
+				[]*v1beta1.CustomResourceDefinition{},
+				[]*v1beta1.CustomResourceDefinition{},
+				v1alpha1.CSVPhasePending,
+			), nil, required)
+
+			met, statuses := op.requirementStatus(csv)
+			require.Equal(t, tt.expectedMet, met)
+
+			// the requirement is reported under the requested version, whichever version met it
+			status := requirementStatusFor(statuses, "APIService", "v1beta1.a1")
+			require.NotNil(t, status)
+			if tt.expectedMet {
+				require.Equal(t, v1alpha1.RequirementStatusReasonPresent, status.Status)
+			} else {
+				require.Equal(t, v1alpha1.RequirementStatusReasonNotPresent, status.Status)
+			}
+			require.Equal(t, tt.expectedServedVersion, status.Details[v1alpha1.RequirementDetailServedVersion])
+
+			keys, err := csvRequirementsIndexFunc(csv)
+			require.NoError(t, err)
+			for _, version := range tt.acceptableVersions {
+				require.Contains(t, keys, requirementIndexKey("APIService", version+".a1"))
+			}
+		})
+	}
+}
+
+func TestRequirementStatusAPIServiceDetails(t *testing.T) {
+	namespace := "ns"
+	caBundle := []byte("ca-bundle")
+
+	tests := []struct {
+		description     string
+		service         *apiregistrationv1.ServiceReference
+		caBundle        []byte
+		k8sObjs         []runtime.Object
+		expectedDetails map[string]string
+	}{
+		{
+			description: "ReadyEndpoints",
+			service:     &apiregistrationv1.ServiceReference{Namespace: namespace, Name: "a1-service"},
+			caBundle:    caBundle,
+			k8sObjs: []runtime.Object{&v1.Endpoints{
+				ObjectMeta: metav1.ObjectMeta{Name: "a1-service", Namespace: namespace},
+				Subsets: []v1.EndpointSubset{
+					{Addresses: []v1.EndpointAddress{{IP: "10.0.0.1"}}},
+				},
+			}},
+			expectedDetails: map[string]string{
+				v1alpha1.RequirementDetailService:        "ns/a1-service",
+				v1alpha1.RequirementDetailEndpointsReady: "true",
+				v1alpha1.RequirementDetailCABundleSHA256: fmt.Sprintf("%x", sha256.Sum256(caBundle)),
+			},
+		},
+		{
+			description: "NoEndpoints",
+			service:     &apiregistrationv1.ServiceReference{Namespace: namespace, Name: "a1-service"},
+			caBundle:    caBundle,
+			k8sObjs:     nil,
+			expectedDetails: map[string]string{
+				v1alpha1.RequirementDetailService:        "ns/a1-service",
+				v1alpha1.RequirementDetailEndpointsReady: "false",
+				v1alpha1.RequirementDetailCABundleSHA256: fmt.Sprintf("%x", sha256.Sum256(caBundle)),
+			},
+		},
+		{
+			description:     "Local",
+			service:         nil,
+			caBundle:        nil,
+			k8sObjs:         nil,
+			expectedDetails: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			api := apiService("a1", "v1", apiregistrationv1.ConditionTrue)
+			api.Spec.Service = tt.service
+			api.Spec.CABundle = tt.caBundle
+			op, err := NewFakeOperator(nil, tt.k8sObjs, nil, []runtime.Object{api}, &install.StrategyResolver{}, namespace)
+			require.NoError(t, err)
+
+			csv := withAPIServices(csv("csv1",
+				namespace,
+				"",
+				installStrategy("csv1-dep1"),
+				[]*v1beta1.CustomResourceDefinition{},
+				[]*v1beta1.CustomResourceDefinition{},
+				v1alpha1.CSVPhasePending,
+			), nil, apis("a1.v1.a1Kind"))
+
+			_, statuses := op.requirementStatus(csv)
+			status := requirementStatusFor(statuses, "APIService", "v1.a1")
+			require.NotNil(t, status)
+			require.Equal(t, v1alpha1.RequirementStatusReasonPresent, status.Status)
+			require.Equal(t, tt.expectedDetails, status.Details)
+		})
+	}
+}
+
+func TestRequirementStatusAPIServiceOrphaned(t *testing.T) {
+	namespace := "ns"
+
+	ownerLabels := func(kind, name string) map[string]string {
+		return map[string]string{
+			ownerutil.OwnerKey:          name,
+			ownerutil.OwnerNamespaceKey: namespace,
+			ownerutil.OwnerKind:         kind,
+		}
+	}
+	existing := csv("csv0",
+		namespace,
+		"",
+		installStrategy("csv0-dep1"),
+		[]*v1beta1.CustomResourceDefinition{},
+		[]*v1beta1.CustomResourceDefinition{},
+		v1alpha1.CSVPhaseSucceeded,
+	)
+	tests := []struct {
+		description     string
+		labels          map[string]string
+		clientObjs      []runtime.Object
+		expectedWarning string
+	}{
+		{
+			description:     "OwnerDeleted",
+			labels:          ownerLabels(v1alpha1.ClusterServiceVersionKind, "csv0"),
+			expectedWarning: "APIService v1.a1 is labeled as owned by ClusterServiceVersion ns/csv0, which no longer exists; delete the APIService or update its olm.owner labels",
+		},
+		{
+			description: "OwnerExists",
+			labels:      ownerLabels(v1alpha1.ClusterServiceVersionKind, "csv0"),
+			clientObjs:  []runtime.Object{existing},
+		},
+		{
+			description: "OwnedByCSV",
+			labels:      ownerLabels(v1alpha1.ClusterServiceVersionKind, "csv1"),
+		},
+		{
+			description: "OwnerNotCSV",
+			labels:      ownerLabels("InstallPlan", "install-abc"),
+		},
+		{
+			description: "Unlabeled",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			api := apiService("a1", "v1", apiregistrationv1.ConditionTrue)
+			api.SetLabels(tt.labels)
+			op, err := NewFakeOperator(tt.clientObjs, nil, nil, []runtime.Object{api}, &install.StrategyResolver{}, namespace)
+			require.NoError(t, err)
+
+			csv := withAPIServices(csv("csv1",
+				namespace,
+				"",
+				installStrategy("csv1-dep1"),
+				[]*v1beta1.CustomResourceDefinition{},
+				[]*v1beta1.CustomResourceDefinition{},
+				v1alpha1.CSVPhasePending,
+			), nil, apis("a1.v1.a1Kind"))
+
+			met, statuses := op.requirementStatus(csv)
+			require.True(t, met, "an orphaned APIService shouldn't make the requirement unmet")
+
+			status := requirementStatusFor(statuses, "APIService", "v1.a1")
+			require.NotNil(t, status)
+			require.Equal(t, v1alpha1.RequirementStatusReasonPresent, status.Status)
+			if tt.expectedWarning == "" {
+				require.Empty(t, status.Dependents)
+				return
+			}
+			require.Len(t, status.Dependents, 1)
+			require.Equal(t, v1alpha1.DependentStatusReasonOrphaned, status.Dependents[0].Status)
+			require.Equal(t, tt.expectedWarning, status.Dependents[0].Message)
+		})
+	}
+}
+
+func TestRequirementStatusAPIServiceCABundleConfigMap(t *testing.T) {
+	namespace := "ns"
+	configMap := func(data map[string]string) *v1.ConfigMap {
+		return &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "a1-ca",
+				Namespace:   namespace,
+				Annotations: map[string]string{"service.beta.openshift.io/inject-cabundle": "true"},
+			},
+			Data: data,
+		}
+	}
+
+	tests := []struct {
+		description       string
+		annotated         bool
+		k8sObjs           []runtime.Object
+		expectedMet       bool
+		expectedStatus    v1alpha1.StatusReason
+		expectedDependent v1alpha1.StatusReason
+		expectedMessage   string
+	}{
+		{
+			description:    "NotAnnotated",
+			annotated:      false,
+			expectedMet:    true,
+			expectedStatus: v1alpha1.RequirementStatusReasonPresent,
+		},
+		{
+			description:       "Populated",
+			annotated:         true,
+			k8sObjs:           []runtime.Object{configMap(map[string]string{InjectedCABundleKey: "ca-bundle"})},
+			expectedMet:       true,
+			expectedStatus:    v1alpha1.RequirementStatusReasonPresent,
+			expectedDependent: v1alpha1.DependentStatusReasonSatisfied,
+			expectedMessage:   "CA bundle injected into ConfigMap ns/a1-ca",
+		},
+		{
+			description:       "Empty",
+			annotated:         true,
+			k8sObjs:           []runtime.Object{configMap(nil)},
+			expectedMet:       false,
+			expectedStatus:    v1alpha1.RequirementStatusReasonPresentNotSatisfied,
+			expectedDependent: v1alpha1.DependentStatusReasonNotSatisfied,
+			expectedMessage:   "CA bundle hasn't been injected into ConfigMap ns/a1-ca yet; service-ca.crt is empty",
+		},
+		{
+			description:       "Missing",
+			annotated:         true,
+			expectedMet:       false,
+			expectedStatus:    v1alpha1.RequirementStatusReasonPresentNotSatisfied,
+			expectedDependent: v1alpha1.DependentStatusReasonNotSatisfied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			api := apiService("a1", "v1", apiregistrationv1.ConditionTrue)
+			api.Spec.Service = &apiregistrationv1.ServiceReference{Namespace: namespace, Name: "a1-service"}
+			if tt.annotated {
+				api.SetAnnotations(map[string]string{CABundleConfigMapAnnotationKey: "a1-ca"})
+			}
+			op, err := NewFakeOperator(nil, tt.k8sObjs, nil, []runtime.Object{api}, &install.StrategyResolver{}, namespace)
+			require.NoError(t, err)
+
+			csv := withAPIServices(csv("csv1",
+				namespace,
+				"",
+				installStrategy("csv1-dep1"),
+				[]*v1beta1.CustomResourceDefinition{},
+				[]*v1beta1.CustomResourceDefinition{},
+				v1alpha1.CSVPhasePending,
+			), nil, apis("a1.v1.a1Kind"))
+
+			met, statuses := op.requirementStatus(csv)
+			require.Equal(t, tt.expectedMet, met)
+			status := requirementStatusFor(statuses, "APIService", "v1.a1")
+			require.NotNil(t, status)
+			require.Equal(t, tt.expectedStatus, status.Status)
+
+			if !tt.annotated {
+				require.Empty(t, status.Dependents)
+				return
+			}
+			require.Len(t, status.Dependents, 1)
+			dependent := status.Dependents[0]
+			require.Equal(t, "ConfigMap", dependent.Kind)
+			require.Equal(t, tt.expectedDependent, dependent.Status)
+			if tt.expectedMessage != "" {
+				require.Equal(t, tt.expectedMessage, dependent.Message)
+			}
+		})
+	}
+}
+
+func TestRequirementStatusTrace(t *testing.T) {
+	namespace := "ns"
+
+	tests := []struct {
+		description     string
+		annotations     map[string]string
+		expectedEntries []RequirementTraceEntry
+	}{
+		{
+			description:     "Disabled",
+			annotations:     nil,
+			expectedEntries: nil,
+		},
+		{
+			description: "Enabled",
+			annotations: map[string]string{RequirementsTraceAnnotationKey: "true"},
+			expectedEntries: []RequirementTraceEntry{
+				{Kind: "CustomResourceDefinition", Name: "c1group", Status: v1alpha1.RequirementStatusReasonPresent},
+				{Kind: "CustomResourceDefinition", Name: "c2group", Status: v1alpha1.RequirementStatusReasonNotPresent},
+				{Kind: "APIService", Name: "v1.a1", Status: v1alpha1.RequirementStatusReasonPresent},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			crds := []runtime.Object{crd("c1", "v1")}
+			regObjs := []runtime.Object{apiService("a1", "v1", apiregistrationv1.ConditionTrue)}
+			op, err := NewFakeOperator(nil, nil, crds, regObjs, &install.StrategyResolver{}, namespace)
+			require.NoError(t, err)
+
+			csv := withAPIServices(csv("csv1",
+				namespace,
+				"",
+				installStrategy("csv1-dep1"),
+				[]*v1beta1.CustomResourceDefinition{crd("c1", "v1")},
+				[]*v1beta1.CustomResourceDefinition{crd("c2", "v1")},
+				v1alpha1.CSVPhasePending,
+			), nil, apis("a1.v1.a1Kind"))
+			csv.SetAnnotations(tt.annotations)
+
+			met, _ := op.requirementStatus(csv)
+			require.False(t, met)
+
+			entries := op.RequirementsTrace(namespace, "csv1")
+			require.Len(t, entries, len(tt.expectedEntries))
+			for i, expected := range tt.expectedEntries {
+				require.Equal(t, expected.Kind, entries[i].Kind)
+				require.Equal(t, expected.Name, entries[i].Name)
+				require.Equal(t, expected.Status, entries[i].Status)
+				require.NotEmpty(t, entries[i].Check)
+			}
+		})
+	}
+}
+
+func TestRequirementsForNamespace(t *testing.T) {
+	namespace := "ns"
+	permissions := []install.StrategyDeploymentPermissions{
+		{
+			ServiceAccountName: "sa",
+			Rules: []rbacv1.PolicyRule{
+				{
+					Verbs:     []string{"get"},
+					APIGroups: []string{""},
+					Resources: []string{"pods"},
+				},
+			},
+		},
+	}
+
+	csvs := []*v1alpha1.ClusterServiceVersion{
+		csv("csv1",
+			namespace,
+			"",
+			installStrategy("csv1-dep1"),
+			[]*v1beta1.CustomResourceDefinition{crd("c1", "v1")},
+			[]*v1beta1.CustomResourceDefinition{},
+			v1alpha1.CSVPhasePending,
+		),
+		csv("csv2",
+			namespace,
+			"",
+			withPermissions(installStrategy("csv2-dep1"), permissions, nil),
+			[]*v1beta1.CustomResourceDefinition{},
+			[]*v1beta1.CustomResourceDefinition{crd("c1", "v1"), crd("c2", "v1")},
+			v1alpha1.CSVPhasePending,
+		),
+		withAPIServices(csv("csv3",
+			namespace,
+			"",
+			installStrategy("csv3-dep1"),
+			[]*v1beta1.CustomResourceDefinition{},
+			[]*v1beta1.CustomResourceDefinition{},
+			v1alpha1.CSVPhasePending,
+		), nil, apis("a1.v1.a1Kind", "a2.v1.a2Kind")),
+		csv("other",
+			"other-ns",
+			"",
+			installStrategy("other-dep1"),
+			[]*v1beta1.CustomResourceDefinition{},
+			[]*v1beta1.CustomResourceDefinition{crd("c2", "v1")},
+			v1alpha1.CSVPhasePending,
+		),
+	}
+	clientObjs := []runtime.Object{}
+	for _, csv := range csvs {
+		clientObjs = append(clientObjs, csv)
+	}
+	k8sObjs := []runtime.Object{serviceAccount("sa", namespace)}
+	extObjs := []runtime.Object{crd("c1", "v1")}
+	regObjs := []runtime.Object{apiService("a1", "v1", apiregistrationv1.ConditionTrue)}
+
+	op, err := NewFakeOperator(clientObjs, k8sObjs, extObjs, regObjs, &install.StrategyResolver{}, namespace)
+	require.NoError(t, err)
+
+	// both checks are stamped with the same time
+	op.clock = clock.NewFakeClock(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	requirements := op.RequirementsForNamespace(context.Background(), namespace)
+	require.Len(t, requirements, 3)
+	for _, csv := range csvs[:3] {
+		_, expected := op.requirementStatus(csv)
+		require.ElementsMatch(t, expected, requirements[csv.GetName()], "requirements for %s", csv.GetName())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.Empty(t, op.RequirementsForNamespace(ctx, namespace))
+}
+
+func TestSyncRequirementRequeuesCSVs(t *testing.T) {
+	namespace := "ns"
+
+	tests := []struct {
+		description  string
+		requirement  runtime.Object
+		expectedKeys []string
+	}{
+		{
+			description:  "CRD",
+			requirement:  crd("c1", "v1"),
+			expectedKeys: []string{"ns/csv1", "ns/csv2"},
+		},
+		{
+			description:  "APIService",
+			requirement:  apiService("a1", "v1", apiregistrationv1.ConditionTrue),
+			expectedKeys: []string{"ns/csv2"},
+		},
+		{
+			description:  "NotRequired",
+			requirement:  crd("c3", "v1"),
+			expectedKeys: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			op, err := NewFakeOperator(nil, nil, nil, nil, &install.StrategyResolver{}, namespace)
+			require.NoError(t, err)
+			require.Len(t, op.csvIndexers, 1)
+
+			csvs := []*v1alpha1.ClusterServiceVersion{
+				csv("csv1",
+					namespace,
+					"",
+					installStrategy("csv1-dep1"),
+					[]*v1beta1.CustomResourceDefinition{},
+					[]*v1beta1.CustomResourceDefinition{crd("c1", "v1")},
+					v1alpha1.CSVPhasePending,
+				),
+				withAPIServices(csv("csv2",
+					namespace,
+					"",
+					installStrategy("csv2-dep1"),
+					[]*v1beta1.CustomResourceDefinition{crd("c1", "v1")},
+					[]*v1beta1.CustomResourceDefinition{},
+					v1alpha1.CSVPhasePending,
+				), nil, apis("a1.v1.a1Kind")),
+				csv("csv3",
+					namespace,
+					"",
+					installStrategy("csv3-dep1"),
+					[]*v1beta1.CustomResourceDefinition{},
+					[]*v1beta1.CustomResourceDefinition{crd("c2", "v1")},
+					v1alpha1.CSVPhasePending,
+				),
+			}
+			for _, csv := range csvs {
+				require.NoError(t, op.csvIndexers[0].Add(csv))
+			}
+
+			require.NoError(t, op.syncRequirement(tt.requirement))
+
+			keys := []string{}
+			for op.csvQueue.Len() > 0 {
+				key, _ := op.csvQueue.Get()
+				keys = append(keys, key.(string))
+				op.csvQueue.Done(key)
+			}
+			require.ElementsMatch(t, tt.expectedKeys, keys)
+		})
+	}
+}
+
+// delayRecordingQueue records the delay of each key added with AddAfter
+type delayRecordingQueue struct {
+	workqueue.RateLimitingInterface
+	delays map[interface{}]time.Duration
+}
+
+func (q *delayRecordingQueue) AddAfter(item interface{}, duration time.Duration) {
+	q.delays[item] = duration
+}
+
+func TestSyncRequirementSpreadsRequeues(t *testing.T) {
+	namespace := "ns"
+	spread := time.Second
+
+	op, err := NewFakeOperator(nil, nil, nil, nil, &install.StrategyResolver{}, namespace)
+	require.NoError(t, err)
+	queue := &delayRecordingQueue{RateLimitingInterface: op.csvQueue, delays: map[interface{}]time.Duration{}}
+	op.csvQueue = queue
+	op.requeueSpread = spread
+
+	count := 20
+	for i := 0; i < count; i++ {
+		require.NoError(t, op.csvIndexers[0].Add(csv(fmt.Sprintf("csv%d", i),
+			namespace,
+			"",
+			installStrategy(fmt.Sprintf("csv%d-dep1", i)),
+			[]*v1beta1.CustomResourceDefinition{},
+			[]*v1beta1.CustomResourceDefinition{crd("c1", "v1")},
+			v1alpha1.CSVPhasePending,
+		)))
+	}
+	require.NoError(t, op.syncRequirement(crd("c1", "v1")))
+
+	// every CSV is requeued, within the spread but not all at the same time
+	require.Len(t, queue.delays, count)
+	delays := map[time.Duration]struct{}{}
+	for key, delay := range queue.delays {
+		require.True(t, delay >= 0 && delay < spread, "%s delayed by %s", key, delay)
+		delays[delay] = struct{}{}
+	}
+	require.True(t, len(delays) > 1, "all CSVs delayed by the same %v", delays)
+	require.Equal(t, 0, op.csvQueue.Len())
+}
+
+func TestRequirementStatusCRDVersionRange(t *testing.T) {
+	namespace := "ns"
+
+	tests := []struct {
+		description    string
+		served         []string
+		versionRange   string
+		expectedStatus v1alpha1.StatusReason
+		expectedMet    bool
+	}{
+		{
+			description:    "ServedVersionInRange",
+			served:         []string{"v1alpha1", "v1beta2"},
+			versionRange:   ">=v1beta1",
+			expectedStatus: v1alpha1.RequirementStatusReasonPresent,
+			expectedMet:    true,
+		},
+		{
+			description:    "GAVersionInBoundedRange",
+			served:         []string{"v1"},
+			versionRange:   ">=v1beta1 <v2",
+			expectedStatus: v1alpha1.RequirementStatusReasonPresent,
+			expectedMet:    true,
+		},
+		{
+			description:    "NoServedVersionInRange",
+			served:         []string{"v1alpha1", "v1alpha2"},
+			versionRange:   ">=v1beta1",
+			expectedStatus: v1alpha1.RequirementStatusReasonPresentNotSatisfied,
+			expectedMet:    false,
+		},
+		{
+			description:    "ServedVersionAboveRange",
+			served:         []string{"v2alpha1"},
+			versionRange:   ">=v1beta1, <v2alpha1",
+			expectedStatus: v1alpha1.RequirementStatusReasonPresentNotSatisfied,
+			expectedMet:    false,
+		},
+		{
+			description:    "InvalidRange",
+			served:         []string{"v1"},
+			versionRange:   ">=1.0",
+			expectedStatus: v1alpha1.RequirementStatusReasonPresentNotSatisfied,
+			expectedMet:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			c := crd("c1", tt.served[0])
+			c.Spec.Versions = nil
+			for _, version := range tt.served {
+				c.Spec.Versions = append(c.Spec.Versions, v1beta1.CustomResourceDefinitionVersion{Name: version, Served: true})
+			}
+
+			op, err := NewFakeOperator(nil, nil, []runtime.Object{c}, nil, &install.StrategyResolver{}, namespace)
+			require.NoError(t, err)
+
+			csv := csv("csv1",
+				namespace,
+				"",
+				installStrategy("csv1-dep1"),
+				[]*v1beta1.CustomResourceDefinition{},
+				[]*v1beta1.CustomResourceDefinition{crd("c1", "v1")},
+				v1alpha1.CSVPhasePending,
+			)
+			csv.Spec.CustomResourceDefinitions.Required[0].VersionRange = tt.versionRange
+
+			met, statuses := op.requirementStatus(csv)
+			status := requirementStatusFor(statuses, "CustomResourceDefinition", "c1group")
+			require.NotNil(t, status)
+			require.Equal(t, tt.expectedStatus, status.Status)
+			if tt.expectedMet {
+				require.Empty(t, status.Message)
+			} else {
+				require.NotEmpty(t, status.Message)
+			}
+
+			// the requirement is the only one that can be unmet, since the CSV has no permissions
+			require.Equal(t, tt.expectedMet, met)
+		})
+	}
+}
+
+func TestRequirementStatusLogger(t *testing.T) {
+	namespace := "ns"
+
+	op, err := NewFakeOperator(nil, nil, nil, []runtime.Object{apiService("a1", "v1", apiregistrationv1.ConditionTrue)}, &install.StrategyResolver{}, namespace)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	logger := logrus.New()
+	logger.Out = buf
+	logger.Formatter = &logrus.JSONFormatter{}
+	logger.Level = logrus.DebugLevel
+	op.logger = logger
+
+	csv := withAPIServices(csv("csv1",
+		namespace,
+		"",
+		installStrategy("csv1-dep1"),
+		[]*v1beta1.CustomResourceDefinition{},
+		[]*v1beta1.CustomResourceDefinition{},
+		v1alpha1.CSVPhasePending,
+	), nil, apis("a1.v1.otherKind"))
+
+	met, _ := op.requirementStatus(csv)
+	require.False(t, met)
+
+	entries := []map[string]interface{}{}
+	decoder := json.NewDecoder(buf)
+	for decoder.More() {
+		entry := map[string]interface{}{}
+		require.NoError(t, decoder.Decode(&entry))
+		entries = append(entries, entry)
+	}
+	require.NotEmpty(t, entries)
+
+	// every entry from the requirement checks is scoped to the CSV
+	messages := []string{}
+	for _, entry := range entries {
+		require.Equal(t, "csv1", entry["csv"])
+		require.Equal(t, namespace, entry["namespace"])
+		messages = append(messages, entry["msg"].(string))
+	}
+	require.Contains(t, messages, "couldn't find GVK in api discovery")
+	require.Contains(t, messages, "permission met: true")
+	require.Contains(t, messages, "requirements checked")
+
+	for _, entry := range entries {
+		if entry["msg"] == "couldn't find GVK in api discovery" {
+			require.Equal(t, "a1", entry["group"])
+			require.Equal(t, "otherKind", entry["kind"])
+		}
+		if entry["msg"] == "requirements checked" {
+			require.Equal(t, false, entry["met"])
+			require.Equal(t, requirementInputsHash(csv), entry["inputsHash"])
+		}
+	}
+}
+
+func TestRequirementInputsHash(t *testing.T) {
+	namespace := "ns"
+	rules := []rbacv1.PolicyRule{
+		{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"pods"}},
+		{Verbs: []string{"list"}, APIGroups: []string{""}, Resources: []string{"services"}},
+	}
+	reversed := []rbacv1.PolicyRule{rules[1], rules[0]}
+	newCSV := func(required []*v1beta1.CustomResourceDefinition, permissions []install.StrategyDeploymentPermissions) *v1alpha1.ClusterServiceVersion {
+		return withAPIServices(csv("csv1",
+			namespace,
+			"",
+			withPermissions(installStrategy("csv1-dep1"), permissions, nil),
+			[]*v1beta1.CustomResourceDefinition{crd("c1", "v1")},
+			required,
+			v1alpha1.CSVPhasePending,
+		), nil, apis("a1.v1.a1Kind", "a2.v1.a2Kind"))
+	}
+	base := func() *v1alpha1.ClusterServiceVersion {
+		return newCSV([]*v1beta1.CustomResourceDefinition{crd("c2", "v1"), crd("c3", "v1")}, []install.StrategyDeploymentPermissions{
+			{ServiceAccountName: "sa1", Rules: rules},
+			{ServiceAccountName: "sa2", Rules: rules[:1]},
+		})
+	}
+
+	tests := []struct {
+		description     string
+		csv             func() *v1alpha1.ClusterServiceVersion
+		expectedChanged bool
+	}{
+		{
+			description: "Unchanged",
+			csv:         base,
+		},
+		{
+			description: "StatusChanged",
+			csv: func() *v1alpha1.ClusterServiceVersion {
+				csv := base()
+				csv.SetPhase(v1alpha1.CSVPhaseSucceeded, v1alpha1.CSVReasonInstallSuccessful, "install strategy completed with no errors")
+				csv.Status.RequirementStatus = []v1alpha1.RequirementStatus{{Kind: "ServiceAccount", Name: "sa1", Status: v1alpha1.RequirementStatusReasonPresent}}
+				return csv
+			},
+		},
+		{
+			description: "Reordered",
+			csv: func() *v1alpha1.ClusterServiceVersion {
+				csv := newCSV([]*v1beta1.CustomResourceDefinition{crd("c3", "v1"), crd("c2", "v1")}, []install.StrategyDeploymentPermissions{
+					{ServiceAccountName: "sa2", Rules: rules[:1]},
+					{ServiceAccountName: "sa1", Rules: reversed},
+				})
+				required := csv.Spec.APIServiceDefinitions.Required
+				required[0], required[1] = required[1], required[0]
+				return csv
+			},
+		},
+		{
+			description: "RequiredCRDAdded",
+			csv: func() *v1alpha1.ClusterServiceVersion {
+				csv := base()
+				csv.Spec.CustomResourceDefinitions.Required = append(csv.Spec.CustomResourceDefinitions.Required, v1alpha1.CRDDescription{Name: "c4group", Version: "v1", Kind: "c4"})
+				return csv
+			},
+			expectedChanged: true,
+		},
+		{
+			description: "CRDMovedFromRequiredToOwned",
+			csv: func() *v1alpha1.ClusterServiceVersion {
+				csv := base()
+				crds := &csv.Spec.CustomResourceDefinitions
+				crds.Owned = append(crds.Owned, crds.Required[0])
+				crds.Required = crds.Required[1:]
+				return csv
+			},
+			expectedChanged: true,
+		},
+		{
+			description: "APIServiceVersionChanged",
+			csv: func() *v1alpha1.ClusterServiceVersion {
+				csv := base()
+				csv.Spec.APIServiceDefinitions.Required[0].Version = "v2"
+				return csv
+			},
+			expectedChanged: true,
+		},
+		{
+			description: "RuleChanged",
+			csv: func() *v1alpha1.ClusterServiceVersion {
+				return newCSV([]*v1beta1.CustomResourceDefinition{crd("c2", "v1"), crd("c3", "v1")}, []install.StrategyDeploymentPermissions{
+					{ServiceAccountName: "sa1", Rules: rules},
+					{ServiceAccountName: "sa2", Rules: rules[1:]},
+				})
+			},
+			expectedChanged: true,
+		},
+		{
+			description: "TargetNamespacesChanged",
+			csv: func() *v1alpha1.ClusterServiceVersion {
+				csv := base()
+				csv.SetAnnotations(map[string]string{TargetNamespacesAnnotationKey: "t1"})
+				return csv
+			},
+			expectedChanged: true,
+		},
+	}
+
+	expected := requirementInputsHash(base())
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			hash := requirementInputsHash(tt.csv())
+			require.Len(t, hash, 64)
+			if tt.expectedChanged {
+				require.NotEqual(t, expected, hash)
+			} else {
+				require.Equal(t, expected, hash)
+			}
+		})
+	}
+}
+
+func TestPermissionStatusStableOrder(t *testing.T) {
+	namespace := "ns"
+	rules := []rbacv1.PolicyRule{
+		{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"pods"}},
+		{Verbs: []string{"list"}, APIGroups: []string{""}, Resources: []string{"configmaps"}},
+		{Verbs: []string{"watch"}, APIGroups: []string{"apps"}, Resources: []string{"deployments"}},
+		{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"pods"}},
+	}
+	saNames := []string{"sa-c", "sa-a", "sa-b", "missing"}
+
+	op, err := NewFakeOperator(nil, []runtime.Object{
+		serviceAccount("sa-a", namespace),
+		serviceAccount("sa-b", namespace),
+		serviceAccount("sa-c", namespace),
+	}, nil, nil, &install.StrategyResolver{}, namespace)
+	require.NoError(t, err)
+
+	rnd := rand.New(rand.NewSource(1))
+	var expected []byte
+	for i := 0; i < 20; i++ {
+		permissions := []install.StrategyDeploymentPermissions{}
+		for _, name := range saNames {
+			shuffled := append([]rbacv1.PolicyRule{}, rules...)
+			rnd.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+			permissions = append(permissions, install.StrategyDeploymentPermissions{ServiceAccountName: name, Rules: shuffled})
+		}
+		rnd.Shuffle(len(permissions), func(i, j int) { permissions[i], permissions[j] = permissions[j], permissions[i] })
+
+		csv := csv("csv1",
+			namespace,
+			"",
+			withPermissions(installStrategy("csv1-dep1"), permissions, nil),
+			[]*v1beta1.CustomResourceDefinition{},
+			[]*v1beta1.CustomResourceDefinition{},
+			v1alpha1.CSVPhasePending,
+		)
+
+		_, statuses := op.permissionStatus(csv, newRequirementsSnapshot(op.OpClient), op.logger)
+		require.Len(t, statuses, len(saNames))
+		for j := 1; j < len(statuses); j++ {
+			require.True(t, statuses[j-1].Name < statuses[j].Name)
+		}
+		for _, status := range statuses {
+			if status.Name != "missing" {
+				// the duplicated rule is only reported once
+				require.Len(t, status.Dependents, len(rules)-1)
+			}
+		}
+
+		marshalled, err := json.Marshal(statuses)
+		require.NoError(t, err)
+		if expected == nil {
+			expected = marshalled
+			continue
+		}
+		require.Equal(t, string(expected), string(marshalled))
+	}
+}
+
+// serviceAccountsCSV returns a CSV requesting the given rules for each of count ServiceAccounts, named sa-0 onwards, and
+// the RBAC listers granting them to every other ServiceAccount
+func serviceAccountsCSV(namespace string, count int, rules []rbacv1.PolicyRule) (*v1alpha1.ClusterServiceVersion, crbacv1.RoleLister, crbacv1.RoleBindingLister) {
+	roles := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	roleBindings := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	roles.Add(&rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: "granted", Namespace: namespace}, Rules: rules})
+
+	permissions := []install.StrategyDeploymentPermissions{}
+	for i := 0; i < count; i++ {
+		name := fmt.Sprintf("sa-%d", i)
+		permissions = append(permissions, install.StrategyDeploymentPermissions{ServiceAccountName: name, Rules: rules})
+		if i%2 == 0 {
+			roleBindings.Add(&rbacv1.RoleBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: name + "-binding", Namespace: namespace},
+				RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "granted"},
+				Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: name}},
+			})
+		}
+	}
+
+	csv := csv("csv1",
+		namespace,
+		"",
+		withPermissions(installStrategy("csv1-dep1"), permissions, nil),
+		[]*v1beta1.CustomResourceDefinition{},
+		[]*v1beta1.CustomResourceDefinition{},
+		v1alpha1.CSVPhasePending,
+	)
+	return csv, crbacv1.NewRoleLister(roles), crbacv1.NewRoleBindingLister(roleBindings)
+}
+
+// TestPermissionStatusConcurrent checks more ServiceAccounts than are checked at once, so that running it with -race
+// exercises the concurrent rule checks
+func TestPermissionStatusConcurrent(t *testing.T) {
+	namespace := "ns"
+	count := 3 * maxPermissionCheckWorkers
+	rules := []rbacv1.PolicyRule{
+		{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"pods"}},
+		{Verbs: []string{"list"}, APIGroups: []string{""}, Resources: []string{"configmaps"}},
+	}
+
+	k8sObjs := []runtime.Object{}
+	for i := 0; i < count; i++ {
+		k8sObjs = append(k8sObjs, serviceAccount(fmt.Sprintf("sa-%d", i), namespace))
+	}
+	op, err := NewFakeOperator(nil, k8sObjs, nil, nil, &install.StrategyResolver{}, namespace)
+	require.NoError(t, err)
+
+	csv, roleLister, roleBindingLister := serviceAccountsCSV(namespace, count, rules)
+	// tracing records from every worker
+	csv.SetAnnotations(map[string]string{RequirementsTraceAnnotationKey: "true"})
+	op.roleLister = roleLister
+	op.roleBindingLister = roleBindingLister
+
+	var expected []byte
+	for i := 0; i < 10; i++ {
+		met, statuses := op.permissionStatus(csv, newRequirementsSnapshot(op.OpClient), op.logger)
+		require.False(t, met)
+		require.Len(t, statuses, count)
+
+		for _, status := range statuses {
+			var index int
+			_, err := fmt.Sscanf(status.Name, "sa-%d", &index)
+			require.NoError(t, err)
+
+			expectedStatus, expectedDependent := v1alpha1.RequirementStatusReasonPresent, v1alpha1.DependentStatusReasonSatisfied
+			if index%2 != 0 {
+				expectedStatus, expectedDependent = v1alpha1.RequirementStatusReasonPresentNotSatisfied, v1alpha1.DependentStatusReasonNotSatisfied
+			}
+			require.Equal(t, expectedStatus, status.Status, status.Name)
+			require.Len(t, status.Dependents, len(rules))
+			for _, dependent := range status.Dependents {
+				require.Equal(t, expectedDependent, dependent.Status, status.Name)
+			}
+		}
+
+		marshalled, err := json.Marshal(statuses)
+		require.NoError(t, err)
+		if expected == nil {
+			expected = marshalled
+			continue
+		}
+		require.Equal(t, string(expected), string(marshalled))
+	}
+	require.NotEmpty(t, op.RequirementsTrace(namespace, csv.GetName()))
+}
+
+func BenchmarkPermissionStatus(b *testing.B) {
+	namespace := "ns"
+	rules := []rbacv1.PolicyRule{}
+	for i := 0; i < 20; i++ {
+		rules = append(rules, rbacv1.PolicyRule{Verbs: []string{"get"}, APIGroups: []string{fmt.Sprintf("group%d.example.com", i)}, Resources: []string{"widgets"}})
+	}
+
+	for _, count := range []int{1, 10, 50} {
+		b.Run(fmt.Sprintf("ServiceAccounts%d", count), func(b *testing.B) {
+			k8sObjs := []runtime.Object{}
+			for i := 0; i < count; i++ {
+				k8sObjs = append(k8sObjs, serviceAccount(fmt.Sprintf("sa-%d", i), namespace))
+			}
+			op, err := NewFakeOperator(nil, k8sObjs, nil, nil, &install.StrategyResolver{}, namespace)
+			if err != nil {
+				b.Fatal(err)
+			}
+			csv, roleLister, roleBindingLister := serviceAccountsCSV(namespace, count, rules)
+			op.roleLister = roleLister
+			op.roleBindingLister = roleBindingLister
+			logger := logrus.New()
+			logger.SetLevel(logrus.WarnLevel)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				op.permissionStatus(csv, newRequirementsSnapshot(op.OpClient), logger)
+			}
+		})
+	}
+}
+
+func TestSyncRBACRequeuesCSVs(t *testing.T) {
+	namespace := "ns"
+	podReader := []rbacv1.PolicyRule{{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"pods"}}}
+	saSubject := rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: "sa"}
+
+	binding := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "reader-binding", Namespace: namespace},
+		RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "reader"},
+		Subjects:   []rbacv1.Subject{saSubject},
+	}
+	clusterBinding := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-reader-binding"},
+		RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "cluster-reader"},
+		Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "other-sa", Namespace: namespace}},
+	}
+
+	tests := []struct {
+		description  string
+		obj          interface{}
+		expectedKeys []string
+	}{
+		{
+			description:  "ServiceAccount",
+			obj:          serviceAccount("sa", namespace),
+			expectedKeys: []string{"ns/csv1", "ns/csv2"},
+		},
+		{
+			description:  "RoleBinding",
+			obj:          binding,
+			expectedKeys: []string{"ns/csv1", "ns/csv2"},
+		},
+		{
+			description:  "BoundRole",
+			obj:          &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: "reader", Namespace: namespace}},
+			expectedKeys: []string{"ns/csv1", "ns/csv2"},
+		},
+		{
+			description:  "BoundClusterRole",
+			obj:          &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "cluster-reader"}},
+			expectedKeys: []string{"ns/csv2"},
+		},
+		{
+			description:  "UnboundRole",
+			obj:          &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: "writer", Namespace: namespace}},
+			expectedKeys: []string{},
+		},
+		{
+			description:  "OtherNamespaceServiceAccount",
+			obj:          serviceAccount("sa", "other"),
+			expectedKeys: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			op, err := NewFakeOperator(nil, nil, nil, nil, &install.StrategyResolver{}, namespace)
+			require.NoError(t, err)
+			require.Len(t, op.csvIndexers, 1)
+
+			roleBindings := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			require.NoError(t, roleBindings.Add(binding))
+			clusterRoleBindings := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			require.NoError(t, clusterRoleBindings.Add(clusterBinding))
+			op.roleBindingLister = crbacv1.NewRoleBindingLister(roleBindings)
+			op.clusterRoleBindingLister = crbacv1.NewClusterRoleBindingLister(clusterRoleBindings)
+
+			csvs := []*v1alpha1.ClusterServiceVersion{
+				csv("csv1",
+					namespace,
+					"",
+					withPermissions(installStrategy("csv1-dep1"), []install.StrategyDeploymentPermissions{{ServiceAccountName: "sa", Rules: podReader}}, nil),
+					[]*v1beta1.CustomResourceDefinition{},
+					[]*v1beta1.CustomResourceDefinition{},
+					v1alpha1.CSVPhasePending,
+				),
+				csv("csv2",
+					namespace,
+					"",
+					withPermissions(installStrategy("csv2-dep1"), nil, []install.StrategyDeploymentPermissions{{ServiceAccountName: "sa", Rules: podReader}, {ServiceAccountName: "other-sa", Rules: podReader}}),
+					[]*v1beta1.CustomResourceDefinition{},
+					[]*v1beta1.CustomResourceDefinition{},
+					v1alpha1.CSVPhasePending,
+				),
+				csv("csv3",
+					namespace,
+					"",
+					installStrategy("csv3-dep1"),
+					[]*v1beta1.CustomResourceDefinition{},
+					[]*v1beta1.CustomResourceDefinition{},
+					v1alpha1.CSVPhasePending,
+				),
+			}
+			for _, csv := range csvs {
+				require.NoError(t, op.csvIndexers[0].Add(csv))
+			}
+
+			require.NoError(t, op.syncRBAC(tt.obj))
+
+			keys := []string{}
+			for op.csvQueue.Len() > 0 {
+				key, _ := op.csvQueue.Get()
+				keys = append(keys, key.(string))
+				op.csvQueue.Done(key)
+			}
+			require.ElementsMatch(t, tt.expectedKeys, keys)
+		})
+	}
+}
+
+func TestGrantingPermissionMeetsRequirements(t *testing.T) {
+	namespace := "ns"
+	rules := []rbacv1.PolicyRule{{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"pods"}}}
+
+	op, err := NewFakeOperator(nil, []runtime.Object{serviceAccount("sa", namespace)}, nil, nil, &install.StrategyResolver{}, namespace)
+	require.NoError(t, err)
+
+	roles := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	roleBindings := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	op.roleLister = crbacv1.NewRoleLister(roles)
+	op.roleBindingLister = crbacv1.NewRoleBindingLister(roleBindings)
+
+	pending := csv("csv1",
+		namespace,
+		"",
+		withPermissions(installStrategy("csv1-dep1"), []install.StrategyDeploymentPermissions{{ServiceAccountName: "sa", Rules: rules}}, nil),
+		[]*v1beta1.CustomResourceDefinition{},
+		[]*v1beta1.CustomResourceDefinition{},
+		v1alpha1.CSVPhasePending,
+	)
+	require.NoError(t, op.csvIndexers[0].Add(pending))
+
+	out, err := op.transitionCSVState(*pending)
+	require.Equal(t, ErrRequirementsNotMet, err)
+	require.Equal(t, v1alpha1.CSVReasonRequirementsNotMet, out.Status.Reason)
+
+	// grant the missing permission
+	role := &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: "reader", Namespace: namespace}, Rules: rules}
+	binding := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "reader-binding", Namespace: namespace},
+		RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "reader"},
+		Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "sa"}},
+	}
+	require.NoError(t, roles.Add(role))
+	require.NoError(t, roleBindings.Add(binding))
+	require.NoError(t, op.syncRBAC(binding))
+
+	require.Equal(t, 1, op.csvQueue.Len())
+	key, _ := op.csvQueue.Get()
+	defer op.csvQueue.Done(key)
+	require.Equal(t, "ns/csv1", key)
+
+	obj, exists, err := op.csvIndexers[0].GetByKey(key.(string))
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	out, err = op.transitionCSVState(*obj.(*v1alpha1.ClusterServiceVersion))
+	require.NoError(t, err)
+	require.Equal(t, v1alpha1.CSVPhaseInstallReady, out.Status.Phase)
+	met, _ := v1alpha1.RequirementMet(out.Status.RequirementStatus, schema.GroupVersionKind{Version: "v1", Kind: "ServiceAccount"}, "sa")
+	require.True(t, met)
+}
+
+func TestRequirementStatusCRDSchemaMismatch(t *testing.T) {
+	namespace := "ns"
+
+	installed := func(scope v1beta1.ResourceScope) *v1beta1.CustomResourceDefinition {
+		c := crd("c1", "v1")
+		c.SetName("c1s.c1group")
+		c.Spec.Names.Plural = "c1s"
+		c.Spec.Scope = scope
+		return c
+	}
+
+	tests := []struct {
+		description     string
+		installed       *v1beta1.CustomResourceDefinition
+		desc            v1alpha1.CRDDescription
+		expectedStatus  v1alpha1.StatusReason
+		expectedMessage string
+	}{
+		{
+			description:    "Matches",
+			installed:      installed(v1beta1.NamespaceScoped),
+			desc:           v1alpha1.CRDDescription{Name: "c1s.c1group", Version: "v1", Kind: "c1", Scope: "Namespaced"},
+			expectedStatus: v1alpha1.RequirementStatusReasonPresent,
+		},
+		{
+			description:    "ScopeUnspecified",
+			installed:      installed(v1beta1.ClusterScoped),
+			desc:           v1alpha1.CRDDescription{Name: "c1s.c1group", Version: "v1", Kind: "c1"},
+			expectedStatus: v1alpha1.RequirementStatusReasonPresent,
+		},
+		{
+			description:     "ExpectedNamespacedGotCluster",
+			installed:       installed(v1beta1.ClusterScoped),
+			desc:            v1alpha1.CRDDescription{Name: "c1s.c1group", Version: "v1", Kind: "c1", Scope: "Namespaced"},
+			expectedStatus:  v1alpha1.RequirementStatusReasonPresentSchemaMismatch,
+			expectedMessage: `CustomResourceDefinition c1s.c1group has scope "Cluster", expected "Namespaced"`,
+		},
+		{
+			description:     "ExpectedClusterGotNamespaced",
+			installed:       installed(v1beta1.NamespaceScoped),
+			desc:            v1alpha1.CRDDescription{Name: "c1s.c1group", Version: "v1", Kind: "c1", Scope: "Cluster"},
+			expectedStatus:  v1alpha1.RequirementStatusReasonPresentSchemaMismatch,
+			expectedMessage: `CustomResourceDefinition c1s.c1group has scope "Namespaced", expected "Cluster"`,
+		},
+		{
+			description:     "KindAndScopeDiffer",
+			installed:       installed(v1beta1.NamespaceScoped),
+			desc:            v1alpha1.CRDDescription{Name: "c1s.c1group", Version: "v1", Kind: "C1", Scope: "Cluster"},
+			expectedStatus:  v1alpha1.RequirementStatusReasonPresentSchemaMismatch,
+			expectedMessage: `CustomResourceDefinition c1s.c1group has kind "c1", expected "C1", scope "Namespaced", expected "Cluster"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			op, err := NewFakeOperator(nil, nil, []runtime.Object{tt.installed}, nil, &install.StrategyResolver{}, namespace)
+			require.NoError(t, err)
+
+			csv := csv("csv1",
+				namespace,
+				"",
+				installStrategy("csv1-dep1"),
+				[]*v1beta1.CustomResourceDefinition{},
+				[]*v1beta1.CustomResourceDefinition{},
+				v1alpha1.CSVPhasePending,
+			)
+			csv.Spec.CustomResourceDefinitions.Required = []v1alpha1.CRDDescription{tt.desc}
+
+			met, statuses := op.requirementStatus(csv)
+			require.Equal(t, tt.expectedStatus == v1alpha1.RequirementStatusReasonPresent, met)
+
+			status := requirementStatusFor(statuses, "CustomResourceDefinition", "c1s.c1group")
+			require.NotNil(t, status)
+			require.Equal(t, tt.expectedStatus, status.Status)
+			require.Equal(t, tt.expectedMessage, status.Message)
+			require.Equal(t, string(tt.installed.GetUID()), status.UUID)
+		})
+	}
+}
+
+func TestPermissionStatusOverlyBroad(t *testing.T) {
+	namespace := "ns"
+	tests := []struct {
+		description   string
+		rule          rbacv1.PolicyRule
+		expectedBroad string
+	}{
+		{
+			description:   "AllVerbsOnAllResources",
+			rule:          rbacv1.PolicyRule{Verbs: []string{"*"}, APIGroups: []string{""}, Resources: []string{"*"}},
+			expectedBroad: "verbs, resources",
+		},
+		{
+			description:   "AllVerbsOnAllGroupsAndResources",
+			rule:          rbacv1.PolicyRule{Verbs: []string{"*"}, APIGroups: []string{"*"}, Resources: []string{"*"}},
+			expectedBroad: "verbs, apiGroups, resources",
+		},
+		{
+			description: "AllVerbsOnPods",
+			rule:        rbacv1.PolicyRule{Verbs: []string{"*"}, APIGroups: []string{""}, Resources: []string{"pods"}},
+		},
+		{
+			description: "GetPods",
+			rule:        rbacv1.PolicyRule{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"pods"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			rules := []rbacv1.PolicyRule{tt.rule}
+			op, err := NewFakeOperator(nil, []runtime.Object{serviceAccount("sa", namespace)}, nil, nil, &install.StrategyResolver{}, namespace)
+			require.NoError(t, err)
+
+			// grant exactly what's requested, so that the rule is satisfied
+			roles := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			roleBindings := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			require.NoError(t, roles.Add(&rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: "granted", Namespace: namespace}, Rules: rules}))
+			require.NoError(t, roleBindings.Add(&rbacv1.RoleBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "granted-binding", Namespace: namespace},
+				RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "granted"},
+				Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "sa"}},
+			}))
+			op.roleLister = crbacv1.NewRoleLister(roles)
+			op.roleBindingLister = crbacv1.NewRoleBindingLister(roleBindings)
+
+			csv := csv("csv1",
+				namespace,
+				"",
+				withPermissions(installStrategy("csv1-dep1"), []install.StrategyDeploymentPermissions{{ServiceAccountName: "sa", Rules: rules}}, nil),
+				[]*v1beta1.CustomResourceDefinition{},
+				[]*v1beta1.CustomResourceDefinition{},
+				v1alpha1.CSVPhasePending,
+			)
+
+			met, statuses := op.permissionStatus(csv, newRequirementsSnapshot(op.OpClient), op.logger)
+			require.True(t, met, "overly broad rules shouldn't make the requirement unmet")
+			require.Len(t, statuses, 1)
+			require.Equal(t, v1alpha1.RequirementStatusReasonPresent, statuses[0].Status)
+
+			var broad []v1alpha1.DependentStatus
+			for _, dependent := range statuses[0].Dependents {
+				switch dependent.Status {
+				case v1alpha1.DependentStatusReasonOverlyBroadPermissions:
+					broad = append(broad, dependent)
+				default:
+					require.Equal(t, v1alpha1.DependentStatusReasonSatisfied, dependent.Status)
+				}
+			}
+
+			if tt.expectedBroad == "" {
+				require.Empty(t, broad)
+				return
+			}
+			require.Len(t, broad, 1)
+			require.Contains(t, broad[0].Message, fmt.Sprintf("wildcards for %s;", tt.expectedBroad))
+		})
+	}
+}
+
+func TestRequirementStatusAPIServiceVersionPrefixedName(t *testing.T) {
+	namespace := "ns"
+
+	tests := []struct {
+		description     string
+		desc            v1alpha1.APIServiceDescription
+		expectedMessage string
+	}{
+		{
+			description: "GroupName",
+			desc:        v1alpha1.APIServiceDescription{Name: "a1", Version: "v1", Kind: "a1Kind"},
+		},
+		{
+			description:     "VersionPrefixedName",
+			desc:            v1alpha1.APIServiceDescription{Name: "v1.a1", Version: "v1", Kind: "a1Kind"},
+			expectedMessage: "APIServiceDescription name v1.a1 already includes its version v1; it should be the API group a1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			op, err := NewFakeOperator(nil, nil, nil, []runtime.Object{apiService("a1", "v1", apiregistrationv1.ConditionTrue)}, &install.StrategyResolver{}, namespace)
+			require.NoError(t, err)
+
+			csv := withAPIServices(csv("csv1",
+				namespace,
+				"",
+				installStrategy("csv1-dep1"),
+				[]*v1beta1.CustomResourceDefinition{},
+				[]*v1beta1.CustomResourceDefinition{},
+				v1alpha1.CSVPhasePending,
+			), nil, []v1alpha1.APIServiceDescription{tt.desc})
+
+			met, statuses := op.requirementStatus(csv)
+			require.True(t, met)
+
+			// the version isn't prefixed twice
+			require.Nil(t, requirementStatusFor(statuses, "APIService", "v1.v1.a1"))
+			status := requirementStatusFor(statuses, "APIService", "v1.a1")
+			require.NotNil(t, status)
+			require.Equal(t, v1alpha1.RequirementStatusReasonPresent, status.Status)
+			require.Equal(t, tt.expectedMessage, status.Message)
+
+			keys, err := csvRequirementsIndexFunc(csv)
+			require.NoError(t, err)
+			require.Contains(t, keys, requirementIndexKey("APIService", "v1.a1"))
+		})
+	}
+}
+
+func TestRequirementStatusCRDStatusSubresource(t *testing.T) {
+	namespace := "ns"
+
+	withStatusSubresource := crd("c1", "v1")
+	withStatusSubresource.Spec.Subresources = &v1beta1.CustomResourceSubresources{Status: &v1beta1.CustomResourceSubresourceStatus{}}
+	withScaleSubresource := crd("c1", "v1")
+	withScaleSubresource.Spec.Subresources = &v1beta1.CustomResourceSubresources{Scale: &v1beta1.CustomResourceSubresourceScale{}}
+
+	tests := []struct {
+		description       string
+		installed         *v1beta1.CustomResourceDefinition
+		expectSubresource bool
+		expectedWarning   bool
+	}{
+		{
+			description:       "Expected/Missing",
+			installed:         crd("c1", "v1"),
+			expectSubresource: true,
+			expectedWarning:   true,
+		},
+		{
+			description:       "Expected/OnlyScale",
+			installed:         withScaleSubresource,
+			expectSubresource: true,
+			expectedWarning:   true,
+		},
+		{
+			description:       "Expected/Present",
+			installed:         withStatusSubresource,
+			expectSubresource: true,
+		},
+		{
+			description: "NotExpected/Missing",
+			installed:   crd("c1", "v1"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			op, err := NewFakeOperator(nil, nil, []runtime.Object{tt.installed}, nil, &install.StrategyResolver{}, namespace)
+			require.NoError(t, err)
+
+			csv := csv("csv1",
+				namespace,
+				"",
+				installStrategy("csv1-dep1"),
+				[]*v1beta1.CustomResourceDefinition{},
+				[]*v1beta1.CustomResourceDefinition{},
+				v1alpha1.CSVPhasePending,
+			)
+			csv.Spec.CustomResourceDefinitions.Required = []v1alpha1.CRDDescription{
+				{Name: "c1group", Version: "v1", Kind: "c1", StatusSubresource: tt.expectSubresource},
+			}
+
+			met, statuses := op.requirementStatus(csv)
+			require.True(t, met, "a missing status subresource shouldn't make the requirement unmet")
+
+			status := requirementStatusFor(statuses, "CustomResourceDefinition", "c1group")
+			require.NotNil(t, status)
+			require.Equal(t, v1alpha1.RequirementStatusReasonPresent, status.Status)
+			if !tt.expectedWarning {
+				require.Empty(t, status.Dependents)
+				return
+			}
+			require.Len(t, status.Dependents, 1)
+			require.Equal(t, v1alpha1.DependentStatusReasonMissingStatusSubresource, status.Dependents[0].Status)
+			require.Equal(t, "CustomResourceDefinition c1group doesn't enable the status subresource expected for version v1", status.Dependents[0].Message)
+		})
+	}
+}
+
+func TestRequirementStatusCRDStorageVersion(t *testing.T) {
+	namespace := "ns"
+
+	migrating := crd("c1", "v1")
+	migrating.Spec.Versions = []v1beta1.CustomResourceDefinitionVersion{
+		{Name: "v1", Served: true, Storage: true},
+		{Name: "v2", Served: true},
+	}
+	tests := []struct {
+		description            string
+		installed              *v1beta1.CustomResourceDefinition
+		version                string
+		required               bool
+		expectedStorageVersion string
+		expectedWarning        string
+	}{
+		{
+			description:            "Matches",
+			installed:              migrating,
+			version:                "v1",
+			expectedStorageVersion: "v1",
+		},
+		{
+			description:            "Lags",
+			installed:              migrating,
+			version:                "v2",
+			expectedStorageVersion: "v1",
+			expectedWarning:        "CustomResourceDefinition c1group stores version v1, not version v2 owned by the CSV",
+		},
+		{
+			description: "Required",
+			installed:   migrating,
+			version:     "v2",
+			required:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			op, err := NewFakeOperator(nil, nil, []runtime.Object{tt.installed}, nil, &install.StrategyResolver{}, namespace)
+			require.NoError(t, err)
+
+			csv := csv("csv1",
+				namespace,
+				"",
+				installStrategy("csv1-dep1"),
+				[]*v1beta1.CustomResourceDefinition{},
+				[]*v1beta1.CustomResourceDefinition{},
+				v1alpha1.CSVPhasePending,
+			)
+			desc := []v1alpha1.CRDDescription{{Name: "c1group", Version: tt.version, Kind: "c1"}}
+			if tt.required {
+				csv.Spec.CustomResourceDefinitions.Required = desc
+			} else {
+				csv.Spec.CustomResourceDefinitions.Owned = desc
+			}
+
+			met, statuses := op.requirementStatus(csv)
+			require.True(t, met, "a storage version mismatch shouldn't make the requirement unmet")
+
+			status := requirementStatusFor(statuses, "CustomResourceDefinition", "c1group")
+			require.NotNil(t, status)
+			require.Equal(t, v1alpha1.RequirementStatusReasonPresent, status.Status)
+			require.Equal(t, tt.expectedStorageVersion, status.Details[v1alpha1.RequirementDetailStorageVersion])
+			if tt.expectedWarning == "" {
+				require.Empty(t, status.Dependents)
+				return
+			}
+			require.Len(t, status.Dependents, 1)
+			require.Equal(t, v1alpha1.DependentStatusReasonStorageVersionMismatch, status.Dependents[0].Status)
+			require.Equal(t, tt.expectedWarning, status.Dependents[0].Message)
+		})
+	}
+
+	// CRDs that don't list their versions store their only version
+	unversioned := crd("c1", "v1")
+	unversioned.Spec.Version = "v1"
+	unversioned.Spec.Versions = nil
+	require.Equal(t, "v1", crdStorageVersion(unversioned))
+}
+
+func TestRequirementStatusCRDPrinterColumns(t *testing.T) {
+	namespace := "ns"
+
+	installed := crd("c1", "v1")
+	installed.Spec.AdditionalPrinterColumns = []v1beta1.CustomResourceColumnDefinition{
+		{Name: "Phase", Type: "string", JSONPath: ".status.phase"},
+		{Name: "Age", Type: "date", JSONPath: ".metadata.creationTimestamp"},
+	}
+	tests := []struct {
+		description     string
+		printerColumns  []string
+		expectedWarning string
+	}{
+		{
+			description: "NoneExpected",
+		},
+		{
+			description:    "Present",
+			printerColumns: []string{"Age", "Phase"},
+		},
+		{
+			description:     "Missing",
+			printerColumns:  []string{"Phase", "Ready", "Version"},
+			expectedWarning: "CustomResourceDefinition c1group lacks the printer columns Ready, Version",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			op, err := NewFakeOperator(nil, nil, []runtime.Object{installed}, nil, &install.StrategyResolver{}, namespace)
+			require.NoError(t, err)
+
+			csv := csv("csv1",
+				namespace,
+				"",
+				installStrategy("csv1-dep1"),
+				[]*v1beta1.CustomResourceDefinition{},
+				[]*v1beta1.CustomResourceDefinition{},
+				v1alpha1.CSVPhasePending,
+			)
+			csv.Spec.CustomResourceDefinitions.Owned = []v1alpha1.CRDDescription{{Name: "c1group", Version: "v1", Kind: "c1", PrinterColumns: tt.printerColumns}}
+
+			met, statuses := op.requirementStatus(csv)
+			require.True(t, met, "missing printer columns shouldn't make the requirement unmet")
+
+			status := requirementStatusFor(statuses, "CustomResourceDefinition", "c1group")
+			require.NotNil(t, status)
+			require.Equal(t, v1alpha1.RequirementStatusReasonPresent, status.Status)
+			if tt.expectedWarning == "" {
+				require.Empty(t, status.Dependents)
+				return
+			}
+			require.Len(t, status.Dependents, 1)
+			require.Equal(t, v1alpha1.DependentStatusReasonMissingPrinterColumns, status.Dependents[0].Status)
+			require.Equal(t, tt.expectedWarning, status.Dependents[0].Message)
+		})
+	}
+}
+
+func TestRequirementStatusCRDSchema(t *testing.T) {
+	namespace := "ns"
+
+	schemaless := crd("c1", "v1")
+	validated := crd("c1", "v1")
+	validated.Spec.Versions = append(validated.Spec.Versions, v1beta1.CustomResourceDefinitionVersion{Name: "v2"})
+	validated.Spec.Validation = &v1beta1.CustomResourceValidation{
+		OpenAPIV3Schema: &v1beta1.JSONSchemaProps{Type: "object"},
+	}
+	tests := []struct {
+		description     string
+		installed       *v1beta1.CustomResourceDefinition
+		version         string
+		requiresSchema  bool
+		expectedWarning string
+	}{
+		{
+			description: "SchemalessNotRequired",
+			installed:   schemaless,
+			version:     "v1",
+		},
+		{
+			description:     "Schemaless",
+			installed:       schemaless,
+			version:         "v1",
+			requiresSchema:  true,
+			expectedWarning: "CustomResourceDefinition c1group doesn't define the OpenAPI v3 validation schema expected for version v1",
+		},
+		{
+			description:    "Validated",
+			installed:      validated,
+			version:        "v1",
+			requiresSchema: true,
+		},
+		{
+			description:     "VersionNotServed",
+			installed:       validated,
+			version:         "v2",
+			requiresSchema:  true,
+			expectedWarning: "CustomResourceDefinition c1group doesn't define the OpenAPI v3 validation schema expected for version v2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			op, err := NewFakeOperator(nil, nil, []runtime.Object{tt.installed}, nil, &install.StrategyResolver{}, namespace)
+			require.NoError(t, err)
+
+			csv := csv("csv1",
+				namespace,
+				"",
+				installStrategy("csv1-dep1"),
+				[]*v1beta1.CustomResourceDefinition{},
+				[]*v1beta1.CustomResourceDefinition{},
+				v1alpha1.CSVPhasePending,
+			)
+			csv.Spec.CustomResourceDefinitions.Owned = []v1alpha1.CRDDescription{{Name: "c1group", Version: tt.version, Kind: "c1", RequiresSchema: tt.requiresSchema}}
+
+			met, statuses := op.requirementStatus(csv)
+			require.True(t, met, "a missing schema shouldn't make the requirement unmet")
+
+			status := requirementStatusFor(statuses, "CustomResourceDefinition", "c1group")
+			require.NotNil(t, status)
+			require.Equal(t, v1alpha1.RequirementStatusReasonPresent, status.Status)
+			var warnings []v1alpha1.DependentStatus
+			for _, dependent := range status.Dependents {
+				if dependent.Status == v1alpha1.DependentStatusReasonMissingSchema {
+					warnings = append(warnings, dependent)
+				}
+			}
+			if tt.expectedWarning == "" {
+				require.Empty(t, warnings)
+				return
+			}
+			require.Len(t, warnings, 1)
+			require.Equal(t, tt.expectedWarning, warnings[0].Message)
+		})
+	}
+}
+
+func TestRequirementStatusCRDServedVersions(t *testing.T) {
+	namespace := "ns"
+
+	migrating := crd("c1", "v2")
+	migrating.Spec.Versions = append(migrating.Spec.Versions,
+		v1beta1.CustomResourceDefinitionVersion{Name: "v1", Served: true},
+		v1beta1.CustomResourceDefinitionVersion{Name: "v1alpha1"},
+	)
+	tests := []struct {
+		description     string
+		servedVersions  []string
+		owned           bool
+		expectedStatus  v1alpha1.StatusReason
+		expectedMessage string
+	}{
+		{
+			description:    "NoneDeclared",
+			owned:          true,
+			expectedStatus: v1alpha1.RequirementStatusReasonPresent,
+		},
+		{
+			description:    "AllServed",
+			servedVersions: []string{"v1", "v2"},
+			owned:          true,
+			expectedStatus: v1alpha1.RequirementStatusReasonPresent,
+		},
+		{
+			description:     "OneNotServed",
+			servedVersions:  []string{"v1alpha1", "v1", "v2"},
+			owned:           true,
+			expectedStatus:  v1alpha1.RequirementStatusReasonPresentNotSatisfied,
+			expectedMessage: "CustomResourceDefinition c1group serves versions [v2 v1], missing required versions [v1alpha1]",
+		},
+		{
+			description:     "SeveralNotServed",
+			servedVersions:  []string{"v1alpha1", "v2", "v3"},
+			owned:           true,
+			expectedStatus:  v1alpha1.RequirementStatusReasonPresentNotSatisfied,
+			expectedMessage: "CustomResourceDefinition c1group serves versions [v2 v1], missing required versions [v1alpha1 v3]",
+		},
+		{
+			description:    "Required",
+			servedVersions: []string{"v1alpha1", "v1", "v2"},
+			expectedStatus: v1alpha1.RequirementStatusReasonPresent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			op, err := NewFakeOperator(nil, nil, []runtime.Object{migrating}, nil, &install.StrategyResolver{}, namespace)
+			require.NoError(t, err)
+
+			csv := csv("csv1",
+				namespace,
+				"",
+				installStrategy("csv1-dep1"),
+				[]*v1beta1.CustomResourceDefinition{},
+				[]*v1beta1.CustomResourceDefinition{},
+				v1alpha1.CSVPhasePending,
+			)
+			desc := v1alpha1.CRDDescription{Name: "c1group", Version: "v2", Kind: "c1", ServedVersions: tt.servedVersions}
+			if tt.owned {
+				csv.Spec.CustomResourceDefinitions.Owned = []v1alpha1.CRDDescription{desc}
+			} else {
+				csv.Spec.CustomResourceDefinitions.Required = []v1alpha1.CRDDescription{desc}
+			}
+
+			met, statuses := op.requirementStatus(csv)
+			require.Equal(t, tt.expectedStatus == v1alpha1.RequirementStatusReasonPresent, met)
+
+			status := requirementStatusFor(statuses, "CustomResourceDefinition", "c1group")
+			require.NotNil(t, status)
+			require.Equal(t, tt.expectedStatus, status.Status)
+			require.Equal(t, tt.expectedMessage, status.Message)
+			require.Equal(t, string(migrating.GetUID()), status.UUID)
+		})
+	}
+}
+
+func TestRequirementStatusCRDScope(t *testing.T) {
+	namespace := "ns"
+
+	modes := func(supported ...v1alpha1.InstallModeType) []v1alpha1.InstallMode {
+		installModes := []v1alpha1.InstallMode{}
+		for _, mode := range v1alpha1.InstallModeTypes {
+			installMode := v1alpha1.InstallMode{Type: mode}
+			for _, s := range supported {
+				installMode.Supported = installMode.Supported || s == mode
+			}
+			installModes = append(installModes, installMode)
+		}
+		return installModes
+	}
+	tests := []struct {
+		description     string
+		scope           v1beta1.ResourceScope
+		installModes    []v1alpha1.InstallMode
+		required        bool
+		expectedWarning string
+	}{
+		{
+			description:     "ClusterScopedNamespacedOnly",
+			scope:           v1beta1.ClusterScoped,
+			installModes:    modes(v1alpha1.InstallModeTypeOwnNamespace, v1alpha1.InstallModeTypeSingleNamespace),
+			expectedWarning: "CustomResourceDefinition c1group is Cluster scoped, but the CSV doesn't support the AllNamespaces install mode",
+		},
+		{
+			description:  "ClusterScopedAllNamespaces",
+			scope:        v1beta1.ClusterScoped,
+			installModes: modes(v1alpha1.InstallModeTypeAllNamespaces),
+		},
+		{
+			description:     "NamespacedAllNamespacesOnly",
+			scope:           v1beta1.NamespaceScoped,
+			installModes:    modes(v1alpha1.InstallModeTypeAllNamespaces),
+			expectedWarning: "CustomResourceDefinition c1group is Namespaced, but the CSV only supports the AllNamespaces install mode",
+		},
+		{
+			description:  "NamespacedOwnNamespace",
+			scope:        v1beta1.NamespaceScoped,
+			installModes: modes(v1alpha1.InstallModeTypeOwnNamespace),
+		},
+		{
+			description:  "NamespacedAnyMode",
+			scope:        v1beta1.NamespaceScoped,
+			installModes: modes(v1alpha1.InstallModeTypes...),
+		},
+		{
+			description: "NoInstallModes",
+			scope:       v1beta1.ClusterScoped,
+		},
+		{
+			description:  "Required",
+			scope:        v1beta1.ClusterScoped,
+			installModes: modes(v1alpha1.InstallModeTypeOwnNamespace),
+			required:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			installed := crd("c1", "v1")
+			installed.Spec.Scope = tt.scope
+			op, err := NewFakeOperator(nil, nil, []runtime.Object{installed}, nil, &install.StrategyResolver{}, namespace)
+			require.NoError(t, err)
+
+			csv := csv("csv1",
+				namespace,
+				"",
+				installStrategy("csv1-dep1"),
+				[]*v1beta1.CustomResourceDefinition{},
+				[]*v1beta1.CustomResourceDefinition{},
+				v1alpha1.CSVPhasePending,
+			)
+			csv.Spec.InstallModes = tt.installModes
+			desc := []v1alpha1.CRDDescription{{Name: "c1group", Version: "v1", Kind: "c1"}}
+			if tt.required {
+				csv.Spec.CustomResourceDefinitions.Required = desc
+			} else {
+				csv.Spec.CustomResourceDefinitions.Owned = desc
+			}
+
+			met, statuses := op.requirementStatus(csv)
+			require.True(t, met, "an inconsistent scope shouldn't make the requirement unmet")
+
+			status := requirementStatusFor(statuses, "CustomResourceDefinition", "c1group")
+			require.NotNil(t, status)
+			require.Equal(t, v1alpha1.RequirementStatusReasonPresent, status.Status)
+			if tt.expectedWarning == "" {
+				require.Empty(t, status.Dependents)
+				return
+			}
+			require.Len(t, status.Dependents, 1)
+			require.Equal(t, v1alpha1.DependentStatusReasonInconsistentScope, status.Dependents[0].Status)
+			require.Equal(t, tt.expectedWarning, status.Dependents[0].Message)
+		})
+	}
+}
+
+func TestRequirementStatusRequiredDeployment(t *testing.T) {
+	namespace := "ns"
+
+	withAvailable := func(deployment *appsv1.Deployment, replicas, available int32) *appsv1.Deployment {
+		deployment.SetUID(types.UID(deployment.GetName() + "-uid"))
+		deployment.Spec.Replicas = &replicas
+		deployment.Status.AvailableReplicas = available
+		return deployment
+	}
+
+	tests := []struct {
+		description     string
+		required        v1alpha1.DeploymentRequirement
+		existing        []runtime.Object
+		err             error
+		expectedMet     bool
+		expectedStatus  v1alpha1.RequirementStatus
+		expectedMessage string
+	}{
+		{
+			description:    "Ready",
+			required:       v1alpha1.DeploymentRequirement{Name: "shared"},
+			existing:       []runtime.Object{withAvailable(deployment("shared", namespace), 2, 2)},
+			expectedMet:    true,
+			expectedStatus: v1alpha1.RequirementStatus{Group: "apps", Version: "v1", Kind: "Deployment", Name: "ns/shared", Status: v1alpha1.RequirementStatusReasonPresent, UUID: "shared-uid"},
+		},
+		{
+			description:    "ReadyInOtherNamespace",
+			required:       v1alpha1.DeploymentRequirement{Name: "shared", Namespace: "infra"},
+			existing:       []runtime.Object{withAvailable(deployment("shared", "infra"), 1, 1)},
+			expectedMet:    true,
+			expectedStatus: v1alpha1.RequirementStatus{Group: "apps", Version: "v1", Kind: "Deployment", Name: "infra/shared", Status: v1alpha1.RequirementStatusReasonPresent, UUID: "shared-uid"},
+		},
+		{
+			description: "NotReady",
+			required:    v1alpha1.DeploymentRequirement{Name: "shared"},
+			existing:    []runtime.Object{withAvailable(deployment("shared", namespace), 2, 1)},
+			expectedMet: false,
+			expectedStatus: v1alpha1.RequirementStatus{Group: "apps", Version: "v1", Kind: "Deployment", Name: "ns/shared", Status: v1alpha1.RequirementStatusReasonPresentNotSatisfied, UUID: "shared-uid",
+				Message: "Deployment ns/shared has 1 of 2 replicas available"},
+		},
+		{
+			description:     "Missing",
+			required:        v1alpha1.DeploymentRequirement{Name: "shared"},
+			existing:        []runtime.Object{withAvailable(deployment("shared", "infra"), 1, 1)},
+			expectedMet:     false,
+			expectedStatus:  v1alpha1.RequirementStatus{Group: "apps", Version: "v1", Kind: "Deployment", Name: "ns/shared", Status: v1alpha1.RequirementStatusReasonNotPresent},
+			expectedMessage: "not found",
+		},
+		{
+			description:     "Forbidden",
+			required:        v1alpha1.DeploymentRequirement{Name: "shared"},
+			err:             k8serrors.NewForbidden(schema.GroupResource{Group: "apps", Resource: "deployments"}, "shared", fmt.Errorf("olm can't get deployments")),
+			expectedMet:     false,
+			expectedStatus:  v1alpha1.RequirementStatus{Group: "apps", Version: "v1", Kind: "Deployment", Name: "ns/shared", Status: v1alpha1.RequirementStatusReasonAccessDenied},
+			expectedMessage: "OLM is not permitted",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			op, err := NewFakeOperator(nil, tt.existing, nil, nil, &install.StrategyResolver{}, namespace)
+			require.NoError(t, err)
+			if tt.err != nil {
+				k8sClient, ok := op.OpClient.KubernetesInterface().(*k8sfake.Clientset)
+				require.True(t, ok)
+				k8sClient.PrependReactor("get", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+					return true, nil, tt.err
+				})
+			}
+
+			csv := csv("csv1",
+				namespace,
+				"",
+				installStrategy("csv1-dep1"),
+				[]*v1beta1.CustomResourceDefinition{},
+				[]*v1beta1.CustomResourceDefinition{},
+				v1alpha1.CSVPhasePending,
+			)
+			csv.Spec.RequiredDeployments = []v1alpha1.DeploymentRequirement{tt.required}
+
+			met, statuses := op.requirementStatus(csv)
+			require.Equal(t, tt.expectedMet, met)
+
+			status := requirementStatusFor(statuses, "Deployment", tt.expectedStatus.Name)
+			require.NotNil(t, status)
+			status.LastTransitionTime = metav1.Time{}
+			if tt.expectedMessage != "" {
+				require.Contains(t, status.Message, tt.expectedMessage)
+				status.Message = ""
+			}
+			require.Equal(t, tt.expectedStatus, *status)
+		})
+	}
+}
+
+func TestSyncRequirementInvalidatesGVKCache(t *testing.T) {
+	namespace := "ns"
+	op, err := NewFakeOperator(nil, nil, nil, nil, &install.StrategyResolver{}, namespace)
+	require.NoError(t, err)
+
+	snapshot := op.requirementsSnapshot()
+	require.Error(t, snapshot.isGVKRegistered("a1", "v1", "a1Kind", op.logger))
+
+	// the APIService becomes available while discovery is still cached, so it's only found by the live recheck a
+	// cache miss forces
+	registered := apiService("a1", "v1", apiregistrationv1.ConditionTrue)
+	k8sClient, ok := op.OpClient.KubernetesInterface().(*k8sfake.Clientset)
+	require.True(t, ok)
+	k8sClient.Resources = apiResourcesForObjects([]runtime.Object{registered})
+	rechecks := recheckCount(t, true)
+	snapshot = op.requirementsSnapshot()
+	require.NoError(t, snapshot.isGVKRegistered("a1", "v1", "a1Kind", op.logger))
+	require.Equal(t, rechecks+1, recheckCount(t, true))
+
+	require.NoError(t, op.syncRequirement(registered))
+	snapshot = op.requirementsSnapshot()
+	require.NoError(t, snapshot.isGVKRegistered("a1", "v1", "a1Kind", op.logger))
+}
+
+func TestSetRequirementsClient(t *testing.T) {
+	namespace := "ns"
+	op, err := NewFakeOperator(nil, nil, nil, nil, &install.StrategyResolver{}, namespace)
+	require.NoError(t, err)
+
+	csv := csv("csv1",
+		namespace,
+		"",
+		installStrategy("csv1-dep1"),
+		[]*v1beta1.CustomResourceDefinition{},
+		[]*v1beta1.CustomResourceDefinition{crd("c1", "v1")},
+		v1alpha1.CSVPhasePending,
+	)
+	met, _ := op.requirementStatus(csv)
+	require.False(t, met)
+
+	// only the requirements client can see the CRD, so the requirement is met only if checks use it
+	k8sClient := k8sfake.NewSimpleClientset()
+	k8sClient.Resources = apiResourcesForObjects([]runtime.Object{crd("c1", "v1")})
+	op.SetRequirementsClient(operatorclient.NewClient(k8sClient, apiextensionsfake.NewSimpleClientset(crd("c1", "v1")), apiregistrationfake.NewSimpleClientset()))
+
+	met, statuses := op.requirementStatus(csv)
+	require.True(t, met)
+	status := requirementStatusFor(statuses, "CustomResourceDefinition", "c1group")
+	require.NotNil(t, status)
+	require.Equal(t, v1alpha1.RequirementStatusReasonPresent, status.Status)
+}
+
+func TestRequirementStatusEmptyInstallStrategy(t *testing.T) {
+	namespace := "ns"
+	op, err := NewFakeOperator(nil, nil, nil, nil, &install.StrategyResolver{}, namespace)
+	require.NoError(t, err)
+
+	csv := csv("csv1",
+		namespace,
+		"",
+		v1alpha1.NamedInstallStrategy{},
+		[]*v1beta1.CustomResourceDefinition{},
+		[]*v1beta1.CustomResourceDefinition{},
+		v1alpha1.CSVPhasePending,
+	)
+
+	met, statuses := op.requirementStatus(csv)
+	require.False(t, met)
+
+	status := requirementStatusFor(statuses, v1alpha1.ClusterServiceVersionKind, "csv1")
+	require.NotNil(t, status)
+	require.Equal(t, v1alpha1.GroupName, status.Group)
+	require.Equal(t, v1alpha1.GroupVersion, status.Version)
+	require.Equal(t, v1alpha1.RequirementStatusReasonNotPresent, status.Status)
+	require.Equal(t, "no install strategy defined", status.Message)
+}
+
+func TestRequirementStatusUUIDAfterRecreate(t *testing.T) {
+	namespace := "ns"
+
+	withUID := func(uid types.UID) *v1beta1.CustomResourceDefinition {
+		c := crd("c1", "v1")
+		c.SetUID(uid)
+		return c
+	}
+
+	op, err := NewFakeOperator(nil, nil, []runtime.Object{withUID("uid-1")}, nil, &install.StrategyResolver{}, namespace)
+	require.NoError(t, err)
+	crds := op.OpClient.ApiextensionsV1beta1Interface().ApiextensionsV1beta1().CustomResourceDefinitions()
+
+	pending := csv("csv1",
+		namespace,
+		"",
+		installStrategy("csv1-dep1"),
+		[]*v1beta1.CustomResourceDefinition{},
+		[]*v1beta1.CustomResourceDefinition{crd("c1", "v1")},
+		v1alpha1.CSVPhasePending,
+	)
+	// statuses from a previous check that no longer apply are dropped rather than merged
+	pending.SetRequirementStatus([]v1alpha1.RequirementStatus{
+		{Group: "apiextensions.k8s.io", Version: "v1beta1", Kind: "CustomResourceDefinition", Name: "stale", Status: v1alpha1.RequirementStatusReasonPresent, UUID: "uid-0"},
+	})
+
+	transition := func(in *v1alpha1.ClusterServiceVersion) *v1alpha1.ClusterServiceVersion {
+		out, _ := op.transitionCSVState(*in)
+		require.NotNil(t, out)
+		require.Nil(t, requirementStatusFor(out.Status.RequirementStatus, "CustomResourceDefinition", "stale"))
+		return out
+	}
+
+	out := transition(pending)
+	status := requirementStatusFor(out.Status.RequirementStatus, "CustomResourceDefinition", "c1group")
+	require.NotNil(t, status)
+	require.Equal(t, v1alpha1.RequirementStatusReasonPresent, status.Status)
+	require.Equal(t, "uid-1", status.UUID)
+
+	// deleted
+	require.NoError(t, crds.Delete("c1group", &metav1.DeleteOptions{}))
+	out.SetPhase(v1alpha1.CSVPhasePending, v1alpha1.CSVReasonRequirementsUnknown, "")
+	out = transition(out)
+	status = requirementStatusFor(out.Status.RequirementStatus, "CustomResourceDefinition", "c1group")
+	require.NotNil(t, status)
+	require.Equal(t, v1alpha1.RequirementStatusReasonNotPresent, status.Status)
+	require.Empty(t, status.UUID)
+
+	// recreated
+	_, err = crds.Create(withUID("uid-2"))
+	require.NoError(t, err)
+	out = transition(out)
+	status = requirementStatusFor(out.Status.RequirementStatus, "CustomResourceDefinition", "c1group")
+	require.NotNil(t, status)
+	require.Equal(t, v1alpha1.RequirementStatusReasonPresent, status.Status)
+	require.Equal(t, "uid-2", status.UUID)
+}
+
+func TestRequirementStatusAPIServiceGVKNotFoundMessage(t *testing.T) {
+	namespace := "ns"
+
+	tests := []struct {
+		description     string
+		desc            v1alpha1.APIServiceDescription
+		expectedMessage string
+	}{
+		{
+			description:     "Kind",
+			desc:            v1alpha1.APIServiceDescription{Name: "a1", Version: "v1", Kind: "a1Kind"},
+			expectedMessage: "GroupVersionKind a1/v1, Kind=a1Kind not found in discovery; ensure the APIService serving it is installed and available",
+		},
+		{
+			description:     "NoKind",
+			desc:            v1alpha1.APIServiceDescription{Name: "a1", Version: "v1"},
+			expectedMessage: "GroupVersion a1/v1 not found in discovery; ensure the APIService serving it is installed and available",
+		},
+		{
+			description:     "VersionPrefixedName",
+			desc:            v1alpha1.APIServiceDescription{Name: "v1.a1", Version: "v1", Kind: "a1Kind"},
+			expectedMessage: "APIServiceDescription name v1.a1 already includes its version v1; it should be the API group a1; GroupVersionKind a1/v1, Kind=a1Kind not found in discovery; ensure the APIService serving it is installed and available",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			op, err := NewFakeOperator(nil, nil, nil, nil, &install.StrategyResolver{}, namespace)
+			require.NoError(t, err)
+
+			csv := withAPIServices(csv("csv1",
+				namespace,
+				"",
+				installStrategy("csv1-dep1"),
+				[]*v1beta1.CustomResourceDefinition{},
+				[]*v1beta1.CustomResourceDefinition{},
+				v1alpha1.CSVPhasePending,
+			), nil, []v1alpha1.APIServiceDescription{tt.desc})
+
+			met, statuses := op.requirementStatus(csv)
+			require.False(t, met)
+
+			status := requirementStatusFor(statuses, "APIService", "v1.a1")
+			require.NotNil(t, status)
+			require.Equal(t, v1alpha1.RequirementStatusReasonNotPresent, status.Status)
+			require.Equal(t, tt.expectedMessage, status.Message)
+		})
+	}
+}
+
+func TestRequirementStatusOwnedAPIServiceDeployment(t *testing.T) {
+	namespace := "ns"
+
+	tests := []struct {
+		description       string
+		owned             []v1alpha1.APIServiceDescription
+		required          []v1alpha1.APIServiceDescription
+		expectedMet       bool
+		expectedStatus    v1alpha1.StatusReason
+		expectedDependent *v1alpha1.DependentStatus
+	}{
+		{
+			description:    "Owned/Matching",
+			owned:          []v1alpha1.APIServiceDescription{{Name: "a1", Version: "v1", Kind: "a1Kind", DeploymentName: "csv1-dep1"}},
+			expectedMet:    true,
+			expectedStatus: v1alpha1.RequirementStatusReasonPresent,
+			expectedDependent: &v1alpha1.DependentStatus{
+				Group:   "apps",
+				Version: "v1",
+				Kind:    "Deployment",
+				Status:  v1alpha1.DependentStatusReasonSatisfied,
+				Message: "deployment csv1-dep1 serves APIService v1.a1",
+			},
+		},
+		{
+			description:    "Owned/Mismatched",
+			owned:          []v1alpha1.APIServiceDescription{{Name: "a1", Version: "v1", Kind: "a1Kind", DeploymentName: "csv1-dep2"}},
+			expectedMet:    false,
+			expectedStatus: v1alpha1.RequirementStatusReasonPresentNotSatisfied,
+			expectedDependent: &v1alpha1.DependentStatus{
+				Group:   "apps",
+				Version: "v1",
+				Kind:    "Deployment",
+				Status:  v1alpha1.DependentStatusReasonNotSatisfied,
+				Message: "deployment csv1-dep2 named to serve APIService v1.a1 isn't in the install strategy",
+			},
+		},
+		{
+			description:    "Owned/Unnamed",
+			owned:          []v1alpha1.APIServiceDescription{{Name: "a1", Version: "v1", Kind: "a1Kind"}},
+			expectedMet:    true,
+			expectedStatus: v1alpha1.RequirementStatusReasonPresent,
+		},
+		{
+			description:    "Required/Mismatched",
+			required:       []v1alpha1.APIServiceDescription{{Name: "a1", Version: "v1", Kind: "a1Kind", DeploymentName: "csv1-dep2"}},
+			expectedMet:    true,
+			expectedStatus: v1alpha1.RequirementStatusReasonPresent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			op, err := NewFakeOperator(nil, nil, nil, []runtime.Object{apiService("a1", "v1", apiregistrationv1.ConditionTrue)}, &install.StrategyResolver{}, namespace)
+			require.NoError(t, err)
+
+			csv := withAPIServices(csv("csv1",
+				namespace,
+				"",
+				installStrategy("csv1-dep1"),
+				[]*v1beta1.CustomResourceDefinition{},
+				[]*v1beta1.CustomResourceDefinition{},
+				v1alpha1.CSVPhasePending,
+			), tt.owned, tt.required)
+
+			met, statuses := op.requirementStatus(csv)
+			require.Equal(t, tt.expectedMet, met)
+
+			status := requirementStatusFor(statuses, "APIService", "v1.a1")
+			require.NotNil(t, status)
+			require.Equal(t, tt.expectedStatus, status.Status)
+			if tt.expectedDependent == nil {
+				require.Empty(t, status.Dependents)
+				return
+			}
+			require.Equal(t, []v1alpha1.DependentStatus{*tt.expectedDependent}, status.Dependents)
+		})
+	}
+}
+
+func TestPermissionStatusTargetNamespaces(t *testing.T) {
+	namespace := "ns"
+	rules := []rbacv1.PolicyRule{{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"pods"}}}
+	raw := `rule raw:{"verbs":["get"],"apiGroups":[""],"resources":["pods"]}`
+
+	tests := []struct {
+		description        string
+		targets            string
+		grantedIn          []string
+		expectedMet        bool
+		expectedDependents map[string]v1alpha1.StatusReason
+	}{
+		{
+			description:        "NoTargets",
+			grantedIn:          []string{namespace},
+			expectedMet:        true,
+			expectedDependents: map[string]v1alpha1.StatusReason{raw: v1alpha1.DependentStatusReasonSatisfied},
+		},
+		{
+			description: "TwoTargets/GrantedInAll",
+			targets:     "t2, t1",
+			grantedIn:   []string{namespace, "t1", "t2"},
+			expectedMet: true,
+			expectedDependents: map[string]v1alpha1.StatusReason{
+				"namespace ns: " + raw: v1alpha1.DependentStatusReasonSatisfied,
+				"namespace t1: " + raw: v1alpha1.DependentStatusReasonSatisfied,
+				"namespace t2: " + raw: v1alpha1.DependentStatusReasonSatisfied,
+			},
+		},
+		{
+			description: "TwoTargets/MissingInOne",
+			targets:     "t1,t2",
+			grantedIn:   []string{namespace, "t1"},
+			expectedMet: false,
+			expectedDependents: map[string]v1alpha1.StatusReason{
+				"namespace ns: " + raw: v1alpha1.DependentStatusReasonSatisfied,
+				"namespace t1: " + raw: v1alpha1.DependentStatusReasonSatisfied,
+				"namespace t2: " + raw: v1alpha1.DependentStatusReasonNotSatisfied,
+			},
+		},
+		{
+			description: "TargetsIncludeOwnNamespace",
+			targets:     "ns,t1,,t1",
+			grantedIn:   []string{namespace, "t1"},
+			expectedMet: true,
+			expectedDependents: map[string]v1alpha1.StatusReason{
+				"namespace ns: " + raw: v1alpha1.DependentStatusReasonSatisfied,
+				"namespace t1: " + raw: v1alpha1.DependentStatusReasonSatisfied,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			op, err := NewFakeOperator(nil, []runtime.Object{serviceAccount("sa", namespace)}, nil, nil, &install.StrategyResolver{}, namespace)
+			require.NoError(t, err)
+
+			roles := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			roleBindings := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			op.roleLister = crbacv1.NewRoleLister(roles)
+			op.roleBindingLister = crbacv1.NewRoleBindingLister(roleBindings)
+			for _, ns := range tt.grantedIn {
+				require.NoError(t, roles.Add(&rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: "reader", Namespace: ns}, Rules: rules}))
+				require.NoError(t, roleBindings.Add(&rbacv1.RoleBinding{
+					ObjectMeta: metav1.ObjectMeta{Name: "reader-binding", Namespace: ns},
+					RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "reader"},
+					Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "sa", Namespace: namespace}},
+				}))
+			}
+
+			csv := csv("csv1",
+				namespace,
+				"",
+				withPermissions(installStrategy("csv1-dep1"), []install.StrategyDeploymentPermissions{{ServiceAccountName: "sa", Rules: rules}}, nil),
+				[]*v1beta1.CustomResourceDefinition{},
+				[]*v1beta1.CustomResourceDefinition{},
+				v1alpha1.CSVPhasePending,
+			)
+			if tt.targets != "" {
+				csv.SetAnnotations(map[string]string{TargetNamespacesAnnotationKey: tt.targets})
+			}
+
+			met, statuses := op.permissionStatus(csv, newRequirementsSnapshot(op.OpClient), op.logger)
+			require.Equal(t, tt.expectedMet, met)
+			require.Len(t, statuses, 1)
+
+			dependents := map[string]v1alpha1.StatusReason{}
+			for _, dependent := range statuses[0].Dependents {
+				dependents[dependent.Message] = dependent.Status
+			}
+			require.Equal(t, tt.expectedDependents, dependents)
+		})
+	}
+}
+
+func TestPermissionStatusTargetNamespaceSelector(t *testing.T) {
+	namespace := "ns"
+	rules := []rbacv1.PolicyRule{{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"pods"}}}
+	raw := `rule raw:{"verbs":["get"],"apiGroups":[""],"resources":["pods"]}`
+	labeled := map[string]string{"team": "a"}
+
+	tests := []struct {
+		description        string
+		targets            string
+		selector           string
+		grantedIn          []string
+		expectedMet        bool
+		expectedError      bool
+		expectedDependents map[string]v1alpha1.StatusReason
+	}{
+		{
+			description: "GrantedInMatched",
+			selector:    "team=a",
+			grantedIn:   []string{namespace, "a1", "a2"},
+			expectedMet: true,
+			expectedDependents: map[string]v1alpha1.StatusReason{
+				"namespace ns: " + raw: v1alpha1.DependentStatusReasonSatisfied,
+				"namespace a1: " + raw: v1alpha1.DependentStatusReasonSatisfied,
+				"namespace a2: " + raw: v1alpha1.DependentStatusReasonSatisfied,
+			},
+		},
+		{
+			description: "MissingInMatched",
+			selector:    "team=a",
+			grantedIn:   []string{namespace, "a1", "unlabeled"},
+			expectedMet: false,
+			expectedDependents: map[string]v1alpha1.StatusReason{
+				"namespace ns: " + raw: v1alpha1.DependentStatusReasonSatisfied,
+				"namespace a1: " + raw: v1alpha1.DependentStatusReasonSatisfied,
+				"namespace a2: " + raw: v1alpha1.DependentStatusReasonNotSatisfied,
+			},
+		},
+		{
+			description: "WithTargets",
+			targets:     "unlabeled,a1",
+			selector:    "team=a",
+			grantedIn:   []string{namespace, "a1", "a2", "unlabeled"},
+			expectedMet: true,
+			expectedDependents: map[string]v1alpha1.StatusReason{
+				"namespace ns: " + raw:        v1alpha1.DependentStatusReasonSatisfied,
+				"namespace a1: " + raw:        v1alpha1.DependentStatusReasonSatisfied,
+				"namespace a2: " + raw:        v1alpha1.DependentStatusReasonSatisfied,
+				"namespace unlabeled: " + raw: v1alpha1.DependentStatusReasonSatisfied,
+			},
+		},
+		{
+			description:        "MatchesNone",
+			selector:           "team=b",
+			grantedIn:          []string{namespace},
+			expectedMet:        true,
+			expectedDependents: map[string]v1alpha1.StatusReason{raw: v1alpha1.DependentStatusReasonSatisfied},
+		},
+		{
+			description:        "Invalid",
+			selector:           "team in (a",
+			grantedIn:          []string{namespace},
+			expectedMet:        false,
+			expectedError:      true,
+			expectedDependents: map[string]v1alpha1.StatusReason{raw: v1alpha1.DependentStatusReasonSatisfied},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			op, err := NewFakeOperator(nil, []runtime.Object{serviceAccount("sa", namespace)}, nil, nil, &install.StrategyResolver{}, namespace)
+			require.NoError(t, err)
+
+			namespaces := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			op.namespaceLister = corev1listers.NewNamespaceLister(namespaces)
+			for _, ns := range []*v1.Namespace{
+				{ObjectMeta: metav1.ObjectMeta{Name: namespace}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "a1", Labels: labeled}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "a2", Labels: labeled}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "unlabeled"}},
+			} {
+				require.NoError(t, namespaces.Add(ns))
+			}
+
+			roles := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			roleBindings := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			op.roleLister = crbacv1.NewRoleLister(roles)
+			op.roleBindingLister = crbacv1.NewRoleBindingLister(roleBindings)
+			for _, ns := range tt.grantedIn {
+				require.NoError(t, roles.Add(&rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: "reader", Namespace: ns}, Rules: rules}))
+				require.NoError(t, roleBindings.Add(&rbacv1.RoleBinding{
+					ObjectMeta: metav1.ObjectMeta{Name: "reader-binding", Namespace: ns},
+					RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "reader"},
+					Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "sa", Namespace: namespace}},
+				}))
+			}
+
+			csv := csv("csv1",
+				namespace,
+				"",
+				withPermissions(installStrategy("csv1-dep1"), []install.StrategyDeploymentPermissions{{ServiceAccountName: "sa", Rules: rules}}, nil),
+				[]*v1beta1.CustomResourceDefinition{},
+				[]*v1beta1.CustomResourceDefinition{},
+				v1alpha1.CSVPhasePending,
+			)
+			annotations := map[string]string{TargetNamespaceSelectorAnnotationKey: tt.selector}
+			if tt.targets != "" {
+				annotations[TargetNamespacesAnnotationKey] = tt.targets
+			}
+			csv.SetAnnotations(annotations)
+
+			met, statuses := op.permissionStatus(csv, newRequirementsSnapshot(op.OpClient), op.logger)
+			require.Equal(t, tt.expectedMet, met)
+			if tt.expectedError {
+				// the selector is reported against the CSV, ahead of the permissions checked without it
+				require.Len(t, statuses, 2)
+				require.Equal(t, v1alpha1.ClusterServiceVersionKind, statuses[0].Kind)
+				require.Equal(t, v1alpha1.RequirementStatusReasonNotPresent, statuses[0].Status)
+				require.Contains(t, statuses[0].Message, TargetNamespaceSelectorAnnotationKey)
+				statuses = statuses[1:]
+			}
+			require.Len(t, statuses, 1)
+
+			dependents := map[string]v1alpha1.StatusReason{}
+			for _, dependent := range statuses[0].Dependents {
+				dependents[dependent.Message] = dependent.Status
+			}
+			require.Equal(t, tt.expectedDependents, dependents)
+
+			// namespace changes recheck the CSV, since they may change which namespaces the selector matches
+			require.NoError(t, op.csvIndexers[0].Add(csv))
+			require.NoError(t, op.syncRequirement(&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "a3", Labels: labeled}}))
+			require.Equal(t, 1, op.csvQueue.Len())
+		})
+	}
+}
+
+func TestRequirementStatusTransitionTimes(t *testing.T) {
+	namespace := "ns"
+
+	first := metav1.NewTime(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+	second := metav1.NewTime(first.Add(time.Minute))
+
+	tests := []struct {
+		description            string
+		recordUpdateTimes      bool
+		recreated              bool
+		expectedTransitionTime metav1.Time
+		expectedUpdateTime     metav1.Time
+	}{
+		{
+			description:            "Unchanged",
+			expectedTransitionTime: first,
+		},
+		{
+			description:            "Changed",
+			recreated:              true,
+			expectedTransitionTime: second,
+		},
+		{
+			description:            "Unchanged/RecordUpdateTimes",
+			recordUpdateTimes:      true,
+			expectedTransitionTime: first,
+			expectedUpdateTime:     second,
+		},
+		{
+			description:            "Changed/RecordUpdateTimes",
+			recordUpdateTimes:      true,
+			recreated:              true,
+			expectedTransitionTime: second,
+			expectedUpdateTime:     second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			op, err := NewFakeOperator(nil, nil, nil, nil, &install.StrategyResolver{}, namespace)
+			require.NoError(t, err)
+			op.SetRecordRequirementUpdateTimes(tt.recordUpdateTimes)
+			fakeClock := clock.NewFakeClock(first.Time)
+			op.clock = fakeClock
+
+			csv := csv("csv1",
+				namespace,
+				"",
+				installStrategy("csv1-dep1"),
+				[]*v1beta1.CustomResourceDefinition{},
+				[]*v1beta1.CustomResourceDefinition{crd("c1", "v1")},
+				v1alpha1.CSVPhasePending,
+			)
+
+			_, statuses := op.requirementStatus(csv)
+			status := requirementStatusFor(statuses, "CustomResourceDefinition", "c1group")
+			require.NotNil(t, status)
+			require.Equal(t, v1alpha1.RequirementStatusReasonNotPresent, status.Status)
+			require.Equal(t, first, status.LastTransitionTime)
+			csv.SetRequirementStatus(statuses)
+
+			if tt.recreated {
+				_, err := op.OpClient.ApiextensionsV1beta1Interface().ApiextensionsV1beta1().CustomResourceDefinitions().Create(crd("c1", "v1"))
+				require.NoError(t, err)
+			}
+
+			fakeClock.SetTime(second.Time)
+			_, statuses = op.requirementStatus(csv)
+			status = requirementStatusFor(statuses, "CustomResourceDefinition", "c1group")
+			require.NotNil(t, status)
+			require.Equal(t, tt.expectedTransitionTime, status.LastTransitionTime)
+			require.Equal(t, tt.expectedUpdateTime, status.LastUpdateTime)
+		})
+	}
+}
+
+func TestRequirementStatusAnnotations(t *testing.T) {
+	namespace := "ns"
+	annotations := map[string]string{"example.com/owner": "team-a"}
+
+	tests := []struct {
+		description         string
+		merge               RequirementStatusMergeFunc
+		expectedAnnotations map[string]string
+	}{
+		{
+			description:         "DefaultMerge",
+			expectedAnnotations: annotations,
+		},
+		{
+			description: "CustomMerge",
+			merge: func(previous v1alpha1.RequirementStatus, current *v1alpha1.RequirementStatus) {
+				current.Annotations = map[string]string{"example.com/previous-status": string(previous.Status)}
+			},
+			expectedAnnotations: map[string]string{"example.com/previous-status": string(v1alpha1.RequirementStatusReasonNotPresent)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			op, err := NewFakeOperator(nil, nil, nil, nil, &install.StrategyResolver{}, namespace)
+			require.NoError(t, err)
+			if tt.merge != nil {
+				op.SetRequirementStatusMerge(tt.merge)
+			}
+
+			csv := csv("csv1",
+				namespace,
+				"",
+				installStrategy("csv1-dep1"),
+				[]*v1beta1.CustomResourceDefinition{},
+				[]*v1beta1.CustomResourceDefinition{crd("c1", "v1")},
+				v1alpha1.CSVPhasePending,
+			)
+
+			_, statuses := op.requirementStatus(csv)
+			status := requirementStatusFor(statuses, "CustomResourceDefinition", "c1group")
+			require.NotNil(t, status)
+			require.Empty(t, status.Annotations)
+
+			// another controller annotates the recorded status, then the requirement is met
+			for i := range statuses {
+				statuses[i].Annotations = annotations
+			}
+			csv.SetRequirementStatus(statuses)
+			_, err = op.OpClient.ApiextensionsV1beta1Interface().ApiextensionsV1beta1().CustomResourceDefinitions().Create(crd("c1", "v1"))
+			require.NoError(t, err)
+
+			_, statuses = op.requirementStatus(csv)
+			status = requirementStatusFor(statuses, "CustomResourceDefinition", "c1group")
+			require.NotNil(t, status)
+			require.Equal(t, v1alpha1.RequirementStatusReasonPresent, status.Status)
+			require.Equal(t, tt.expectedAnnotations, status.Annotations)
+		})
+	}
+}
+
+func TestMergeRequirementStatusAnnotations(t *testing.T) {
+	tests := []struct {
+		description string
+		previous    map[string]string
+		current     map[string]string
+		expected    map[string]string
+	}{
+		{
+			description: "NoneRecorded",
+			previous:    nil,
+			current:     nil,
+			expected:    nil,
+		},
+		{
+			description: "Kept",
+			previous:    map[string]string{"a": "1"},
+			current:     nil,
+			expected:    map[string]string{"a": "1"},
+		},
+		{
+			description: "CurrentTakesPrecedence",
+			previous:    map[string]string{"a": "1", "b": "2"},
+			current:     map[string]string{"a": "3"},
+			expected:    map[string]string{"a": "3", "b": "2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			current := v1alpha1.RequirementStatus{Annotations: tt.current}
+			MergeRequirementStatusAnnotations(v1alpha1.RequirementStatus{Annotations: tt.previous}, &current)
+			require.Equal(t, tt.expected, current.Annotations)
+		})
+	}
+}
+
+func TestRequirementStatusPriorityClass(t *testing.T) {
+	namespace := "ns"
+
+	withPriorityClasses := func(strategy v1alpha1.NamedInstallStrategy, classes map[string]string) v1alpha1.NamedInstallStrategy {
+		details := install.StrategyDetailsDeployment{}
+		require.NoError(t, json.Unmarshal(strategy.StrategySpecRaw, &details))
+		template := details.DeploymentSpecs[0]
+		details.DeploymentSpecs = nil
+		deployments := make([]string, 0, len(classes))
+		for deployment := range classes {
+			deployments = append(deployments, deployment)
+		}
+		sort.Strings(deployments)
+		for _, deployment := range deployments {
+			spec := *template.Spec.DeepCopy()
+			spec.Template.Spec.PriorityClassName = classes[deployment]
+			details.DeploymentSpecs = append(details.DeploymentSpecs, install.StrategyDeploymentSpec{Name: deployment, Spec: spec})
+		}
+		raw, err := json.Marshal(details)
+		require.NoError(t, err)
+		strategy.StrategySpecRaw = raw
+		return strategy
+	}
+	priorityClass := func(name string) *schedulingv1beta1.PriorityClass {
+		return &schedulingv1beta1.PriorityClass{ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID(name + "-uid")}, Value: 1000}
+	}
+
+	tests := []struct {
+		description      string
+		classes          map[string]string
+		existing         []runtime.Object
+		expectedMet      bool
+		expectedStatuses []v1alpha1.RequirementStatus
+	}{
+		{
+			description: "NoneRequested",
+			classes:     map[string]string{"dep1": ""},
+			expectedMet: true,
+		},
+		{
+			description: "Present",
+			classes:     map[string]string{"dep1": "high"},
+			existing:    []runtime.Object{priorityClass("high")},
+			expectedMet: true,
+			expectedStatuses: []v1alpha1.RequirementStatus{
+				{Group: "scheduling.k8s.io", Version: "v1beta1", Kind: "PriorityClass", Name: "high", Status: v1alpha1.RequirementStatusReasonPresent, UUID: "high-uid"},
+			},
+		},
+		{
+			description: "Absent",
+			classes:     map[string]string{"dep1": "high", "dep2": "high"},
+			expectedMet: false,
+			expectedStatuses: []v1alpha1.RequirementStatus{
+				{Group: "scheduling.k8s.io", Version: "v1beta1", Kind: "PriorityClass", Name: "high", Status: v1alpha1.RequirementStatusReasonNotPresent, Message: "PriorityClass high requested by deployment dep1, dep2 not found"},
+			},
+		},
+		{
+			description: "OneOfTwoAbsent",
+			classes:     map[string]string{"dep1": "high", "dep2": "low"},
+			existing:    []runtime.Object{priorityClass("low")},
+			expectedMet: false,
+			expectedStatuses: []v1alpha1.RequirementStatus{
+				{Group: "scheduling.k8s.io", Version: "v1beta1", Kind: "PriorityClass", Name: "high", Status: v1alpha1.RequirementStatusReasonNotPresent, Message: "PriorityClass high requested by deployment dep1 not found"},
+				{Group: "scheduling.k8s.io", Version: "v1beta1", Kind: "PriorityClass", Name: "low", Status: v1alpha1.RequirementStatusReasonPresent, UUID: "low-uid"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			op, err := NewFakeOperator(nil, tt.existing, nil, nil, &install.StrategyResolver{}, namespace)
+			require.NoError(t, err)
+
+			csv := csv("csv1",
+				namespace,
+				"",
+				withPriorityClasses(installStrategy("csv1-dep1"), tt.classes),
+				[]*v1beta1.CustomResourceDefinition{},
+				[]*v1beta1.CustomResourceDefinition{},
+				v1alpha1.CSVPhasePending,
+			)
+
+			met, statuses := op.requirementStatus(csv)
+			require.Equal(t, tt.expectedMet, met)
+
+			priorityClassStatuses := []v1alpha1.RequirementStatus{}
+			for _, status := range statuses {
+				if status.Kind == "PriorityClass" {
+					status.LastTransitionTime = metav1.Time{}
+					priorityClassStatuses = append(priorityClassStatuses, status)
+				}
+			}
+			if tt.expectedStatuses == nil {
+				tt.expectedStatuses = []v1alpha1.RequirementStatus{}
+			}
+			require.Equal(t, tt.expectedStatuses, priorityClassStatuses)
+
+			keys, err := csvRequirementsIndexFunc(csv)
+			require.NoError(t, err)
+			for _, status := range tt.expectedStatuses {
+				require.Contains(t, keys, requirementIndexKey("PriorityClass", status.Name))
+			}
+		})
+	}
+}
+
+func TestPermissionStatusUndeclaredServiceAccount(t *testing.T) {
+	namespace := "ns"
+
+	withServiceAccounts := func(strategy v1alpha1.NamedInstallStrategy, serviceAccounts map[string]string) v1alpha1.NamedInstallStrategy {
+		details := install.StrategyDetailsDeployment{}
+		require.NoError(t, json.Unmarshal(strategy.StrategySpecRaw, &details))
+		template := details.DeploymentSpecs[0]
+		details.DeploymentSpecs = nil
+		deployments := make([]string, 0, len(serviceAccounts))
+		for deployment := range serviceAccounts {
+			deployments = append(deployments, deployment)
+		}
+		sort.Strings(deployments)
+		for _, deployment := range deployments {
+			spec := *template.Spec.DeepCopy()
+			spec.Template.Spec.ServiceAccountName = serviceAccounts[deployment]
+			details.DeploymentSpecs = append(details.DeploymentSpecs, install.StrategyDeploymentSpec{Name: deployment, Spec: spec})
+		}
+		raw, err := json.Marshal(details)
+		require.NoError(t, err)
+		strategy.StrategySpecRaw = raw
+		return strategy
+	}
+	existingServiceAccount := func(name string) *v1.ServiceAccount {
+		sa := serviceAccount(name, namespace)
+		sa.SetUID(types.UID(name + "-uid"))
+		return sa
+	}
+	undeclared := func(deployment, sa string) v1alpha1.DependentStatus {
+		return v1alpha1.DependentStatus{
+			Group:   "apps",
+			Version: "v1",
+			Kind:    "Deployment",
+			Status:  v1alpha1.DependentStatusReasonUndeclaredServiceAccount,
+			Message: fmt.Sprintf("deployment %s runs as ServiceAccount %s, which the install strategy declares no permissions for", deployment, sa),
+		}
+	}
+
+	tests := []struct {
+		description      string
+		serviceAccounts  map[string]string
+		permissions      []install.StrategyDeploymentPermissions
+		existing         []runtime.Object
+		expectedMet      bool
+		expectedStatuses []v1alpha1.RequirementStatus
+	}{
+		{
+			description:     "DefaultServiceAccount",
+			serviceAccounts: map[string]string{"dep1": ""},
+			expectedMet:     true,
+		},
+		{
+			description:     "Declared",
+			serviceAccounts: map[string]string{"dep1": "sa"},
+			permissions:     []install.StrategyDeploymentPermissions{{ServiceAccountName: "sa"}},
+			existing:        []runtime.Object{existingServiceAccount("sa")},
+			expectedMet:     true,
+			expectedStatuses: []v1alpha1.RequirementStatus{
+				{Group: "", Version: "v1", Kind: "ServiceAccount", Name: "sa", Status: v1alpha1.RequirementStatusReasonPresent, UUID: "sa-uid", Dependents: []v1alpha1.DependentStatus{}},
+			},
+		},
+		{
+			description:     "UndeclaredPresent",
+			serviceAccounts: map[string]string{"dep1": "runner", "dep2": "runner"},
+			existing:        []runtime.Object{existingServiceAccount("runner")},
+			expectedMet:     true,
+			expectedStatuses: []v1alpha1.RequirementStatus{
+				{
+					Group:      "",
+					Version:    "v1",
+					Kind:       "ServiceAccount",
+					Name:       "runner",
+					Status:     v1alpha1.RequirementStatusReasonPresent,
+					UUID:       "runner-uid",
+					Dependents: []v1alpha1.DependentStatus{undeclared("dep1", "runner"), undeclared("dep2", "runner")},
+				},
+			},
+		},
+		{
+			description:     "UndeclaredAbsent",
+			serviceAccounts: map[string]string{"dep1": "runner"},
+			expectedMet:     false,
+			expectedStatuses: []v1alpha1.RequirementStatus{
+				{
+					Group:      "",
+					Version:    "v1",
+					Kind:       "ServiceAccount",
+					Name:       "runner",
+					Status:     v1alpha1.RequirementStatusReasonNotPresent,
+					Message:    "ServiceAccount runner used by deployment dep1 not found in namespace ns",
+					Dependents: []v1alpha1.DependentStatus{undeclared("dep1", "runner")},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			op, err := NewFakeOperator(nil, tt.existing, nil, nil, &install.StrategyResolver{}, namespace)
+			require.NoError(t, err)
+
+			csv := csv("csv1",
+				namespace,
+				"",
+				withPermissions(withServiceAccounts(installStrategy("csv1-dep1"), tt.serviceAccounts), tt.permissions, nil),
+				[]*v1beta1.CustomResourceDefinition{},
+				[]*v1beta1.CustomResourceDefinition{},
+				v1alpha1.CSVPhasePending,
+			)
+
+			met, statuses := op.requirementStatus(csv)
+			require.Equal(t, tt.expectedMet, met)
+
+			serviceAccountStatuses := []v1alpha1.RequirementStatus{}
+			for _, status := range statuses {
+				if status.Kind == "ServiceAccount" {
+					status.LastTransitionTime = metav1.Time{}
+					serviceAccountStatuses = append(serviceAccountStatuses, status)
+				}
+			}
+			if tt.expectedStatuses == nil {
+				tt.expectedStatuses = []v1alpha1.RequirementStatus{}
+			}
+			require.Equal(t, tt.expectedStatuses, serviceAccountStatuses)
+
+			keys, err := csvRequirementsIndexFunc(csv)
+			require.NoError(t, err)
+			for _, status := range tt.expectedStatuses {
+				require.Contains(t, keys, serviceAccountIndexKey(namespace, status.Name))
+			}
+		})
+	}
+}
+
+func TestRequirementStatusAPIServiceAvailabilityPoll(t *testing.T) {
+	namespace := "ns"
+
+	tests := []struct {
+		description string
+		attempts    int
+		// availableFrom is the get that first finds the APIService available
+		availableFrom  int
+		expectedMet    bool
+		expectedStatus v1alpha1.StatusReason
+		expectedGets   int
+	}{
+		{
+			description:    "NoPoll",
+			attempts:       0,
+			availableFrom:  2,
+			expectedMet:    false,
+			expectedStatus: v1alpha1.RequirementStatusReasonNotPresent,
+			expectedGets:   1,
+		},
+		{
+			description:    "AvailableWithinPoll",
+			attempts:       3,
+			availableFrom:  2,
+			expectedMet:    true,
+			expectedStatus: v1alpha1.RequirementStatusReasonPresent,
+			expectedGets:   2,
+		},
+		{
+			description:    "AvailableOnLastAttempt",
+			attempts:       3,
+			availableFrom:  3,
+			expectedMet:    true,
+			expectedStatus: v1alpha1.RequirementStatusReasonPresent,
+			expectedGets:   3,
+		},
+		{
+			description:    "AvailableAfterPoll",
+			attempts:       3,
+			availableFrom:  4,
+			expectedMet:    false,
+			expectedStatus: v1alpha1.RequirementStatusReasonNotPresent,
+			expectedGets:   3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			op, err := NewFakeOperator(nil, nil, nil, nil, &install.StrategyResolver{}, namespace)
+			require.NoError(t, err)
+			op.SetGVKChecker(&fakeGVKChecker{gvks: []schema.GroupVersionKind{{Group: "a1", Version: "v1", Kind: "a1Kind"}}})
+			op.SetAPIServiceAvailabilityPoll(tt.attempts, time.Millisecond)
+
+			gets := 0
+			regClient, ok := op.OpClient.ApiregistrationV1Interface().(*apiregistrationfake.Clientset)
+			require.True(t, ok)
+			regClient.PrependReactor("get", "apiservices", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				gets++
+				if gets >= tt.availableFrom {
+					return true, apiService("a1", "v1", apiregistrationv1.ConditionTrue), nil
+				}
+				return true, apiService("a1", "v1", apiregistrationv1.ConditionFalse), nil
+			})
+
+			csv := withAPIServices(csv("csv1",
+				namespace,
+				"",
+				installStrategy("csv1-dep1"),
+				[]*v1beta1.CustomResourceDefinition{},
+				[]*v1beta1.CustomResourceDefinition{},
+				v1alpha1.CSVPhasePending,
+			), nil, []v1alpha1.APIServiceDescription{{Name: "a1", Version: "v1", Kind: "a1Kind"}})
+
+			met, statuses := op.requirementStatus(csv)
+			require.Equal(t, tt.expectedMet, met)
+			require.Equal(t, tt.expectedGets, gets)
+
+			status := requirementStatusFor(statuses, "APIService", "v1.a1")
+			require.NotNil(t, status)
+			require.Equal(t, tt.expectedStatus, status.Status)
+		})
+	}
+}
+
+func TestRequirementStatusAPIServiceAvailabilityPollContext(t *testing.T) {
+	namespace := "ns"
+	op, err := NewFakeOperator(nil, nil, nil, []runtime.Object{apiService("a1", "v1", apiregistrationv1.ConditionFalse)}, &install.StrategyResolver{}, namespace)
+	require.NoError(t, err)
+	op.SetGVKChecker(&fakeGVKChecker{gvks: []schema.GroupVersionKind{{Group: "a1", Version: "v1", Kind: "a1Kind"}}})
+	op.SetAPIServiceAvailabilityPoll(3, time.Hour)
+
+	csv := withAPIServices(csv("csv1",
+		namespace,
+		"",
+		installStrategy("csv1-dep1"),
+		[]*v1beta1.CustomResourceDefinition{},
+		[]*v1beta1.CustomResourceDefinition{},
+		v1alpha1.CSVPhasePending,
+	), nil, []v1alpha1.APIServiceDescription{{Name: "a1", Version: "v1", Kind: "a1Kind"}})
+
+	// the check's deadline passes long before the poll interval, so the poll gives up when it does
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	snapshot := op.requirementsSnapshot()
+	snapshot.ctx = ctx
+
+	met, statuses := op.requirementStatusFromSnapshot(csv, snapshot)
+	require.False(t, met)
+	status := requirementStatusFor(statuses, "APIService", "v1.a1")
+	require.NotNil(t, status)
+	require.Equal(t, v1alpha1.RequirementStatusReasonNotPresent, status.Status)
+}
+
+func TestPermissionStatusSubjectAccessReviewFallback(t *testing.T) {
+	namespace := "ns"
+	rules := []rbacv1.PolicyRule{{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"pods"}}}
+
+	tests := []struct {
+		description       string
+		fallback          bool
+		allowed           bool
+		err               error
+		expectedMet       bool
+		expectedDependent v1alpha1.StatusReason
+		expectedReviews   int
+	}{
+		{
+			description:       "FallbackDisabled",
+			fallback:          false,
+			allowed:           true,
+			expectedMet:       false,
+			expectedDependent: v1alpha1.DependentStatusReasonNotSatisfied,
+			expectedReviews:   0,
+		},
+		{
+			description:       "Allowed",
+			fallback:          true,
+			allowed:           true,
+			expectedMet:       true,
+			expectedDependent: v1alpha1.DependentStatusReasonSatisfied,
+			expectedReviews:   1,
+		},
+		{
+			description:       "Denied",
+			fallback:          true,
+			allowed:           false,
+			expectedMet:       false,
+			expectedDependent: v1alpha1.DependentStatusReasonNotSatisfied,
+			expectedReviews:   1,
+		},
+		{
+			description:       "ReviewFailed",
+			fallback:          true,
+			err:               k8serrors.NewForbidden(schema.GroupResource{Group: "authorization.k8s.io", Resource: "subjectaccessreviews"}, "", nil),
+			expectedMet:       false,
+			expectedDependent: v1alpha1.DependentStatusReasonNotSatisfied,
+			expectedReviews:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			op, err := NewFakeOperator(nil, []runtime.Object{serviceAccount("sa", namespace)}, nil, nil, &install.StrategyResolver{}, namespace)
+			require.NoError(t, err)
+			op.SetSubjectAccessReviewFallback(tt.fallback)
+
+			// the listers haven't seen the binding that grants the rule yet
+			op.roleLister = crbacv1.NewRoleLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}))
+			op.roleBindingLister = crbacv1.NewRoleBindingLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}))
+
+			reviews := 0
+			k8sClient, ok := op.OpClient.KubernetesInterface().(*k8sfake.Clientset)
+			require.True(t, ok)
+			k8sClient.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				reviews++
+				review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+				require.Equal(t, "system:serviceaccount:ns:sa", review.Spec.User)
+				require.Equal(t, &authorizationv1.ResourceAttributes{Namespace: namespace, Verb: "get", Resource: "pods"}, review.Spec.ResourceAttributes)
+				review.Status.Allowed = tt.allowed
+				return true, review, tt.err
+			})
+
+			csv := csv("csv1",
+				namespace,
+				"",
+				withPermissions(installStrategy("csv1-dep1"), []install.StrategyDeploymentPermissions{{ServiceAccountName: "sa", Rules: rules}}, nil),
+				[]*v1beta1.CustomResourceDefinition{},
+				[]*v1beta1.CustomResourceDefinition{},
+				v1alpha1.CSVPhasePending,
+			)
+
+			met, statuses := op.permissionStatus(csv, newRequirementsSnapshot(op.OpClient), op.logger)
+			require.Equal(t, tt.expectedMet, met)
+			require.Equal(t, tt.expectedReviews, reviews)
+			require.Len(t, statuses, 1)
+			require.Len(t, statuses[0].Dependents, 1)
+			require.Equal(t, tt.expectedDependent, statuses[0].Dependents[0].Status)
+		})
+	}
+}
+
+func TestPermissionStatusBoundedSize(t *testing.T) {
+	namespace := "ns"
+	op, err := NewFakeOperator(nil, []runtime.Object{serviceAccount("sa", namespace)}, nil, nil, &install.StrategyResolver{}, namespace)
+	require.NoError(t, err)
+	op.roleLister = crbacv1.NewRoleLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}))
+	op.roleBindingLister = crbacv1.NewRoleBindingLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}))
+
+	// a thousand unsatisfied rules, each naming enough resources to be several kilobytes of JSON
+	names := make([]string, 200)
+	for i := range names {
+		names[i] = fmt.Sprintf("resource-name-%d", i)
+	}
+	rules := make([]rbacv1.PolicyRule, 1000)
+	for i := range rules {
+		rules[i] = rbacv1.PolicyRule{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{fmt.Sprintf("resource%d", i)}, ResourceNames: names}
+	}
+	csv := csv("csv1",
+		namespace,
+		"",
+		withPermissions(installStrategy("csv1-dep1"), []install.StrategyDeploymentPermissions{{ServiceAccountName: "sa", Rules: rules}}, nil),
+		[]*v1beta1.CustomResourceDefinition{},
+		[]*v1beta1.CustomResourceDefinition{},
+		v1alpha1.CSVPhasePending,
+	)
+
+	met, statuses := op.permissionStatus(csv, newRequirementsSnapshot(op.OpClient), op.logger)
+	require.False(t, met)
+	require.Len(t, statuses, 1)
+
+	dependents := statuses[0].Dependents
+	require.Len(t, dependents, maxPermissionDependents)
+	for _, dependent := range dependents[:len(dependents)-1] {
+		require.Equal(t, v1alpha1.DependentStatusReasonNotSatisfied, dependent.Status)
+		require.Contains(t, dependent.Message, "... (truncated, sha256:")
+		require.True(t, len(dependent.Message) < 2*maxRuleMessageBytes, "message is %d bytes", len(dependent.Message))
+	}
+	summary := dependents[len(dependents)-1]
+	require.Equal(t, v1alpha1.DependentStatusReasonNotSatisfied, summary.Status)
+	require.Equal(t, "901 more dependents omitted, 901 of them not satisfied", summary.Message)
+
+	serialized, err := json.Marshal(statuses)
+	require.NoError(t, err)
+	require.True(t, len(serialized) < 256*1024, "serialized status is %d bytes", len(serialized))
+}
+
+func TestCapDependentsKeepsUnsatisfied(t *testing.T) {
+	dependents := []v1alpha1.DependentStatus{}
+	for i := 0; i < maxPermissionDependents+10; i++ {
+		status := v1alpha1.DependentStatusReasonSatisfied
+		if i%20 == 0 {
+			status = v1alpha1.DependentStatusReasonNotSatisfied
+		}
+		dependents = append(dependents, v1alpha1.DependentStatus{Status: status, Message: fmt.Sprintf("rule %03d", i)})
+	}
+
+	capped := capDependents(dependents)
+	require.Len(t, capped, maxPermissionDependents)
+
+	unsatisfied := 0
+	for i, dependent := range capped[:len(capped)-1] {
+		if i > 0 {
+			require.True(t, capped[i-1].Message < dependent.Message, "dependents should keep their order")
+		}
+		if dependent.Status == v1alpha1.DependentStatusReasonNotSatisfied {
+			unsatisfied++
+		}
+	}
+	require.Equal(t, 6, unsatisfied)
+	require.Equal(t, v1alpha1.DependentStatusReasonSatisfied, capped[len(capped)-1].Status)
+	require.Equal(t, "11 more dependents omitted, 0 of them not satisfied", capped[len(capped)-1].Message)
+
+	require.Equal(t, dependents[:10], capDependents(dependents[:10]))
+}
@@ -0,0 +1,108 @@
+package olm
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/watch"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+)
+
+// RequirementEvent is a change in the requirement status of a CSV observed by WatchRequirements
+type RequirementEvent struct {
+	// Type is Added when a requirement is first evaluated, Modified when its reason changes, and Deleted when it's no
+	// longer evaluated, such as when its condition stops holding
+	Type watch.EventType `json:"type"`
+	// Status is the requirement's status, or its last status if it was deleted
+	Status v1alpha1.RequirementStatus `json:"status"`
+	// Previous is the requirement's reason before a Modified event
+	Previous v1alpha1.StatusReason `json:"previous,omitempty"`
+	// Met is true if every requirement of the CSV was met by the evaluation the event was observed in
+	Met bool `json:"met"`
+}
+
+// String describes the event on a single line, for instance "CustomResourceDefinition c1.example.com became
+// Present (was NotPresent)"
+func (e RequirementEvent) String() string {
+	requirement := fmt.Sprintf("%s %s", e.Status.Kind, e.Status.Name)
+	switch e.Type {
+	case watch.Modified:
+		return fmt.Sprintf("%s became %s (was %s)", requirement, e.Status.Status, e.Previous)
+	case watch.Deleted:
+		return fmt.Sprintf("%s is no longer required", requirement)
+	default:
+		return fmt.Sprintf("%s is %s", requirement, e.Status.Status)
+	}
+}
+
+// WatchRequirements re-evaluates the requirements of a CSV every interval, with the same checks used to sync CSVs,
+// until ctx is done. The returned channel receives an Added event for each requirement of the first evaluation, then
+// an event for each requirement whose reason changed since the previous evaluation, and is closed once ctx is done.
+// Events are only sent as fast as they're received, so a slow consumer delays the next evaluation rather than missing
+// changes. The CSV isn't modified, and no requirement traces are recorded.
+func (a *Operator) WatchRequirements(ctx context.Context, csv *v1alpha1.ClusterServiceVersion, interval time.Duration) <-chan RequirementEvent {
+	events := make(chan RequirementEvent)
+	csv = csv.DeepCopy()
+
+	go func() {
+		defer close(events)
+
+		ticker := a.clock.NewTicker(interval)
+		defer ticker.Stop()
+
+		var previous []v1alpha1.RequirementStatus
+		for {
+			snapshot := a.requirementsSnapshot()
+			snapshot.untraced = true
+			met, statuses := a.requirementStatusFromSnapshot(csv, snapshot)
+			for _, event := range requirementEvents(previous, statuses, met) {
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+			previous = statuses
+
+			select {
+			case <-ticker.C():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events
+}
+
+// requirementEvents returns the events that turn the previous statuses into the current ones, in the order of the
+// current statuses followed by those of the previous statuses that were deleted
+func requirementEvents(previous, current []v1alpha1.RequirementStatus, met bool) []RequirementEvent {
+	previousByKey := map[string]v1alpha1.RequirementStatus{}
+	for _, status := range previous {
+		previousByKey[requirementStatusKey(status)] = status
+	}
+
+	events := []RequirementEvent{}
+	seen := map[string]struct{}{}
+	for _, status := range current {
+		key := requirementStatusKey(status)
+		seen[key] = struct{}{}
+		old, ok := previousByKey[key]
+		switch {
+		case !ok:
+			events = append(events, RequirementEvent{Type: watch.Added, Status: status, Met: met})
+		case old.Status != status.Status:
+			events = append(events, RequirementEvent{Type: watch.Modified, Status: status, Previous: old.Status, Met: met})
+		}
+	}
+	for _, status := range previous {
+		if _, ok := seen[requirementStatusKey(status)]; !ok {
+			events = append(events, RequirementEvent{Type: watch.Deleted, Status: status, Met: met})
+		}
+	}
+
+	return events
+}
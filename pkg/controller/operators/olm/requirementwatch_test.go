@@ -0,0 +1,137 @@
+package olm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/install"
+)
+
+func TestWatchRequirements(t *testing.T) {
+	namespace := "ns"
+	interval := time.Minute
+
+	op, err := NewFakeOperator(nil, nil, nil, nil, &install.StrategyResolver{}, namespace)
+	require.NoError(t, err)
+	fakeClock := clock.NewFakeClock(time.Now())
+	op.clock = fakeClock
+
+	pending := csv("csv1",
+		namespace,
+		"",
+		installStrategy("csv1-dep1"),
+		[]*v1beta1.CustomResourceDefinition{},
+		[]*v1beta1.CustomResourceDefinition{crd("c1", "v1")},
+		v1alpha1.CSVPhasePending,
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := op.WatchRequirements(ctx, pending, interval)
+
+	next := func() RequirementEvent {
+		select {
+		case event, ok := <-events:
+			require.True(t, ok, "events closed early")
+			return event
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for a requirement event")
+		}
+		return RequirementEvent{}
+	}
+	tick := func() {
+		for !fakeClock.HasWaiters() {
+			time.Sleep(time.Millisecond)
+		}
+		fakeClock.Step(interval)
+	}
+
+	// the first evaluation reports every requirement
+	event := next()
+	require.Equal(t, watch.Added, event.Type)
+	require.Equal(t, "c1group", event.Status.Name)
+	require.Equal(t, v1alpha1.RequirementStatusReasonNotPresent, event.Status.Status)
+	require.False(t, event.Met)
+	require.Equal(t, "CustomResourceDefinition c1group is NotPresent", event.String())
+
+	// the CRD appears
+	_, err = op.OpClient.ApiextensionsV1beta1Interface().ApiextensionsV1beta1().CustomResourceDefinitions().Create(crd("c1", "v1"))
+	require.NoError(t, err)
+	tick()
+	event = next()
+	require.Equal(t, watch.Modified, event.Type)
+	require.Equal(t, v1alpha1.RequirementStatusReasonPresent, event.Status.Status)
+	require.Equal(t, v1alpha1.RequirementStatusReasonNotPresent, event.Previous)
+	require.True(t, event.Met)
+	require.Equal(t, "CustomResourceDefinition c1group became Present (was NotPresent)", event.String())
+
+	// unchanged requirements aren't reported again
+	tick()
+	select {
+	case event := <-events:
+		t.Fatalf("unexpected event %s", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// the channel is closed once the context is done
+	cancel()
+	select {
+	case _, ok := <-events:
+		require.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("events weren't closed")
+	}
+}
+
+func TestRequirementEvents(t *testing.T) {
+	status := func(name string, reason v1alpha1.StatusReason) v1alpha1.RequirementStatus {
+		return v1alpha1.RequirementStatus{Group: "apiextensions.k8s.io", Version: "v1beta1", Kind: "CustomResourceDefinition", Name: name, Status: reason}
+	}
+	tests := []struct {
+		description string
+		previous    []v1alpha1.RequirementStatus
+		current     []v1alpha1.RequirementStatus
+		expected    []string
+	}{
+		{
+			description: "First",
+			current:     []v1alpha1.RequirementStatus{status("a", v1alpha1.RequirementStatusReasonPresent), status("b", v1alpha1.RequirementStatusReasonNotPresent)},
+			expected:    []string{"CustomResourceDefinition a is Present", "CustomResourceDefinition b is NotPresent"},
+		},
+		{
+			description: "Unchanged",
+			previous:    []v1alpha1.RequirementStatus{status("a", v1alpha1.RequirementStatusReasonPresent)},
+			current:     []v1alpha1.RequirementStatus{status("a", v1alpha1.RequirementStatusReasonPresent)},
+			expected:    []string{},
+		},
+		{
+			description: "Changed",
+			previous:    []v1alpha1.RequirementStatus{status("a", v1alpha1.RequirementStatusReasonPresent), status("b", v1alpha1.RequirementStatusReasonNotPresent)},
+			current:     []v1alpha1.RequirementStatus{status("a", v1alpha1.RequirementStatusReasonPresent), status("b", v1alpha1.RequirementStatusReasonPresentSchemaMismatch)},
+			expected:    []string{"CustomResourceDefinition b became PresentSchemaMismatch (was NotPresent)"},
+		},
+		{
+			description: "AddedAndDeleted",
+			previous:    []v1alpha1.RequirementStatus{status("a", v1alpha1.RequirementStatusReasonPresent)},
+			current:     []v1alpha1.RequirementStatus{status("b", v1alpha1.RequirementStatusReasonPresent)},
+			expected:    []string{"CustomResourceDefinition b is Present", "CustomResourceDefinition a is no longer required"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			described := []string{}
+			for _, event := range requirementEvents(tt.previous, tt.current, true) {
+				described = append(described, event.String())
+			}
+			require.Equal(t, tt.expected, described)
+		})
+	}
+}
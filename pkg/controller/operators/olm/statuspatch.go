@@ -0,0 +1,137 @@
+package olm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+)
+
+// jsonPatchOperation is an operation of a JSON patch (RFC 6902)
+type jsonPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// requirementStatusOnlyChange returns true if a transition of a CSV's status leaves its phase, reason, and message as
+// they were, so that it can be written with requirementStatusPatch. As when nothing changed, the status's
+// LastUpdateTime, which is stamped by every transition, isn't worth writing on its own.
+func requirementStatusOnlyChange(old, new *v1alpha1.ClusterServiceVersionStatus) bool {
+	return old.Phase == new.Phase && old.Reason == new.Reason && old.Message == new.Message
+}
+
+// requirementStatusPatch returns a JSON patch that changes the requirement statuses and status conditions recorded in
+// old's status to those of new, or nil if they're the same.
+//
+// Requirement checks order their statuses deterministically, so a recheck usually produces the same requirements in
+// the same order, and only the entries that changed are replaced. Each replaced entry is tested first, so the patch
+// fails rather than overwriting an entry that another update changed since old was read. If the requirements
+// themselves changed, the whole list is replaced.
+func requirementStatusPatch(old, new *v1alpha1.ClusterServiceVersion) ([]byte, error) {
+	var operations []jsonPatchOperation
+
+	oldRequirements, newRequirements := make([]string, len(old.Status.RequirementStatus)), make([]string, len(new.Status.RequirementStatus))
+	for i, status := range old.Status.RequirementStatus {
+		oldRequirements[i] = requirementStatusKey(status)
+	}
+	for i, status := range new.Status.RequirementStatus {
+		newRequirements[i] = requirementStatusKey(status)
+	}
+	requirementOperations, err := listPatchOperations("/status/requirementStatus", oldRequirements, newRequirements, old.Status.RequirementStatus, new.Status.RequirementStatus)
+	if err != nil {
+		return nil, err
+	}
+	operations = append(operations, requirementOperations...)
+
+	oldConditions, newConditions := make([]string, len(old.Status.StatusConditions)), make([]string, len(new.Status.StatusConditions))
+	for i, condition := range old.Status.StatusConditions {
+		oldConditions[i] = string(condition.Type)
+	}
+	for i, condition := range new.Status.StatusConditions {
+		newConditions[i] = string(condition.Type)
+	}
+	conditionOperations, err := listPatchOperations("/status/statusConditions", oldConditions, newConditions, old.Status.StatusConditions, new.Status.StatusConditions)
+	if err != nil {
+		return nil, err
+	}
+	operations = append(operations, conditionOperations...)
+
+	if len(operations) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(operations)
+}
+
+// listPatchOperations returns the operations that change the list at path from old to new, given the keys of their
+// entries. The lists must be slices.
+func listPatchOperations(path string, oldKeys, newKeys []string, old, new interface{}) ([]jsonPatchOperation, error) {
+	oldEntries, err := marshalEntries(old)
+	if err != nil {
+		return nil, err
+	}
+	newEntries, err := marshalEntries(new)
+	if err != nil {
+		return nil, err
+	}
+
+	if !sameKeys(oldKeys, newKeys) {
+		var operations []jsonPatchOperation
+		if len(oldEntries) > 0 {
+			oldList, err := json.Marshal(old)
+			if err != nil {
+				return nil, err
+			}
+			operations = append(operations, jsonPatchOperation{Op: "test", Path: path, Value: oldList})
+		}
+		// the list is omitted when empty, so an emptied list is removed rather than replaced
+		if len(newEntries) == 0 {
+			return append(operations, jsonPatchOperation{Op: "remove", Path: path}), nil
+		}
+		newList, err := json.Marshal(new)
+		if err != nil {
+			return nil, err
+		}
+		return append(operations, jsonPatchOperation{Op: "add", Path: path, Value: newList}), nil
+	}
+
+	var operations []jsonPatchOperation
+	for i := range newEntries {
+		if bytes.Equal(oldEntries[i], newEntries[i]) {
+			continue
+		}
+		entryPath := fmt.Sprintf("%s/%d", path, i)
+		operations = append(operations,
+			jsonPatchOperation{Op: "test", Path: entryPath, Value: oldEntries[i]},
+			jsonPatchOperation{Op: "replace", Path: entryPath, Value: newEntries[i]},
+		)
+	}
+	return operations, nil
+}
+
+// marshalEntries returns the JSON of each entry of a list
+func marshalEntries(list interface{}) ([]json.RawMessage, error) {
+	raw, err := json.Marshal(list)
+	if err != nil {
+		return nil, err
+	}
+	var entries []json.RawMessage
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// sameKeys returns true if two lists have the same keys in the same order
+func sameKeys(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,236 @@
+package olm
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/util/clock"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/client/clientset/versioned/fake"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/install"
+)
+
+// applyJSONPatch applies a JSON patch to the JSON of a CSV
+func applyJSONPatch(t *testing.T, csv *v1alpha1.ClusterServiceVersion, patch []byte) (*v1alpha1.ClusterServiceVersion, error) {
+	decoded, err := jsonpatch.DecodePatch(patch)
+	require.NoError(t, err)
+	doc, err := json.Marshal(csv)
+	require.NoError(t, err)
+	patched, err := decoded.Apply(doc)
+	if err != nil {
+		return nil, err
+	}
+	out := &v1alpha1.ClusterServiceVersion{}
+	require.NoError(t, json.Unmarshal(patched, out))
+	return out, nil
+}
+
+func TestRequirementStatusPatch(t *testing.T) {
+	transitioned := metav1.NewTime(time.Date(2018, 10, 1, 0, 0, 0, 0, time.UTC))
+	later := metav1.NewTime(time.Date(2018, 10, 2, 0, 0, 0, 0, time.UTC))
+	crdStatus := v1alpha1.RequirementStatus{Group: "apiextensions.k8s.io", Version: "v1beta1", Kind: "CustomResourceDefinition", Name: "c1.g1", Status: v1alpha1.RequirementStatusReasonNotPresent, LastTransitionTime: transitioned}
+	saStatus := v1alpha1.RequirementStatus{Group: "", Version: "v1", Kind: "ServiceAccount", Name: "sa", Status: v1alpha1.RequirementStatusReasonPresent, LastTransitionTime: transitioned}
+	condition := v1alpha1.ClusterServiceVersionStatusCondition{Type: v1alpha1.CSVConditionRequirementsMet, Status: corev1.ConditionFalse, Reason: v1alpha1.CSVReasonRequirementsNotMet, LastTransitionTime: transitioned}
+
+	crdPresent := crdStatus
+	crdPresent.Status = v1alpha1.RequirementStatusReasonPresent
+	crdPresent.LastTransitionTime = later
+	met := condition
+	met.Status = corev1.ConditionTrue
+	met.Reason = v1alpha1.CSVReasonRequirementsMet
+	met.LastTransitionTime = later
+
+	tests := []struct {
+		description        string
+		oldStatuses        []v1alpha1.RequirementStatus
+		newStatuses        []v1alpha1.RequirementStatus
+		oldConditions      []v1alpha1.ClusterServiceVersionStatusCondition
+		newConditions      []v1alpha1.ClusterServiceVersionStatusCondition
+		expectedOperations []string
+	}{
+		{
+			description:   "Unchanged",
+			oldStatuses:   []v1alpha1.RequirementStatus{crdStatus, saStatus},
+			newStatuses:   []v1alpha1.RequirementStatus{crdStatus, saStatus},
+			oldConditions: []v1alpha1.ClusterServiceVersionStatusCondition{condition},
+			newConditions: []v1alpha1.ClusterServiceVersionStatusCondition{condition},
+		},
+		{
+			description:        "EntryChanged",
+			oldStatuses:        []v1alpha1.RequirementStatus{crdStatus, saStatus},
+			newStatuses:        []v1alpha1.RequirementStatus{crdPresent, saStatus},
+			expectedOperations: []string{"test /status/requirementStatus/0", "replace /status/requirementStatus/0"},
+		},
+		{
+			description:        "EntryAndConditionChanged",
+			oldStatuses:        []v1alpha1.RequirementStatus{saStatus, crdStatus},
+			newStatuses:        []v1alpha1.RequirementStatus{saStatus, crdPresent},
+			oldConditions:      []v1alpha1.ClusterServiceVersionStatusCondition{condition},
+			newConditions:      []v1alpha1.ClusterServiceVersionStatusCondition{met},
+			expectedOperations: []string{"test /status/requirementStatus/1", "replace /status/requirementStatus/1", "test /status/statusConditions/0", "replace /status/statusConditions/0"},
+		},
+		{
+			description:        "RequirementAdded",
+			oldStatuses:        []v1alpha1.RequirementStatus{crdStatus},
+			newStatuses:        []v1alpha1.RequirementStatus{crdStatus, saStatus},
+			expectedOperations: []string{"test /status/requirementStatus", "add /status/requirementStatus"},
+		},
+		{
+			description:        "NoneRecorded",
+			newStatuses:        []v1alpha1.RequirementStatus{crdStatus, saStatus},
+			newConditions:      []v1alpha1.ClusterServiceVersionStatusCondition{condition},
+			expectedOperations: []string{"add /status/requirementStatus", "add /status/statusConditions"},
+		},
+		{
+			description:        "Emptied",
+			oldStatuses:        []v1alpha1.RequirementStatus{crdStatus, saStatus},
+			expectedOperations: []string{"test /status/requirementStatus", "remove /status/requirementStatus"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			old := csv("csv1", "ns", "", installStrategy("csv1-dep1"), []*v1beta1.CustomResourceDefinition{}, []*v1beta1.CustomResourceDefinition{}, v1alpha1.CSVPhasePending)
+			old.Status.RequirementStatus = tt.oldStatuses
+			old.Status.StatusConditions = tt.oldConditions
+			new := old.DeepCopy()
+			new.Status.RequirementStatus = tt.newStatuses
+			new.Status.StatusConditions = tt.newConditions
+
+			patch, err := requirementStatusPatch(old, new)
+			require.NoError(t, err)
+			if tt.expectedOperations == nil {
+				require.Nil(t, patch)
+				return
+			}
+
+			var operations []jsonPatchOperation
+			require.NoError(t, json.Unmarshal(patch, &operations))
+			actualOperations := []string{}
+			for _, operation := range operations {
+				actualOperations = append(actualOperations, operation.Op+" "+operation.Path)
+			}
+			require.Equal(t, tt.expectedOperations, actualOperations)
+
+			// the patch applies cleanly to the recorded CSV and produces exactly the new one
+			patched, err := applyJSONPatch(t, old, patch)
+			require.NoError(t, err)
+			expected, err := applyJSONPatch(t, new, []byte("[]"))
+			require.NoError(t, err)
+			require.Equal(t, expected, patched)
+		})
+	}
+}
+
+func TestRequirementStatusPatchConflict(t *testing.T) {
+	transitioned := metav1.NewTime(time.Date(2018, 10, 1, 0, 0, 0, 0, time.UTC))
+	old := csv("csv1", "ns", "", installStrategy("csv1-dep1"), []*v1beta1.CustomResourceDefinition{}, []*v1beta1.CustomResourceDefinition{}, v1alpha1.CSVPhasePending)
+	old.Status.RequirementStatus = []v1alpha1.RequirementStatus{
+		{Version: "v1", Kind: "ServiceAccount", Name: "sa1", Status: v1alpha1.RequirementStatusReasonNotPresent, LastTransitionTime: transitioned},
+		{Version: "v1", Kind: "ServiceAccount", Name: "sa2", Status: v1alpha1.RequirementStatusReasonNotPresent, LastTransitionTime: transitioned},
+	}
+	new := old.DeepCopy()
+	new.Status.RequirementStatus[0].Status = v1alpha1.RequirementStatusReasonPresent
+
+	patch, err := requirementStatusPatch(old, new)
+	require.NoError(t, err)
+
+	// another update to a different entry doesn't conflict
+	otherEntry := old.DeepCopy()
+	otherEntry.Status.RequirementStatus[1].Status = v1alpha1.RequirementStatusReasonPresent
+	patched, err := applyJSONPatch(t, otherEntry, patch)
+	require.NoError(t, err)
+	require.Equal(t, v1alpha1.RequirementStatusReasonPresent, patched.Status.RequirementStatus[0].Status)
+	require.Equal(t, v1alpha1.RequirementStatusReasonPresent, patched.Status.RequirementStatus[1].Status)
+
+	// but one to the patched entry does
+	sameEntry := old.DeepCopy()
+	sameEntry.Status.RequirementStatus[0].Message = "changed concurrently"
+	_, err = applyJSONPatch(t, sameEntry, patch)
+	require.Error(t, err)
+}
+
+func TestSyncClusterServiceVersionPatchesRequirementStatus(t *testing.T) {
+	namespace := "ns"
+	pending := csv("csv1",
+		namespace,
+		"",
+		installStrategy("csv1-dep1"),
+		[]*v1beta1.CustomResourceDefinition{},
+		[]*v1beta1.CustomResourceDefinition{crd("c1", "v1")},
+		v1alpha1.CSVPhasePending,
+	)
+	op, err := NewFakeOperator([]runtime.Object{pending}, nil, nil, nil, &install.StrategyResolver{}, namespace)
+	require.NoError(t, err)
+	op.SetRecordRequirementUpdateTimes(true)
+	op.SetPatchRequirementStatus(true)
+	fakeClock := clock.NewFakeClock(time.Date(2018, 10, 1, 0, 0, 0, 0, time.UTC))
+	op.clock = fakeClock
+
+	// the fake clientset only applies strategic merge patches, so status writes go to a clientset that applies JSON
+	// patches to its own tracker
+	scheme := runtime.NewScheme()
+	fake.AddToScheme(scheme)
+	tracker := k8stesting.NewObjectTracker(scheme, serializer.NewCodecFactory(scheme).UniversalDecoder())
+	require.NoError(t, tracker.Add(pending))
+	client := &fake.Clientset{}
+	csvs := v1alpha1.SchemeGroupVersion.WithResource("clusterserviceversions")
+	client.AddReactor("patch", "clusterserviceversions", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patchAction := action.(k8stesting.PatchAction)
+		obj, err := tracker.Get(csvs, patchAction.GetNamespace(), patchAction.GetName())
+		if err != nil {
+			return true, nil, err
+		}
+		patched, err := applyJSONPatch(t, obj.(*v1alpha1.ClusterServiceVersion), patchAction.GetPatch())
+		if err != nil {
+			return true, nil, err
+		}
+		return true, patched, tracker.Update(csvs, patched, patchAction.GetNamespace())
+	})
+	client.AddReactor("*", "*", k8stesting.ObjectReaction(tracker))
+	op.client = client
+
+	statusWrites := func(verb string) int {
+		writes := 0
+		for _, action := range client.Actions() {
+			if action.GetVerb() == verb && action.GetSubresource() == "status" {
+				writes++
+			}
+		}
+		return writes
+	}
+
+	// the first check also sets the reason the CSV is pending, so the whole status is updated
+	require.Equal(t, ErrRequirementsNotMet, op.syncClusterServiceVersion(pending))
+	require.Equal(t, 1, statusWrites("update"))
+	require.Equal(t, 0, statusWrites("patch"))
+
+	synced, err := client.OperatorsV1alpha1().ClusterServiceVersions(namespace).Get("csv1", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.NotEmpty(t, synced.Status.RequirementStatus)
+	require.NotNil(t, synced.GetStatusCondition(v1alpha1.CSVConditionRequirementsMet))
+
+	// rechecking only changes the times recorded in the requirement statuses, so they're patched in
+	fakeClock.Step(time.Second)
+	require.Equal(t, ErrRequirementsNotMet, op.syncClusterServiceVersion(synced))
+	require.Equal(t, 1, statusWrites("update"))
+	require.Equal(t, 1, statusWrites("patch"))
+
+	resynced, err := client.OperatorsV1alpha1().ClusterServiceVersions(namespace).Get("csv1", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, synced.Status.Reason, resynced.Status.Reason)
+	require.Len(t, resynced.Status.RequirementStatus, len(synced.Status.RequirementStatus))
+	for i, status := range resynced.Status.RequirementStatus {
+		require.True(t, synced.Status.RequirementStatus[i].LastUpdateTime.Before(&status.LastUpdateTime), "%s wasn't rechecked", status.Name)
+	}
+}
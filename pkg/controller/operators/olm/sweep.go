@@ -0,0 +1,106 @@
+package olm
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+)
+
+// DefaultRequirementSweepJitter is the default maximum fraction of the requirement sweep interval that is added as
+// random jitter to the wait before each sweep
+const DefaultRequirementSweepJitter = 0.2
+
+// syncTimes records when each CSV was last synced, keyed by namespace/name, so that requirement sweeps can skip CSVs
+// that events have just had re-evaluated
+type syncTimes struct {
+	mu    sync.Mutex
+	times map[string]time.Time
+}
+
+func newSyncTimes() *syncTimes {
+	return &syncTimes{times: map[string]time.Time{}}
+}
+
+// set records that the CSV with the given key was synced at the given time
+func (s *syncTimes) set(key string, t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.times[key] = t
+}
+
+// since returns true if the CSV with the given key was synced at or after the given time
+func (s *syncTimes) since(key string, t time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	synced, ok := s.times[key]
+	return ok && !synced.Before(t)
+}
+
+// forget stops recording the sync time of the CSV with the given key
+func (s *syncTimes) forget(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.times, key)
+}
+
+// SetRequirementSweep sets how often RunRequirementSweep re-evaluates every CSV, regardless of events, to catch changes
+// the informers missed. Each wait is lengthened by up to jitter * interval so that sweeps of several operators don't
+// line up. An interval <= 0, the default, disables sweeps.
+func (a *Operator) SetRequirementSweep(interval time.Duration, jitter float64) {
+	a.requirementSweepInterval = interval
+	a.requirementSweepJitter = jitter
+}
+
+// RunRequirementSweep periodically enqueues every watched CSV for a sync, which re-evaluates the requirements of those
+// that are pending, until stopc is closed. It returns at once if sweeps are disabled.
+func (a *Operator) RunRequirementSweep(stopc <-chan struct{}) {
+	interval := a.requirementSweepInterval
+	if interval <= 0 {
+		return
+	}
+
+	for {
+		// wait.Jitter treats a maxFactor <= 0 as 1, so only call it when jitter is wanted
+		delay := interval
+		if a.requirementSweepJitter > 0 {
+			delay = wait.Jitter(interval, a.requirementSweepJitter)
+		}
+		timer := a.clock.NewTimer(delay)
+		select {
+		case <-stopc:
+			timer.Stop()
+			return
+		case <-timer.C():
+		}
+		a.sweepRequirements(interval)
+	}
+}
+
+// sweepRequirements enqueues the watched CSVs that haven't been synced within the last interval. The CSV queue
+// ignores keys that are already waiting, and CSVs synced since the previous sweep are skipped, so sweeps only add work
+// for CSVs that events haven't had re-evaluated. The CSVs are spread over requeueSpread rather than enqueued at once.
+func (a *Operator) sweepRequirements(interval time.Duration) {
+	cutoff := a.clock.Now().Add(-interval)
+	swept := 0
+	for _, indexer := range a.csvIndexers {
+		for _, obj := range indexer.List() {
+			csv, ok := obj.(*v1alpha1.ClusterServiceVersion)
+			if !ok {
+				continue
+			}
+			key := fmt.Sprintf("%s/%s", csv.GetNamespace(), csv.GetName())
+			if a.csvSyncTimes.since(key, cutoff) {
+				continue
+			}
+			// skip the rate limiter so the sweep isn't delayed by backoff from earlier failures
+			a.addSpread(key)
+			swept++
+		}
+	}
+	log.Debugf("requirement sweep enqueued %d CSVs", swept)
+}
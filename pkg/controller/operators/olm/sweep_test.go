@@ -0,0 +1,154 @@
+package olm
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/install"
+)
+
+func TestRunRequirementSweep(t *testing.T) {
+	namespace := "ns"
+	interval := time.Minute
+
+	tests := []struct {
+		description string
+		jitter      float64
+		// steps are how far the clock is moved before each check of the queue, and expectedKeys what should be
+		// enqueued after each step. The step before the first check waits out any jitter.
+		steps []time.Duration
+		// synced are the CSVs synced by events after each check
+		synced       [][]string
+		expectedKeys [][]string
+	}{
+		{
+			description:  "Cadence",
+			steps:        []time.Duration{interval - time.Second, time.Second, interval / 2, interval / 2},
+			synced:       [][]string{nil, nil, nil, nil},
+			expectedKeys: [][]string{{}, {"ns/csv1", "ns/csv2"}, {}, {"ns/csv1", "ns/csv2"}},
+		},
+		{
+			description:  "SkipsCSVsSyncedByEvents",
+			steps:        []time.Duration{interval, interval, interval},
+			synced:       [][]string{{"csv1"}, nil, nil},
+			expectedKeys: [][]string{{"ns/csv1", "ns/csv2"}, {"ns/csv2"}, {"ns/csv1", "ns/csv2"}},
+		},
+		{
+			description:  "Jitter",
+			jitter:       0.5,
+			steps:        []time.Duration{interval - time.Second, interval/2 + time.Second},
+			synced:       [][]string{nil, nil},
+			expectedKeys: [][]string{{}, {"ns/csv1", "ns/csv2"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			csvs := map[string]*v1alpha1.ClusterServiceVersion{}
+			clientObjs := []runtime.Object{}
+			for _, name := range []string{"csv1", "csv2"} {
+				csvs[name] = csv(name,
+					namespace,
+					"",
+					installStrategy(name+"-dep1"),
+					[]*v1beta1.CustomResourceDefinition{},
+					[]*v1beta1.CustomResourceDefinition{},
+					v1alpha1.CSVPhasePending,
+				)
+				clientObjs = append(clientObjs, csvs[name])
+			}
+			op, err := NewFakeOperator(clientObjs, nil, nil, nil, &install.StrategyResolver{}, namespace)
+			require.NoError(t, err)
+			for _, csv := range csvs {
+				require.NoError(t, op.csvIndexers[0].Add(csv))
+			}
+
+			fakeClock := clock.NewFakeClock(time.Now())
+			op.clock = fakeClock
+			op.SetRequirementSweep(interval, tt.jitter)
+
+			stopc := make(chan struct{})
+			done := make(chan struct{})
+			go func() {
+				op.RunRequirementSweep(stopc)
+				close(done)
+			}()
+			// the sweep loop waits on a new timer once it has finished any sweep it was woken for
+			waitForTimer := func() {
+				for !fakeClock.HasWaiters() {
+					time.Sleep(time.Millisecond)
+				}
+			}
+
+			for i, step := range tt.steps {
+				waitForTimer()
+				fakeClock.Step(step)
+				waitForTimer()
+
+				keys := []string{}
+				for op.csvQueue.Len() > 0 {
+					key, _ := op.csvQueue.Get()
+					keys = append(keys, key.(string))
+					op.csvQueue.Done(key)
+				}
+				require.ElementsMatch(t, tt.expectedKeys[i], keys, "step %d", i)
+
+				for _, name := range tt.synced[i] {
+					op.syncClusterServiceVersion(csvs[name])
+				}
+			}
+
+			close(stopc)
+			<-done
+		})
+	}
+}
+
+func TestRunRequirementSweepDisabled(t *testing.T) {
+	op, err := NewFakeOperator(nil, nil, nil, nil, &install.StrategyResolver{}, "ns")
+	require.NoError(t, err)
+
+	// with no interval set, the sweep returns without waiting to be stopped
+	op.RunRequirementSweep(make(chan struct{}))
+	op.SetRequirementSweep(0, DefaultRequirementSweepJitter)
+	op.RunRequirementSweep(make(chan struct{}))
+}
+
+func TestSweepRequirementsSpreadsRequeues(t *testing.T) {
+	namespace := "ns"
+	spread := time.Second
+
+	op, err := NewFakeOperator(nil, nil, nil, nil, &install.StrategyResolver{}, namespace)
+	require.NoError(t, err)
+	queue := &delayRecordingQueue{RateLimitingInterface: op.csvQueue, delays: map[interface{}]time.Duration{}}
+	op.csvQueue = queue
+	op.requeueSpread = spread
+
+	count := 20
+	for i := 0; i < count; i++ {
+		require.NoError(t, op.csvIndexers[0].Add(csv(fmt.Sprintf("csv%d", i),
+			namespace,
+			"",
+			installStrategy(fmt.Sprintf("csv%d-dep1", i)),
+			[]*v1beta1.CustomResourceDefinition{},
+			[]*v1beta1.CustomResourceDefinition{},
+			v1alpha1.CSVPhasePending,
+		)))
+	}
+	op.sweepRequirements(time.Minute)
+
+	require.Len(t, queue.delays, count)
+	delays := map[time.Duration]struct{}{}
+	for key, delay := range queue.delays {
+		require.True(t, delay >= 0 && delay < spread, "%s delayed by %s", key, delay)
+		delays[delay] = struct{}{}
+	}
+	require.True(t, len(delays) > 1, "all CSVs delayed by the same %v", delays)
+}
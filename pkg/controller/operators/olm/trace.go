@@ -0,0 +1,132 @@
+package olm
+
+import (
+	"fmt"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+)
+
+const (
+	// RequirementsTraceAnnotationKey enables tracing of requirement checks for a CSV when set to "true"
+	RequirementsTraceAnnotationKey = "olm-trace-requirements"
+
+	// requirementsTraceSize is the number of trace entries kept per CSV
+	requirementsTraceSize = 64
+)
+
+// RequirementTraceEntry records the inputs and outcome of a single requirement check
+type RequirementTraceEntry struct {
+	Time    metav1.Time           `json:"time"`
+	Group   string                `json:"group"`
+	Version string                `json:"version"`
+	Kind    string                `json:"kind"`
+	Name    string                `json:"name"`
+	Check   string                `json:"check"`
+	Status  v1alpha1.StatusReason `json:"status"`
+	Message string                `json:"message,omitempty"`
+}
+
+// requirementsTrace is a fixed size ring buffer of RequirementTraceEntries.
+// A nil *requirementsTrace is valid and records nothing.
+type requirementsTrace struct {
+	mu      sync.Mutex
+	entries []RequirementTraceEntry
+	next    int
+	clock   clock.Clock
+}
+
+func newRequirementsTrace(clock clock.Clock) *requirementsTrace {
+	return &requirementsTrace{entries: make([]RequirementTraceEntry, 0, requirementsTraceSize), clock: clock}
+}
+
+// record adds an entry for the given requirement, overwriting the oldest entry when full
+func (t *requirementsTrace) record(status v1alpha1.RequirementStatus, check string, args ...interface{}) {
+	if t == nil {
+		return
+	}
+
+	entry := RequirementTraceEntry{
+		Time:    metav1.NewTime(t.clock.Now()),
+		Group:   status.Group,
+		Version: status.Version,
+		Kind:    status.Kind,
+		Name:    status.Name,
+		Check:   fmt.Sprintf(check, args...),
+		Status:  status.Status,
+		Message: status.Message,
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.entries) < cap(t.entries) {
+		t.entries = append(t.entries, entry)
+	} else {
+		t.entries[t.next] = entry
+	}
+	t.next = (t.next + 1) % cap(t.entries)
+}
+
+// list returns the recorded entries from oldest to newest
+func (t *requirementsTrace) list() []RequirementTraceEntry {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entries := make([]RequirementTraceEntry, 0, len(t.entries))
+	if len(t.entries) < cap(t.entries) {
+		return append(entries, t.entries...)
+	}
+	entries = append(entries, t.entries[t.next:]...)
+	return append(entries, t.entries[:t.next]...)
+}
+
+// requirementsTraceFor returns the trace for a CSV, or nil if tracing isn't enabled by the CSV's annotations
+func (a *Operator) requirementsTraceFor(csv *v1alpha1.ClusterServiceVersion) *requirementsTrace {
+	key := fmt.Sprintf("%s/%s", csv.GetNamespace(), csv.GetName())
+
+	a.tracesMu.Lock()
+	defer a.tracesMu.Unlock()
+	if csv.GetAnnotations()[RequirementsTraceAnnotationKey] != "true" {
+		delete(a.traces, key)
+		return nil
+	}
+
+	trace, ok := a.traces[key]
+	if !ok {
+		trace = newRequirementsTrace(a.clock)
+		a.traces[key] = trace
+	}
+	return trace
+}
+
+// forgetTrace drops the recorded trace of the CSV with the given key
+func (a *Operator) forgetTrace(key string) {
+	a.tracesMu.Lock()
+	defer a.tracesMu.Unlock()
+	delete(a.traces, key)
+}
+
+// snapshotTraceFor returns the trace that checks made with the given snapshot record to, or nil if the snapshot is
+// untraced
+func (a *Operator) snapshotTraceFor(csv *v1alpha1.ClusterServiceVersion, snapshot *requirementsSnapshot) *requirementsTrace {
+	if snapshot.untraced {
+		return nil
+	}
+	return a.requirementsTraceFor(csv)
+}
+
+// RequirementsTrace returns the recorded requirement checks for a CSV from oldest to newest.
+// Checks are only recorded for CSVs annotated with RequirementsTraceAnnotationKey.
+func (a *Operator) RequirementsTrace(namespace, name string) []RequirementTraceEntry {
+	a.tracesMu.Lock()
+	trace := a.traces[fmt.Sprintf("%s/%s", namespace, name)]
+	a.tracesMu.Unlock()
+
+	return trace.list()
+}
@@ -0,0 +1,60 @@
+package olm
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/install"
+)
+
+func TestRequirementsTraceWraps(t *testing.T) {
+	start := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	fakeClock := clock.NewFakeClock(start)
+	trace := newRequirementsTrace(fakeClock)
+	total := requirementsTraceSize + 3
+	for i := 0; i < total; i++ {
+		trace.record(v1alpha1.RequirementStatus{Name: fmt.Sprintf("r%d", i)}, "check")
+		fakeClock.Step(time.Second)
+	}
+
+	entries := trace.list()
+	require.Len(t, entries, requirementsTraceSize)
+	require.Equal(t, fmt.Sprintf("r%d", total-requirementsTraceSize), entries[0].Name)
+	require.Equal(t, fmt.Sprintf("r%d", total-1), entries[len(entries)-1].Name)
+	// entries are stamped with the trace's clock
+	require.True(t, entries[0].Time.Equal(&metav1.Time{Time: start.Add(time.Duration(total-requirementsTraceSize) * time.Second)}))
+
+	var disabled *requirementsTrace
+	disabled.record(v1alpha1.RequirementStatus{}, "check")
+	require.Nil(t, disabled.list())
+}
+
+func TestRequirementsTraceForgottenOnDelete(t *testing.T) {
+	namespace := "ns"
+	traced := csv("csv1",
+		namespace,
+		"",
+		installStrategy("csv1-dep1"),
+		[]*v1beta1.CustomResourceDefinition{},
+		[]*v1beta1.CustomResourceDefinition{crd("c1", "v1")},
+		v1alpha1.CSVPhasePending,
+	)
+	traced.SetAnnotations(map[string]string{RequirementsTraceAnnotationKey: "true"})
+
+	op, err := NewFakeOperator(nil, nil, nil, nil, &install.StrategyResolver{}, namespace)
+	require.NoError(t, err)
+	met, _ := op.requirementStatus(traced)
+	require.False(t, met)
+	require.NotEmpty(t, op.RequirementsTrace(namespace, "csv1"))
+
+	op.handleDeletedCSV(traced)
+	require.Empty(t, op.RequirementsTrace(namespace, "csv1"))
+	require.Empty(t, op.traces)
+}
@@ -0,0 +1,66 @@
+package olm
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+)
+
+// unmetRequirement is a requirement kind and the reason it isn't met
+type unmetRequirement struct {
+	kind   string
+	reason v1alpha1.StatusReason
+}
+
+// unmetRequirementsTracker keeps a gauge of how many CSVs have an unmet requirement of each kind and reason.
+// Each CSV is counted at most once per kind and reason, no matter how many of its requirements share them.
+type unmetRequirementsTracker struct {
+	mu    sync.Mutex
+	gauge *prometheus.GaugeVec
+	csvs  map[string]map[unmetRequirement]struct{}
+}
+
+func newUnmetRequirementsTracker(gauge *prometheus.GaugeVec) *unmetRequirementsTracker {
+	return &unmetRequirementsTracker{
+		gauge: gauge,
+		csvs:  map[string]map[unmetRequirement]struct{}{},
+	}
+}
+
+// set replaces the unmet requirements counted for the CSV with the given key with those found in statuses
+func (t *unmetRequirementsTracker) set(key string, statuses []v1alpha1.RequirementStatus) {
+	unmet := map[unmetRequirement]struct{}{}
+	for _, status := range statuses {
+		if status.Status == "" || v1alpha1.ReasonSeverity(status.Status) != v1alpha1.SeverityBlocking {
+			continue
+		}
+		unmet[unmetRequirement{kind: status.Kind, reason: status.Status}] = struct{}{}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	previous := t.csvs[key]
+	for r := range previous {
+		if _, ok := unmet[r]; !ok {
+			t.gauge.WithLabelValues(r.kind, string(r.reason)).Dec()
+		}
+	}
+	for r := range unmet {
+		if _, ok := previous[r]; !ok {
+			t.gauge.WithLabelValues(r.kind, string(r.reason)).Inc()
+		}
+	}
+
+	if len(unmet) == 0 {
+		delete(t.csvs, key)
+		return
+	}
+	t.csvs[key] = unmet
+}
+
+// forget stops counting the CSV with the given key
+func (t *unmetRequirementsTracker) forget(key string) {
+	t.set(key, nil)
+}
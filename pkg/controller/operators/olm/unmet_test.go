@@ -0,0 +1,91 @@
+package olm
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/install"
+)
+
+func gaugeValue(t *testing.T, gauge *prometheus.GaugeVec, kind string, reason v1alpha1.StatusReason) float64 {
+	metric := &dto.Metric{}
+	require.NoError(t, gauge.WithLabelValues(kind, string(reason)).Write(metric))
+	return metric.GetGauge().GetValue()
+}
+
+func TestUnmetRequirementsGauge(t *testing.T) {
+	namespace := "ns"
+	csv1 := csv("csv1",
+		namespace,
+		"",
+		installStrategy("csv1-dep1"),
+		[]*v1beta1.CustomResourceDefinition{},
+		[]*v1beta1.CustomResourceDefinition{crd("c1", "v1"), crd("c2", "v1")},
+		v1alpha1.CSVPhasePending,
+	)
+	csv2 := csv("csv2",
+		namespace,
+		"",
+		installStrategy("csv2-dep1"),
+		[]*v1beta1.CustomResourceDefinition{},
+		[]*v1beta1.CustomResourceDefinition{crd("c1", "v1")},
+		v1alpha1.CSVPhasePending,
+	)
+
+	op, err := NewFakeOperator([]runtime.Object{csv1, csv2}, nil, nil, nil, &install.StrategyResolver{}, namespace)
+	require.NoError(t, err)
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_unmet_requirements"}, []string{"kind", "reason"})
+	op.unmetRequirements = newUnmetRequirementsTracker(gauge)
+
+	// both CSVs are counted once, even though csv1 is missing two CRDs
+	for _, csv := range []*v1alpha1.ClusterServiceVersion{csv1, csv2} {
+		out, err := op.transitionCSVState(*csv)
+		require.Equal(t, ErrRequirementsNotMet, err)
+		require.Equal(t, v1alpha1.CSVPhasePending, out.Status.Phase)
+	}
+	require.Equal(t, float64(2), gaugeValue(t, gauge, "CustomResourceDefinition", v1alpha1.RequirementStatusReasonNotPresent))
+
+	// re-checking an unmet CSV doesn't count it again
+	_, err = op.transitionCSVState(*csv1)
+	require.Equal(t, ErrRequirementsNotMet, err)
+	require.Equal(t, float64(2), gaugeValue(t, gauge, "CustomResourceDefinition", v1alpha1.RequirementStatusReasonNotPresent))
+
+	// csv2 becomes met once its CRD exists
+	_, err = op.OpClient.ApiextensionsV1beta1Interface().ApiextensionsV1beta1().CustomResourceDefinitions().Create(crd("c1", "v1"))
+	require.NoError(t, err)
+	out, err := op.transitionCSVState(*csv2)
+	require.NoError(t, err)
+	require.Equal(t, v1alpha1.CSVPhaseInstallReady, out.Status.Phase)
+	require.Equal(t, float64(1), gaugeValue(t, gauge, "CustomResourceDefinition", v1alpha1.RequirementStatusReasonNotPresent))
+
+	// csv1 is still missing c2 until it's deleted
+	_, err = op.transitionCSVState(*csv1)
+	require.Equal(t, ErrRequirementsNotMet, err)
+	require.Equal(t, float64(1), gaugeValue(t, gauge, "CustomResourceDefinition", v1alpha1.RequirementStatusReasonNotPresent))
+	op.handleDeletedCSV(csv1)
+	require.Equal(t, float64(0), gaugeValue(t, gauge, "CustomResourceDefinition", v1alpha1.RequirementStatusReasonNotPresent))
+
+	// forgetting a CSV that isn't counted is a no-op
+	op.handleDeletedCSV(csv2)
+	require.Equal(t, float64(0), gaugeValue(t, gauge, "CustomResourceDefinition", v1alpha1.RequirementStatusReasonNotPresent))
+}
+
+func TestUnmetRequirementsGaugeIgnoresWarnings(t *testing.T) {
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_unmet_requirements"}, []string{"kind", "reason"})
+	tracker := newUnmetRequirementsTracker(gauge)
+
+	tracker.set("ns/csv", []v1alpha1.RequirementStatus{
+		{Kind: "CustomResourceDefinition", Name: "c1", Status: v1alpha1.DependentStatusReasonMissingPrinterColumns},
+		{Kind: "ServiceAccount", Name: "sa", Status: v1alpha1.DependentStatusReasonOverlyBroadPermissions},
+		{Kind: "CustomResourceDefinition", Name: "c2", Status: v1alpha1.RequirementStatusReasonNotPresent},
+	})
+	require.Equal(t, float64(0), gaugeValue(t, gauge, "CustomResourceDefinition", v1alpha1.DependentStatusReasonMissingPrinterColumns))
+	require.Equal(t, float64(0), gaugeValue(t, gauge, "ServiceAccount", v1alpha1.DependentStatusReasonOverlyBroadPermissions))
+	require.Equal(t, float64(1), gaugeValue(t, gauge, "CustomResourceDefinition", v1alpha1.RequirementStatusReasonNotPresent))
+}
@@ -0,0 +1,80 @@
+package olm
+
+import (
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+)
+
+// UpgradeRequirements is the requirement status of a CSV that would replace another, evaluated against the current
+// state of the cluster
+type UpgradeRequirements struct {
+	// Safe is true if every requirement of the next CSV is met
+	Safe bool `json:"safe"`
+	// Statuses are the requirement statuses of the next CSV
+	Statuses []v1alpha1.RequirementStatus `json:"statuses"`
+	// Added are the statuses of the requirements the next CSV has and the current CSV doesn't. A requirement both
+	// CSVs have is included if the next CSV adds dependents to it, such as new permission rules for a ServiceAccount,
+	// and carries only the added dependents.
+	Added []v1alpha1.RequirementStatus `json:"added"`
+}
+
+// RequirementsForUpgrade evaluates the requirements of next, a CSV that would replace current, with the same checks
+// used to sync CSVs. Neither CSV is modified, and no requirement traces are recorded.
+func (a *Operator) RequirementsForUpgrade(current, next *v1alpha1.ClusterServiceVersion) *UpgradeRequirements {
+	snapshot := a.requirementsSnapshot()
+	snapshot.untraced = true
+
+	safe, statuses := a.requirementStatusFromSnapshot(next.DeepCopy(), snapshot)
+	upgrade := &UpgradeRequirements{
+		Safe:     safe,
+		Statuses: statuses,
+		Added:    []v1alpha1.RequirementStatus{},
+	}
+
+	existing := map[string]v1alpha1.RequirementStatus{}
+	if current != nil {
+		_, currentStatuses := a.requirementStatusFromSnapshot(current.DeepCopy(), snapshot)
+		for _, status := range currentStatuses {
+			existing[requirementStatusKey(status)] = status
+		}
+	}
+	for _, status := range statuses {
+		old, ok := existing[requirementStatusKey(status)]
+		if !ok {
+			upgrade.Added = append(upgrade.Added, status)
+			continue
+		}
+		if dependents := addedDependents(old.Dependents, status.Dependents); len(dependents) > 0 {
+			added := status
+			added.Dependents = dependents
+			upgrade.Added = append(upgrade.Added, added)
+		}
+	}
+
+	return upgrade
+}
+
+// requirementStatusKey identifies the requirement a status is for
+func requirementStatusKey(status v1alpha1.RequirementStatus) string {
+	return status.Group + "/" + status.Version + "/" + status.Kind + "/" + status.Name
+}
+
+// addedDependents returns the dependents in next that aren't in current
+func addedDependents(current, next []v1alpha1.DependentStatus) []v1alpha1.DependentStatus {
+	seen := map[string]struct{}{}
+	for _, dependent := range current {
+		seen[dependentStatusKey(dependent)] = struct{}{}
+	}
+	var added []v1alpha1.DependentStatus
+	for _, dependent := range next {
+		if _, ok := seen[dependentStatusKey(dependent)]; !ok {
+			added = append(added, dependent)
+		}
+	}
+	return added
+}
+
+// dependentStatusKey identifies the dependent a status is for. Dependents are identified by their message, since the
+// message of a permission dependent is the rule it checks.
+func dependentStatusKey(dependent v1alpha1.DependentStatus) string {
+	return dependent.Group + "/" + dependent.Version + "/" + dependent.Kind + "/" + dependent.Message
+}
@@ -0,0 +1,158 @@
+package olm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	crbacv1 "k8s.io/client-go/listers/rbac/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/install"
+)
+
+func TestRequirementsForUpgrade(t *testing.T) {
+	namespace := "ns"
+	getPods := rbacv1.PolicyRule{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"pods"}}
+	listConfigMaps := rbacv1.PolicyRule{Verbs: []string{"list"}, APIGroups: []string{""}, Resources: []string{"configmaps"}}
+
+	// added describes an expected entry of UpgradeRequirements.Added
+	type added struct {
+		kind       string
+		name       string
+		status     v1alpha1.StatusReason
+		dependents []v1alpha1.StatusReason
+	}
+
+	tests := []struct {
+		description   string
+		existingCRDs  []string
+		granted       []rbacv1.PolicyRule
+		nextCRDs      []string
+		nextRules     []rbacv1.PolicyRule
+		expectedSafe  bool
+		expectedAdded []added
+	}{
+		{
+			description:  "Unchanged",
+			existingCRDs: []string{"c1"},
+			granted:      []rbacv1.PolicyRule{getPods},
+			nextCRDs:     []string{"c1"},
+			nextRules:    []rbacv1.PolicyRule{getPods},
+			expectedSafe: true,
+		},
+		{
+			description:   "AddsPresentCRD",
+			existingCRDs:  []string{"c1", "c2"},
+			granted:       []rbacv1.PolicyRule{getPods},
+			nextCRDs:      []string{"c1", "c2"},
+			nextRules:     []rbacv1.PolicyRule{getPods},
+			expectedSafe:  true,
+			expectedAdded: []added{{kind: "CustomResourceDefinition", name: "c2group", status: v1alpha1.RequirementStatusReasonPresent}},
+		},
+		{
+			description:   "AddsAbsentCRD",
+			existingCRDs:  []string{"c1"},
+			granted:       []rbacv1.PolicyRule{getPods},
+			nextCRDs:      []string{"c1", "c2"},
+			nextRules:     []rbacv1.PolicyRule{getPods},
+			expectedSafe:  false,
+			expectedAdded: []added{{kind: "CustomResourceDefinition", name: "c2group", status: v1alpha1.RequirementStatusReasonNotPresent}},
+		},
+		{
+			description:  "AddsGrantedPermission",
+			existingCRDs: []string{"c1"},
+			granted:      []rbacv1.PolicyRule{getPods, listConfigMaps},
+			nextCRDs:     []string{"c1"},
+			nextRules:    []rbacv1.PolicyRule{getPods, listConfigMaps},
+			expectedSafe: true,
+			expectedAdded: []added{{
+				kind:       "ServiceAccount",
+				name:       "sa",
+				status:     v1alpha1.RequirementStatusReasonPresent,
+				dependents: []v1alpha1.StatusReason{v1alpha1.DependentStatusReasonSatisfied},
+			}},
+		},
+		{
+			description:  "AddsUngrantedPermission",
+			existingCRDs: []string{"c1"},
+			granted:      []rbacv1.PolicyRule{getPods},
+			nextCRDs:     []string{"c1"},
+			nextRules:    []rbacv1.PolicyRule{getPods, listConfigMaps},
+			expectedSafe: false,
+			expectedAdded: []added{{
+				kind:       "ServiceAccount",
+				name:       "sa",
+				status:     v1alpha1.RequirementStatusReasonPresentNotSatisfied,
+				dependents: []v1alpha1.StatusReason{v1alpha1.DependentStatusReasonNotSatisfied},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			crds := func(names []string) []*v1beta1.CustomResourceDefinition {
+				crds := []*v1beta1.CustomResourceDefinition{}
+				for _, name := range names {
+					crds = append(crds, crd(name, "v1"))
+				}
+				return crds
+			}
+			strategy := func(rules []rbacv1.PolicyRule) v1alpha1.NamedInstallStrategy {
+				return withPermissions(installStrategy("dep1"), []install.StrategyDeploymentPermissions{{ServiceAccountName: "sa", Rules: rules}}, nil)
+			}
+			current := csv("csv1", namespace, "", strategy([]rbacv1.PolicyRule{getPods}), []*v1beta1.CustomResourceDefinition{}, crds([]string{"c1"}), v1alpha1.CSVPhaseSucceeded)
+			current.SetAnnotations(map[string]string{RequirementsTraceAnnotationKey: "true"})
+			next := csv("csv2", namespace, "csv1", strategy(tt.nextRules), []*v1beta1.CustomResourceDefinition{}, crds(tt.nextCRDs), v1alpha1.CSVPhaseNone)
+			next.SetAnnotations(map[string]string{RequirementsTraceAnnotationKey: "true"})
+
+			extObjs := []runtime.Object{}
+			for _, crd := range crds(tt.existingCRDs) {
+				extObjs = append(extObjs, crd)
+			}
+			op, err := NewFakeOperator(nil, []runtime.Object{serviceAccount("sa", namespace)}, extObjs, nil, &install.StrategyResolver{}, namespace)
+			require.NoError(t, err)
+
+			roles := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			roleBindings := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			require.NoError(t, roles.Add(&rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: "granted", Namespace: namespace}, Rules: tt.granted}))
+			require.NoError(t, roleBindings.Add(&rbacv1.RoleBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "granted-binding", Namespace: namespace},
+				RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "granted"},
+				Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "sa"}},
+			}))
+			op.roleLister = crbacv1.NewRoleLister(roles)
+			op.roleBindingLister = crbacv1.NewRoleBindingLister(roleBindings)
+
+			// trace the current CSV as a sync would, so that the upgrade check can be seen to leave the trace alone
+			op.requirementStatus(current)
+			traced := op.RequirementsTrace(namespace, current.GetName())
+			require.NotEmpty(t, traced)
+			currentBefore, nextBefore := current.DeepCopy(), next.DeepCopy()
+
+			upgrade := op.RequirementsForUpgrade(current, next)
+			require.Equal(t, tt.expectedSafe, upgrade.Safe)
+			require.NotEmpty(t, upgrade.Statuses)
+
+			actualAdded := []added{}
+			for _, status := range upgrade.Added {
+				entry := added{kind: status.Kind, name: status.Name, status: status.Status}
+				for _, dependent := range status.Dependents {
+					entry.dependents = append(entry.dependents, dependent.Status)
+				}
+				actualAdded = append(actualAdded, entry)
+			}
+			require.ElementsMatch(t, tt.expectedAdded, actualAdded)
+
+			// the check is side-effect free
+			require.Equal(t, currentBefore, current)
+			require.Equal(t, nextBefore, next)
+			require.Equal(t, traced, op.RequirementsTrace(namespace, current.GetName()))
+			require.Empty(t, op.RequirementsTrace(namespace, next.GetName()))
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package olm
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+)
+
+var apiVersionRegex = regexp.MustCompile(`^v(\d+)(?:(alpha|beta)(\d+))?$`)
+
+// apiVersion is a parsed Kubernetes API version of the form vN[alpha|beta]M
+type apiVersion struct {
+	major int
+	// stage orders alpha before beta before GA within a major version
+	stage int
+	minor int
+}
+
+const (
+	stageAlpha = iota
+	stageBeta
+	stageGA
+)
+
+func parseAPIVersion(version string) (apiVersion, error) {
+	submatches := apiVersionRegex.FindStringSubmatch(version)
+	if submatches == nil {
+		return apiVersion{}, fmt.Errorf("%q is not an API version of the form vN[alpha|beta]M", version)
+	}
+
+	v := apiVersion{stage: stageGA}
+	v.major, _ = strconv.Atoi(submatches[1])
+	switch submatches[2] {
+	case "alpha":
+		v.stage = stageAlpha
+	case "beta":
+		v.stage = stageBeta
+	}
+	if v.stage != stageGA {
+		v.minor, _ = strconv.Atoi(submatches[3])
+	}
+
+	return v, nil
+}
+
+// compare returns a negative number if v sorts before o, a positive number if v sorts after o, and zero if they're
+// equal. Versions compare like semver: v1alpha1 < v1beta1 < v1 < v2alpha1 < v2.
+func (v apiVersion) compare(o apiVersion) int {
+	if v.major != o.major {
+		return v.major - o.major
+	}
+	if v.stage != o.stage {
+		return v.stage - o.stage
+	}
+	return v.minor - o.minor
+}
+
+type versionConstraint struct {
+	op      string
+	version apiVersion
+}
+
+func (c versionConstraint) satisfiedBy(v apiVersion) bool {
+	cmp := v.compare(c.version)
+	switch c.op {
+	case ">=":
+		return cmp >= 0
+	case ">":
+		return cmp > 0
+	case "<=":
+		return cmp <= 0
+	case "<":
+		return cmp < 0
+	case "!=":
+		return cmp != 0
+	default:
+		return cmp == 0
+	}
+}
+
+// versionRange is a set of constraints that must all be satisfied
+type versionRange []versionConstraint
+
+var versionRangeOps = []string{">=", "<=", "!=", ">", "<", "="}
+
+// parseVersionRange parses whitespace or comma separated constraints such as ">=v1beta1 <v2". Each constraint is an
+// optional operator (>=, >, <=, <, =, or !=; defaulting to =) followed by an API version.
+func parseVersionRange(r string) (versionRange, error) {
+	fields := strings.FieldsFunc(r, func(c rune) bool {
+		return c == ',' || c == ' ' || c == '\t'
+	})
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("version range %q has no constraints", r)
+	}
+
+	constraints := versionRange{}
+	for _, field := range fields {
+		c := versionConstraint{op: "="}
+		for _, op := range versionRangeOps {
+			if strings.HasPrefix(field, op) {
+				c.op = op
+				field = strings.TrimPrefix(field, op)
+				break
+			}
+		}
+
+		v, err := parseAPIVersion(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version range %q: %s", r, err)
+		}
+		c.version = v
+		constraints = append(constraints, c)
+	}
+
+	return constraints, nil
+}
+
+// matches returns true if version is an API version satisfying every constraint
+func (r versionRange) matches(version string) bool {
+	v, err := parseAPIVersion(version)
+	if err != nil {
+		return false
+	}
+	for _, c := range r {
+		if !c.satisfiedBy(v) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// servedVersions returns the versions a CRD is served at
+func servedVersions(crd *v1beta1.CustomResourceDefinition) []string {
+	versions := []string{}
+	for _, version := range crd.Spec.Versions {
+		if version.Served {
+			versions = append(versions, version.Name)
+		}
+	}
+	if len(crd.Spec.Versions) == 0 && crd.Spec.Version != "" {
+		versions = append(versions, crd.Spec.Version)
+	}
+
+	return versions
+}
+
+// crdMissingServedVersions returns the given versions that a CRD doesn't serve, in order
+func crdMissingServedVersions(crd *v1beta1.CustomResourceDefinition, versions []string) []string {
+	served := map[string]struct{}{}
+	for _, version := range servedVersions(crd) {
+		served[version] = struct{}{}
+	}
+	missing := []string{}
+	for _, version := range versions {
+		if _, ok := served[version]; !ok {
+			missing = append(missing, version)
+		}
+	}
+
+	return missing
+}
+
+// crdSatisfiesVersionRange returns true if the CRD serves a version within the given range, or a message explaining
+// why it doesn't
+func crdSatisfiesVersionRange(crd *v1beta1.CustomResourceDefinition, versionRange string) (bool, string) {
+	r, err := parseVersionRange(versionRange)
+	if err != nil {
+		return false, err.Error()
+	}
+
+	served := servedVersions(crd)
+	for _, version := range served {
+		if r.matches(version) {
+			return true, ""
+		}
+	}
+
+	return false, fmt.Sprintf("CustomResourceDefinition %s serves versions %v, none of which satisfy %q", crd.GetName(), served, versionRange)
+}
+
+// newerAcceptableVersions returns the acceptable versions of a required APIService that are newer than its version,
+// newest first. Versions that aren't of the form vN[alpha|beta]M can't be ordered, so they're never considered newer, and
+// a required APIService whose own version can't be parsed has none.
+func newerAcceptableVersions(desc v1alpha1.APIServiceDescription) []string {
+	requested, err := parseAPIVersion(desc.Version)
+	if err != nil {
+		return nil
+	}
+
+	type candidate struct {
+		name    string
+		version apiVersion
+	}
+	candidates := []candidate{}
+	for _, name := range desc.AcceptableVersions {
+		v, err := parseAPIVersion(name)
+		if err != nil || v.compare(requested) <= 0 {
+			continue
+		}
+		candidates = append(candidates, candidate{name: name, version: v})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].version.compare(candidates[j].version) > 0
+	})
+
+	versions := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		versions = append(versions, c.name)
+	}
+	return versions
+}
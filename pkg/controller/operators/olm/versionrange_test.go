@@ -0,0 +1,103 @@
+package olm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+)
+
+func TestVersionRangeMatches(t *testing.T) {
+	tests := []struct {
+		description  string
+		versionRange string
+		matches      []string
+		misses       []string
+	}{
+		{
+			description:  "Exact",
+			versionRange: "v1beta1",
+			matches:      []string{"v1beta1"},
+			misses:       []string{"v1beta2", "v1", "v1alpha1"},
+		},
+		{
+			description:  "AtLeast",
+			versionRange: ">=v1beta1",
+			matches:      []string{"v1beta1", "v1beta2", "v1", "v2alpha1", "v10"},
+			misses:       []string{"v1alpha1", "v1alpha9"},
+		},
+		{
+			description:  "Bounded",
+			versionRange: ">v1alpha1,<=v1",
+			matches:      []string{"v1alpha2", "v1beta1", "v1"},
+			misses:       []string{"v1alpha1", "v2alpha1", "v2"},
+		},
+		{
+			description:  "Excluded",
+			versionRange: ">=v1 !=v2",
+			matches:      []string{"v1", "v2beta1", "v3"},
+			misses:       []string{"v2", "v1beta1"},
+		},
+		{
+			description:  "NotAnAPIVersion",
+			versionRange: ">=v1",
+			misses:       []string{"1.0.0", "v1.1", "vbeta1", "v1gamma1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			r, err := parseVersionRange(tt.versionRange)
+			require.NoError(t, err)
+			for _, version := range tt.matches {
+				require.True(t, r.matches(version), version)
+			}
+			for _, version := range tt.misses {
+				require.False(t, r.matches(version), version)
+			}
+		})
+	}
+}
+
+func TestParseVersionRangeErrors(t *testing.T) {
+	for _, versionRange := range []string{"", " , ", ">=", ">=1.0", "~v1", "=>v1"} {
+		_, err := parseVersionRange(versionRange)
+		require.Error(t, err, versionRange)
+	}
+}
+
+func TestNewerAcceptableVersions(t *testing.T) {
+	tests := []struct {
+		description string
+		desc        v1alpha1.APIServiceDescription
+		expected    []string
+	}{
+		{
+			description: "NewestFirst",
+			desc:        v1alpha1.APIServiceDescription{Version: "v1beta1", AcceptableVersions: []string{"v1", "v2alpha1", "v1beta2"}},
+			expected:    []string{"v2alpha1", "v1", "v1beta2"},
+		},
+		{
+			description: "OlderAndSameIgnored",
+			desc:        v1alpha1.APIServiceDescription{Version: "v1", AcceptableVersions: []string{"v1alpha1", "v1", "v1beta1"}},
+			expected:    []string{},
+		},
+		{
+			description: "UnparseableIgnored",
+			desc:        v1alpha1.APIServiceDescription{Version: "v1", AcceptableVersions: []string{"latest", "v2"}},
+			expected:    []string{"v2"},
+		},
+		{
+			description: "UnparseableVersion",
+			desc:        v1alpha1.APIServiceDescription{Version: "latest", AcceptableVersions: []string{"v2"}},
+			expected:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			require.Equal(t, tt.expected, newerAcceptableVersions(tt.desc))
+		})
+	}
+}
@@ -0,0 +1,110 @@
+// Package gvkcache caches the group/version/kinds a cluster serves, so that components checking whether a GVK exists
+// can share a single discovery query instead of each querying discovery on every check.
+package gvkcache
+
+import (
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/clock"
+)
+
+// DefaultTTL is how long discovery information is cached by default
+const DefaultTTL = 30 * time.Second
+
+// ServerResourcesGetter queries discovery for the resources the cluster serves, and is implemented by
+// discovery.DiscoveryInterface
+type ServerResourcesGetter interface {
+	ServerResources() ([]*metav1.APIResourceList, error)
+}
+
+// Cache holds the result of a discovery query for up to its TTL, or until it's invalidated.
+// All lookups in that time are answered from one query, and concurrent lookups that find the cache empty or expired
+// wait for a single query rather than each making their own. Failed queries aren't cached.
+// A Cache is safe for concurrent use.
+type Cache struct {
+	discovery ServerResourcesGetter
+	ttl       time.Duration
+	clock     clock.Clock
+
+	mu        sync.Mutex
+	cached    bool
+	resources []*metav1.APIResourceList
+	expires   time.Time
+}
+
+// New returns a Cache that queries the given discovery client. A ttl of zero or less uses DefaultTTL.
+func New(discovery ServerResourcesGetter, ttl time.Duration) *Cache {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+
+	return &Cache{
+		discovery: discovery,
+		ttl:       ttl,
+		clock:     clock.RealClock{},
+	}
+}
+
+// ServerResources returns the resources the cluster serves, querying discovery if the cache is empty or expired
+func (c *Cache) ServerResources() ([]*metav1.APIResourceList, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cached && c.clock.Now().Before(c.expires) {
+		return c.resources, nil
+	}
+
+	resources, err := c.discovery.ServerResources()
+	if err != nil {
+		return nil, err
+	}
+	c.cached = true
+	c.resources = resources
+	c.expires = c.clock.Now().Add(c.ttl)
+
+	return resources, nil
+}
+
+// Has reports whether the cluster serves the given GVK. An empty kind matches any resource in the group and version.
+func (c *Cache) Has(gvk schema.GroupVersionKind) (bool, error) {
+	resources, err := c.ServerResources()
+	if err != nil {
+		return false, err
+	}
+
+	return Contains(resources, gvk), nil
+}
+
+// Invalidate discards the cached discovery information, so that the next lookup queries discovery again.
+// It should be called when the served APIs are known to have changed, such as when a CRD or APIService is added.
+func (c *Cache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cached = false
+	c.resources = nil
+}
+
+// Contains reports whether the given discovery information includes the given GVK. An empty kind matches any resource
+// in the group and version.
+func Contains(resources []*metav1.APIResourceList, gvk schema.GroupVersionKind) bool {
+	gv := gvk.GroupVersion().String()
+	for _, list := range resources {
+		if list.GroupVersion != gv {
+			continue
+		}
+		if gvk.Kind == "" {
+			return true
+		}
+		for _, r := range list.APIResources {
+			if r.Kind == gvk.Kind {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,142 @@
+package gvkcache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/clock"
+)
+
+// fakeDiscovery counts the discovery queries made of it
+type fakeDiscovery struct {
+	mu        sync.Mutex
+	queries   int
+	resources []*metav1.APIResourceList
+	err       error
+}
+
+func (d *fakeDiscovery) ServerResources() ([]*metav1.APIResourceList, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.queries++
+	return d.resources, d.err
+}
+
+func (d *fakeDiscovery) set(resources []*metav1.APIResourceList, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.resources = resources
+	d.err = err
+}
+
+func resourceList(groupVersion string, kinds ...string) *metav1.APIResourceList {
+	list := &metav1.APIResourceList{GroupVersion: groupVersion}
+	for _, kind := range kinds {
+		list.APIResources = append(list.APIResources, metav1.APIResource{Kind: kind})
+	}
+	return list
+}
+
+func TestContains(t *testing.T) {
+	resources := []*metav1.APIResourceList{
+		resourceList("v1", "Pod", "ConfigMap"),
+		resourceList("apps/v1", "Deployment"),
+	}
+
+	tests := []struct {
+		gvk         schema.GroupVersionKind
+		expected    bool
+		description string
+	}{
+		{gvk: schema.GroupVersionKind{Version: "v1", Kind: "Pod"}, expected: true, description: "CoreKind"},
+		{gvk: schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, expected: true, description: "GroupKind"},
+		{gvk: schema.GroupVersionKind{Group: "apps", Version: "v1"}, expected: true, description: "AnyKind"},
+		{gvk: schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Pod"}, expected: false, description: "KindInOtherGroup"},
+		{gvk: schema.GroupVersionKind{Group: "apps", Version: "v2"}, expected: false, description: "MissingVersion"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			require.Equal(t, test.expected, Contains(resources, test.gvk))
+		})
+	}
+}
+
+func TestHas(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC))
+	discovery := &fakeDiscovery{resources: []*metav1.APIResourceList{resourceList("apps/v1", "Deployment")}}
+	cache := New(discovery, time.Minute)
+	cache.clock = fakeClock
+	deployment := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	widget := schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"}
+
+	// the first lookup queries discovery
+	has, err := cache.Has(deployment)
+	require.NoError(t, err)
+	require.True(t, has)
+	require.Equal(t, 1, discovery.queries)
+
+	// hits and misses within the TTL are answered from the cache
+	discovery.set(append(discovery.resources, resourceList("example.com/v1", "Widget")), nil)
+	has, err = cache.Has(deployment)
+	require.NoError(t, err)
+	require.True(t, has)
+	has, err = cache.Has(widget)
+	require.NoError(t, err)
+	require.False(t, has)
+	require.Equal(t, 1, discovery.queries)
+
+	// once expired, discovery is queried again
+	fakeClock.Step(time.Minute)
+	has, err = cache.Has(widget)
+	require.NoError(t, err)
+	require.True(t, has)
+	require.Equal(t, 2, discovery.queries)
+
+	// invalidating forces a query before the TTL
+	discovery.set([]*metav1.APIResourceList{resourceList("apps/v1", "Deployment")}, nil)
+	cache.Invalidate()
+	has, err = cache.Has(widget)
+	require.NoError(t, err)
+	require.False(t, has)
+	require.Equal(t, 3, discovery.queries)
+}
+
+func TestHasErrorsNotCached(t *testing.T) {
+	discovery := &fakeDiscovery{err: fmt.Errorf("discovery unavailable")}
+	cache := New(discovery, time.Minute)
+	deployment := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+
+	_, err := cache.Has(deployment)
+	require.Error(t, err)
+
+	discovery.set([]*metav1.APIResourceList{resourceList("apps/v1", "Deployment")}, nil)
+	has, err := cache.Has(deployment)
+	require.NoError(t, err)
+	require.True(t, has)
+	require.Equal(t, 2, discovery.queries)
+}
+
+func TestConcurrentMissesShareQuery(t *testing.T) {
+	discovery := &fakeDiscovery{resources: []*metav1.APIResourceList{resourceList("apps/v1", "Deployment")}}
+	cache := New(discovery, time.Minute)
+
+	lookups := 10
+	found := make(chan bool, lookups)
+	for i := 0; i < lookups; i++ {
+		go func() {
+			has, err := cache.Has(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"})
+			found <- has && err == nil
+		}()
+	}
+	for i := 0; i < lookups; i++ {
+		require.True(t, <-found)
+	}
+
+	require.Equal(t, 1, discovery.queries)
+}
@@ -110,6 +110,26 @@ type Client struct {
 
 // NewClient creates a kubernetes client or bails out on on failures.
 func NewClientFromConfig(kubeconfig string) ClientInterface {
+	return newClientForConfig(loadConfig(kubeconfig))
+}
+
+// NewClientFromConfigWithRateLimit creates a kubernetes client like NewClientFromConfig, with its client-side rate
+// limit set to the given QPS and burst. A qps or burst of zero keeps the client-go default.
+func NewClientFromConfigWithRateLimit(kubeconfig string, qps float32, burst int) ClientInterface {
+	config := loadConfig(kubeconfig)
+	if qps > 0 {
+		config.QPS = qps
+	}
+	if burst > 0 {
+		config.Burst = burst
+	}
+
+	return newClientForConfig(config)
+}
+
+// loadConfig loads the REST config from the kubeconfig at the given path, or the in-cluster config if the path is empty,
+// or bails out on failures.
+func loadConfig(kubeconfig string) *rest.Config {
 	var config *rest.Config
 	var err error
 
@@ -125,6 +145,10 @@ func NewClientFromConfig(kubeconfig string) ClientInterface {
 		log.Fatalf("Cannot load config for REST client: %v", err)
 	}
 
+	return config
+}
+
+func newClientForConfig(config *rest.Config) ClientInterface {
 	return &Client{kubernetes.NewForConfigOrDie(config), apiextensions.NewForConfigOrDie(config), apiregistration.NewForConfigOrDie(config)}
 }
 
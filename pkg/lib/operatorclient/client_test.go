@@ -0,0 +1,74 @@
+package operatorclient
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: https://127.0.0.1:6443
+contexts:
+- name: test
+  context:
+    cluster: test
+current-context: test
+`
+
+func TestNewClientFromConfigWithRateLimit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "operatorclient")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	kubeconfig := filepath.Join(dir, "kubeconfig")
+	require.NoError(t, ioutil.WriteFile(kubeconfig, []byte(testKubeconfig), 0600))
+
+	// unlike other clients, client-go doesn't rate limit discovery unless a QPS is set
+	tests := []struct {
+		qps                  float32
+		burst                int
+		expectedQPS          float32
+		expectedDiscoveryQPS float32
+		description          string
+	}{
+		{
+			qps:                  50,
+			burst:                100,
+			expectedQPS:          50,
+			expectedDiscoveryQPS: 50,
+			description:          "Configured",
+		},
+		{
+			expectedQPS:          rest.DefaultQPS,
+			expectedDiscoveryQPS: 0,
+			description:          "Default",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			client := NewClientFromConfigWithRateLimit(kubeconfig, tt.qps, tt.burst)
+
+			// discovery and CRD lookups, the requests requirement checks make, share the limit
+			require.Equal(t, tt.expectedDiscoveryQPS, qps(client.KubernetesInterface().Discovery().RESTClient().GetRateLimiter()))
+			require.Equal(t, tt.expectedQPS, qps(client.KubernetesInterface().CoreV1().RESTClient().GetRateLimiter()))
+			require.Equal(t, tt.expectedQPS, qps(client.ApiextensionsV1beta1Interface().ApiextensionsV1beta1().RESTClient().GetRateLimiter()))
+		})
+	}
+}
+
+// qps returns the QPS of a rate limiter, or zero if there's no rate limiter
+func qps(limiter flowcontrol.RateLimiter) float32 {
+	if limiter == nil {
+		return 0
+	}
+	return limiter.QPS()
+}
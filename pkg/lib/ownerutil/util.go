@@ -13,6 +13,24 @@ import (
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
 )
 
+const (
+	// OwnerKey labels a cluster-scoped object created for a namespaced owner, which can't be its owner reference,
+	// with the owner's name
+	OwnerKey = "olm.owner"
+	// OwnerNamespaceKey labels an object with the namespace of the owner named by OwnerKey
+	OwnerNamespaceKey = "olm.owner.namespace"
+	// OwnerKind labels an object with the kind of the owner named by OwnerKey
+	OwnerKind = "olm.owner.kind"
+)
+
+// GetOwnerLabels returns the kind, namespace, and name of the owner an object is labeled with, and whether it has an
+// OwnerKey label
+func GetOwnerLabels(object metav1.Object) (kind, namespace, name string, ok bool) {
+	labels := object.GetLabels()
+	name, ok = labels[OwnerKey]
+	return labels[OwnerKind], labels[OwnerNamespaceKey], name, ok
+}
+
 // Owner is used to build an OwnerReference, and we need type and object metadata
 type Owner interface {
 	metav1.Object
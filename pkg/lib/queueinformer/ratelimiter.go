@@ -0,0 +1,35 @@
+package queueinformer
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// JitteredRateLimiter wraps a RateLimiter and adds a random jitter to each delay it returns.
+// This spreads out requeues of items that fail at the same time so they don't all retry at once.
+type JitteredRateLimiter struct {
+	workqueue.RateLimiter
+	maxFactor float64
+}
+
+var _ workqueue.RateLimiter = &JitteredRateLimiter{}
+
+// NewJitteredRateLimiter returns a RateLimiter that adds up to maxFactor * delay of jitter to the delays from limiter.
+// A maxFactor <= 0 disables jitter.
+func NewJitteredRateLimiter(limiter workqueue.RateLimiter, maxFactor float64) workqueue.RateLimiter {
+	return &JitteredRateLimiter{
+		RateLimiter: limiter,
+		maxFactor:   maxFactor,
+	}
+}
+
+// When returns the jittered delay for the given item
+func (r *JitteredRateLimiter) When(item interface{}) time.Duration {
+	delay := r.RateLimiter.When(item)
+	if r.maxFactor <= 0 || delay <= 0 {
+		return delay
+	}
+	return wait.Jitter(delay, r.maxFactor)
+}
@@ -0,0 +1,50 @@
+package queueinformer
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/util/workqueue"
+)
+
+func TestJitteredRateLimiter(t *testing.T) {
+	baseDelay := 100 * time.Millisecond
+	tests := []struct {
+		maxFactor   float64
+		distributed bool
+		description string
+	}{
+		{
+			maxFactor:   0,
+			distributed: false,
+			description: "NoJitter",
+		},
+		{
+			maxFactor:   0.5,
+			distributed: true,
+			description: "Jitter",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			limiter := NewJitteredRateLimiter(workqueue.NewItemExponentialFailureRateLimiter(baseDelay, time.Second), tt.maxFactor)
+
+			delays := map[time.Duration]struct{}{}
+			for i := 0; i < 20; i++ {
+				delay := limiter.When(fmt.Sprintf("ns/csv%d", i))
+				require.True(t, delay >= baseDelay, "delay %s shorter than base delay", delay)
+				require.True(t, delay <= baseDelay+time.Duration(tt.maxFactor*float64(baseDelay)), "delay %s exceeds max jitter", delay)
+				delays[delay] = struct{}{}
+			}
+
+			if tt.distributed {
+				require.True(t, len(delays) > 1, "expected requeue delays to be distributed")
+			} else {
+				require.Len(t, delays, 1)
+			}
+		})
+	}
+}
@@ -129,6 +129,24 @@ var (
 			Help: "Monotonic count of catalog sources",
 		},
 	)
+
+	// exported since it's updated by the CSV sync loop rather than HandleMetrics
+	CSVUnmetRequirements = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "csv_unmet_requirements",
+			Help: "Number of CSVs with an unmet requirement, by requirement kind and reason",
+		},
+		[]string{"kind", "reason"},
+	)
+
+	// exported since it's updated by requirement checks rather than HandleMetrics
+	GVKCacheRechecks = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gvk_cache_rechecks",
+			Help: "Monotonic count of live api discovery rechecks forced by a cached GVK miss, by whether the recheck found the GVK",
+		},
+		[]string{"found"},
+	)
 )
 
 func Register() {
@@ -137,4 +155,6 @@ func Register() {
 	prometheus.MustRegister(subscriptionCount)
 	prometheus.MustRegister(catalogSourceCount)
 	prometheus.MustRegister(CSVUpgradeCount)
+	prometheus.MustRegister(CSVUnmetRequirements)
+	prometheus.MustRegister(GVKCacheRechecks)
 }
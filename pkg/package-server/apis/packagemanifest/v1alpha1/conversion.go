@@ -0,0 +1,98 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+const (
+	// DisplayNameField selects PackageManifests by the display name of their default channel's current CSV
+	DisplayNameField = "status.displayName"
+	// DisplayNameContainsField selects PackageManifests whose display name contains the selector's value, ignoring
+	// case. Unlike other fields, it isn't matched by equality, so selectors using it must be matched by
+	// PackageManifestFieldsMatch.
+	DisplayNameContainsField = "status.displayNameContains"
+	// ProvidedAPIField selects PackageManifests with a channel whose current CSV owns the API given as the selector's
+	// value, formatted by ProvidedAPI. Like DisplayNameContainsField, selectors using it must be matched by
+	// PackageManifestFieldsMatch.
+	ProvidedAPIField = "status.providedAPI"
+)
+
+// PackageManifestSelectableFields returns the fields of a PackageManifest that can be used in field selectors.
+// It is the single allowlist of selectable fields; selectors on any other field are rejected by the apiserver.
+func PackageManifestSelectableFields(manifest *PackageManifest) fields.Set {
+	return fields.Set{
+		"metadata.name":                 manifest.GetName(),
+		"metadata.namespace":            manifest.GetNamespace(),
+		"status.catalogSource":          manifest.Status.CatalogSourceName,
+		"status.catalogSourceNamespace": manifest.Status.CatalogSourceNamespace,
+		DisplayNameField:                manifest.GetDisplayName(),
+		DisplayNameContainsField:        manifest.GetDisplayName(),
+		ProvidedAPIField:                strings.Join(manifest.ProvidedAPIs(), ","),
+	}
+}
+
+// PackageManifestFieldsMatch returns true if a PackageManifest satisfies every requirement of a field selector.
+// DisplayNameContainsField requirements are satisfied by display names containing their value, ignoring case.
+// ProvidedAPIField requirements are satisfied by PackageManifests providing their API, and all other requirements by
+// fields equal to their value.
+func PackageManifestFieldsMatch(manifest *PackageManifest, fs fields.Selector) bool {
+	set := PackageManifestSelectableFields(manifest)
+	for _, requirement := range fs.Requirements() {
+		value, ok := set[requirement.Field]
+		if !ok {
+			return false
+		}
+
+		var equal bool
+		switch requirement.Field {
+		case DisplayNameContainsField:
+			equal = strings.Contains(strings.ToLower(value), strings.ToLower(requirement.Value))
+		case ProvidedAPIField:
+			equal = providesAPI(manifest, requirement.Value)
+		default:
+			equal = value == requirement.Value
+		}
+
+		switch requirement.Operator {
+		case selection.Equals, selection.DoubleEquals:
+			if !equal {
+				return false
+			}
+		case selection.NotEquals:
+			if equal {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+func providesAPI(manifest *PackageManifest, api string) bool {
+	for _, provided := range manifest.ProvidedAPIs() {
+		if provided == api {
+			return true
+		}
+	}
+	return false
+}
+
+// PackageManifestFieldLabelConversionFunc validates field selector labels for PackageManifests against
+// PackageManifestSelectableFields
+func PackageManifestFieldLabelConversionFunc(label, value string) (string, string, error) {
+	if _, ok := PackageManifestSelectableFields(&PackageManifest{})[label]; !ok {
+		return "", "", fmt.Errorf("field label not supported: %s", label)
+	}
+	return label, value, nil
+}
+
+func addFieldLabelConversionFuncs(scheme *runtime.Scheme) error {
+	return scheme.AddFieldLabelConversionFunc(SchemeGroupVersion.String(), PackageManifestKind, PackageManifestFieldLabelConversionFunc)
+}
@@ -1,6 +1,16 @@
 package v1alpha1
 
-import operatorsv1alpha1 "github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+import (
+	"fmt"
+	"strings"
+
+	operatorsv1alpha1 "github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+)
+
+// ProvidedAPI formats an API as it's listed in a CSVDescription's ProvidedAPIs and selected by ProvidedAPIField
+func ProvidedAPI(group, version, kind string) string {
+	return fmt.Sprintf("%s/%s/%s", group, version, kind)
+}
 
 // CreateCSVDescription creates a CSVDescription from a given CSV
 func CreateCSVDescription(csv *operatorsv1alpha1.ClusterServiceVersion) CSVDescription {
@@ -11,6 +21,7 @@ func CreateCSVDescription(csv *operatorsv1alpha1.ClusterServiceVersion) CSVDescr
 			Name: csv.Spec.Provider.Name,
 			URL:  csv.Spec.Provider.URL,
 		},
+		MinKubeVersion: csv.Spec.MinKubeVersion,
 	}
 
 	icons := make([]Icon, len(csv.Spec.Icon))
@@ -25,5 +36,28 @@ func CreateCSVDescription(csv *operatorsv1alpha1.ClusterServiceVersion) CSVDescr
 		desc.Icon = icons
 	}
 
+	for _, crd := range csv.Spec.CustomResourceDefinitions.Owned {
+		// CRDs are named <plural>.<group>
+		group := ""
+		if i := strings.Index(crd.Name, "."); i >= 0 {
+			group = crd.Name[i+1:]
+		}
+		desc.ProvidedAPIs = append(desc.ProvidedAPIs, ProvidedAPI(group, crd.Version, crd.Kind))
+	}
+	// APIServices are named by their group
+	for _, api := range csv.Spec.APIServiceDefinitions.Owned {
+		desc.ProvidedAPIs = append(desc.ProvidedAPIs, ProvidedAPI(api.Name, api.Version, api.Kind))
+	}
+
+	for _, mode := range csv.Spec.InstallModes {
+		if mode.Supported {
+			desc.InstallModes = append(desc.InstallModes, string(mode.Type))
+		}
+	}
+
+	if len(csv.Spec.Keywords) > 0 {
+		desc.Keywords = append([]string{}, csv.Spec.Keywords...)
+	}
+
 	return desc
 }
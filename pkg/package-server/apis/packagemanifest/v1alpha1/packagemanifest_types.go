@@ -1,7 +1,10 @@
 package v1alpha1
 
 import (
+	"strings"
+
 	"github.com/coreos/go-semver/semver"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -12,6 +15,10 @@ type PackageManifestList struct {
 	metav1.ListMeta `json:"metadata,omitempty"`
 
 	Items []PackageManifest `json:"items"`
+
+	// ProviderCounts is the number of listed PackageManifests from each provider, keyed by provider name. It's only
+	// set for lists requested with the olm.summarizeProviders label selector key.
+	ProviderCounts map[string]int `json:"providerCounts,omitempty"`
 }
 
 // PackageManifest holds information about a package, which is a reference to one (or more)
@@ -34,7 +41,7 @@ type PackageManifestStatus struct {
 	// CatalogSourceName is the name of the CatalogSource this package belongs to
 	CatalogSourceName string `json:"catalogSource"`
 
-	//  CatalogSourceNamespace is the namespace of the owning CatalogSource
+	// CatalogSourceNamespace is the namespace of the owning CatalogSource
 	CatalogSourceNamespace string `json:"catalogSourceNamespace"`
 
 	// Provider is the provider of the PackageManifest's default CSV
@@ -50,6 +57,122 @@ type PackageManifestStatus struct {
 	// default channel will be installed if no other channel is explicitly given. If the package
 	// has a single channel, then that channel is implicitly the default.
 	DefaultChannelName string `json:"defaultChannel"`
+
+	// Conditions describe why the package failed validation. They're only set on the invalid PackageManifests that
+	// are listed, rather than dropped, for lists requested with the olm.includeInvalid label selector key.
+	Conditions []PackageManifestCondition `json:"conditions,omitempty"`
+}
+
+// PackageManifestConditionType is the type of a PackageManifestCondition
+type PackageManifestConditionType string
+
+const (
+	// PackageManifestValid is False for a package that failed validation
+	PackageManifestValid PackageManifestConditionType = "Valid"
+)
+
+const (
+	// PackageManifestReasonCSVNotFound is the reason a package is invalid when a channel's current CSV isn't in the
+	// catalog
+	PackageManifestReasonCSVNotFound = "CSVNotFound"
+	// PackageManifestReasonDefaultChannelNotFound is the reason a package is invalid when its default channel isn't
+	// one of its channels
+	PackageManifestReasonDefaultChannelNotFound = "DefaultChannelNotFound"
+)
+
+// PackageManifestCondition describes an aspect of the state of a package
+type PackageManifestCondition struct {
+	// Type is the type of the condition
+	Type PackageManifestConditionType `json:"type"`
+
+	// Status is the status of the condition: True, False, or Unknown
+	Status corev1.ConditionStatus `json:"status"`
+
+	// Reason is a CamelCase reason for the condition's status
+	Reason string `json:"reason,omitempty"`
+
+	// Message is a human readable message explaining the condition's status
+	Message string `json:"message,omitempty"`
+}
+
+// GetDisplayName returns the display name of the PackageManifest's default channel's current CSV, or "" if it has no
+// default channel
+func (m PackageManifest) GetDisplayName() string {
+	defaultChannel := m.GetDefaultChannel()
+	if defaultChannel == "" {
+		return ""
+	}
+	for _, channel := range m.Status.Channels {
+		if channel.Name == defaultChannel {
+			return channel.CurrentCSVDesc.DisplayName
+		}
+	}
+
+	return ""
+}
+
+// ProvidedAPIs returns the APIs owned by the current CSV of any of the PackageManifest's channels, each as
+// group/version/kind, in the order they're first seen
+func (m PackageManifest) ProvidedAPIs() []string {
+	seen := map[string]struct{}{}
+	apis := []string{}
+	for _, channel := range m.Status.Channels {
+		for _, api := range channel.CurrentCSVDesc.ProvidedAPIs {
+			if _, ok := seen[api]; ok {
+				continue
+			}
+			seen[api] = struct{}{}
+			apis = append(apis, api)
+		}
+	}
+
+	return apis
+}
+
+// SupportsInstallMode returns true if the current CSV of any of the PackageManifest's channels supports the given
+// install mode
+func (m PackageManifest) SupportsInstallMode(mode string) bool {
+	for _, channel := range m.Status.Channels {
+		for _, supported := range channel.CurrentCSVDesc.InstallModes {
+			if supported == mode {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// InstallModes returns the install modes supported by the current CSV of any of the PackageManifest's channels, in
+// the order they're first seen
+func (m PackageManifest) InstallModes() []string {
+	seen := map[string]struct{}{}
+	modes := []string{}
+	for _, channel := range m.Status.Channels {
+		for _, mode := range channel.CurrentCSVDesc.InstallModes {
+			if _, ok := seen[mode]; ok {
+				continue
+			}
+			seen[mode] = struct{}{}
+			modes = append(modes, mode)
+		}
+	}
+
+	return modes
+}
+
+// HasKeyword returns true if the current CSV of any of the PackageManifest's channels has the given keyword, ignoring
+// case
+func (m PackageManifest) HasKeyword(keyword string) bool {
+	for _, channel := range m.Status.Channels {
+		for _, k := range channel.CurrentCSVDesc.Keywords {
+			if strings.EqualFold(k, keyword) {
+				return true
+			}
+		}
+	}
+
+	return false
 }
 
 // GetDefaultChannel gets the default channel or returns the only one if there's only one. returns empty string if it
@@ -76,6 +199,22 @@ type PackageChannel struct {
 
 	// CurrentCSVSpec holds the spec of the current CSV
 	CurrentCSVDesc CSVDescription `json:"currentCSVDesc,omitempty"`
+
+	// CurrentCSVSource records where the current CSV was loaded from
+	CurrentCSVSource CSVSource `json:"currentCSVSource,omitempty"`
+}
+
+// CSVSource identifies the catalog, and the bundle within it, that a CSV was loaded from
+type CSVSource struct {
+	// CatalogSourceName is the name of the CatalogSource that provided the CSV
+	CatalogSourceName string `json:"catalogSource,omitempty"`
+
+	// CatalogSourceNamespace is the namespace of the CatalogSource that provided the CSV
+	CatalogSourceNamespace string `json:"catalogSourceNamespace,omitempty"`
+
+	// BundlePath is the location of the CSV within the catalog. For catalogs backed by a ConfigMap, it's the
+	// ConfigMap's name and the key holding the CSV, as <name>/<key>.
+	BundlePath string `json:"bundlePath,omitempty"`
 }
 
 // CSVDescription defines a description of a CSV
@@ -92,6 +231,18 @@ type CSVDescription struct {
 
 	// Provider is the CSV's provider
 	Provider AppLink `json:"provider,omitempty"`
+
+	// MinKubeVersion is the minimum Kubernetes version the CSV supports
+	MinKubeVersion string `json:"minKubeVersion,omitempty"`
+
+	// ProvidedAPIs are the APIs owned by the CSV, each as group/version/kind
+	ProvidedAPIs []string `json:"providedAPIs,omitempty"`
+
+	// InstallModes are the install modes the CSV supports
+	InstallModes []string `json:"installModes,omitempty"`
+
+	// Keywords are the CSV's keywords
+	Keywords []string `json:"keywords,omitempty"`
 }
 
 // AppLink defines a link to an application
@@ -7,7 +7,7 @@ import (
 )
 
 var (
-	SchemeBuilder = runtime.NewSchemeBuilder(addKnownTypes)
+	SchemeBuilder = runtime.NewSchemeBuilder(addKnownTypes, addFieldLabelConversionFuncs)
 	AddToScheme   = SchemeBuilder.AddToScheme
 )
 
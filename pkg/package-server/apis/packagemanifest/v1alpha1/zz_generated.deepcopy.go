@@ -50,6 +50,21 @@ func (in *CSVDescription) DeepCopyInto(out *CSVDescription) {
 	}
 	out.Version = in.Version
 	out.Provider = in.Provider
+	if in.ProvidedAPIs != nil {
+		in, out := &in.ProvidedAPIs, &out.ProvidedAPIs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.InstallModes != nil {
+		in, out := &in.InstallModes, &out.InstallModes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Keywords != nil {
+		in, out := &in.Keywords, &out.Keywords
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -63,6 +78,22 @@ func (in *CSVDescription) DeepCopy() *CSVDescription {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CSVSource) DeepCopyInto(out *CSVSource) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CSVSource.
+func (in *CSVSource) DeepCopy() *CSVSource {
+	if in == nil {
+		return nil
+	}
+	out := new(CSVSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Icon) DeepCopyInto(out *Icon) {
 	*out = *in
@@ -83,6 +114,7 @@ func (in *Icon) DeepCopy() *Icon {
 func (in *PackageChannel) DeepCopyInto(out *PackageChannel) {
 	*out = *in
 	in.CurrentCSVDesc.DeepCopyInto(&out.CurrentCSVDesc)
+	out.CurrentCSVSource = in.CurrentCSVSource
 	return
 }
 
@@ -124,6 +156,22 @@ func (in *PackageManifest) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PackageManifestCondition) DeepCopyInto(out *PackageManifestCondition) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PackageManifestCondition.
+func (in *PackageManifestCondition) DeepCopy() *PackageManifestCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(PackageManifestCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PackageManifestList) DeepCopyInto(out *PackageManifestList) {
 	*out = *in
@@ -136,6 +184,13 @@ func (in *PackageManifestList) DeepCopyInto(out *PackageManifestList) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ProviderCounts != nil {
+		in, out := &in.ProviderCounts, &out.ProviderCounts
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	return
 }
 
@@ -184,6 +239,11 @@ func (in *PackageManifestStatus) DeepCopyInto(out *PackageManifestStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]PackageManifestCondition, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -24,6 +24,7 @@ import (
 	openapinamer "k8s.io/apiserver/pkg/endpoints/openapi"
 	genericapiserver "k8s.io/apiserver/pkg/server"
 	"k8s.io/client-go/informers"
+	openapicommon "k8s.io/kube-openapi/pkg/common"
 
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/install"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apiserver/generic"
@@ -70,9 +71,7 @@ func (c *Config) Complete(informers informers.SharedInformerFactory) completedCo
 	c.GenericConfig.Version = version.VersionInfo()
 
 	// enable OpenAPI schemas
-	c.GenericConfig.OpenAPIConfig = genericapiserver.DefaultOpenAPIConfig(generatedopenapi.GetOpenAPIDefinitions, openapinamer.NewDefinitionNamer(generic.Scheme))
-	c.GenericConfig.OpenAPIConfig.Info.Title = "Package API server"
-	c.GenericConfig.OpenAPIConfig.Info.Version = strings.Split(c.GenericConfig.Version.String(), "-")[0]
+	c.GenericConfig.OpenAPIConfig = openAPIConfig(c.GenericConfig.Version.String())
 	c.GenericConfig.SwaggerConfig = genericapiserver.DefaultSwaggerConfig()
 
 	return completedConfig{
@@ -81,6 +80,16 @@ func (c *Config) Complete(informers informers.SharedInformerFactory) completedCo
 	}
 }
 
+// openAPIConfig returns the OpenAPI config used to publish the schemas of the served types, which is required for
+// `kubectl explain` and client-side validation.
+func openAPIConfig(version string) *openapicommon.Config {
+	config := genericapiserver.DefaultOpenAPIConfig(generatedopenapi.GetOpenAPIDefinitions, openapinamer.NewDefinitionNamer(generic.Scheme))
+	config.Info.Title = "Package API server"
+	config.Info.Version = strings.Split(version, "-")[0]
+
+	return config
+}
+
 type PackageManifestServer struct {
 	*genericapiserver.GenericAPIServer
 }
@@ -0,0 +1,49 @@
+package apiserver
+
+import (
+	"testing"
+
+	"github.com/go-openapi/spec"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kube-openapi/pkg/builder"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1"
+)
+
+const packageManifestTypePrefix = "github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1."
+
+func TestOpenAPIConfigPackageManifest(t *testing.T) {
+	config := openAPIConfig("v0.0.0-test")
+	require.Equal(t, "v0.0.0", config.Info.Version)
+
+	swagger, err := builder.BuildOpenAPIDefinitionsForResources(config, packageManifestTypePrefix+"PackageManifest")
+	require.NoError(t, err)
+
+	definition := func(typeName string) spec.Schema {
+		name, _ := config.GetDefinitionName(packageManifestTypePrefix + typeName)
+		schema, ok := swagger.Definitions[name]
+		require.True(t, ok, "missing definition %s", name)
+		return schema
+	}
+
+	// kubectl explain locates the schema for a resource by its group-version-kind extension
+	manifest := definition("PackageManifest")
+	gvks, ok := manifest.Extensions["x-kubernetes-group-version-kind"]
+	require.True(t, ok, "PackageManifest definition is missing its group-version-kind")
+	require.Contains(t, gvks, metav1.GroupVersionKind{
+		Group:   v1alpha1.Group,
+		Version: v1alpha1.Version,
+		Kind:    v1alpha1.PackageManifestKind,
+	})
+	require.Contains(t, manifest.Properties, "status")
+
+	status := definition("PackageManifestStatus")
+	require.Contains(t, status.Properties, "channels")
+	require.Contains(t, status.Properties, "defaultChannel")
+
+	channel := definition("PackageChannel")
+	for _, field := range []string{"name", "currentCSV", "currentCSVDesc"} {
+		require.Contains(t, channel.Properties, field)
+	}
+}
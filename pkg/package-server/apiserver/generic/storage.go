@@ -19,6 +19,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/version"
 	"k8s.io/apiserver/pkg/registry/rest"
 	genericapiserver "k8s.io/apiserver/pkg/server"
 
@@ -44,15 +45,29 @@ func init() {
 // for serving the resource metrics API.
 type ProviderConfig struct {
 	Provider provider.PackageManifestProvider
+
+	// ServerVersion is the version of the cluster, used to determine package compatibility if set
+	ServerVersion *version.Info
+
+	// WatchBacklog is the maximum number of undelivered events buffered for each watch
+	WatchBacklog int
+	// WatchOverflowPolicy determines what happens to a watch whose consumer falls further behind than WatchBacklog
+	WatchOverflowPolicy packagemanifeststorage.WatchOverflowPolicy
+	// MaxWatchesPerNamespace is the maximum number of active watches in each namespace; less than 1 allows any number
+	MaxWatchesPerNamespace int
 }
 
 // BuildStorage constructs APIGroupInfo the metrics.k8s.io API group using the given providers.
 func BuildStorage(providers *ProviderConfig) genericapiserver.APIGroupInfo {
 	apiGroupInfo := genericapiserver.NewDefaultAPIGroupInfo(packagemanifest.Group, Scheme, metav1.ParameterCodec, Codecs)
 
-	packageManifestStorage := packagemanifeststorage.NewStorage(packagemanifest.Resource("packagemanifests"), providers.Provider)
+	packageManifestStorage := packagemanifeststorage.NewStorage(packagemanifest.Resource("packagemanifests"), providers.Provider, providers.ServerVersion)
+	packageManifestStorage.SetWatchLimits(providers.WatchBacklog, providers.WatchOverflowPolicy)
+	packageManifestStorage.SetMaxWatchesPerNamespace(providers.MaxWatchesPerNamespace)
 	packageManifestResources := map[string]rest.Storage{
-		"packagemanifests": packageManifestStorage,
+		"packagemanifests":          packageManifestStorage,
+		"packagemanifests/channels": packagemanifeststorage.NewChannelStorage(packagemanifest.Resource("packagemanifests"), providers.Provider),
+		"packagemanifests/status":   packagemanifeststorage.NewStatusStorage(packageManifestStorage),
 	}
 	apiGroupInfo.VersionedResourcesStorageMap[packagemanifest.Version] = packageManifestResources
 
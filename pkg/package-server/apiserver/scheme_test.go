@@ -1 +1,45 @@
 package apiserver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apiserver/generic"
+)
+
+func TestSchemeConvertFieldLabel(t *testing.T) {
+	tests := []struct {
+		label       string
+		supported   bool
+		description string
+	}{
+		{label: "metadata.name", supported: true, description: "Name"},
+		{label: "metadata.namespace", supported: true, description: "Namespace"},
+		{label: "status.catalogSource", supported: true, description: "CatalogSource"},
+		{label: "status.catalogSourceNamespace", supported: true, description: "CatalogSourceNamespace"},
+		{label: "status.displayName", supported: true, description: "DisplayName"},
+		{label: "status.displayNameContains", supported: true, description: "DisplayNameContains"},
+		{label: "status.defaultChannel", supported: false, description: "UnsupportedStatusField"},
+		{label: "spec.name", supported: false, description: "UnsupportedSpecField"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			for _, scheme := range []interface {
+				ConvertFieldLabel(version, kind, label, value string) (string, string, error)
+			}{Scheme, generic.Scheme} {
+				label, value, err := scheme.ConvertFieldLabel(v1alpha1.SchemeGroupVersion.String(), v1alpha1.PackageManifestKind, test.label, "value")
+				if !test.supported {
+					require.EqualError(t, err, "field label not supported: "+test.label)
+					continue
+				}
+
+				require.NoError(t, err)
+				require.Equal(t, test.label, label)
+				require.Equal(t, "value", value)
+			}
+		})
+	}
+}
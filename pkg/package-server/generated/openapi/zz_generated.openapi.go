@@ -1,3 +1,4 @@
+//go:build !ignore_autogenerated
 // +build !ignore_autogenerated
 
 /*
@@ -30,14 +31,16 @@ import (
 
 func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenAPIDefinition {
 	return map[string]common.OpenAPIDefinition{
-		"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1.AppLink":               schema_package_server_apis_packagemanifest_v1alpha1_AppLink(ref),
-		"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1.CSVDescription":        schema_package_server_apis_packagemanifest_v1alpha1_CSVDescription(ref),
-		"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1.Icon":                  schema_package_server_apis_packagemanifest_v1alpha1_Icon(ref),
-		"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1.PackageChannel":        schema_package_server_apis_packagemanifest_v1alpha1_PackageChannel(ref),
-		"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1.PackageManifest":       schema_package_server_apis_packagemanifest_v1alpha1_PackageManifest(ref),
-		"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1.PackageManifestList":   schema_package_server_apis_packagemanifest_v1alpha1_PackageManifestList(ref),
-		"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1.PackageManifestSpec":   schema_package_server_apis_packagemanifest_v1alpha1_PackageManifestSpec(ref),
-		"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1.PackageManifestStatus": schema_package_server_apis_packagemanifest_v1alpha1_PackageManifestStatus(ref),
+		"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1.AppLink":                  schema_package_server_apis_packagemanifest_v1alpha1_AppLink(ref),
+		"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1.CSVDescription":           schema_package_server_apis_packagemanifest_v1alpha1_CSVDescription(ref),
+		"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1.CSVSource":                schema_package_server_apis_packagemanifest_v1alpha1_CSVSource(ref),
+		"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1.Icon":                     schema_package_server_apis_packagemanifest_v1alpha1_Icon(ref),
+		"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1.PackageChannel":           schema_package_server_apis_packagemanifest_v1alpha1_PackageChannel(ref),
+		"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1.PackageManifest":          schema_package_server_apis_packagemanifest_v1alpha1_PackageManifest(ref),
+		"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1.PackageManifestCondition": schema_package_server_apis_packagemanifest_v1alpha1_PackageManifestCondition(ref),
+		"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1.PackageManifestList":      schema_package_server_apis_packagemanifest_v1alpha1_PackageManifestList(ref),
+		"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1.PackageManifestSpec":      schema_package_server_apis_packagemanifest_v1alpha1_PackageManifestSpec(ref),
+		"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1.PackageManifestStatus":    schema_package_server_apis_packagemanifest_v1alpha1_PackageManifestStatus(ref),
 		"k8s.io/apimachinery/pkg/apis/meta/v1.APIGroup":                  schema_pkg_apis_meta_v1_APIGroup(ref),
 		"k8s.io/apimachinery/pkg/apis/meta/v1.APIGroupList":              schema_pkg_apis_meta_v1_APIGroupList(ref),
 		"k8s.io/apimachinery/pkg/apis/meta/v1.APIResource":               schema_pkg_apis_meta_v1_APIResource(ref),
@@ -139,6 +142,55 @@ func schema_package_server_apis_packagemanifest_v1alpha1_CSVDescription(ref comm
 							Ref:         ref("github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1.AppLink"),
 						},
 					},
+					"minKubeVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MinKubeVersion is the minimum Kubernetes version the CSV supports",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"providedAPIs": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ProvidedAPIs are the APIs owned by the CSV, each as group/version/kind",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"installModes": {
+						SchemaProps: spec.SchemaProps{
+							Description: "InstallModes are the install modes the CSV supports",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"keywords": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Keywords are the CSV's keywords",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
 				},
 			},
 		},
@@ -147,6 +199,40 @@ func schema_package_server_apis_packagemanifest_v1alpha1_CSVDescription(ref comm
 	}
 }
 
+func schema_package_server_apis_packagemanifest_v1alpha1_CSVSource(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "CSVSource identifies the catalog, and the bundle within it, that a CSV was loaded from",
+				Properties: map[string]spec.Schema{
+					"catalogSource": {
+						SchemaProps: spec.SchemaProps{
+							Description: "CatalogSourceName is the name of the CatalogSource that provided the CSV",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"catalogSourceNamespace": {
+						SchemaProps: spec.SchemaProps{
+							Description: "CatalogSourceNamespace is the namespace of the CatalogSource that provided the CSV",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"bundlePath": {
+						SchemaProps: spec.SchemaProps{
+							Description: "BundlePath is the location of the CSV within the catalog. For catalogs backed by a ConfigMap, it's the ConfigMap's name and the key holding the CSV, as <name>/<key>.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
+
 func schema_package_server_apis_packagemanifest_v1alpha1_Icon(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -198,12 +284,18 @@ func schema_package_server_apis_packagemanifest_v1alpha1_PackageChannel(ref comm
 							Ref:         ref("github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1.CSVDescription"),
 						},
 					},
+					"currentCSVSource": {
+						SchemaProps: spec.SchemaProps{
+							Description: "CurrentCSVSource records where the current CSV was loaded from",
+							Ref:         ref("github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1.CSVSource"),
+						},
+					},
 				},
 				Required: []string{"name", "currentCSV"},
 			},
 		},
 		Dependencies: []string{
-			"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1.CSVDescription"},
+			"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1.CSVDescription", "github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1.CSVSource"},
 	}
 }
 
@@ -250,6 +342,48 @@ func schema_package_server_apis_packagemanifest_v1alpha1_PackageManifest(ref com
 	}
 }
 
+func schema_package_server_apis_packagemanifest_v1alpha1_PackageManifestCondition(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "PackageManifestCondition describes an aspect of the state of a package",
+				Properties: map[string]spec.Schema{
+					"type": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Type is the type of the condition",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"status": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Status is the status of the condition: True, False, or Unknown",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"reason": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Reason is a CamelCase reason for the condition's status",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"message": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Message is a human readable message explaining the condition's status",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"type", "status"},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
+
 func schema_package_server_apis_packagemanifest_v1alpha1_PackageManifestList(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -287,6 +421,20 @@ func schema_package_server_apis_packagemanifest_v1alpha1_PackageManifestList(ref
 							},
 						},
 					},
+					"providerCounts": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ProviderCounts is the number of listed PackageManifests from each provider, keyed by provider name. It's only set for lists requested with the olm.summarizeProviders label selector key.",
+							Type:        []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"integer"},
+										Format: "int32",
+									},
+								},
+							},
+						},
+					},
 				},
 				Required: []string{"items"},
 			},
@@ -323,7 +471,7 @@ func schema_package_server_apis_packagemanifest_v1alpha1_PackageManifestStatus(r
 					},
 					"catalogSourceNamespace": {
 						SchemaProps: spec.SchemaProps{
-							Description: "\n CatalogSourceNamespace is the namespace of the owning CatalogSource",
+							Description: "CatalogSourceNamespace is the namespace of the owning CatalogSource",
 							Type:        []string{"string"},
 							Format:      "",
 						},
@@ -361,12 +509,25 @@ func schema_package_server_apis_packagemanifest_v1alpha1_PackageManifestStatus(r
 							Format:      "",
 						},
 					},
+					"conditions": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Conditions describe why the package failed validation. They're only set on the invalid PackageManifests that are listed, rather than dropped, for lists requested with the olm.includeInvalid label selector key.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1.PackageManifestCondition"),
+									},
+								},
+							},
+						},
+					},
 				},
 				Required: []string{"catalogSource", "catalogSourceNamespace", "packageName", "channels", "defaultChannel"},
 			},
 		},
 		Dependencies: []string{
-			"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1.AppLink", "github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1.PackageChannel"},
+			"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1.AppLink", "github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1.PackageChannel", "github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1.PackageManifestCondition"},
 	}
 }
 
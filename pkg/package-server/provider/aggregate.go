@@ -0,0 +1,163 @@
+package provider
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1"
+)
+
+// DefaultAggregateConcurrency is the default number of providers an AggregateProvider queries at once
+const DefaultAggregateConcurrency = 4
+
+var _ PackageManifestProvider = &AggregateProvider{}
+
+// AggregateProvider serves the PackageManifests of several providers, such as one per catalog, as if they were one.
+// Providers are queried concurrently, at most maxConcurrency at a time.
+type AggregateProvider struct {
+	providers      []PackageManifestProvider
+	maxConcurrency int
+}
+
+// NewAggregateProvider returns an AggregateProvider for the given providers.
+// A maxConcurrency less than 1 uses DefaultAggregateConcurrency.
+func NewAggregateProvider(maxConcurrency int, providers ...PackageManifestProvider) *AggregateProvider {
+	if maxConcurrency < 1 {
+		maxConcurrency = DefaultAggregateConcurrency
+	}
+
+	return &AggregateProvider{
+		providers:      providers,
+		maxConcurrency: maxConcurrency,
+	}
+}
+
+// fanOut calls query for each provider index, running at most maxConcurrency calls at once, and returns once all
+// calls are done
+func (a *AggregateProvider) fanOut(query func(i int)) {
+	sem := make(chan struct{}, a.maxConcurrency)
+	var wg sync.WaitGroup
+	for i := range a.providers {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			query(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// Get returns the PackageManifest with the given name in the given namespace from the first provider, in the order the
+// providers were given, that has it
+func (a *AggregateProvider) Get(namespace, name string) (*v1alpha1.PackageManifest, error) {
+	manifests := make([]*v1alpha1.PackageManifest, len(a.providers))
+	errs := make([]error, len(a.providers))
+	a.fanOut(func(i int) {
+		manifests[i], errs[i] = a.providers[i].Get(namespace, name)
+	})
+
+	for i, manifest := range manifests {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		if manifest != nil {
+			return manifest, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// List returns the PackageManifests of every provider in the given namespace, sorted by namespace, name, and
+// CatalogSource.
+// The list's resourceVersion is the sum of the providers' resourceVersions, which increases whenever any of them does.
+func (a *AggregateProvider) List(namespace string) (*v1alpha1.PackageManifestList, error) {
+	lists := make([]*v1alpha1.PackageManifestList, len(a.providers))
+	errs := make([]error, len(a.providers))
+	a.fanOut(func(i int) {
+		lists[i], errs[i] = a.providers[i].List(namespace)
+	})
+
+	manifestList := &v1alpha1.PackageManifestList{}
+	var resourceVersion uint64
+	for i, list := range lists {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		if list == nil {
+			continue
+		}
+		manifestList.Items = append(manifestList.Items, list.Items...)
+
+		// an unversioned list can't be ordered against the others, so it doesn't contribute
+		if version, err := strconv.ParseUint(list.GetResourceVersion(), 10, 64); err == nil {
+			resourceVersion += version
+		}
+	}
+
+	sort.SliceStable(manifestList.Items, func(i, j int) bool {
+		a, b := manifestList.Items[i], manifestList.Items[j]
+		if a.GetNamespace() != b.GetNamespace() {
+			return a.GetNamespace() < b.GetNamespace()
+		}
+		if a.GetName() != b.GetName() {
+			return a.GetName() < b.GetName()
+		}
+		if a.Status.CatalogSourceNamespace != b.Status.CatalogSourceNamespace {
+			return a.Status.CatalogSourceNamespace < b.Status.CatalogSourceNamespace
+		}
+		return a.Status.CatalogSourceName < b.Status.CatalogSourceName
+	})
+	manifestList.ResourceVersion = strconv.FormatUint(resourceVersion, 10)
+
+	return manifestList, nil
+}
+
+// Subscribe subscribes to every provider, merging their events into a single set of channels that are closed once
+// every provider has closed its own
+func (a *AggregateProvider) Subscribe(stopCh <-chan struct{}) (PackageChan, PackageChan, PackageChan, error) {
+	var adds, modifies, deletes []PackageChan
+	for _, p := range a.providers {
+		add, modify, delete, err := p.Subscribe(stopCh)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		adds = append(adds, add)
+		modifies = append(modifies, modify)
+		deletes = append(deletes, delete)
+	}
+
+	return mergePackageChans(adds), mergePackageChans(modifies), mergePackageChans(deletes), nil
+}
+
+// Invalidate invalidates the CatalogSource in every provider, since any of them may be serving it
+func (a *AggregateProvider) Invalidate(catalogSourceName, catalogSourceNamespace string) {
+	for _, p := range a.providers {
+		p.Invalidate(catalogSourceName, catalogSourceNamespace)
+	}
+}
+
+func mergePackageChans(chans []PackageChan) PackageChan {
+	merged := make(chan v1alpha1.PackageManifest)
+	var wg sync.WaitGroup
+	for _, ch := range chans {
+		wg.Add(1)
+		go func(ch PackageChan) {
+			defer wg.Done()
+			for manifest := range ch {
+				merged <- manifest
+			}
+		}(ch)
+	}
+	go func() {
+		wg.Wait()
+		close(merged)
+	}()
+
+	return merged
+}
@@ -0,0 +1,149 @@
+package provider
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	packagev1alpha1 "github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1"
+)
+
+// blockingProvider tracks how many of its Lists are in flight across providers, holding each until released
+type blockingProvider struct {
+	*FakeProvider
+	tracker *inFlightTracker
+}
+
+type inFlightTracker struct {
+	mu       sync.Mutex
+	inFlight int
+	max      int
+	release  chan struct{}
+}
+
+func (t *inFlightTracker) current() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.inFlight
+}
+
+func (b *blockingProvider) List(namespace string) (*packagev1alpha1.PackageManifestList, error) {
+	b.tracker.mu.Lock()
+	b.tracker.inFlight++
+	if b.tracker.inFlight > b.tracker.max {
+		b.tracker.max = b.tracker.inFlight
+	}
+	b.tracker.mu.Unlock()
+
+	<-b.tracker.release
+
+	b.tracker.mu.Lock()
+	b.tracker.inFlight--
+	b.tracker.mu.Unlock()
+
+	return b.FakeProvider.List(namespace)
+}
+
+func catalogManifest(name, namespace, catalog string) packagev1alpha1.PackageManifest {
+	manifest := packageManifest(packageValue{name: name, namespace: namespace})
+	manifest.Status.CatalogSourceName = catalog
+	manifest.Status.CatalogSourceNamespace = namespace
+	return manifest
+}
+
+func TestAggregateListConcurrency(t *testing.T) {
+	catalogs := 5
+	maxConcurrency := 2
+	tracker := &inFlightTracker{release: make(chan struct{})}
+
+	providers := []PackageManifestProvider{}
+	for i := 0; i < catalogs; i++ {
+		fake := NewFakeProvider()
+		catalog := fmt.Sprintf("catalog-%d", i)
+		// every catalog has a package of the same name, and the catalogs are added in reverse order of their names
+		fake.Add(catalogManifest("etcd", "ns", catalog))
+		fake.Add(catalogManifest(fmt.Sprintf("package-%d", catalogs-i), "ns", catalog))
+		providers = append(providers, &blockingProvider{FakeProvider: fake, tracker: tracker})
+	}
+	aggregate := NewAggregateProvider(maxConcurrency, providers...)
+
+	type result struct {
+		list *packagev1alpha1.PackageManifestList
+		err  error
+	}
+	done := make(chan result)
+	go func() {
+		list, err := aggregate.List(metav1.NamespaceAll)
+		done <- result{list, err}
+	}()
+
+	// wait for the pool to fill before letting any List finish
+	deadline := time.Now().Add(5 * time.Second)
+	for tracker.current() < maxConcurrency {
+		require.True(t, time.Now().Before(deadline), "timed out waiting for %d concurrent Lists", maxConcurrency)
+		time.Sleep(time.Millisecond)
+	}
+	close(tracker.release)
+
+	res := <-done
+	require.NoError(t, res.err)
+	require.Equal(t, maxConcurrency, tracker.max)
+
+	require.Len(t, res.list.Items, 2*catalogs)
+	seen := map[string]bool{}
+	for _, manifest := range res.list.Items {
+		seen[manifest.Status.CatalogSourceName] = true
+	}
+	for i := 0; i < catalogs; i++ {
+		require.True(t, seen[fmt.Sprintf("catalog-%d", i)], "missing catalog-%d", i)
+	}
+
+	names := []string{}
+	for _, manifest := range res.list.Items {
+		names = append(names, manifest.GetName()+"/"+manifest.Status.CatalogSourceName)
+	}
+	require.Equal(t, []string{
+		"etcd/catalog-0",
+		"etcd/catalog-1",
+		"etcd/catalog-2",
+		"etcd/catalog-3",
+		"etcd/catalog-4",
+		"package-1/catalog-4",
+		"package-2/catalog-3",
+		"package-3/catalog-2",
+		"package-4/catalog-1",
+		"package-5/catalog-0",
+	}, names)
+	require.Equal(t, fmt.Sprintf("%d", 2*catalogs), res.list.GetResourceVersion())
+
+	// the merged order doesn't depend on which catalogs answer first
+	for i := 0; i < 10; i++ {
+		list, err := aggregate.List(metav1.NamespaceAll)
+		require.NoError(t, err)
+		require.Equal(t, res.list.Items, list.Items)
+	}
+}
+
+func TestAggregateGet(t *testing.T) {
+	first, second := NewFakeProvider(), NewFakeProvider()
+	second.Add(catalogManifest("etcd", "ns", "second"))
+	first.Add(catalogManifest("prometheus", "ns", "first"))
+	second.Add(catalogManifest("prometheus", "ns", "second"))
+	aggregate := NewAggregateProvider(0, first, second)
+
+	manifest, err := aggregate.Get("ns", "etcd")
+	require.NoError(t, err)
+	require.Equal(t, "second", manifest.Status.CatalogSourceName)
+
+	manifest, err = aggregate.Get("ns", "prometheus")
+	require.NoError(t, err)
+	require.Equal(t, "first", manifest.Status.CatalogSourceName)
+
+	manifest, err = aggregate.Get("ns", "missing")
+	require.NoError(t, err)
+	require.Nil(t, manifest)
+}
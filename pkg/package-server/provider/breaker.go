@@ -0,0 +1,197 @@
+package provider
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	operatorsv1alpha1 "github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1"
+)
+
+const (
+	// DefaultBreakerThreshold is the default number of consecutive failures that opens a BreakerProvider
+	DefaultBreakerThreshold = 5
+	// DefaultBreakerCooldown is the default time an open BreakerProvider fails requests before probing its provider
+	DefaultBreakerCooldown = 30 * time.Second
+)
+
+// breakerState is the state of a BreakerProvider's circuit
+type breakerState string
+
+const (
+	// breakerClosed passes every request to the provider
+	breakerClosed breakerState = "closed"
+	// breakerOpen fails every request without passing it to the provider
+	breakerOpen breakerState = "open"
+	// breakerHalfOpen passes a single probe request to the provider, failing the rest, to find out if it has recovered
+	breakerHalfOpen breakerState = "half-open"
+)
+
+var _ PackageManifestProvider = &BreakerProvider{}
+var _ FilteredPackageManifestLister = &BreakerProvider{}
+var _ ChannelCSVGetter = &BreakerProvider{}
+var _ EventHistory = &BreakerProvider{}
+var _ InvalidPackageManifestLister = &BreakerProvider{}
+var _ SnapshotVersioner = &BreakerProvider{}
+
+// BreakerProvider wraps a provider with a circuit breaker, so that a broken backend isn't queried by every request.
+//
+// After threshold consecutive failed queries the breaker opens, failing queries with a ServiceUnavailable error
+// without passing them to the provider. Once the cooldown has passed, the next query is passed through as a probe:
+// if it succeeds the breaker closes, otherwise it opens for another cooldown. NotFound errors aren't failures.
+// Subscriptions and invalidations don't query the backend, so they're always passed through.
+type BreakerProvider struct {
+	provider  PackageManifestProvider
+	threshold int
+	cooldown  time.Duration
+	clock     clock.Clock
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+}
+
+// NewBreakerProvider returns a BreakerProvider for the given provider. A threshold less than 1 uses
+// DefaultBreakerThreshold and a cooldown of zero or less uses DefaultBreakerCooldown.
+func NewBreakerProvider(provider PackageManifestProvider, threshold int, cooldown time.Duration) *BreakerProvider {
+	if threshold < 1 {
+		threshold = DefaultBreakerThreshold
+	}
+	if cooldown <= 0 {
+		cooldown = DefaultBreakerCooldown
+	}
+
+	return &BreakerProvider{
+		provider:  provider,
+		threshold: threshold,
+		cooldown:  cooldown,
+		clock:     clock.RealClock{},
+		state:     breakerClosed,
+	}
+}
+
+// allow returns nil if a query may be passed to the provider, or the error to fail it with. A half-open breaker allows
+// only one query, the probe, until it's done.
+func (b *BreakerProvider) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		retry := b.openedAt.Add(b.cooldown).Sub(b.clock.Now())
+		if retry > 0 {
+			return k8serrors.NewServiceUnavailable(fmt.Sprintf("package manifest provider failed %d consecutive times, retrying in %s", b.failures, retry.Round(time.Second)))
+		}
+		b.state = breakerHalfOpen
+		return nil
+	case breakerHalfOpen:
+		return k8serrors.NewServiceUnavailable("package manifest provider is being probed after repeated failures")
+	default:
+		return nil
+	}
+}
+
+// done records the result of a query allowed by allow
+func (b *BreakerProvider) done(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil || ErrorClassOf(err) == ErrorClassNotFound {
+		b.state = breakerClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		b.state = breakerOpen
+		b.openedAt = b.clock.Now()
+	}
+}
+
+// Get returns the provider's PackageManifest unless the breaker is open
+func (b *BreakerProvider) Get(namespace, name string) (*v1alpha1.PackageManifest, error) {
+	if err := b.allow(); err != nil {
+		return nil, err
+	}
+	manifest, err := b.provider.Get(namespace, name)
+	b.done(err)
+	return manifest, err
+}
+
+// List returns the provider's PackageManifests unless the breaker is open
+func (b *BreakerProvider) List(namespace string) (*v1alpha1.PackageManifestList, error) {
+	if err := b.allow(); err != nil {
+		return nil, err
+	}
+	manifests, err := b.provider.List(namespace)
+	b.done(err)
+	return manifests, err
+}
+
+// ListFiltered returns the provider's PackageManifests, listed as ListFiltered would list them, unless the breaker is
+// open
+func (b *BreakerProvider) ListFiltered(namespace string, filter ListFilter) (*v1alpha1.PackageManifestList, error) {
+	if err := b.allow(); err != nil {
+		return nil, err
+	}
+	manifests, err := ListFiltered(b.provider, namespace, filter)
+	b.done(err)
+	return manifests, err
+}
+
+// ListInvalid returns the provider's invalid PackageManifests, listed as ListInvalid would list them, unless the
+// breaker is open
+func (b *BreakerProvider) ListInvalid(namespace string) (*v1alpha1.PackageManifestList, error) {
+	if err := b.allow(); err != nil {
+		return nil, err
+	}
+	manifests, err := ListInvalid(b.provider, namespace)
+	b.done(err)
+	return manifests, err
+}
+
+// GetChannelCSV returns the provider's channel CSV unless the breaker is open, or an error if the provider doesn't
+// keep channel CSVs
+func (b *BreakerProvider) GetChannelCSV(namespace, name, channel string) (*operatorsv1alpha1.ClusterServiceVersion, error) {
+	getter, ok := b.provider.(ChannelCSVGetter)
+	if !ok {
+		return nil, fmt.Errorf("provider %T doesn't keep channel CSVs", b.provider)
+	}
+	if err := b.allow(); err != nil {
+		return nil, err
+	}
+	csv, err := getter.GetChannelCSV(namespace, name, channel)
+	b.done(err)
+	return csv, err
+}
+
+// EventsSince returns the provider's recorded events. If the provider doesn't record events there are none to replay,
+// so watches start from the current state as they would without the breaker.
+func (b *BreakerProvider) EventsSince(namespace string, resourceVersion uint64) ([]Event, bool) {
+	history, ok := b.provider.(EventHistory)
+	if !ok {
+		return nil, true
+	}
+	return history.EventsSince(namespace, resourceVersion)
+}
+
+// SnapshotVersion returns the provider's snapshot version
+func (b *BreakerProvider) SnapshotVersion() (uint64, bool) {
+	return SnapshotVersion(b.provider)
+}
+
+// Subscribe subscribes to the provider's changes
+func (b *BreakerProvider) Subscribe(stopCh <-chan struct{}) (add, modify, delete PackageChan, err error) {
+	return b.provider.Subscribe(stopCh)
+}
+
+// Invalidate invalidates the provider's PackageManifests for the given CatalogSource
+func (b *BreakerProvider) Invalidate(catalogSourceName, catalogSourceNamespace string) {
+	b.provider.Invalidate(catalogSourceName, catalogSourceNamespace)
+}
@@ -0,0 +1,172 @@
+package provider
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	packagev1alpha1 "github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1"
+)
+
+// failingProvider fails its queries with err, if it's set, and counts the queries it's passed
+type failingProvider struct {
+	*FakeProvider
+	err     error
+	queries int
+}
+
+func (f *failingProvider) Get(namespace, name string) (*packagev1alpha1.PackageManifest, error) {
+	f.queries++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.FakeProvider.Get(namespace, name)
+}
+
+func (f *failingProvider) List(namespace string) (*packagev1alpha1.PackageManifestList, error) {
+	f.queries++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.FakeProvider.List(namespace)
+}
+
+func TestBreakerProviderTransitions(t *testing.T) {
+	prov := &failingProvider{FakeProvider: NewFakeProvider(), err: errors.New("catalog unavailable")}
+	breaker := NewBreakerProvider(prov, 3, time.Minute)
+	fakeClock := clock.NewFakeClock(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC))
+	breaker.clock = fakeClock
+
+	// closed: failures are passed through until the threshold is reached
+	for i := 1; i <= 3; i++ {
+		_, err := breaker.List("default")
+		require.EqualError(t, err, "catalog unavailable")
+		require.Equal(t, i, prov.queries)
+	}
+	require.Equal(t, breakerOpen, breaker.state)
+
+	// open: queries fail fast without reaching the provider
+	_, err := breaker.List("default")
+	require.True(t, k8serrors.IsServiceUnavailable(err), "expected ServiceUnavailable, got %v", err)
+	_, err = breaker.Get("default", "etcd")
+	require.True(t, k8serrors.IsServiceUnavailable(err), "expected ServiceUnavailable, got %v", err)
+	require.Equal(t, 3, prov.queries)
+
+	// half-open: after the cooldown a failed probe opens the breaker for another cooldown
+	fakeClock.Step(time.Minute)
+	_, err = breaker.List("default")
+	require.EqualError(t, err, "catalog unavailable")
+	require.Equal(t, 4, prov.queries)
+	require.Equal(t, breakerOpen, breaker.state)
+	_, err = breaker.List("default")
+	require.True(t, k8serrors.IsServiceUnavailable(err), "expected ServiceUnavailable, got %v", err)
+	require.Equal(t, 4, prov.queries)
+
+	// half-open: a successful probe closes the breaker
+	fakeClock.Step(time.Minute)
+	prov.err = nil
+	_, err = breaker.List("default")
+	require.NoError(t, err)
+	require.Equal(t, 5, prov.queries)
+	require.Equal(t, breakerClosed, breaker.state)
+	require.Equal(t, 0, breaker.failures)
+
+	// closed: queries are passed through again
+	_, err = breaker.Get("default", "etcd")
+	require.NoError(t, err)
+	require.Equal(t, 6, prov.queries)
+}
+
+func TestBreakerProviderHalfOpenAllowsOneProbe(t *testing.T) {
+	breaker := NewBreakerProvider(NewFakeProvider(), 1, time.Minute)
+	fakeClock := clock.NewFakeClock(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC))
+	breaker.clock = fakeClock
+
+	require.NoError(t, breaker.allow())
+	breaker.done(errors.New("catalog unavailable"))
+	require.Equal(t, breakerOpen, breaker.state)
+
+	fakeClock.Step(time.Minute)
+	require.NoError(t, breaker.allow())
+	require.Equal(t, breakerHalfOpen, breaker.state)
+
+	// queries made while the probe is in flight fail rather than piling onto the recovering provider
+	err := breaker.allow()
+	require.True(t, k8serrors.IsServiceUnavailable(err), "expected ServiceUnavailable, got %v", err)
+
+	breaker.done(nil)
+	require.Equal(t, breakerClosed, breaker.state)
+	require.NoError(t, breaker.allow())
+}
+
+func TestBreakerProviderFailureCounting(t *testing.T) {
+	tests := []struct {
+		description   string
+		errs          []error
+		expectedState breakerState
+	}{
+		{
+			description:   "Consecutive",
+			errs:          []error{errors.New("a"), errors.New("b"), errors.New("c")},
+			expectedState: breakerOpen,
+		},
+		{
+			description:   "ResetBySuccess",
+			errs:          []error{errors.New("a"), errors.New("b"), nil, errors.New("c")},
+			expectedState: breakerClosed,
+		},
+		{
+			description: "NotFoundIsNotFailure",
+			errs: []error{
+				errors.New("a"),
+				errors.New("b"),
+				k8serrors.NewNotFound(schema.GroupResource{Group: "packages.apps.redhat.com", Resource: "packagemanifests"}, "etcd"),
+				errors.New("c"),
+			},
+			expectedState: breakerClosed,
+		},
+		{
+			description: "ClassifiedNotFoundIsNotFailure",
+			errs: []error{
+				errors.New("a"),
+				errors.New("b"),
+				NewNotFoundError(errors.New("catalog source not found")),
+				errors.New("c"),
+			},
+			expectedState: breakerClosed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			prov := &failingProvider{FakeProvider: NewFakeProvider()}
+			breaker := NewBreakerProvider(prov, 3, time.Minute)
+
+			for _, err := range tt.errs {
+				prov.err = err
+				breaker.List("default")
+			}
+			require.Equal(t, tt.expectedState, breaker.state)
+			require.Equal(t, len(tt.errs), prov.queries)
+		})
+	}
+}
+
+func TestBreakerProviderPassesThroughOptionalInterfaces(t *testing.T) {
+	prov := NewFakeProvider()
+	prov.Add(packageManifest(packageValue{name: "etcd", namespace: "default"}))
+	breaker := NewBreakerProvider(prov, 1, time.Minute)
+
+	manifests, err := ListFiltered(breaker, "default", ListFilter{Name: "etcd"})
+	require.NoError(t, err)
+	require.Len(t, manifests.Items, 1)
+
+	events, ok := breaker.EventsSince("default", 0)
+	require.True(t, ok)
+	require.Len(t, events, 1)
+}
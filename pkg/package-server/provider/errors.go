@@ -0,0 +1,83 @@
+package provider
+
+import (
+	"net"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// ErrorClass classifies a provider's failure by whether retrying the query can help, so that storage can report it to
+// clients with a status that tells them whether to retry
+type ErrorClass string
+
+const (
+	// ErrorClassTransient is a failure that may not recur if the query is retried, such as a timeout. It's reported as
+	// ServiceUnavailable (503).
+	ErrorClassTransient ErrorClass = "Transient"
+	// ErrorClassPermanent is a failure that recurs until the catalog or the provider's configuration is fixed, such as
+	// a catalog that can't be parsed or credentials that are refused. It's reported as InternalError (500).
+	ErrorClassPermanent ErrorClass = "Permanent"
+	// ErrorClassNotFound is a failure to find what was queried. It's reported as NotFound (404).
+	ErrorClassNotFound ErrorClass = "NotFound"
+)
+
+// ClassifiedError is a provider error with its class
+type ClassifiedError struct {
+	Class ErrorClass
+	Err   error
+}
+
+// Error returns the message of the underlying error
+func (e *ClassifiedError) Error() string {
+	return e.Err.Error()
+}
+
+// NewTransientError returns err classified as ErrorClassTransient
+func NewTransientError(err error) error {
+	return &ClassifiedError{Class: ErrorClassTransient, Err: err}
+}
+
+// NewPermanentError returns err classified as ErrorClassPermanent
+func NewPermanentError(err error) error {
+	return &ClassifiedError{Class: ErrorClassPermanent, Err: err}
+}
+
+// NewNotFoundError returns err classified as ErrorClassNotFound
+func NewNotFoundError(err error) error {
+	return &ClassifiedError{Class: ErrorClassNotFound, Err: err}
+}
+
+// ErrorClassOf returns the class of a provider error. Errors that weren't classified by the provider are classified
+// by Classify's rules, and any error those don't recognize is permanent.
+func ErrorClassOf(err error) ErrorClass {
+	if classified, ok := Classify(err).(*ClassifiedError); ok {
+		return classified.Class
+	}
+	return ErrorClassPermanent
+}
+
+// Classify returns err classified by its type, for providers passing on the errors of the clients they query:
+// timeouts, throttling, and unavailable servers are transient, missing objects are not found, and other API errors,
+// such as refused credentials, are permanent. Errors that are already classified, and errors it doesn't recognize,
+// are returned as they are.
+func Classify(err error) error {
+	switch err.(type) {
+	case nil, *ClassifiedError:
+		return err
+	}
+
+	switch {
+	case k8serrors.IsNotFound(err):
+		return NewNotFoundError(err)
+	case k8serrors.IsTimeout(err), k8serrors.IsServerTimeout(err), k8serrors.IsTooManyRequests(err), k8serrors.IsServiceUnavailable(err):
+		return NewTransientError(err)
+	}
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		return NewTransientError(err)
+	}
+	if _, ok := err.(k8serrors.APIStatus); ok {
+		return NewPermanentError(err)
+	}
+
+	return err
+}
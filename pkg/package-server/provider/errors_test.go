@@ -0,0 +1,87 @@
+package provider
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestErrorClassOf(t *testing.T) {
+	gr := schema.GroupResource{Group: "operators.coreos.com", Resource: "catalogsources"}
+	tests := []struct {
+		description string
+		err         error
+		class       ErrorClass
+	}{
+		{
+			description: "Transient",
+			err:         NewTransientError(errors.New("registry timed out")),
+			class:       ErrorClassTransient,
+		},
+		{
+			description: "Permanent",
+			err:         NewPermanentError(errors.New("catalog can't be parsed")),
+			class:       ErrorClassPermanent,
+		},
+		{
+			description: "NotFound",
+			err:         NewNotFoundError(errors.New("catalog source not found")),
+			class:       ErrorClassNotFound,
+		},
+		{
+			description: "Unclassified",
+			err:         errors.New("catalog unavailable"),
+			class:       ErrorClassPermanent,
+		},
+		{
+			description: "APINotFound",
+			err:         k8serrors.NewNotFound(gr, "ocs"),
+			class:       ErrorClassNotFound,
+		},
+		{
+			description: "APITimeout",
+			err:         k8serrors.NewTimeoutError("timed out", 1),
+			class:       ErrorClassTransient,
+		},
+		{
+			description: "APITooManyRequests",
+			err:         k8serrors.NewTooManyRequests("throttled", 1),
+			class:       ErrorClassTransient,
+		},
+		{
+			description: "APIServiceUnavailable",
+			err:         k8serrors.NewServiceUnavailable("unavailable"),
+			class:       ErrorClassTransient,
+		},
+		{
+			description: "APIForbidden",
+			err:         k8serrors.NewForbidden(gr, "ocs", errors.New("refused")),
+			class:       ErrorClassPermanent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			require.Equal(t, tt.class, ErrorClassOf(tt.err))
+		})
+	}
+}
+
+func TestClassify(t *testing.T) {
+	require.NoError(t, Classify(nil))
+
+	// errors it doesn't recognize are returned as they are
+	unrecognized := errors.New("catalog unavailable")
+	require.Equal(t, unrecognized, Classify(unrecognized))
+
+	// classified errors keep their class and message
+	transient := NewTransientError(errors.New("registry timed out"))
+	require.Equal(t, transient, Classify(transient))
+	timeout := k8serrors.NewTimeoutError("timed out", 1)
+	classified := Classify(timeout)
+	require.Equal(t, timeout.Error(), classified.Error())
+	require.Equal(t, &ClassifiedError{Class: ErrorClassTransient, Err: timeout}, classified)
+}
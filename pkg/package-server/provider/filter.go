@@ -0,0 +1,39 @@
+package provider
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1"
+)
+
+// ListFiltered lists the PackageManifests in a namespace, passing the filter to providers that implement
+// FilteredPackageManifestLister. Other providers fall back to their name index if the filter names a single package in
+// a single namespace, or to listing every PackageManifest in the namespace.
+// The returned list may include PackageManifests that don't match the filter.
+func ListFiltered(prov PackageManifestProvider, namespace string, filter ListFilter) (*v1alpha1.PackageManifestList, error) {
+	if filtered, ok := prov.(FilteredPackageManifestLister); ok {
+		return filtered.ListFiltered(namespace, filter)
+	}
+	if named, ok := prov.(NamedPackageManifestLister); ok && filter.Name != "" && namespace != metav1.NamespaceAll {
+		return named.ListNamed(namespace, filter.Name)
+	}
+	return prov.List(namespace)
+}
+
+// ListInvalid lists the invalid PackageManifests in a namespace kept by providers that implement
+// InvalidPackageManifestLister. Other providers don't keep the packages they drop, so there are none to list.
+func ListInvalid(prov PackageManifestProvider, namespace string) (*v1alpha1.PackageManifestList, error) {
+	if invalid, ok := prov.(InvalidPackageManifestLister); ok {
+		return invalid.ListInvalid(namespace)
+	}
+	return &v1alpha1.PackageManifestList{}, nil
+}
+
+// SnapshotVersion returns the current snapshot version of providers that implement SnapshotVersioner. Other providers
+// don't version their snapshots.
+func SnapshotVersion(prov PackageManifestProvider) (uint64, bool) {
+	if versioner, ok := prov.(SnapshotVersioner); ok {
+		return versioner.SnapshotVersion()
+	}
+	return 0, false
+}
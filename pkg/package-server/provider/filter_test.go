@@ -0,0 +1,98 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1"
+)
+
+// namedProvider lists only the PackageManifest named by ListNamed
+type namedProvider struct {
+	*FakeProvider
+}
+
+func (n namedProvider) ListNamed(namespace, name string) (*v1alpha1.PackageManifestList, error) {
+	list := &v1alpha1.PackageManifestList{}
+	manifest, err := n.Get(namespace, name)
+	if manifest != nil {
+		list.Items = append(list.Items, *manifest)
+	}
+	return list, err
+}
+
+// filteredProvider lists only the PackageManifests with the filter's name
+type filteredProvider struct {
+	*FakeProvider
+	filter ListFilter
+}
+
+func (f *filteredProvider) ListFiltered(namespace string, filter ListFilter) (*v1alpha1.PackageManifestList, error) {
+	f.filter = filter
+	return namedProvider{f.FakeProvider}.ListNamed(namespace, filter.Name)
+}
+
+func TestListFiltered(t *testing.T) {
+	manifest := func(name string) v1alpha1.PackageManifest {
+		return v1alpha1.PackageManifest{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"}}
+	}
+	fake := func() *FakeProvider {
+		prov := NewFakeProvider()
+		prov.Add(manifest("etcd"))
+		prov.Add(manifest("prometheus"))
+		return prov
+	}
+	filter := ListFilter{Name: "etcd", Labels: labels.SelectorFromSet(labels.Set{"provider": "acme"})}
+
+	tests := []struct {
+		description   string
+		prov          PackageManifestProvider
+		namespace     string
+		expectedNames []string
+	}{
+		{
+			description:   "List",
+			prov:          fake(),
+			namespace:     "ns",
+			expectedNames: []string{"etcd", "prometheus"},
+		},
+		{
+			description:   "ListNamed",
+			prov:          namedProvider{fake()},
+			namespace:     "ns",
+			expectedNames: []string{"etcd"},
+		},
+		{
+			description:   "ListNamed/AllNamespaces",
+			prov:          namedProvider{fake()},
+			namespace:     metav1.NamespaceAll,
+			expectedNames: []string{"etcd", "prometheus"},
+		},
+		{
+			description:   "ListFiltered",
+			prov:          &filteredProvider{FakeProvider: fake()},
+			namespace:     "ns",
+			expectedNames: []string{"etcd"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			list, err := ListFiltered(tt.prov, tt.namespace, filter)
+			require.NoError(t, err)
+
+			names := []string{}
+			for _, manifest := range list.Items {
+				names = append(names, manifest.GetName())
+			}
+			require.ElementsMatch(t, tt.expectedNames, names)
+
+			if filtered, ok := tt.prov.(*filteredProvider); ok {
+				require.Equal(t, filter, filtered.filter)
+			}
+		})
+	}
+}
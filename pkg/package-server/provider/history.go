@@ -0,0 +1,142 @@
+package provider
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1"
+)
+
+// DefaultEventHistorySize is the default number of recent events a provider keeps for each namespace
+const DefaultEventHistorySize = 100
+
+// Event is a change to a PackageManifest, along with the resourceVersion of the list that first includes it
+type Event struct {
+	Type            watch.EventType
+	Manifest        v1alpha1.PackageManifest
+	ResourceVersion uint64
+}
+
+// eventHistory keeps a ring buffer of the most recent events in each namespace, so that watches resuming from a recent
+// resourceVersion can be replayed the events they missed.
+// It has its own lock, since providers record events while holding theirs and block until subscribers receive them.
+type eventHistory struct {
+	size int
+
+	// seq orders events across namespaces that share a resourceVersion
+	seq     uint64
+	events  map[string][]recordedEvent
+	evicted map[string]uint64
+
+	mu sync.Mutex
+}
+
+type recordedEvent struct {
+	Event
+	seq uint64
+}
+
+// newEventHistory returns an eventHistory that keeps up to size events per namespace.
+// A size less than 1 uses DefaultEventHistorySize.
+func newEventHistory(size int) *eventHistory {
+	if size < 1 {
+		size = DefaultEventHistorySize
+	}
+
+	return &eventHistory{
+		size:    size,
+		events:  make(map[string][]recordedEvent),
+		evicted: make(map[string]uint64),
+	}
+}
+
+// setSize changes the number of events kept per namespace, evicting the oldest events of namespaces over the new size.
+// A size less than 1 uses DefaultEventHistorySize.
+func (h *eventHistory) setSize(size int) {
+	if size < 1 {
+		size = DefaultEventHistorySize
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.size = size
+	for namespace, events := range h.events {
+		for len(events) > size {
+			h.evict(namespace)
+			events = h.events[namespace]
+		}
+	}
+}
+
+// evict discards the oldest event of a namespace. Callers must hold the lock.
+func (h *eventHistory) evict(namespace string) {
+	events := h.events[namespace]
+	if events[0].ResourceVersion > h.evicted[namespace] {
+		h.evicted[namespace] = events[0].ResourceVersion
+	}
+	h.events[namespace] = events[1:]
+}
+
+// record adds an event to its namespace's history, evicting the namespace's oldest event if the history is full.
+// The manifest is stamped with the event's resourceVersion, and the stamped copy is returned for delivery to
+// subscribers.
+func (h *eventHistory) record(eventType watch.EventType, manifest v1alpha1.PackageManifest, resourceVersion uint64) v1alpha1.PackageManifest {
+	manifest.ResourceVersion = strconv.FormatUint(resourceVersion, 10)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	namespace := manifest.GetNamespace()
+	if len(h.events[namespace]) >= h.size {
+		h.evict(namespace)
+	}
+	h.seq++
+	h.events[namespace] = append(h.events[namespace], recordedEvent{
+		Event: Event{Type: eventType, Manifest: manifest, ResourceVersion: resourceVersion},
+		seq:   h.seq,
+	})
+
+	return manifest
+}
+
+// since returns the recorded events in the namespace, or in every namespace for metav1.NamespaceAll, that are newer than
+// resourceVersion, oldest first.
+// It returns false if any of those events have already been evicted, in which case the caller must relist.
+func (h *eventHistory) since(namespace string, resourceVersion uint64) ([]Event, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	namespaces := []string{namespace}
+	if namespace == metav1.NamespaceAll {
+		namespaces = namespaces[:0]
+		for ns := range h.events {
+			namespaces = append(namespaces, ns)
+		}
+	}
+
+	recorded := []recordedEvent{}
+	for _, ns := range namespaces {
+		if h.evicted[ns] > resourceVersion {
+			return nil, false
+		}
+		for _, event := range h.events[ns] {
+			if event.ResourceVersion > resourceVersion {
+				recorded = append(recorded, event)
+			}
+		}
+	}
+	sort.Slice(recorded, func(i, j int) bool {
+		return recorded[i].seq < recorded[j].seq
+	})
+
+	events := make([]Event, 0, len(recorded))
+	for _, event := range recorded {
+		events = append(events, event.Event)
+	}
+
+	return events, true
+}
@@ -0,0 +1,152 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1"
+)
+
+func TestEventHistory(t *testing.T) {
+	manifest := func(namespace, name string) v1alpha1.PackageManifest {
+		return v1alpha1.PackageManifest{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	}
+
+	type recorded struct {
+		eventType       watch.EventType
+		manifest        v1alpha1.PackageManifest
+		resourceVersion uint64
+	}
+
+	tests := []struct {
+		size            int
+		resize          int
+		recorded        []recorded
+		namespace       string
+		resourceVersion uint64
+		expected        []string
+		expectedOK      bool
+		description     string
+	}{
+		{
+			size: 3,
+			recorded: []recorded{
+				{watch.Added, manifest("ns", "etcd"), 1},
+				{watch.Modified, manifest("ns", "etcd"), 2},
+				{watch.Deleted, manifest("ns", "etcd"), 3},
+			},
+			namespace:       "ns",
+			resourceVersion: 1,
+			expected:        []string{"MODIFIED ns/etcd@2", "DELETED ns/etcd@3"},
+			expectedOK:      true,
+			description:     "WithinWindow",
+		},
+		{
+			size: 2,
+			recorded: []recorded{
+				{watch.Added, manifest("ns", "etcd"), 1},
+				{watch.Added, manifest("ns", "kafka"), 2},
+				{watch.Added, manifest("ns", "vault"), 3},
+			},
+			namespace:       "ns",
+			resourceVersion: 0,
+			expectedOK:      false,
+			description:     "Evicted",
+		},
+		{
+			size: 2,
+			recorded: []recorded{
+				{watch.Added, manifest("ns", "etcd"), 1},
+				{watch.Added, manifest("ns", "kafka"), 2},
+				{watch.Added, manifest("ns", "vault"), 3},
+			},
+			namespace:       "ns",
+			resourceVersion: 1,
+			expected:        []string{"ADDED ns/kafka@2", "ADDED ns/vault@3"},
+			expectedOK:      true,
+			description:     "EvictedAlreadySeen",
+		},
+		{
+			size: 2,
+			recorded: []recorded{
+				{watch.Added, manifest("ns", "etcd"), 1},
+				{watch.Added, manifest("ns", "kafka"), 1},
+				{watch.Added, manifest("ns", "vault"), 1},
+			},
+			namespace:       "ns",
+			resourceVersion: 0,
+			expectedOK:      false,
+			description:     "PartlyEvictedResourceVersion",
+		},
+		{
+			size: 2,
+			recorded: []recorded{
+				{watch.Added, manifest("a", "etcd"), 1},
+				{watch.Added, manifest("b", "kafka"), 2},
+				{watch.Added, manifest("a", "vault"), 3},
+				{watch.Added, manifest("a", "prometheus"), 4},
+			},
+			namespace:       metav1.NamespaceAll,
+			resourceVersion: 1,
+			expected:        []string{"ADDED b/kafka@2", "ADDED a/vault@3", "ADDED a/prometheus@4"},
+			expectedOK:      true,
+			description:     "AllNamespacesInOrder",
+		},
+		{
+			size: 2,
+			recorded: []recorded{
+				{watch.Added, manifest("a", "etcd"), 1},
+				{watch.Added, manifest("a", "vault"), 2},
+				{watch.Added, manifest("a", "prometheus"), 3},
+				{watch.Added, manifest("b", "kafka"), 4},
+			},
+			namespace:       "b",
+			resourceVersion: 0,
+			expected:        []string{"ADDED b/kafka@4"},
+			expectedOK:      true,
+			description:     "PerNamespaceWindow",
+		},
+		{
+			size:   3,
+			resize: 1,
+			recorded: []recorded{
+				{watch.Added, manifest("ns", "etcd"), 1},
+				{watch.Added, manifest("ns", "kafka"), 2},
+				{watch.Added, manifest("ns", "vault"), 3},
+			},
+			namespace:       "ns",
+			resourceVersion: 1,
+			expectedOK:      false,
+			description:     "Shrunk",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			history := newEventHistory(test.size)
+			for _, r := range test.recorded {
+				stamped := history.record(r.eventType, r.manifest, r.resourceVersion)
+				require.Empty(t, r.manifest.GetResourceVersion(), "recorded manifest modified")
+				require.NotEmpty(t, stamped.GetResourceVersion())
+			}
+			if test.resize > 0 {
+				history.setSize(test.resize)
+			}
+
+			events, ok := history.since(test.namespace, test.resourceVersion)
+			require.Equal(t, test.expectedOK, ok)
+			if !ok {
+				return
+			}
+
+			described := []string{}
+			for _, event := range events {
+				described = append(described, string(event.Type)+" "+event.Manifest.GetNamespace()+"/"+event.Manifest.GetName()+"@"+event.Manifest.GetResourceVersion())
+			}
+			require.Equal(t, test.expected, described)
+		})
+	}
+}
@@ -3,13 +3,17 @@ package provider
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
 	"sync"
-	"time"
 
 	"github.com/ghodss/yaml"
 	log "github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
 
@@ -32,13 +36,61 @@ type packageKey struct {
 	packageName            string
 }
 
+// nameKey identifies the PackageManifests served under a namespace and name
+type nameKey struct {
+	namespace string
+	name      string
+}
+
+// catalogKey identifies a CatalogSource
+type catalogKey struct {
+	name      string
+	namespace string
+}
+
+// csvKey identifies a CSV provided by a CatalogSource
+type csvKey struct {
+	catalogSourceName      string
+	catalogSourceNamespace string
+	name                   string
+}
+
+var _ PackageManifestProvider = &InMemoryProvider{}
+var _ NamedPackageManifestLister = &InMemoryProvider{}
+var _ FilteredPackageManifestLister = &InMemoryProvider{}
+var _ ChannelCSVGetter = &InMemoryProvider{}
+var _ EventHistory = &InMemoryProvider{}
+var _ InvalidPackageManifestLister = &InMemoryProvider{}
+var _ SnapshotVersioner = &InMemoryProvider{}
+
 // InMemoryProvider syncs and provides PackageManifests from the cluster using an in-memory cache.
 // Should be a global singleton.
 type InMemoryProvider struct {
 	*queueinformer.Operator
 	mu sync.RWMutex
 
+	// informers are the CatalogSource informers, which hold the latest version of each CatalogSource
+	informers []cache.SharedIndexInformer
+	// invalidated holds the CatalogSources that must be synced again before the cached manifests are next served
+	invalidated map[catalogKey]struct{}
+
 	manifests map[packageKey]packagev1alpha1.PackageManifest
+	// index holds the keys of the cached manifests for each namespace and name, in the order they were first seen
+	index map[nameKey][]packageKey
+	// apiIndex holds the keys of the cached manifests providing each API, formatted by packagev1alpha1.ProvidedAPI
+	apiIndex map[string][]packageKey
+	// installModeIndex holds the keys of the cached manifests supporting each install mode
+	installModeIndex map[string][]packageKey
+	// invalid holds the packages each CatalogSource provided in its last sync that failed validation
+	invalid map[catalogKey][]packagev1alpha1.PackageManifest
+	// csvs holds the CSVs provided by each CatalogSource, so that channels can be resolved to their current CSV
+	csvs map[csvKey]operatorsv1alpha1.ClusterServiceVersion
+	// generation is incremented each time the cached manifests change and is served as the list resourceVersion
+	generation uint64
+	// history holds the most recent events sent to subscribers
+	history *eventHistory
+	// clock stamps the CreationTimestamp of newly seen manifests
+	clock clock.Clock
 
 	add    []chan packagev1alpha1.PackageManifest
 	modify []chan packagev1alpha1.PackageManifest
@@ -48,8 +100,18 @@ type InMemoryProvider struct {
 // NewInMemoryProvider returns a pointer to a new InMemoryProvider instance
 func NewInMemoryProvider(informers []cache.SharedIndexInformer, queueOperator *queueinformer.Operator) *InMemoryProvider {
 	prov := &InMemoryProvider{
-		Operator:  queueOperator,
-		manifests: make(map[packageKey]packagev1alpha1.PackageManifest),
+		Operator:    queueOperator,
+		informers:   informers,
+		invalidated: make(map[catalogKey]struct{}),
+		manifests:   make(map[packageKey]packagev1alpha1.PackageManifest),
+		index:       make(map[nameKey][]packageKey),
+		apiIndex:    make(map[string][]packageKey),
+		invalid:     make(map[catalogKey][]packagev1alpha1.PackageManifest),
+		csvs:        make(map[csvKey]operatorsv1alpha1.ClusterServiceVersion),
+		history:     newEventHistory(DefaultEventHistorySize),
+		clock:       clock.RealClock{},
+
+		installModeIndex: make(map[string][]packageKey),
 	}
 
 	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "catalogsources")
@@ -68,8 +130,10 @@ func NewInMemoryProvider(informers []cache.SharedIndexInformer, queueOperator *q
 	return prov
 }
 
-// parsePackageManifestsFromConfigMap returns a list of PackageManifests from a given ConfigMap
-func parsePackageManifestsFromConfigMap(cm *corev1.ConfigMap, catalogSourceName, catalogSourceNamespace string) ([]packagev1alpha1.PackageManifest, error) {
+// parsePackageManifestsFromConfigMap returns a list of PackageManifests from a given ConfigMap, along with the CSVs it
+// contains keyed by name. Packages that fail validation are returned separately, as invalid PackageManifests with a
+// False PackageManifestValid condition, rather than failing the whole ConfigMap.
+func parsePackageManifestsFromConfigMap(cm *corev1.ConfigMap, catalogSourceName, catalogSourceNamespace string) ([]packagev1alpha1.PackageManifest, []packagev1alpha1.PackageManifest, map[string]operatorsv1alpha1.ClusterServiceVersion, error) {
 	cmName := cm.GetName()
 	logger := log.WithFields(log.Fields{
 		"Action": "Load ConfigMap",
@@ -84,14 +148,14 @@ func parsePackageManifestsFromConfigMap(cm *corev1.ConfigMap, catalogSourceName,
 		csvListJSON, err := yaml.YAMLToJSON([]byte(csvListYaml))
 		if err != nil {
 			log.Debugf("Load ConfigMap     -- ERROR %s : error=%s", cmName, err)
-			return nil, fmt.Errorf("error loading CSV list yaml from ConfigMap %s: %s", cmName, err)
+			return nil, nil, nil, fmt.Errorf("error loading CSV list yaml from ConfigMap %s: %s", cmName, err)
 		}
 
 		var parsedCSVList []operatorsv1alpha1.ClusterServiceVersion
 		err = json.Unmarshal([]byte(csvListJSON), &parsedCSVList)
 		if err != nil {
 			log.Debugf("Load ConfigMap     -- ERROR %s : error=%s", cmName, err)
-			return nil, fmt.Errorf("error parsing CSV list (json) from ConfigMap %s: %s", cmName, err)
+			return nil, nil, nil, fmt.Errorf("error parsing CSV list (json) from ConfigMap %s: %s", cmName, err)
 		}
 
 		for _, csv := range parsedCSVList {
@@ -104,20 +168,21 @@ func parsePackageManifestsFromConfigMap(cm *corev1.ConfigMap, catalogSourceName,
 	}
 
 	manifests := []packagev1alpha1.PackageManifest{}
+	invalid := []packagev1alpha1.PackageManifest{}
 	packageListYaml, ok := cm.Data[ConfigMapPackageName]
 	if ok {
 		logger.Debug("ConfigMap contains packages")
 		packageListJSON, err := yaml.YAMLToJSON([]byte(packageListYaml))
 		if err != nil {
 			logger.Debugf("ERROR: %s", err)
-			return nil, fmt.Errorf("error loading package list yaml from ConfigMap %s: %s", cmName, err)
+			return nil, nil, nil, fmt.Errorf("error loading package list yaml from ConfigMap %s: %s", cmName, err)
 		}
 
 		var parsedStatuses []packagev1alpha1.PackageManifestStatus
 		err = json.Unmarshal([]byte(packageListJSON), &parsedStatuses)
 		if err != nil {
 			logger.Debugf("ERROR: %s", err)
-			return nil, fmt.Errorf("error parsing package list (json) from ConfigMap %s: %s", cmName, err)
+			return nil, nil, nil, fmt.Errorf("error parsing package list (json) from ConfigMap %s: %s", cmName, err)
 		}
 
 		for _, status := range parsedStatuses {
@@ -137,13 +202,24 @@ func parsePackageManifestsFromConfigMap(cm *corev1.ConfigMap, catalogSourceName,
 			manifest.Status.CatalogSourceNamespace = catalogSourceNamespace
 
 			// add all PackageChannel CSVDescriptions
+			var reason string
+			var failures []string
 			for i, channel := range manifest.Status.Channels {
 				csv, ok := csvs[channel.CurrentCSVName]
 				if !ok {
-					return nil, fmt.Errorf("packagemanifest %s references non-existent csv %s", manifest.Status.PackageName, channel.CurrentCSVName)
+					if reason == "" {
+						reason = packagev1alpha1.PackageManifestReasonCSVNotFound
+					}
+					failures = append(failures, fmt.Sprintf("channel %s references non-existent csv %s", channel.Name, channel.CurrentCSVName))
+					continue
 				}
 
 				manifest.Status.Channels[i].CurrentCSVDesc = packagev1alpha1.CreateCSVDescription(&csv)
+				manifest.Status.Channels[i].CurrentCSVSource = packagev1alpha1.CSVSource{
+					CatalogSourceName:      catalogSourceName,
+					CatalogSourceNamespace: catalogSourceNamespace,
+					BundlePath:             fmt.Sprintf("%s/%s", cmName, ConfigMapCSVName),
+				}
 
 				// set the Provider
 				if manifest.Status.DefaultChannelName != "" && csv.GetName() == manifest.Status.DefaultChannelName || i == 0 {
@@ -158,10 +234,29 @@ func parsePackageManifestsFromConfigMap(cm *corev1.ConfigMap, catalogSourceName,
 				}
 			}
 
+			if manifest.Status.DefaultChannelName != "" && !hasChannel(manifest, manifest.Status.DefaultChannelName) {
+				if reason == "" {
+					reason = packagev1alpha1.PackageManifestReasonDefaultChannelNotFound
+				}
+				failures = append(failures, fmt.Sprintf("default channel %s isn't one of the package's channels", manifest.Status.DefaultChannelName))
+			}
+
 			// set CatalogSource labels
 			manifest.ObjectMeta.Labels["catalog"] = manifest.Status.CatalogSourceName
 			manifest.ObjectMeta.Labels["catalog-namespace"] = manifest.Status.CatalogSourceNamespace
 
+			if len(failures) > 0 {
+				manifest.Status.Conditions = []packagev1alpha1.PackageManifestCondition{{
+					Type:    packagev1alpha1.PackageManifestValid,
+					Status:  corev1.ConditionFalse,
+					Reason:  reason,
+					Message: strings.Join(failures, "; "),
+				}}
+				logger.Warnf("dropping invalid packagemanifest %s: %s", manifest.GetName(), manifest.Status.Conditions[0].Message)
+				invalid = append(invalid, manifest)
+				continue
+			}
+
 			log.Debugf("retrieved packagemanifest %s", manifest.GetName())
 			manifests = append(manifests, manifest)
 		}
@@ -169,10 +264,20 @@ func parsePackageManifestsFromConfigMap(cm *corev1.ConfigMap, catalogSourceName,
 
 	if !found {
 		logger.Debug("ERROR: No valid resource found")
-		return nil, fmt.Errorf("error parsing ConfigMap %s: no valid resources found", cmName)
+		return nil, nil, nil, fmt.Errorf("error parsing ConfigMap %s: no valid resources found", cmName)
 	}
 
-	return manifests, nil
+	return manifests, invalid, csvs, nil
+}
+
+// hasChannel returns true if the manifest has a channel with the given name
+func hasChannel(manifest packagev1alpha1.PackageManifest, name string) bool {
+	for _, channel := range manifest.Status.Channels {
+		if channel.Name == name {
+			return true
+		}
+	}
+	return false
 }
 
 func (m *InMemoryProvider) syncCatalogSource(obj interface{}) error {
@@ -180,10 +285,11 @@ func (m *InMemoryProvider) syncCatalogSource(obj interface{}) error {
 	catsrc, ok := obj.(*operatorsv1alpha1.CatalogSource)
 	if !ok {
 		log.Debugf("wrong type: %#v", obj)
-		return fmt.Errorf("casting catalog source failed")
+		return NewPermanentError(fmt.Errorf("casting catalog source failed"))
 	}
 
-	var manifests []packagev1alpha1.PackageManifest
+	var manifests, invalid []packagev1alpha1.PackageManifest
+	var csvs map[string]operatorsv1alpha1.ClusterServiceVersion
 
 	// handle by sourceType
 	switch catsrc.Spec.SourceType {
@@ -191,17 +297,17 @@ func (m *InMemoryProvider) syncCatalogSource(obj interface{}) error {
 		// get the CatalogSource's ConfigMap
 		cm, err := m.OpClient.KubernetesInterface().CoreV1().ConfigMaps(catsrc.GetNamespace()).Get(catsrc.Spec.ConfigMap, metav1.GetOptions{})
 		if err != nil {
-			return fmt.Errorf("failed to get catalog config map %s when updating status: %s", catsrc.Spec.ConfigMap, err)
+			return &ClassifiedError{Class: ErrorClassOf(err), Err: fmt.Errorf("failed to get catalog config map %s when updating status: %s", catsrc.Spec.ConfigMap, err)}
 		}
 
 		// parse PackageManifest from ConfigMap
-		manifests, err = parsePackageManifestsFromConfigMap(cm, catsrc.GetName(), catsrc.GetNamespace())
+		manifests, invalid, csvs, err = parsePackageManifestsFromConfigMap(cm, catsrc.GetName(), catsrc.GetNamespace())
 		if err != nil {
-			return fmt.Errorf("failed to load package manifest from config map %s", cm.GetName())
+			return NewPermanentError(fmt.Errorf("failed to load package manifest from config map %s", cm.GetName()))
 		}
 
 	default:
-		return fmt.Errorf("catalog source %s in namespace %s source type %s not recognized", catsrc.GetName(), catsrc.GetNamespace(), catsrc.Spec.SourceType)
+		return NewPermanentError(fmt.Errorf("catalog source %s in namespace %s source type %s not recognized", catsrc.GetName(), catsrc.GetNamespace(), catsrc.Spec.SourceType))
 	}
 
 	// update manifests
@@ -215,37 +321,254 @@ func (m *InMemoryProvider) syncCatalogSource(obj interface{}) error {
 		}
 
 		if pm, ok := m.manifests[key]; ok {
-			// use existing CreationTimestamp
+			// use existing CreationTimestamp, and keep the resourceVersion of a manifest that hasn't changed so that
+			// clients can tell it's unchanged
 			manifest.CreationTimestamp = pm.ObjectMeta.CreationTimestamp
+			manifest.ResourceVersion = pm.ResourceVersion
+			if !equality.Semantic.DeepEqual(manifest, pm) {
+				manifest = m.history.record(watch.Modified, manifest, m.generation+1)
+				for _, ch := range m.modify {
+					ch <- manifest
+				}
+			}
 		} else {
 			// set CreationTimestamp if first time seeing the PackageManifest
-			manifest.CreationTimestamp = metav1.NewTime(time.Now())
+			manifest.CreationTimestamp = metav1.NewTime(m.clock.Now())
+			// the event is first included in the list served once this sync completes
+			manifest = m.history.record(watch.Added, manifest, m.generation+1)
 			for _, ch := range m.add {
 				ch <- manifest
 			}
 		}
 
-		m.manifests[key] = manifest
+		m.put(key, manifest)
+	}
+	for name, csv := range csvs {
+		m.csvs[csvKey{catalogSourceName: catsrc.GetName(), catalogSourceNamespace: catsrc.GetNamespace(), name: name}] = csv
 	}
+	m.invalid[catalogKey{name: catsrc.GetName(), namespace: catsrc.GetNamespace()}] = invalid
+	m.generation++
 
 	return nil
 }
 
-func (m *InMemoryProvider) Get(namespace, name string) (*packagev1alpha1.PackageManifest, error) {
+// SetEventHistorySize sets the number of recent events kept for each namespace, evicting any over the new size.
+// A size less than 1 uses DefaultEventHistorySize.
+func (m *InMemoryProvider) SetEventHistorySize(size int) {
+	m.history.setSize(size)
+}
+
+// EventsSince returns the events sent to subscribers in the namespace after the given resourceVersion
+func (m *InMemoryProvider) EventsSince(namespace string, resourceVersion uint64) ([]Event, bool) {
+	return m.history.since(namespace, resourceVersion)
+}
+
+// SnapshotVersion returns the generation of the cached manifests, which is served as the list resourceVersion
+func (m *InMemoryProvider) SnapshotVersion() (uint64, bool) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
+	return m.generation, true
+}
+
+// Invalidate marks a CatalogSource to be synced again before the cached manifests are next served, rather than waiting
+// for its queued sync
+func (m *InMemoryProvider) Invalidate(catalogSourceName, catalogSourceNamespace string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.invalidated[catalogKey{name: catalogSourceName, namespace: catalogSourceNamespace}] = struct{}{}
+}
+
+// syncInvalidated syncs the invalidated CatalogSources from their latest versions in the informers' caches.
+// A CatalogSource that fails to sync isn't retried here, since its queued sync will retry it.
+func (m *InMemoryProvider) syncInvalidated() {
+	m.mu.RLock()
+	pending := len(m.invalidated)
+	m.mu.RUnlock()
+	if pending == 0 {
+		return
+	}
+
+	m.mu.Lock()
+	invalidated := m.invalidated
+	m.invalidated = make(map[catalogKey]struct{})
+	m.mu.Unlock()
+
+	for key := range invalidated {
+		logger := log.WithFields(log.Fields{"catalogSource": key.name, "namespace": key.namespace})
+		catsrc, ok := m.catalogSource(key)
+		if !ok {
+			logger.Debug("invalidated catalog source not found")
+			continue
+		}
+		if err := m.syncCatalogSource(catsrc); err != nil {
+			logger.Warnf("failed to sync invalidated catalog source: %s", err)
+		}
+	}
+}
 
-	var manifest packagev1alpha1.PackageManifest
-	for key, pm := range m.manifests {
-		if key.packageName == name && key.catalogSourceNamespace == namespace {
-			manifest = pm
+// catalogSource returns the latest version of a CatalogSource held by the informers
+func (m *InMemoryProvider) catalogSource(key catalogKey) (*operatorsv1alpha1.CatalogSource, bool) {
+	for _, informer := range m.informers {
+		obj, exists, err := informer.GetStore().GetByKey(key.namespace + "/" + key.name)
+		if err != nil || !exists {
+			continue
+		}
+		if catsrc, ok := obj.(*operatorsv1alpha1.CatalogSource); ok {
+			return catsrc, true
 		}
 	}
 
+	return nil, false
+}
+
+// put caches a manifest under the given key and indexes it by namespace and name, by the APIs it provides, and by
+// the install modes it supports.
+// Callers must hold the write lock.
+func (m *InMemoryProvider) put(key packageKey, manifest packagev1alpha1.PackageManifest) {
+	if old, ok := m.manifests[key]; ok {
+		unindex(m.apiIndex, old.ProvidedAPIs(), key)
+		unindex(m.installModeIndex, old.InstallModes(), key)
+	} else {
+		nk := nameKey{namespace: manifest.GetNamespace(), name: manifest.GetName()}
+		m.index[nk] = append(m.index[nk], key)
+	}
+	for _, api := range manifest.ProvidedAPIs() {
+		m.apiIndex[api] = append(m.apiIndex[api], key)
+	}
+	for _, mode := range manifest.InstallModes() {
+		m.installModeIndex[mode] = append(m.installModeIndex[mode], key)
+	}
+	m.manifests[key] = manifest
+}
+
+// unindex removes the given key from the index entries for values, dropping entries left empty
+func unindex(index map[string][]packageKey, values []string, key packageKey) {
+	for _, value := range values {
+		index[value] = withoutKey(index[value], key)
+		if len(index[value]) == 0 {
+			delete(index, value)
+		}
+	}
+}
+
+// withoutKey returns keys without the given key, reusing its backing array
+func withoutKey(keys []packageKey, key packageKey) []packageKey {
+	kept := keys[:0]
+	for _, k := range keys {
+		if k != key {
+			kept = append(kept, k)
+		}
+	}
+	return kept
+}
+
+// Get returns the PackageManifest with the given name in the given namespace.
+// If more than one CatalogSource provides the package, the first one seen is returned. If none does, it returns nil.
+func (m *InMemoryProvider) Get(namespace, name string) (*packagev1alpha1.PackageManifest, error) {
+	m.syncInvalidated()
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	keys := m.index[nameKey{namespace: namespace, name: name}]
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	manifest := m.manifests[keys[0]]
+
 	return &manifest, nil
 }
 
+// ListNamed returns the PackageManifests with the given name in the given namespace using the name index
+func (m *InMemoryProvider) ListNamed(namespace, name string) (*packagev1alpha1.PackageManifestList, error) {
+	m.syncInvalidated()
+
+	manifestList := &packagev1alpha1.PackageManifestList{}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, key := range m.index[nameKey{namespace: namespace, name: name}] {
+		manifestList.Items = append(manifestList.Items, m.manifests[key])
+	}
+	manifestList.ResourceVersion = strconv.FormatUint(m.generation, 10)
+
+	return manifestList, nil
+}
+
+// ListFiltered returns the PackageManifests in the given namespace providing the filter's API and supporting its install
+// mode using the API and install mode indexes, and with the filter's name if it has one. Without an API or install
+// mode it lists by name as ListFiltered would for a NamedPackageManifestLister. Labels aren't indexed, so the filter's
+// label selector is ignored.
+func (m *InMemoryProvider) ListFiltered(namespace string, filter ListFilter) (*packagev1alpha1.PackageManifestList, error) {
+	if filter.ProvidedAPI == "" && filter.InstallMode == "" {
+		if filter.Name != "" && namespace != metav1.NamespaceAll {
+			return m.ListNamed(namespace, filter.Name)
+		}
+		return m.List(namespace)
+	}
+
+	m.syncInvalidated()
+
+	manifestList := &packagev1alpha1.PackageManifestList{}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	keys := m.apiIndex[filter.ProvidedAPI]
+	if filter.ProvidedAPI == "" {
+		keys = m.installModeIndex[filter.InstallMode]
+	}
+	for _, key := range keys {
+		manifest := m.manifests[key]
+		if namespace != metav1.NamespaceAll && manifest.GetNamespace() != namespace {
+			continue
+		}
+		if filter.Name != "" && manifest.GetName() != filter.Name {
+			continue
+		}
+		if filter.InstallMode != "" && !manifest.SupportsInstallMode(filter.InstallMode) {
+			continue
+		}
+		manifestList.Items = append(manifestList.Items, manifest)
+	}
+	manifestList.ResourceVersion = strconv.FormatUint(m.generation, 10)
+
+	return manifestList, nil
+}
+
+// GetChannelCSV returns the current CSV of a channel of the PackageManifest with the given name in the given namespace,
+// or nil if there's no such package or channel.
+// If more than one CatalogSource provides the package, the channel is resolved from the first one seen, as with Get.
+func (m *InMemoryProvider) GetChannelCSV(namespace, name, channel string) (*operatorsv1alpha1.ClusterServiceVersion, error) {
+	m.syncInvalidated()
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	keys := m.index[nameKey{namespace: namespace, name: name}]
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	for _, ch := range m.manifests[keys[0]].Status.Channels {
+		if ch.Name != channel {
+			continue
+		}
+
+		csv, ok := m.csvs[csvKey{catalogSourceName: keys[0].catalogSourceName, catalogSourceNamespace: keys[0].catalogSourceNamespace, name: ch.CurrentCSVName}]
+		if !ok {
+			return nil, fmt.Errorf("channel %s of package %s references unknown csv %s", channel, name, ch.CurrentCSVName)
+		}
+		return csv.DeepCopy(), nil
+	}
+
+	return nil, nil
+}
+
 func (m *InMemoryProvider) List(namespace string) (*packagev1alpha1.PackageManifestList, error) {
+	m.syncInvalidated()
+
 	manifestList := &packagev1alpha1.PackageManifestList{}
 
 	m.mu.RLock()
@@ -261,6 +584,29 @@ func (m *InMemoryProvider) List(namespace string) (*packagev1alpha1.PackageManif
 
 		manifestList.Items = matching
 	}
+	manifestList.ResourceVersion = strconv.FormatUint(m.generation, 10)
+
+	return manifestList, nil
+}
+
+// ListInvalid returns the packages in the given namespace that failed validation in the last sync of their
+// CatalogSource
+func (m *InMemoryProvider) ListInvalid(namespace string) (*packagev1alpha1.PackageManifestList, error) {
+	m.syncInvalidated()
+
+	manifestList := &packagev1alpha1.PackageManifestList{}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, invalid := range m.invalid {
+		for _, manifest := range invalid {
+			if namespace == metav1.NamespaceAll || manifest.GetNamespace() == namespace {
+				manifestList.Items = append(manifestList.Items, manifest)
+			}
+		}
+	}
+	manifestList.ResourceVersion = strconv.FormatUint(m.generation, 10)
 
 	return manifestList, nil
 }
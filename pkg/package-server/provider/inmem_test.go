@@ -1,11 +1,25 @@
 package provider
 
 import (
+	"fmt"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/watch"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+	apiregistrationfake "k8s.io/kube-aggregator/pkg/client/clientset_generated/clientset/fake"
 
+	operatorsv1alpha1 "github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	versionedfake "github.com/operator-framework/operator-lifecycle-manager/pkg/api/client/clientset/versioned/fake"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/client/informers/externalversions"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/operatorclient"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/queueinformer"
 	packagev1alpha1 "github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1"
 )
@@ -53,14 +67,9 @@ func TestList(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.description, func(t *testing.T) {
-			storedPackages := make(map[packageKey]packagev1alpha1.PackageManifest)
+			prov := NewInMemoryProvider(nil, &queueinformer.Operator{})
 			for _, value := range test.storedPackages {
-				storedPackages[packageKey{catalogSourceName: "test", catalogSourceNamespace: "default", packageName: value.name}] = packageManifest(value)
-			}
-
-			prov := &InMemoryProvider{
-				Operator:  &queueinformer.Operator{},
-				manifests: storedPackages,
+				prov.put(packageKey{catalogSourceName: "test", catalogSourceNamespace: "default", packageName: value.name}, packageManifest(value))
 			}
 
 			manifests, err := prov.List(test.namespace)
@@ -100,24 +109,202 @@ func TestGet(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.description, func(t *testing.T) {
-			storedPackages := make(map[packageKey]packagev1alpha1.PackageManifest)
+			prov := NewInMemoryProvider(nil, &queueinformer.Operator{})
 			for _, value := range test.storedPackages {
-				storedPackages[packageKey{catalogSourceName: "test", catalogSourceNamespace: "default", packageName: value.name}] = packageManifest(value)
-			}
-
-			prov := &InMemoryProvider{
-				Operator:  &queueinformer.Operator{},
-				manifests: storedPackages,
+				prov.put(packageKey{catalogSourceName: "test", catalogSourceNamespace: "default", packageName: value.name}, packageManifest(value))
 			}
 
 			manifest, err := prov.Get(test.namespace, test.packageName)
 
 			require.NoError(t, err)
+			if test.expectedPackage == (packageValue{}) {
+				require.Nil(t, manifest)
+				return
+			}
 			require.EqualValues(t, packageManifest(test.expectedPackage), *manifest)
 		})
 	}
 }
 
+func TestListNamed(t *testing.T) {
+	prov := NewInMemoryProvider(nil, &queueinformer.Operator{})
+	prov.put(packageKey{catalogSourceName: "a", catalogSourceNamespace: "default", packageName: "etcd"}, packageManifest(packageValue{name: "etcd", namespace: "default"}))
+	prov.put(packageKey{catalogSourceName: "b", catalogSourceNamespace: "default", packageName: "etcd"}, packageManifest(packageValue{name: "etcd", namespace: "default"}))
+	prov.put(packageKey{catalogSourceName: "a", catalogSourceNamespace: "default", packageName: "prometheus"}, packageManifest(packageValue{name: "prometheus", namespace: "default"}))
+	prov.put(packageKey{catalogSourceName: "c", catalogSourceNamespace: "local", packageName: "etcd"}, packageManifest(packageValue{name: "etcd", namespace: "local"}))
+	prov.generation = 4
+
+	manifests, err := prov.ListNamed("default", "etcd")
+	require.NoError(t, err)
+	require.Len(t, manifests.Items, 2)
+	for _, manifest := range manifests.Items {
+		require.Equal(t, "etcd", manifest.GetName())
+		require.Equal(t, "default", manifest.GetNamespace())
+	}
+	require.Equal(t, "4", manifests.GetResourceVersion())
+
+	manifests, err = prov.ListNamed("default", "missing")
+	require.NoError(t, err)
+	require.Empty(t, manifests.Items)
+}
+
+func manifestProviding(name, namespace string, apis ...string) packagev1alpha1.PackageManifest {
+	manifest := packageManifest(packageValue{name: name, namespace: namespace})
+	manifest.Status.Channels = []packagev1alpha1.PackageChannel{{Name: "stable", CurrentCSVDesc: packagev1alpha1.CSVDescription{ProvidedAPIs: apis}}}
+	return manifest
+}
+
+func TestListFilteredProvidedAPI(t *testing.T) {
+	etcdCluster := packagev1alpha1.ProvidedAPI("etcd.database.coreos.com", "v1beta2", "EtcdCluster")
+	prometheus := packagev1alpha1.ProvidedAPI("monitoring.coreos.com", "v1", "Prometheus")
+
+	prov := NewInMemoryProvider(nil, &queueinformer.Operator{})
+	prov.put(packageKey{catalogSourceName: "a", catalogSourceNamespace: "default", packageName: "etcd"}, manifestProviding("etcd", "default", etcdCluster))
+	prov.put(packageKey{catalogSourceName: "b", catalogSourceNamespace: "default", packageName: "etcd-community"}, manifestProviding("etcd-community", "default", etcdCluster))
+	prov.put(packageKey{catalogSourceName: "a", catalogSourceNamespace: "default", packageName: "prometheus"}, manifestProviding("prometheus", "default", prometheus))
+	prov.put(packageKey{catalogSourceName: "c", catalogSourceNamespace: "local", packageName: "etcd"}, manifestProviding("etcd", "local", etcdCluster))
+	prov.generation = 4
+
+	names := func(list *packagev1alpha1.PackageManifestList) []string {
+		names := []string{}
+		for _, manifest := range list.Items {
+			names = append(names, manifest.GetNamespace()+"/"+manifest.GetName())
+		}
+		return names
+	}
+
+	manifests, err := prov.ListFiltered("default", ListFilter{ProvidedAPI: etcdCluster})
+	require.NoError(t, err)
+	require.Equal(t, []string{"default/etcd", "default/etcd-community"}, names(manifests))
+	require.Equal(t, "4", manifests.GetResourceVersion())
+
+	manifests, err = prov.ListFiltered(metav1.NamespaceAll, ListFilter{ProvidedAPI: etcdCluster})
+	require.NoError(t, err)
+	require.Equal(t, []string{"default/etcd", "default/etcd-community", "local/etcd"}, names(manifests))
+
+	manifests, err = prov.ListFiltered("default", ListFilter{Name: "etcd-community", ProvidedAPI: etcdCluster})
+	require.NoError(t, err)
+	require.Equal(t, []string{"default/etcd-community"}, names(manifests))
+
+	manifests, err = prov.ListFiltered("default", ListFilter{ProvidedAPI: packagev1alpha1.ProvidedAPI("etcd.database.coreos.com", "v1beta1", "EtcdCluster")})
+	require.NoError(t, err)
+	require.Empty(t, manifests.Items)
+
+	// replacing a manifest reindexes the APIs it provides
+	prov.put(packageKey{catalogSourceName: "a", catalogSourceNamespace: "default", packageName: "etcd"}, manifestProviding("etcd", "default", prometheus))
+
+	manifests, err = prov.ListFiltered("default", ListFilter{ProvidedAPI: etcdCluster})
+	require.NoError(t, err)
+	require.Equal(t, []string{"default/etcd-community"}, names(manifests))
+
+	manifests, err = prov.ListFiltered("default", ListFilter{ProvidedAPI: prometheus})
+	require.NoError(t, err)
+	require.Equal(t, []string{"default/prometheus", "default/etcd"}, names(manifests))
+
+	// without an API the filter lists by name
+	manifests, err = prov.ListFiltered("default", ListFilter{Name: "etcd"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"default/etcd"}, names(manifests))
+}
+
+func BenchmarkGet(b *testing.B) {
+	const namespaces, packages = 10, 1000
+
+	prov := NewInMemoryProvider(nil, &queueinformer.Operator{})
+	for i := 0; i < namespaces; i++ {
+		namespace := fmt.Sprintf("ns-%d", i)
+		for j := 0; j < packages; j++ {
+			value := packageValue{name: fmt.Sprintf("package-%d", j), namespace: namespace}
+			prov.put(packageKey{catalogSourceName: "test", catalogSourceNamespace: namespace, packageName: value.name}, packageManifest(value))
+		}
+	}
+	namespace, name := fmt.Sprintf("ns-%d", namespaces-1), fmt.Sprintf("package-%d", packages-1)
+
+	b.Run("LinearScan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var manifest packagev1alpha1.PackageManifest
+			for _, pm := range prov.manifests {
+				if pm.GetName() == name && pm.GetNamespace() == namespace {
+					manifest = pm
+				}
+			}
+			if manifest.GetName() != name {
+				b.Fatalf("package %s not found", name)
+			}
+		}
+	})
+
+	b.Run("Indexed", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			manifest, err := prov.Get(namespace, name)
+			if err != nil || manifest.GetName() != name {
+				b.Fatalf("package %s not found: %v", name, err)
+			}
+		}
+	})
+}
+
+func manifestSupporting(name, namespace string, modes ...string) packagev1alpha1.PackageManifest {
+	manifest := packageManifest(packageValue{name: name, namespace: namespace})
+	manifest.Status.Channels = []packagev1alpha1.PackageChannel{{Name: "stable", CurrentCSVDesc: packagev1alpha1.CSVDescription{InstallModes: modes}}}
+	return manifest
+}
+
+func TestListFilteredInstallMode(t *testing.T) {
+	own := string(operatorsv1alpha1.InstallModeTypeOwnNamespace)
+	all := string(operatorsv1alpha1.InstallModeTypeAllNamespaces)
+	etcdCluster := packagev1alpha1.ProvidedAPI("etcd.database.coreos.com", "v1beta2", "EtcdCluster")
+
+	prov := NewInMemoryProvider(nil, &queueinformer.Operator{})
+	prov.put(packageKey{catalogSourceName: "a", catalogSourceNamespace: "default", packageName: "etcd"}, manifestSupporting("etcd", "default", own, all))
+	prov.put(packageKey{catalogSourceName: "a", catalogSourceNamespace: "default", packageName: "vault"}, manifestSupporting("vault", "default", own))
+	prov.put(packageKey{catalogSourceName: "c", catalogSourceNamespace: "local", packageName: "etcd"}, manifestSupporting("etcd", "local", all))
+	prov.generation = 2
+
+	names := func(list *packagev1alpha1.PackageManifestList) []string {
+		names := []string{}
+		for _, manifest := range list.Items {
+			names = append(names, manifest.GetNamespace()+"/"+manifest.GetName())
+		}
+		return names
+	}
+
+	manifests, err := prov.ListFiltered("default", ListFilter{InstallMode: all})
+	require.NoError(t, err)
+	require.Equal(t, []string{"default/etcd"}, names(manifests))
+	require.Equal(t, "2", manifests.GetResourceVersion())
+
+	manifests, err = prov.ListFiltered(metav1.NamespaceAll, ListFilter{InstallMode: all})
+	require.NoError(t, err)
+	require.Equal(t, []string{"default/etcd", "local/etcd"}, names(manifests))
+
+	manifests, err = prov.ListFiltered("default", ListFilter{InstallMode: string(operatorsv1alpha1.InstallModeTypeMultiNamespace)})
+	require.NoError(t, err)
+	require.Empty(t, manifests.Items)
+
+	// replacing a manifest reindexes the install modes it supports
+	prov.put(packageKey{catalogSourceName: "a", catalogSourceNamespace: "default", packageName: "vault"}, manifestSupporting("vault", "default", all))
+
+	manifests, err = prov.ListFiltered("default", ListFilter{InstallMode: own})
+	require.NoError(t, err)
+	require.Equal(t, []string{"default/etcd"}, names(manifests))
+
+	manifests, err = prov.ListFiltered("default", ListFilter{InstallMode: all})
+	require.NoError(t, err)
+	require.Equal(t, []string{"default/etcd", "default/vault"}, names(manifests))
+
+	// an API and an install mode must both be matched
+	prov.put(packageKey{catalogSourceName: "b", catalogSourceNamespace: "default", packageName: "etcd-community"}, manifestProviding("etcd-community", "default", etcdCluster))
+
+	manifests, err = prov.ListFiltered("default", ListFilter{ProvidedAPI: etcdCluster})
+	require.NoError(t, err)
+	require.Equal(t, []string{"default/etcd-community"}, names(manifests))
+
+	manifests, err = prov.ListFiltered("default", ListFilter{ProvidedAPI: etcdCluster, InstallMode: all})
+	require.NoError(t, err)
+	require.Empty(t, manifests.Items)
+}
+
 func TestSubscribe(t *testing.T) {
 	tests := []struct {
 		namespace      string
@@ -183,3 +370,456 @@ func TestSubscribe(t *testing.T) {
 		})
 	}
 }
+
+func TestGetChannelCSV(t *testing.T) {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "catalog", Namespace: "default"},
+		Data: map[string]string{
+			ConfigMapCSVName: `
+- metadata:
+    name: etcdoperator.v0.9.0
+  spec:
+    displayName: etcd
+    version: 0.9.0
+- metadata:
+    name: etcdoperator.v0.9.2
+  spec:
+    displayName: etcd
+    version: 0.9.2
+    replaces: etcdoperator.v0.9.0
+`,
+			ConfigMapPackageName: `
+- packageName: etcd
+  defaultChannel: alpha
+  channels:
+  - name: alpha
+    currentCSV: etcdoperator.v0.9.2
+  - name: stable
+    currentCSV: etcdoperator.v0.9.0
+`,
+		},
+	}
+	catsrc := &operatorsv1alpha1.CatalogSource{
+		ObjectMeta: metav1.ObjectMeta{Name: "ocs", Namespace: "default"},
+		Spec:       operatorsv1alpha1.CatalogSourceSpec{SourceType: "internal", ConfigMap: "catalog"},
+	}
+
+	client := operatorclient.NewClient(k8sfake.NewSimpleClientset(cm), apiextensionsfake.NewSimpleClientset(), apiregistrationfake.NewSimpleClientset())
+	prov := NewInMemoryProvider(nil, &queueinformer.Operator{OpClient: client})
+	require.NoError(t, prov.syncCatalogSource(catsrc))
+
+	tests := []struct {
+		namespace   string
+		name        string
+		channel     string
+		expectedCSV string
+		description string
+	}{
+		{
+			namespace:   "default",
+			name:        "etcd",
+			channel:     "alpha",
+			expectedCSV: "etcdoperator.v0.9.2",
+			description: "Channel",
+		},
+		{
+			namespace:   "default",
+			name:        "etcd",
+			channel:     "stable",
+			expectedCSV: "etcdoperator.v0.9.0",
+			description: "OtherChannel",
+		},
+		{
+			namespace:   "default",
+			name:        "etcd",
+			channel:     "beta",
+			description: "MissingChannel",
+		},
+		{
+			namespace:   "default",
+			name:        "vault",
+			channel:     "alpha",
+			description: "MissingPackage",
+		},
+		{
+			namespace:   "local",
+			name:        "etcd",
+			channel:     "alpha",
+			description: "OtherNamespace",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			csv, err := prov.GetChannelCSV(test.namespace, test.name, test.channel)
+			require.NoError(t, err)
+			if test.expectedCSV == "" {
+				require.Nil(t, csv)
+				return
+			}
+			require.NotNil(t, csv)
+			require.Equal(t, test.expectedCSV, csv.GetName())
+		})
+	}
+}
+
+func TestCurrentCSVSource(t *testing.T) {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "catalog", Namespace: "default"},
+		Data: map[string]string{
+			ConfigMapCSVName: `
+- metadata:
+    name: etcdoperator.v0.9.0
+  spec:
+    displayName: etcd
+- metadata:
+    name: etcdoperator.v0.9.2
+  spec:
+    displayName: etcd
+    replaces: etcdoperator.v0.9.0
+`,
+			ConfigMapPackageName: `
+- packageName: etcd
+  defaultChannel: alpha
+  channels:
+  - name: alpha
+    currentCSV: etcdoperator.v0.9.2
+  - name: stable
+    currentCSV: etcdoperator.v0.9.0
+`,
+		},
+	}
+	catsrc := &operatorsv1alpha1.CatalogSource{
+		ObjectMeta: metav1.ObjectMeta{Name: "ocs", Namespace: "default"},
+		Spec:       operatorsv1alpha1.CatalogSourceSpec{SourceType: "internal", ConfigMap: "catalog"},
+	}
+
+	client := operatorclient.NewClient(k8sfake.NewSimpleClientset(cm), apiextensionsfake.NewSimpleClientset(), apiregistrationfake.NewSimpleClientset())
+	prov := NewInMemoryProvider(nil, &queueinformer.Operator{OpClient: client})
+	require.NoError(t, prov.syncCatalogSource(catsrc))
+
+	expected := packagev1alpha1.CSVSource{
+		CatalogSourceName:      "ocs",
+		CatalogSourceNamespace: "default",
+		BundlePath:             "catalog/" + ConfigMapCSVName,
+	}
+	requireSources := func(manifest packagev1alpha1.PackageManifest) {
+		require.Len(t, manifest.Status.Channels, 2)
+		for _, channel := range manifest.Status.Channels {
+			require.Equal(t, expected, channel.CurrentCSVSource, "channel %s", channel.Name)
+		}
+	}
+
+	manifest, err := prov.Get("default", "etcd")
+	require.NoError(t, err)
+	require.NotNil(t, manifest)
+	requireSources(*manifest)
+
+	list, err := prov.List("default")
+	require.NoError(t, err)
+	require.Len(t, list.Items, 1)
+	requireSources(list.Items[0])
+}
+
+func TestListInvalid(t *testing.T) {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "catalog", Namespace: "default"},
+		Data: map[string]string{
+			ConfigMapCSVName: `
+- metadata:
+    name: etcdoperator.v0.9.2
+  spec:
+    displayName: etcd
+`,
+			ConfigMapPackageName: `
+- packageName: etcd
+  defaultChannel: alpha
+  channels:
+  - name: alpha
+    currentCSV: etcdoperator.v0.9.2
+- packageName: vault
+  defaultChannel: beta
+  channels:
+  - name: alpha
+    currentCSV: etcdoperator.v0.9.2
+- packageName: amq
+  channels:
+  - name: alpha
+    currentCSV: amq.v1.0.0
+- packageName: prometheus
+  defaultChannel: stable
+  channels:
+  - name: alpha
+    currentCSV: prometheus.v0.22.2
+`,
+		},
+	}
+	catsrc := &operatorsv1alpha1.CatalogSource{
+		ObjectMeta: metav1.ObjectMeta{Name: "ocs", Namespace: "default"},
+		Spec:       operatorsv1alpha1.CatalogSourceSpec{SourceType: "internal", ConfigMap: "catalog"},
+	}
+
+	client := operatorclient.NewClient(k8sfake.NewSimpleClientset(cm), apiextensionsfake.NewSimpleClientset(), apiregistrationfake.NewSimpleClientset())
+	prov := NewInMemoryProvider(nil, &queueinformer.Operator{OpClient: client})
+	require.NoError(t, prov.syncCatalogSource(catsrc))
+
+	// only the valid package is served
+	list, err := prov.List("default")
+	require.NoError(t, err)
+	require.Len(t, list.Items, 1)
+	require.Equal(t, "etcd", list.Items[0].GetName())
+	require.Empty(t, list.Items[0].Status.Conditions)
+
+	invalid, err := prov.ListInvalid("default")
+	require.NoError(t, err)
+	conditions := map[string]packagev1alpha1.PackageManifestCondition{}
+	for _, manifest := range invalid.Items {
+		require.Len(t, manifest.Status.Conditions, 1)
+		require.Equal(t, "ocs", manifest.Status.CatalogSourceName)
+		conditions[manifest.GetName()] = manifest.Status.Conditions[0]
+	}
+	require.Equal(t, map[string]packagev1alpha1.PackageManifestCondition{
+		"vault": {
+			Type:    packagev1alpha1.PackageManifestValid,
+			Status:  corev1.ConditionFalse,
+			Reason:  packagev1alpha1.PackageManifestReasonDefaultChannelNotFound,
+			Message: "default channel beta isn't one of the package's channels",
+		},
+		"amq": {
+			Type:    packagev1alpha1.PackageManifestValid,
+			Status:  corev1.ConditionFalse,
+			Reason:  packagev1alpha1.PackageManifestReasonCSVNotFound,
+			Message: "channel alpha references non-existent csv amq.v1.0.0",
+		},
+		"prometheus": {
+			Type:    packagev1alpha1.PackageManifestValid,
+			Status:  corev1.ConditionFalse,
+			Reason:  packagev1alpha1.PackageManifestReasonCSVNotFound,
+			Message: "channel alpha references non-existent csv prometheus.v0.22.2; default channel stable isn't one of the package's channels",
+		},
+	}, conditions)
+
+	invalid, err = prov.ListInvalid("other")
+	require.NoError(t, err)
+	require.Empty(t, invalid.Items)
+}
+
+func TestSyncResourceVersion(t *testing.T) {
+	configMap := func(displayName string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "catalog", Namespace: "default"},
+			Data: map[string]string{
+				ConfigMapCSVName: fmt.Sprintf(`
+- metadata:
+    name: etcdoperator.v0.9.0
+  spec:
+    displayName: %s
+`, displayName),
+				ConfigMapPackageName: `
+- packageName: etcd
+  channels:
+  - name: alpha
+    currentCSV: etcdoperator.v0.9.0
+`,
+			},
+		}
+	}
+	catsrc := &operatorsv1alpha1.CatalogSource{
+		ObjectMeta: metav1.ObjectMeta{Name: "ocs", Namespace: "default"},
+		Spec:       operatorsv1alpha1.CatalogSourceSpec{SourceType: "internal", ConfigMap: "catalog"},
+	}
+
+	kubeClient := k8sfake.NewSimpleClientset(configMap("etcd"))
+	client := operatorclient.NewClient(kubeClient, apiextensionsfake.NewSimpleClientset(), apiregistrationfake.NewSimpleClientset())
+	prov := NewInMemoryProvider(nil, &queueinformer.Operator{OpClient: client})
+	resourceVersion := func() string {
+		manifest, err := prov.Get("default", "etcd")
+		require.NoError(t, err)
+		return manifest.GetResourceVersion()
+	}
+
+	require.NoError(t, prov.syncCatalogSource(catsrc))
+	require.Equal(t, "1", resourceVersion())
+
+	// syncing an unchanged catalog keeps the manifest's resourceVersion
+	require.NoError(t, prov.syncCatalogSource(catsrc))
+	require.Equal(t, "1", resourceVersion())
+
+	// a change to the manifest gives it the resourceVersion of the sync that found it
+	_, err := kubeClient.CoreV1().ConfigMaps("default").Update(configMap("etcd operator"))
+	require.NoError(t, err)
+	require.NoError(t, prov.syncCatalogSource(catsrc))
+	require.Equal(t, "3", resourceVersion())
+}
+
+func TestSyncModifiedEvents(t *testing.T) {
+	configMap := func(displayName string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "catalog", Namespace: "default"},
+			Data: map[string]string{
+				ConfigMapCSVName: fmt.Sprintf(`
+- metadata:
+    name: etcdoperator.v0.9.0
+  spec:
+    displayName: %s
+`, displayName),
+				ConfigMapPackageName: `
+- packageName: etcd
+  channels:
+  - name: alpha
+    currentCSV: etcdoperator.v0.9.0
+`,
+			},
+		}
+	}
+	catsrc := &operatorsv1alpha1.CatalogSource{
+		ObjectMeta: metav1.ObjectMeta{Name: "ocs", Namespace: "default"},
+		Spec:       operatorsv1alpha1.CatalogSourceSpec{SourceType: "internal", ConfigMap: "catalog"},
+	}
+
+	kubeClient := k8sfake.NewSimpleClientset(configMap("etcd"))
+	client := operatorclient.NewClient(kubeClient, apiextensionsfake.NewSimpleClientset(), apiregistrationfake.NewSimpleClientset())
+	prov := NewInMemoryProvider(nil, &queueinformer.Operator{OpClient: client})
+	require.NoError(t, prov.syncCatalogSource(catsrc))
+
+	list, err := prov.List("default")
+	require.NoError(t, err)
+	listed, err := strconv.ParseUint(list.GetResourceVersion(), 10, 64)
+	require.NoError(t, err)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	_, modify, _, err := prov.Subscribe(stopCh)
+	require.NoError(t, err)
+
+	// a change made after the list is sent to subscribers and recorded for watches resuming from the list
+	_, err = kubeClient.CoreV1().ConfigMaps("default").Update(configMap("etcd operator"))
+	require.NoError(t, err)
+	synced := make(chan error)
+	go func() { synced <- prov.syncCatalogSource(catsrc) }()
+
+	select {
+	case manifest := <-modify:
+		require.Equal(t, "etcd", manifest.GetName())
+		require.Equal(t, "2", manifest.GetResourceVersion())
+	case <-time.After(time.Second):
+		t.Fatal("no Modified event sent")
+	}
+	require.NoError(t, <-synced)
+
+	events, ok := prov.EventsSince("default", listed)
+	require.True(t, ok)
+	require.Len(t, events, 1)
+	require.Equal(t, watch.Modified, events[0].Type)
+	require.Equal(t, "etcd", events[0].Manifest.GetName())
+	require.Equal(t, uint64(2), events[0].ResourceVersion)
+	require.Equal(t, "2", events[0].Manifest.GetResourceVersion())
+}
+
+func TestCreationTimestamp(t *testing.T) {
+	configMap := func(displayName string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "catalog", Namespace: "default"},
+			Data: map[string]string{
+				ConfigMapCSVName: fmt.Sprintf(`
+- metadata:
+    name: etcdoperator.v0.9.0
+  spec:
+    displayName: %s
+`, displayName),
+				ConfigMapPackageName: `
+- packageName: etcd
+  channels:
+  - name: alpha
+    currentCSV: etcdoperator.v0.9.0
+`,
+			},
+		}
+	}
+	catsrc := &operatorsv1alpha1.CatalogSource{
+		ObjectMeta: metav1.ObjectMeta{Name: "ocs", Namespace: "default"},
+		Spec:       operatorsv1alpha1.CatalogSourceSpec{SourceType: "internal", ConfigMap: "catalog"},
+	}
+
+	kubeClient := k8sfake.NewSimpleClientset(configMap("etcd"))
+	client := operatorclient.NewClient(kubeClient, apiextensionsfake.NewSimpleClientset(), apiregistrationfake.NewSimpleClientset())
+	prov := NewInMemoryProvider(nil, &queueinformer.Operator{OpClient: client})
+	created := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	fakeClock := clock.NewFakeClock(created)
+	prov.clock = fakeClock
+	creationTimestamp := func() metav1.Time {
+		manifest, err := prov.Get("default", "etcd")
+		require.NoError(t, err)
+		return manifest.GetCreationTimestamp()
+	}
+
+	// a newly seen manifest is stamped with the provider's clock
+	require.NoError(t, prov.syncCatalogSource(catsrc))
+	require.Equal(t, metav1.NewTime(created), creationTimestamp())
+
+	// later changes to the manifest keep its CreationTimestamp
+	fakeClock.Step(time.Hour)
+	_, err := kubeClient.CoreV1().ConfigMaps("default").Update(configMap("etcd operator"))
+	require.NoError(t, err)
+	require.NoError(t, prov.syncCatalogSource(catsrc))
+	require.Equal(t, metav1.NewTime(created), creationTimestamp())
+}
+
+func TestInvalidate(t *testing.T) {
+	configMap := func(name, csv string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Data: map[string]string{
+				ConfigMapCSVName: fmt.Sprintf(`
+- metadata:
+    name: %s
+  spec:
+    displayName: etcd
+`, csv),
+				ConfigMapPackageName: fmt.Sprintf(`
+- packageName: etcd
+  channels:
+  - name: alpha
+    currentCSV: %s
+`, csv),
+			},
+		}
+	}
+	catsrc := &operatorsv1alpha1.CatalogSource{
+		ObjectMeta: metav1.ObjectMeta{Name: "ocs", Namespace: "default", ResourceVersion: "1"},
+		Spec:       operatorsv1alpha1.CatalogSourceSpec{SourceType: "internal", ConfigMap: "catalog-v1"},
+	}
+
+	informer := externalversions.NewSharedInformerFactory(versionedfake.NewSimpleClientset(), 0).Operators().V1alpha1().CatalogSources().Informer()
+	require.NoError(t, informer.GetStore().Add(catsrc))
+
+	kubeClient := k8sfake.NewSimpleClientset(configMap("catalog-v1", "etcdoperator.v0.9.0"), configMap("catalog-v2", "etcdoperator.v0.9.2"))
+	client := operatorclient.NewClient(kubeClient, apiextensionsfake.NewSimpleClientset(), apiregistrationfake.NewSimpleClientset())
+	prov := NewInMemoryProvider([]cache.SharedIndexInformer{informer}, &queueinformer.Operator{OpClient: client})
+	require.NoError(t, prov.syncCatalogSource(catsrc))
+
+	currentCSV := func() string {
+		list, err := prov.List("default")
+		require.NoError(t, err)
+		require.Len(t, list.Items, 1)
+		require.Len(t, list.Items[0].Status.Channels, 1)
+		return list.Items[0].Status.Channels[0].CurrentCSVName
+	}
+	require.Equal(t, "etcdoperator.v0.9.0", currentCSV())
+
+	// the CatalogSource moves to a new catalog, but isn't synced until its queued sync runs
+	updated := catsrc.DeepCopy()
+	updated.SetResourceVersion("2")
+	updated.Spec.ConfigMap = "catalog-v2"
+	require.NoError(t, informer.GetStore().Update(updated))
+	require.Equal(t, "etcdoperator.v0.9.0", currentCSV())
+
+	// invalidating the CatalogSource makes the next List query it again
+	prov.Invalidate("ocs", "default")
+	require.Equal(t, "etcdoperator.v0.9.2", currentCSV())
+	require.Empty(t, prov.invalidated)
+
+	// unknown CatalogSources are ignored
+	prov.Invalidate("missing", "default")
+	require.Equal(t, "etcdoperator.v0.9.2", currentCSV())
+}
@@ -1,13 +1,85 @@
 package provider
 
 import (
+	"k8s.io/apimachinery/pkg/labels"
+
+	operatorsv1alpha1 "github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1"
 )
 
 type PackageChan <-chan v1alpha1.PackageManifest
 
+// PackageManifestProvider provides the PackageManifests served by the package server. Providers should classify the
+// errors they return with NewTransientError, NewPermanentError, NewNotFoundError, or Classify, so that clients are told
+// whether to retry; unclassified errors are reported as permanent.
 type PackageManifestProvider interface {
 	Get(namespace, name string) (*v1alpha1.PackageManifest, error)
 	List(namespace string) (*v1alpha1.PackageManifestList, error)
 	Subscribe(stopCh <-chan struct{}) (add, modify, delete PackageChan, err error)
+	// Invalidate marks the PackageManifests provided by a CatalogSource as out of date, so that the CatalogSource is
+	// queried again before they're next served.
+	Invalidate(catalogSourceName, catalogSourceNamespace string)
+}
+
+// NoopInvalidator can be embedded by providers that have no cached catalog contents to invalidate.
+type NoopInvalidator struct{}
+
+// Invalidate does nothing
+func (NoopInvalidator) Invalidate(catalogSourceName, catalogSourceNamespace string) {}
+
+// NamedPackageManifestLister is implemented by providers that can list the PackageManifests with a given name without
+// scanning every PackageManifest they provide.
+type NamedPackageManifestLister interface {
+	ListNamed(namespace, name string) (*v1alpha1.PackageManifestList, error)
+}
+
+// EventHistory is implemented by providers that keep a bounded history of recent changes, so that a watch resuming
+// from a recent resourceVersion can be replayed the changes it missed rather than relisting.
+type EventHistory interface {
+	// EventsSince returns the recorded events in the namespace newer than resourceVersion, oldest first, or false if
+	// some of them are no longer recorded.
+	EventsSince(namespace string, resourceVersion uint64) ([]Event, bool)
+}
+
+// SnapshotVersioner is implemented by providers whose list resourceVersion is a monotonic snapshot version. Every change
+// made after a snapshot is recorded and sent to subscribers with a newer resourceVersion, so a watch started from a
+// list's resourceVersion resumes exactly where the list ended.
+type SnapshotVersioner interface {
+	// SnapshotVersion returns the version of the provider's current snapshot, or false if it doesn't version its
+	// snapshots.
+	SnapshotVersion() (uint64, bool)
+}
+
+// ListFilter narrows the PackageManifests a provider lists. Filters are hints for providers that can push them down
+// to their catalogs, so providers may return PackageManifests that don't match and callers must still filter.
+type ListFilter struct {
+	// Name, if set, is the name of the PackageManifests to list
+	Name string
+	// Labels, if set, selects the labels of the PackageManifests to list
+	Labels labels.Selector
+	// ProvidedAPI, if set, is an API, formatted by v1alpha1.ProvidedAPI, that the PackageManifests to list provide
+	ProvidedAPI string
+	// InstallMode, if set, is an install mode that the PackageManifests to list support
+	InstallMode string
+}
+
+// FilteredPackageManifestLister is implemented by providers that can fetch only the PackageManifests matching a filter
+// from their catalogs.
+type FilteredPackageManifestLister interface {
+	ListFiltered(namespace string, filter ListFilter) (*v1alpha1.PackageManifestList, error)
+}
+
+// ChannelCSVGetter is implemented by providers that keep the full CSVs their PackageManifests' channels refer to.
+type ChannelCSVGetter interface {
+	// GetChannelCSV returns the current CSV of the named channel of a PackageManifest, or nil if the PackageManifest
+	// or channel doesn't exist.
+	GetChannelCSV(namespace, name, channel string) (*operatorsv1alpha1.ClusterServiceVersion, error)
+}
+
+// InvalidPackageManifestLister is implemented by providers that keep the packages they drop for failing validation, so
+// that catalog authors can see what was dropped and why.
+type InvalidPackageManifestLister interface {
+	// ListInvalid returns the invalid PackageManifests in the namespace, each with a False
+	// v1alpha1.PackageManifestValid condition describing why it's invalid.
+	ListInvalid(namespace string) (*v1alpha1.PackageManifestList, error)
 }
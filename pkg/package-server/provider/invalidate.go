@@ -0,0 +1,30 @@
+package provider
+
+import (
+	"k8s.io/client-go/tools/cache"
+
+	operatorsv1alpha1 "github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+)
+
+// InvalidateOnUpdate returns a CatalogSource event handler that invalidates each updated CatalogSource in the given
+// provider, so that changes such as a new catalog image are served without waiting for the CatalogSource's queued sync
+func InvalidateOnUpdate(prov PackageManifestProvider) cache.ResourceEventHandler {
+	return cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldCatsrc, ok := oldObj.(*operatorsv1alpha1.CatalogSource)
+			if !ok {
+				return
+			}
+			newCatsrc, ok := newObj.(*operatorsv1alpha1.CatalogSource)
+			if !ok {
+				return
+			}
+
+			// resyncs redeliver CatalogSources that haven't changed
+			if oldCatsrc.GetResourceVersion() == newCatsrc.GetResourceVersion() {
+				return
+			}
+			prov.Invalidate(newCatsrc.GetName(), newCatsrc.GetNamespace())
+		},
+	}
+}
@@ -0,0 +1,60 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	operatorsv1alpha1 "github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+)
+
+// invalidationRecorder records the CatalogSources it's asked to invalidate
+type invalidationRecorder struct {
+	*FakeProvider
+	invalidated []catalogKey
+}
+
+func (r *invalidationRecorder) Invalidate(catalogSourceName, catalogSourceNamespace string) {
+	r.invalidated = append(r.invalidated, catalogKey{name: catalogSourceName, namespace: catalogSourceNamespace})
+}
+
+func TestInvalidateOnUpdate(t *testing.T) {
+	catsrc := func(resourceVersion string) *operatorsv1alpha1.CatalogSource {
+		return &operatorsv1alpha1.CatalogSource{
+			ObjectMeta: metav1.ObjectMeta{Name: "ocs", Namespace: "default", ResourceVersion: resourceVersion},
+		}
+	}
+
+	tests := []struct {
+		oldObj              interface{}
+		newObj              interface{}
+		expectedInvalidated []catalogKey
+		description         string
+	}{
+		{
+			oldObj:              catsrc("1"),
+			newObj:              catsrc("2"),
+			expectedInvalidated: []catalogKey{{name: "ocs", namespace: "default"}},
+			description:         "Updated",
+		},
+		{
+			oldObj:      catsrc("1"),
+			newObj:      catsrc("1"),
+			description: "Resync",
+		},
+		{
+			oldObj:      "default/ocs",
+			newObj:      "default/ocs",
+			description: "NotACatalogSource",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			prov := &invalidationRecorder{FakeProvider: NewFakeProvider()}
+			InvalidateOnUpdate(prov).OnUpdate(test.oldObj, test.newObj)
+			require.Equal(t, test.expectedInvalidated, prov.invalidated)
+		})
+	}
+}
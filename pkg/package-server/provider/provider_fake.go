@@ -1,44 +1,132 @@
 package provider
 
 import (
+	"strconv"
 	"sync"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+
+	operatorsv1alpha1 "github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1"
 )
 
 var _ PackageManifestProvider = &FakeProvider{}
+var _ ChannelCSVGetter = &FakeProvider{}
+var _ EventHistory = &FakeProvider{}
+var _ InvalidPackageManifestLister = &FakeProvider{}
+var _ SnapshotVersioner = &FakeProvider{}
 
-// FakeProvider is used for testing.
+// FakeProvider is an in-memory PackageManifestProvider for tests, including those of projects that embed the
+// PackageManifest storage. Changes made with Add, Modify, Delete, and Refresh are sent to subscribers as they're made,
+// and SetError simulates an unavailable provider.
 type FakeProvider struct {
-	manifests map[packageKey]v1alpha1.PackageManifest
-	add       []chan v1alpha1.PackageManifest
-	modify    []chan v1alpha1.PackageManifest
-	delete    []chan v1alpha1.PackageManifest
+	NoopInvalidator
+
+	err        error
+	manifests  map[packageKey]v1alpha1.PackageManifest
+	invalid    []v1alpha1.PackageManifest
+	csvs       map[string]operatorsv1alpha1.ClusterServiceVersion
+	generation uint64
+	history    *eventHistory
+	add        []chan v1alpha1.PackageManifest
+	modify     []chan v1alpha1.PackageManifest
+	delete     []chan v1alpha1.PackageManifest
 
 	mu sync.Mutex
 }
 
 func NewFakeProvider() *FakeProvider {
 	return &FakeProvider{
-		make(map[packageKey]v1alpha1.PackageManifest),
-		[]chan v1alpha1.PackageManifest{},
-		[]chan v1alpha1.PackageManifest{},
-		[]chan v1alpha1.PackageManifest{},
-		sync.Mutex{},
+		manifests: make(map[packageKey]v1alpha1.PackageManifest),
+		csvs:      make(map[string]operatorsv1alpha1.ClusterServiceVersion),
+		history:   newEventHistory(DefaultEventHistorySize),
+		add:       []chan v1alpha1.PackageManifest{},
+		modify:    []chan v1alpha1.PackageManifest{},
+		delete:    []chan v1alpha1.PackageManifest{},
+		mu:        sync.Mutex{},
 	}
 }
 
 func (f *FakeProvider) Get(namespace, name string) (*v1alpha1.PackageManifest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	for key, manifest := range f.manifests {
+		if key.packageName == name && manifest.GetNamespace() == namespace {
+			return manifest.DeepCopy(), nil
+		}
+	}
+
 	return nil, nil
 }
 
-func (f *FakeProvider) List(namespace string) (*v1alpha1.PackageManifestList, error) {
+func (f *FakeProvider) GetChannelCSV(namespace, name, channel string) (*operatorsv1alpha1.ClusterServiceVersion, error) {
+	manifest, err := f.Get(namespace, name)
+	if err != nil || manifest == nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, ch := range manifest.Status.Channels {
+		if ch.Name == channel {
+			if csv, ok := f.csvs[ch.CurrentCSVName]; ok {
+				return csv.DeepCopy(), nil
+			}
+		}
+	}
+
 	return nil, nil
 }
 
+func (f *FakeProvider) List(namespace string) (*v1alpha1.PackageManifestList, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	manifestList := &v1alpha1.PackageManifestList{}
+	for _, manifest := range f.manifests {
+		if namespace == metav1.NamespaceAll || manifest.GetNamespace() == namespace {
+			manifestList.Items = append(manifestList.Items, manifest)
+		}
+	}
+	manifestList.ResourceVersion = strconv.FormatUint(f.generation, 10)
+
+	return manifestList, nil
+}
+
+// ListInvalid returns the invalid PackageManifests added with AddInvalid in the namespace
+func (f *FakeProvider) ListInvalid(namespace string) (*v1alpha1.PackageManifestList, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	manifestList := &v1alpha1.PackageManifestList{}
+	for _, manifest := range f.invalid {
+		if namespace == metav1.NamespaceAll || manifest.GetNamespace() == namespace {
+			manifestList.Items = append(manifestList.Items, manifest)
+		}
+	}
+	manifestList.ResourceVersion = strconv.FormatUint(f.generation, 10)
+
+	return manifestList, nil
+}
+
 func (f *FakeProvider) Subscribe(stopCh <-chan struct{}) (PackageChan, PackageChan, PackageChan, error) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, nil, nil, f.err
+	}
 
 	add := make(chan v1alpha1.PackageManifest)
 	modify := make(chan v1alpha1.PackageManifest)
@@ -47,17 +135,17 @@ func (f *FakeProvider) Subscribe(stopCh <-chan struct{}) (PackageChan, PackageCh
 	f.modify = append(f.modify, modify)
 	f.delete = append(f.delete, delete)
 
+	// only this subscription ends when stopCh is signalled, so that other subscribers keep receiving changes
 	go func() {
 		<-stopCh
-		for _, add := range f.add {
-			close(add)
-		}
-		for _, modify := range f.modify {
-			close(modify)
-		}
-		for _, delete := range f.delete {
-			close(delete)
-		}
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.add = withoutChan(f.add, add)
+		f.modify = withoutChan(f.modify, modify)
+		f.delete = withoutChan(f.delete, delete)
+		close(add)
+		close(modify)
+		close(delete)
 	}()
 
 	return add, modify, delete, nil
@@ -66,23 +154,125 @@ func (f *FakeProvider) Subscribe(stopCh <-chan struct{}) (PackageChan, PackageCh
 func (f *FakeProvider) Add(manifest v1alpha1.PackageManifest) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
-	for _, add := range f.add {
-		add <- manifest
-	}
+	f.apply(watch.Added, manifest)
 }
 
 func (f *FakeProvider) Modify(manifest v1alpha1.PackageManifest) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
-	for _, modify := range f.modify {
-		modify <- manifest
-	}
+	f.apply(watch.Modified, manifest)
 }
 
 func (f *FakeProvider) Delete(manifest v1alpha1.PackageManifest) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
-	for _, delete := range f.delete {
-		delete <- manifest
+	f.apply(watch.Deleted, manifest)
+}
+
+// Refresh simulates a CatalogSource being queried again: the PackageManifests it provides are replaced by manifests,
+// whose catalog source status fields must name it. Subscribers are sent a deletion for each PackageManifest that's no
+// longer provided, a modification for each that still is, and an addition for each new one.
+func (f *FakeProvider) Refresh(catalogSourceName, catalogSourceNamespace string, manifests []v1alpha1.PackageManifest) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	refreshed := make(map[packageKey]struct{}, len(manifests))
+	for _, manifest := range manifests {
+		refreshed[fakeKey(manifest)] = struct{}{}
+	}
+	for key, manifest := range f.manifests {
+		if key.catalogSourceName != catalogSourceName || key.catalogSourceNamespace != catalogSourceNamespace {
+			continue
+		}
+		if _, ok := refreshed[key]; !ok {
+			f.apply(watch.Deleted, manifest)
+		}
+	}
+	for _, manifest := range manifests {
+		if _, ok := f.manifests[fakeKey(manifest)]; ok {
+			f.apply(watch.Modified, manifest)
+		} else {
+			f.apply(watch.Added, manifest)
+		}
+	}
+}
+
+// SetError makes Get, GetChannelCSV, List, ListInvalid, and Subscribe fail with err until it's cleared by setting a nil error.
+// Changes can still be made while it's set, and are sent to existing subscribers.
+func (f *FakeProvider) SetError(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
+
+// apply records a change to a PackageManifest and sends it to subscribers. The manifest is stored stamped with the
+// change's resourceVersion. The caller must hold f.mu.
+func (f *FakeProvider) apply(eventType watch.EventType, manifest v1alpha1.PackageManifest) {
+	f.generation++
+	manifest = f.history.record(eventType, manifest, f.generation)
+
+	subscribers := f.add
+	switch eventType {
+	case watch.Modified:
+		subscribers = f.modify
+		f.manifests[fakeKey(manifest)] = manifest
+	case watch.Deleted:
+		subscribers = f.delete
+		delete(f.manifests, fakeKey(manifest))
+	default:
+		f.manifests[fakeKey(manifest)] = manifest
+	}
+	for _, ch := range subscribers {
+		ch <- manifest
+	}
+}
+
+// withoutChan returns chans without ch
+func withoutChan(chans []chan v1alpha1.PackageManifest, ch chan v1alpha1.PackageManifest) []chan v1alpha1.PackageManifest {
+	remaining := []chan v1alpha1.PackageManifest{}
+	for _, c := range chans {
+		if c != ch {
+			remaining = append(remaining, c)
+		}
+	}
+	return remaining
+}
+
+// SetEventHistorySize sets the number of recent events kept for each namespace, evicting any over the new size
+func (f *FakeProvider) SetEventHistorySize(size int) {
+	f.history.setSize(size)
+}
+
+func (f *FakeProvider) EventsSince(namespace string, resourceVersion uint64) ([]Event, bool) {
+	return f.history.since(namespace, resourceVersion)
+}
+
+// SnapshotVersion returns the resourceVersion of the latest change, which is served as the list resourceVersion
+func (f *FakeProvider) SnapshotVersion() (uint64, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.generation, true
+}
+
+// AddInvalid adds a PackageManifest that's only listed by ListInvalid, as a package that failed validation. It isn't
+// sent to subscribers.
+func (f *FakeProvider) AddInvalid(manifest v1alpha1.PackageManifest) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.invalid = append(f.invalid, manifest)
+}
+
+// AddCSV makes a CSV available to the channels that refer to it by name
+func (f *FakeProvider) AddCSV(csv operatorsv1alpha1.ClusterServiceVersion) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.csvs[csv.GetName()] = csv
+}
+
+func fakeKey(manifest v1alpha1.PackageManifest) packageKey {
+	return packageKey{
+		catalogSourceName:      manifest.Status.CatalogSourceName,
+		catalogSourceNamespace: manifest.Status.CatalogSourceNamespace,
+		packageName:            manifest.GetName(),
 	}
 }
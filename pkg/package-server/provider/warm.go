@@ -0,0 +1,236 @@
+package provider
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	operatorsv1alpha1 "github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1"
+)
+
+const (
+	// DefaultWarmTTL is the default time a WarmProvider serves the lists it prefetched
+	DefaultWarmTTL = time.Minute
+
+	// maxWarmAttempts is the number of times a WarmProvider lists a namespace that changes while it's being listed
+	maxWarmAttempts = 3
+)
+
+var _ PackageManifestProvider = &WarmProvider{}
+var _ FilteredPackageManifestLister = &WarmProvider{}
+var _ ChannelCSVGetter = &WarmProvider{}
+var _ EventHistory = &WarmProvider{}
+var _ InvalidPackageManifestLister = &WarmProvider{}
+var _ SnapshotVersioner = &WarmProvider{}
+
+// warmList is a list prefetched by a WarmProvider
+type warmList struct {
+	list    *v1alpha1.PackageManifestList
+	expires time.Time
+}
+
+// WarmProvider wraps a provider so that the lists it's asked to prefetch with Warm, such as those of the watched
+// namespaces at startup, are served without querying the provider.
+//
+// Prefetched lists are only served until they expire or the provider reports a change to any PackageManifest, either
+// to its subscribers or through Invalidate, after which lists are passed through to the provider as they would be
+// without the WarmProvider. Lists are only prefetched by Warm, never by the queries passed through.
+type WarmProvider struct {
+	provider PackageManifestProvider
+	ttl      time.Duration
+	clock    clock.Clock
+
+	mu sync.Mutex
+	// lists holds the prefetched lists by namespace
+	lists map[string]warmList
+	// generation is incremented by each change, so that a list fetched while a change was made isn't kept
+	generation uint64
+}
+
+// NewWarmProvider returns a WarmProvider for the given provider. A ttl of zero or less uses DefaultWarmTTL.
+func NewWarmProvider(provider PackageManifestProvider, ttl time.Duration) *WarmProvider {
+	if ttl <= 0 {
+		ttl = DefaultWarmTTL
+	}
+
+	return &WarmProvider{
+		provider: provider,
+		ttl:      ttl,
+		clock:    clock.RealClock{},
+		lists:    make(map[string]warmList),
+	}
+}
+
+// Warm lists the PackageManifests in each of the given namespaces, which may include metav1.NamespaceAll, so that they
+// can be served by List without querying the provider. Prefetched lists are dropped on the first change the provider
+// sends to subscribers until stopCh is closed.
+// It returns once every namespace has been listed, or with the first error, keeping the lists fetched before it.
+func (w *WarmProvider) Warm(stopCh <-chan struct{}, namespaces []string) error {
+	add, modify, delete, err := w.provider.Subscribe(stopCh)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to package manifest changes: %s", err)
+	}
+	go w.forgetOnChange(stopCh, add, modify, delete)
+
+	for _, namespace := range namespaces {
+		if err := w.prefetch(namespace); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// prefetch lists the PackageManifests in the namespace and keeps the list. A list fetched while a change was made,
+// such as one made by the provider syncing a catalog to serve the list, may already be out of date, so it's fetched
+// again, up to maxWarmAttempts times.
+func (w *WarmProvider) prefetch(namespace string) error {
+	for attempt := 0; attempt < maxWarmAttempts; attempt++ {
+		w.mu.Lock()
+		generation := w.generation
+		w.mu.Unlock()
+
+		list, err := w.provider.List(namespace)
+		if err != nil {
+			return fmt.Errorf("failed to list package manifests in namespace %q: %s", namespace, err)
+		}
+
+		w.mu.Lock()
+		kept := w.generation == generation
+		if kept {
+			w.lists[namespace] = warmList{list: list, expires: w.clock.Now().Add(w.ttl)}
+		}
+		w.mu.Unlock()
+		if kept {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("package manifests in namespace %q kept changing while being listed", namespace)
+}
+
+// forgetOnChange drops the prefetched lists whenever the provider sends a change, until stopCh is closed or the
+// provider closes its channels
+func (w *WarmProvider) forgetOnChange(stopCh <-chan struct{}, add, modify, delete PackageChan) {
+	for add != nil || modify != nil || delete != nil {
+		var ok bool
+		select {
+		case _, ok = <-add:
+			if !ok {
+				add = nil
+			}
+		case _, ok = <-modify:
+			if !ok {
+				modify = nil
+			}
+		case _, ok = <-delete:
+			if !ok {
+				delete = nil
+			}
+		case <-stopCh:
+			return
+		}
+		if ok {
+			w.forget()
+		}
+	}
+}
+
+// forget drops the prefetched lists
+func (w *WarmProvider) forget() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.generation++
+	w.lists = make(map[string]warmList)
+}
+
+// warm returns a copy of the prefetched list for the namespace, taken from the all-namespace list if the namespace
+// wasn't prefetched itself, or false if there's no unexpired list to serve it from
+func (w *WarmProvider) warm(namespace string) (*v1alpha1.PackageManifestList, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := w.clock.Now()
+	if prefetched, ok := w.lists[namespace]; ok && now.Before(prefetched.expires) {
+		return prefetched.list.DeepCopy(), true
+	}
+	all, ok := w.lists[metav1.NamespaceAll]
+	if !ok || !now.Before(all.expires) {
+		return nil, false
+	}
+
+	list := &v1alpha1.PackageManifestList{}
+	list.ResourceVersion = all.list.GetResourceVersion()
+	for _, manifest := range all.list.Items {
+		if manifest.GetNamespace() == namespace {
+			list.Items = append(list.Items, *manifest.DeepCopy())
+		}
+	}
+	return list, true
+}
+
+// Get returns the provider's PackageManifest
+func (w *WarmProvider) Get(namespace, name string) (*v1alpha1.PackageManifest, error) {
+	return w.provider.Get(namespace, name)
+}
+
+// List returns the prefetched PackageManifests in the namespace, or the provider's if there are none
+func (w *WarmProvider) List(namespace string) (*v1alpha1.PackageManifestList, error) {
+	if list, ok := w.warm(namespace); ok {
+		return list, nil
+	}
+	return w.provider.List(namespace)
+}
+
+// ListFiltered returns the prefetched PackageManifests in the namespace, which callers filter as they would any
+// provider's, or the provider's PackageManifests listed as ListFiltered would list them if there are none
+func (w *WarmProvider) ListFiltered(namespace string, filter ListFilter) (*v1alpha1.PackageManifestList, error) {
+	if list, ok := w.warm(namespace); ok {
+		return list, nil
+	}
+	return ListFiltered(w.provider, namespace, filter)
+}
+
+// ListInvalid returns the provider's invalid PackageManifests, which are never prefetched
+func (w *WarmProvider) ListInvalid(namespace string) (*v1alpha1.PackageManifestList, error) {
+	return ListInvalid(w.provider, namespace)
+}
+
+// GetChannelCSV returns the provider's channel CSV
+func (w *WarmProvider) GetChannelCSV(namespace, name, channel string) (*operatorsv1alpha1.ClusterServiceVersion, error) {
+	getter, ok := w.provider.(ChannelCSVGetter)
+	if !ok {
+		return nil, fmt.Errorf("provider %T doesn't keep channel CSVs", w.provider)
+	}
+	return getter.GetChannelCSV(namespace, name, channel)
+}
+
+// EventsSince returns the provider's recorded events, or none if the provider doesn't record events
+func (w *WarmProvider) EventsSince(namespace string, resourceVersion uint64) ([]Event, bool) {
+	history, ok := w.provider.(EventHistory)
+	if !ok {
+		return nil, true
+	}
+	return history.EventsSince(namespace, resourceVersion)
+}
+
+// SnapshotVersion returns the provider's snapshot version
+func (w *WarmProvider) SnapshotVersion() (uint64, bool) {
+	return SnapshotVersion(w.provider)
+}
+
+// Subscribe subscribes to the provider's changes
+func (w *WarmProvider) Subscribe(stopCh <-chan struct{}) (add, modify, delete PackageChan, err error) {
+	return w.provider.Subscribe(stopCh)
+}
+
+// Invalidate drops the prefetched lists, since they may include the CatalogSource's PackageManifests, and invalidates
+// the CatalogSource in the provider
+func (w *WarmProvider) Invalidate(catalogSourceName, catalogSourceNamespace string) {
+	w.forget()
+	w.provider.Invalidate(catalogSourceName, catalogSourceNamespace)
+}
@@ -0,0 +1,148 @@
+package provider
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+)
+
+// warmProviderFor returns a WarmProvider warmed with the given namespaces, and the provider it wraps
+func warmProviderFor(t *testing.T, stopCh <-chan struct{}, namespaces ...string) (*WarmProvider, *failingProvider) {
+	prov := &failingProvider{FakeProvider: NewFakeProvider()}
+	prov.Add(packageManifest(packageValue{name: "etcd", namespace: "default"}))
+	prov.Add(packageManifest(packageValue{name: "prometheus", namespace: "monitoring"}))
+
+	warm := NewWarmProvider(prov, time.Minute)
+	require.NoError(t, warm.Warm(stopCh, namespaces))
+	require.Equal(t, len(namespaces), prov.queries)
+	prov.queries = 0
+
+	return warm, prov
+}
+
+func TestWarmProviderListAfterWarm(t *testing.T) {
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	warm, prov := warmProviderFor(t, stopCh, "default", metav1.NamespaceAll)
+
+	manifests, err := warm.List("default")
+	require.NoError(t, err)
+	require.Len(t, manifests.Items, 1)
+	require.Equal(t, "etcd", manifests.Items[0].GetName())
+
+	manifests, err = warm.List(metav1.NamespaceAll)
+	require.NoError(t, err)
+	require.Len(t, manifests.Items, 2)
+
+	// namespaces that weren't prefetched themselves are served from the all-namespace list
+	manifests, err = warm.List("monitoring")
+	require.NoError(t, err)
+	require.Len(t, manifests.Items, 1)
+	require.Equal(t, "prometheus", manifests.Items[0].GetName())
+
+	manifests, err = ListFiltered(warm, "default", ListFilter{Name: "etcd"})
+	require.NoError(t, err)
+	require.Len(t, manifests.Items, 1)
+
+	require.Equal(t, 0, prov.queries)
+
+	// gets aren't prefetched
+	_, err = warm.Get("default", "etcd")
+	require.NoError(t, err)
+	require.Equal(t, 1, prov.queries)
+}
+
+func TestWarmProviderServesCopies(t *testing.T) {
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	warm, _ := warmProviderFor(t, stopCh, "default")
+
+	manifests, err := warm.List("default")
+	require.NoError(t, err)
+	manifests.Items[0].SetName("modified")
+
+	manifests, err = warm.List("default")
+	require.NoError(t, err)
+	require.Equal(t, "etcd", manifests.Items[0].GetName())
+}
+
+func TestWarmProviderForgets(t *testing.T) {
+	tests := []struct {
+		description string
+		change      func(warm *WarmProvider, prov *failingProvider, fakeClock *clock.FakeClock)
+	}{
+		{
+			description: "Expired",
+			change: func(warm *WarmProvider, prov *failingProvider, fakeClock *clock.FakeClock) {
+				fakeClock.Step(time.Minute)
+			},
+		},
+		{
+			description: "Invalidated",
+			change: func(warm *WarmProvider, prov *failingProvider, fakeClock *clock.FakeClock) {
+				warm.Invalidate("catsrc", "default")
+			},
+		},
+		{
+			description: "Changed",
+			change: func(warm *WarmProvider, prov *failingProvider, fakeClock *clock.FakeClock) {
+				prov.Add(packageManifest(packageValue{name: "vault", namespace: "default"}))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+			fakeClock := clock.NewFakeClock(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC))
+			prov := &failingProvider{FakeProvider: NewFakeProvider()}
+			prov.Add(packageManifest(packageValue{name: "etcd", namespace: "default"}))
+			warm := NewWarmProvider(prov, time.Minute)
+			warm.clock = fakeClock
+			require.NoError(t, warm.Warm(stopCh, []string{"default"}))
+
+			tt.change(warm, prov, fakeClock)
+
+			// changes are sent to subscribers before they're forgotten, so wait for the prefetched list to be dropped
+			for deadline := time.Now().Add(5 * time.Second); ; {
+				warm.mu.Lock()
+				forgotten := len(warm.lists) == 0 || !fakeClock.Now().Before(warm.lists["default"].expires)
+				warm.mu.Unlock()
+				if forgotten {
+					break
+				}
+				require.True(t, time.Now().Before(deadline), "prefetched list wasn't dropped")
+				time.Sleep(time.Millisecond)
+			}
+
+			queries := prov.queries
+			manifests, err := warm.List("default")
+			require.NoError(t, err)
+			require.Equal(t, queries+1, prov.queries)
+			require.Equal(t, len(prov.manifests), len(manifests.Items))
+		})
+	}
+}
+
+func TestWarmProviderWarmError(t *testing.T) {
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	prov := &failingProvider{FakeProvider: NewFakeProvider()}
+	prov.Add(packageManifest(packageValue{name: "etcd", namespace: "default"}))
+	warm := NewWarmProvider(prov, time.Minute)
+
+	prov.err = errors.New("catalog unavailable")
+	require.EqualError(t, warm.Warm(stopCh, []string{"default"}), `failed to list package manifests in namespace "default": catalog unavailable`)
+
+	// nothing was prefetched, so lists are passed through
+	prov.err = nil
+	manifests, err := warm.List("default")
+	require.NoError(t, err)
+	require.Len(t, manifests.Items, 1)
+	require.Equal(t, 2, prov.queries)
+}
@@ -17,14 +17,19 @@ import (
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
 
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/client"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/client/informers/externalversions"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/queueinformer"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apiserver"
 	genericpackagemanifests "github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apiserver/generic"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/provider"
+	packagemanifeststorage "github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/storage/packagemanifest"
 )
 
+// DefaultWarmupTimeout is the default time the package-server waits at startup for its provider to be warmed
+const DefaultWarmupTimeout = 10 * time.Second
+
 // NewCommandStartPackageServer provides a CLI handler for 'start master' command
 // with a default PackageServerOptions.
 func NewCommandStartPackageServer(defaults *PackageServerOptions, stopCh <-chan struct{}) *cobra.Command {
@@ -46,6 +51,13 @@ func NewCommandStartPackageServer(defaults *PackageServerOptions, stopCh <-chan
 	flags.StringSliceVar(&defaults.WatchedNamespaces, "watched-namespaces", defaults.WatchedNamespaces, "list of namespaces the package-server will watch watch for CatalogSources")
 	flags.StringVar(&defaults.Kubeconfig, "kubeconfig", defaults.Kubeconfig, "path to the kubeconfig used to connect to the Kubernetes API server and the Kubelets (defaults to in-cluster config)")
 	flags.BoolVar(&defaults.Debug, "debug", defaults.Debug, "use debug log level")
+	flags.IntVar(&defaults.WatchBacklog, "watch-backlog", defaults.WatchBacklog, "maximum number of undelivered events buffered for each watch")
+	flags.IntVar(&defaults.MaxWatchesPerNamespace, "max-watches-per-namespace", defaults.MaxWatchesPerNamespace, "maximum number of active watches in each namespace, beyond which new watches are rejected with a 429 error; 0 allows any number")
+	flags.IntVar(&defaults.WatchHistory, "watch-history", defaults.WatchHistory, "number of recent events kept for each namespace, so that watches resuming from a recent resourceVersion can be replayed them rather than relisting")
+	flags.IntVar(&defaults.ProviderFailureThreshold, "provider-failure-threshold", defaults.ProviderFailureThreshold, "number of consecutive failed catalog queries after which requests fail fast with a 503 until the cooldown has passed")
+	flags.DurationVar(&defaults.ProviderFailureCooldown, "provider-failure-cooldown", defaults.ProviderFailureCooldown, "time requests fail fast after the failure threshold is reached before the catalog is queried again")
+	flags.DurationVar(&defaults.WarmupTimeout, "warmup-timeout", defaults.WarmupTimeout, "maximum time to wait at startup for the package manifests of the watched namespaces to be prefetched before serving requests; prefetching continues in the background after it")
+	flags.StringVar(&defaults.WatchOverflowPolicy, "watch-overflow-policy", defaults.WatchOverflowPolicy, "what to do when a watch falls further behind than the backlog: \"close\" ends the watch with a 410 error, \"drop-oldest\" discards the oldest undelivered event")
 
 	defaults.SecureServing.AddFlags(flags)
 	defaults.Authentication.AddFlags(flags)
@@ -65,6 +77,17 @@ type PackageServerOptions struct {
 	WakeupInterval    time.Duration
 	WatchedNamespaces []string
 
+	WatchBacklog        int
+	WatchOverflowPolicy string
+	WatchHistory        int
+
+	MaxWatchesPerNamespace int
+
+	ProviderFailureThreshold int
+	ProviderFailureCooldown  time.Duration
+
+	WarmupTimeout time.Duration
+
 	Kubeconfig string
 
 	// Only to be used to for testing
@@ -89,6 +112,17 @@ func NewPackageServerOptions(out, errOut io.Writer) *PackageServerOptions {
 		WatchedNamespaces: []string{v1.NamespaceAll},
 		WakeupInterval:    5 * time.Minute,
 
+		WatchBacklog:        packagemanifeststorage.DefaultWatchBacklog,
+		WatchOverflowPolicy: string(packagemanifeststorage.WatchOverflowClose),
+		WatchHistory:        provider.DefaultEventHistorySize,
+
+		MaxWatchesPerNamespace: packagemanifeststorage.DefaultMaxWatchesPerNamespace,
+
+		ProviderFailureThreshold: provider.DefaultBreakerThreshold,
+		ProviderFailureCooldown:  provider.DefaultBreakerCooldown,
+
+		WarmupTimeout: DefaultWarmupTimeout,
+
 		DisableAuthForTesting: true,
 		Debug:                 false,
 
@@ -103,6 +137,16 @@ func (o *PackageServerOptions) Complete() error {
 	return nil
 }
 
+// Validate checks that the options are consistent
+func (o *PackageServerOptions) Validate() error {
+	switch packagemanifeststorage.WatchOverflowPolicy(o.WatchOverflowPolicy) {
+	case packagemanifeststorage.WatchOverflowClose, packagemanifeststorage.WatchOverflowDropOldest:
+		return nil
+	default:
+		return fmt.Errorf("unknown watch overflow policy %q", o.WatchOverflowPolicy)
+	}
+}
+
 func (o *PackageServerOptions) Config() (*apiserver.Config, error) {
 	if err := o.SecureServing.MaybeDefaultWithSelfSignedCerts("localhost", nil, []net.IP{net.ParseIP("127.0.0.1")}); err != nil {
 		return nil, fmt.Errorf("error creating self-signed certificates: %v", err)
@@ -123,8 +167,13 @@ func (o *PackageServerOptions) Config() (*apiserver.Config, error) {
 	}
 
 	return &apiserver.Config{
-		GenericConfig:  serverConfig,
-		ProviderConfig: genericpackagemanifests.ProviderConfig{},
+		GenericConfig: serverConfig,
+		ProviderConfig: genericpackagemanifests.ProviderConfig{
+			WatchBacklog:        o.WatchBacklog,
+			WatchOverflowPolicy: packagemanifeststorage.WatchOverflowPolicy(o.WatchOverflowPolicy),
+
+			MaxWatchesPerNamespace: o.MaxWatchesPerNamespace,
+		},
 	}, nil
 }
 
@@ -133,6 +182,10 @@ func (o *PackageServerOptions) Run(stopCh <-chan struct{}) error {
 		log.SetLevel(log.DebugLevel)
 	}
 
+	if err := o.Validate(); err != nil {
+		return err
+	}
+
 	// grab the config for the API server
 	config, err := o.Config()
 	if err != nil {
@@ -178,7 +231,23 @@ func (o *PackageServerOptions) Run(stopCh <-chan struct{}) error {
 	}
 
 	sourceProvider := provider.NewInMemoryProvider(catsrcSharedIndexInformers, queueOperator)
-	config.ProviderConfig.Provider = sourceProvider
+	sourceProvider.SetEventHistorySize(o.WatchHistory)
+	// stop querying a catalog backend that keeps failing, rather than holding up every request on it
+	breakerProvider := provider.NewBreakerProvider(sourceProvider, o.ProviderFailureThreshold, o.ProviderFailureCooldown)
+	// serve the first lists of the watched namespaces from a prefetch rather than querying the catalogs for them
+	warmProvider := provider.NewWarmProvider(breakerProvider, provider.DefaultWarmTTL)
+	config.ProviderConfig.Provider = warmProvider
+	for _, informer := range catsrcSharedIndexInformers {
+		informer.AddEventHandler(provider.InvalidateOnUpdate(warmProvider))
+	}
+
+	// the server version is used to label packages compatible with the cluster
+	serverVersion, err := kubeClient.Discovery().ServerVersion()
+	if err != nil {
+		log.Warnf("unable to determine server version, packages won't be labeled with cluster compatibility: %v", err)
+	} else {
+		config.ProviderConfig.ServerVersion = serverVersion
+	}
 	// we should never need to resync, since we're not worried about missing events,
 	// and resync is actually for regular interval-based reconciliation these days,
 	// so set the default resync interval to 0
@@ -191,5 +260,42 @@ func (o *PackageServerOptions) Run(stopCh <-chan struct{}) error {
 
 	go sourceProvider.Run(stopCh)
 
+	warmed := make(chan struct{})
+	go func() {
+		defer close(warmed)
+		if err := warmUp(stopCh, warmProvider, catsrcSharedIndexInformers, o.WatchedNamespaces); err != nil {
+			log.Warnf("failed to warm package manifest provider: %v", err)
+		}
+	}()
+	select {
+	case <-warmed:
+	case <-time.After(o.WarmupTimeout):
+		log.Infof("package manifest provider not warmed after %s, serving requests while it warms", o.WarmupTimeout)
+	case <-stopCh:
+	}
+
 	return server.GenericAPIServer.PrepareRun().Run(stopCh)
 }
+
+// warmUp prefetches the package manifests of the given namespaces once the CatalogSource informers have synced.
+// Every CatalogSource is invalidated first, so that the prefetch queries the catalogs rather than waiting for their
+// queued syncs.
+func warmUp(stopCh <-chan struct{}, prov *provider.WarmProvider, informers []cache.SharedIndexInformer, namespaces []string) error {
+	var hasSynced []cache.InformerSynced
+	for _, informer := range informers {
+		hasSynced = append(hasSynced, informer.HasSynced)
+	}
+	if !cache.WaitForCacheSync(stopCh, hasSynced...) {
+		return fmt.Errorf("catalog source caches didn't sync")
+	}
+
+	for _, informer := range informers {
+		for _, obj := range informer.GetStore().List() {
+			if catsrc, ok := obj.(*v1alpha1.CatalogSource); ok {
+				prov.Invalidate(catsrc.GetName(), catsrc.GetNamespace())
+			}
+		}
+	}
+
+	return prov.Warm(stopCh, namespaces)
+}
@@ -0,0 +1,115 @@
+package packagemanifest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
+
+	operatorsv1alpha1 "github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/provider"
+)
+
+// ChannelStorage serves the packagemanifests/channels subresource, which returns the current CSV of a PackageManifest's
+// channel at packagemanifests/<name>/channels/<channel>.
+// The CSV is served as JSON, or as YAML if the request accepts application/yaml.
+type ChannelStorage struct {
+	groupResource schema.GroupResource
+	prov          provider.PackageManifestProvider
+}
+
+var _ rest.Storage = &ChannelStorage{}
+var _ rest.Connecter = &ChannelStorage{}
+
+// NewChannelStorage returns storage for the channels subresource of PackageManifests
+func NewChannelStorage(groupResource schema.GroupResource, prov provider.PackageManifestProvider) *ChannelStorage {
+	return &ChannelStorage{
+		groupResource: groupResource,
+		prov:          prov,
+	}
+}
+
+// Storage interface
+func (c *ChannelStorage) New() runtime.Object {
+	return &v1alpha1.PackageManifest{}
+}
+
+// Connecter interface
+func (c *ChannelStorage) ConnectMethods() []string {
+	return []string{http.MethodGet}
+}
+
+// Connecter interface
+func (c *ChannelStorage) NewConnectOptions() (runtime.Object, bool, string) {
+	// the channel is read from the request path, which is only routed here if a subpath is accepted
+	return nil, true, ""
+}
+
+// Connecter interface
+func (c *ChannelStorage) Connect(ctx context.Context, name string, options runtime.Object, responder rest.Responder) (http.Handler, error) {
+	getter, ok := c.prov.(provider.ChannelCSVGetter)
+	if !ok {
+		return nil, k8serrors.NewMethodNotSupported(c.groupResource, "get channels")
+	}
+	namespace := genericapirequest.NamespaceValue(ctx)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		channel, err := channelFor(req)
+		if err != nil {
+			responder.Error(err)
+			return
+		}
+
+		csv, err := getter.GetChannelCSV(namespace, name, channel)
+		if err != nil {
+			responder.Error(providerError(c.groupResource, fmt.Sprintf("%s/channels/%s", name, channel), err))
+			return
+		}
+		if csv == nil {
+			responder.Error(k8serrors.NewNotFound(c.groupResource, fmt.Sprintf("%s/channels/%s", name, channel)))
+			return
+		}
+
+		// CSVs parsed from catalogs don't always carry their type, which clients need to apply them
+		csv.SetGroupVersionKind(operatorsv1alpha1.SchemeGroupVersion.WithKind(operatorsv1alpha1.ClusterServiceVersionKind))
+
+		contentType := "application/json"
+		body, err := json.Marshal(csv)
+		if err == nil && strings.Contains(req.Header.Get("Accept"), "yaml") {
+			contentType = "application/yaml"
+			body, err = yaml.JSONToYAML(body)
+		}
+		if err != nil {
+			responder.Error(k8serrors.NewInternalError(err))
+			return
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(http.StatusOK)
+		w.Write(body)
+	}), nil
+}
+
+// channelFor returns the channel named by a packagemanifests/<name>/channels/<channel> request
+func channelFor(req *http.Request) (string, error) {
+	info, ok := genericapirequest.RequestInfoFrom(req.Context())
+	if !ok {
+		return "", k8serrors.NewBadRequest("missing request info")
+	}
+
+	// parts are the resource, name, subresource, and channel
+	if len(info.Parts) != 4 || info.Parts[3] == "" {
+		return "", k8serrors.NewBadRequest("expected a request for packagemanifests/<name>/channels/<channel>")
+	}
+
+	return info.Parts[3], nil
+}
@@ -0,0 +1,110 @@
+package packagemanifest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/require"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+
+	operatorsv1alpha1 "github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/provider"
+)
+
+type fakeResponder struct {
+	err error
+}
+
+func (r *fakeResponder) Object(statusCode int, obj runtime.Object) {}
+
+func (r *fakeResponder) Error(err error) {
+	r.err = err
+}
+
+func TestConnectChannel(t *testing.T) {
+	tests := []struct {
+		parts        []string
+		accept       string
+		expectedCSV  string
+		expectedYAML bool
+		expectedErr  func(error) bool
+		description  string
+	}{
+		{
+			parts:       []string{"packagemanifests", "etcd", "channels", "alpha"},
+			expectedCSV: "etcdoperator.v0.9.2",
+			description: "Channel",
+		},
+		{
+			parts:        []string{"packagemanifests", "etcd", "channels", "alpha"},
+			accept:       "application/yaml",
+			expectedCSV:  "etcdoperator.v0.9.2",
+			expectedYAML: true,
+			description:  "ChannelAsYAML",
+		},
+		{
+			parts:       []string{"packagemanifests", "etcd", "channels", "beta"},
+			expectedErr: k8serrors.IsNotFound,
+			description: "MissingChannel",
+		},
+		{
+			parts:       []string{"packagemanifests", "etcd", "channels"},
+			expectedErr: k8serrors.IsBadRequest,
+			description: "NoChannel",
+		},
+		{
+			parts:       []string{"packagemanifests", "etcd", "channels", "alpha", "extra"},
+			expectedErr: k8serrors.IsBadRequest,
+			description: "ExtraPath",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			prov := provider.NewFakeProvider()
+			manifest := packageManifest(packageValue{name: "etcd", namespace: "default"})
+			manifest.Status.Channels = []v1alpha1.PackageChannel{{Name: "alpha", CurrentCSVName: "etcdoperator.v0.9.2"}}
+			prov.Add(manifest)
+			prov.AddCSV(operatorsv1alpha1.ClusterServiceVersion{ObjectMeta: metav1.ObjectMeta{Name: "etcdoperator.v0.9.2"}})
+			storage := NewChannelStorage(v1alpha1.Resource("packagemanifests"), prov)
+
+			ctx := genericapirequest.WithNamespace(genericapirequest.NewContext(), "default")
+			responder := &fakeResponder{}
+			handler, err := storage.Connect(ctx, "etcd", nil, responder)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept", test.accept)
+			req = req.WithContext(genericapirequest.WithRequestInfo(req.Context(), &genericapirequest.RequestInfo{Parts: test.parts}))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if test.expectedErr != nil {
+				require.True(t, test.expectedErr(responder.err), "unexpected error %v", responder.err)
+				return
+			}
+			require.NoError(t, responder.err)
+			require.Equal(t, http.StatusOK, rec.Code)
+
+			body := rec.Body.Bytes()
+			if test.expectedYAML {
+				require.Equal(t, "application/yaml", rec.Header().Get("Content-Type"))
+				body, err = yaml.YAMLToJSON(body)
+				require.NoError(t, err)
+			} else {
+				require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+			}
+			csv := operatorsv1alpha1.ClusterServiceVersion{}
+			require.NoError(t, json.Unmarshal(body, &csv))
+			require.Equal(t, test.expectedCSV, csv.GetName())
+			require.Equal(t, operatorsv1alpha1.ClusterServiceVersionKind, csv.Kind)
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package packagemanifest
+
+import (
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1"
+)
+
+// catalogPackage identifies a package within a CatalogSource
+type catalogPackage struct {
+	catalogSourceName      string
+	catalogSourceNamespace string
+	packageName            string
+}
+
+// collapsePackageManifests returns one PackageManifest for each package in each CatalogSource, in the order each was
+// first listed.
+// Providers normally list a package once per CatalogSource, but nothing guarantees it; an aggregate of several
+// providers, for example, lists a package once for each provider serving it. The first manifest listed for a package
+// is kept, with the channels of its duplicates that it doesn't already have appended to its own. Its default channel
+// is kept if it has one, otherwise the first duplicate's default channel is used.
+func collapsePackageManifests(manifests []v1alpha1.PackageManifest) []v1alpha1.PackageManifest {
+	collapsed := []v1alpha1.PackageManifest{}
+	index := map[catalogPackage]int{}
+	for _, manifest := range manifests {
+		key := catalogPackage{
+			catalogSourceName:      manifest.Status.CatalogSourceName,
+			catalogSourceNamespace: manifest.Status.CatalogSourceNamespace,
+			packageName:            manifest.Status.PackageName,
+		}
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(collapsed)
+			collapsed = append(collapsed, *manifest.DeepCopy())
+			continue
+		}
+
+		kept := &collapsed[i]
+		if kept.Status.DefaultChannelName == "" {
+			kept.Status.DefaultChannelName = manifest.Status.DefaultChannelName
+		}
+		for _, channel := range manifest.Status.Channels {
+			if !hasChannel(kept.Status.Channels, channel.Name) {
+				kept.Status.Channels = append(kept.Status.Channels, channel)
+			}
+		}
+	}
+
+	return collapsed
+}
+
+func hasChannel(channels []v1alpha1.PackageChannel, name string) bool {
+	for _, channel := range channels {
+		if channel.Name == name {
+			return true
+		}
+	}
+	return false
+}
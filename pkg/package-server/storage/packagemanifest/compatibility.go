@@ -0,0 +1,69 @@
+package packagemanifest
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/coreos/go-semver/semver"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1"
+)
+
+// CompatibleWithClusterLabel is set on served PackageManifests when the cluster's Kubernetes version is known.
+// Its value is "true" if the current CSV of at least one of the package's channels supports the cluster's version.
+const CompatibleWithClusterLabel = "olm.compatibleWithCluster"
+
+// parseKubeVersion parses a Kubernetes version such as "v1.11.0+d4cacc0" or "1.10", ignoring any pre-release and build
+// metadata.
+func parseKubeVersion(version string) (*semver.Version, error) {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+	if strings.Count(version, ".") == 1 {
+		version = version + ".0"
+	}
+
+	return semver.NewVersion(version)
+}
+
+// supportsKubeVersion returns true if a CSV with the given minimum Kubernetes version can run on kubeVersion
+func supportsKubeVersion(minKubeVersion string, kubeVersion semver.Version) bool {
+	if minKubeVersion == "" {
+		return true
+	}
+
+	min, err := parseKubeVersion(minKubeVersion)
+	if err != nil {
+		log.Debugf("couldn't parse minKubeVersion %q: %s", minKubeVersion, err)
+		return false
+	}
+
+	return !kubeVersion.LessThan(*min)
+}
+
+// withCompatibility returns a copy of manifest labeled with whether it supports the cluster's Kubernetes version,
+// kubeVersion. The manifest is returned unchanged if the cluster's version is unknown.
+func withCompatibility(manifest v1alpha1.PackageManifest, kubeVersion *semver.Version) v1alpha1.PackageManifest {
+	if kubeVersion == nil {
+		return manifest
+	}
+
+	compatible := false
+	for _, channel := range manifest.Status.Channels {
+		if supportsKubeVersion(channel.CurrentCSVDesc.MinKubeVersion, *kubeVersion) {
+			compatible = true
+			break
+		}
+	}
+
+	// copy so the provider's cached manifest isn't modified
+	out := manifest.DeepCopy()
+	if out.Labels == nil {
+		out.Labels = map[string]string{}
+	}
+	out.Labels[CompatibleWithClusterLabel] = strconv.FormatBool(compatible)
+
+	return *out
+}
@@ -3,7 +3,11 @@ package packagemanifest
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
 
+	"github.com/coreos/go-semver/semver"
+	log "github.com/sirupsen/logrus"
 	"k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
@@ -12,6 +16,8 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/version"
 	"k8s.io/apimachinery/pkg/watch"
 	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
 	"k8s.io/apiserver/pkg/registry/rest"
@@ -21,8 +27,12 @@ import (
 )
 
 type PackageManifestStorage struct {
-	groupResource schema.GroupResource
-	prov          provider.PackageManifestProvider
+	groupResource       schema.GroupResource
+	prov                provider.PackageManifestProvider
+	kubeVersion         *semver.Version
+	watchBacklog        int
+	watchOverflowPolicy WatchOverflowPolicy
+	watchLimiter        *watchLimiter
 }
 
 var _ rest.KindProvider = &PackageManifestStorage{}
@@ -33,11 +43,39 @@ var _ rest.Scoper = &PackageManifestStorage{}
 var _ rest.Watcher = &PackageManifestStorage{}
 
 // NewStorage returns an in-memory implementation of storage.Interface.
-func NewStorage(groupResource schema.GroupResource, prov provider.PackageManifestProvider) *PackageManifestStorage {
-	return &PackageManifestStorage{
-		groupResource: groupResource,
-		prov:          prov,
+// If serverVersion is given, served PackageManifests are labeled with whether they support the cluster's version.
+func NewStorage(groupResource schema.GroupResource, prov provider.PackageManifestProvider, serverVersion *version.Info) *PackageManifestStorage {
+	storage := &PackageManifestStorage{
+		groupResource:       groupResource,
+		prov:                prov,
+		watchBacklog:        DefaultWatchBacklog,
+		watchOverflowPolicy: WatchOverflowClose,
+		watchLimiter:        newWatchLimiter(DefaultMaxWatchesPerNamespace),
 	}
+
+	if serverVersion != nil {
+		kubeVersion, err := parseKubeVersion(serverVersion.GitVersion)
+		if err != nil {
+			log.Warnf("couldn't parse server version %q, not labeling package compatibility: %s", serverVersion.GitVersion, err)
+		} else {
+			storage.kubeVersion = kubeVersion
+		}
+	}
+
+	return storage
+}
+
+// SetWatchLimits sets the maximum number of undelivered events buffered for each watch, and what to do when a watch
+// consumer falls further behind
+func (m *PackageManifestStorage) SetWatchLimits(maxBacklog int, overflowPolicy WatchOverflowPolicy) {
+	m.watchBacklog = maxBacklog
+	m.watchOverflowPolicy = overflowPolicy
+}
+
+// SetMaxWatchesPerNamespace sets the maximum number of active watches in each namespace, beyond which new watches are
+// rejected with a 429 TooManyRequests error. A max less than 1 allows any number of watches.
+func (m *PackageManifestStorage) SetMaxWatchesPerNamespace(max int) {
+	m.watchLimiter = newWatchLimiter(max)
 }
 
 // Storage interface
@@ -59,9 +97,13 @@ func (m *PackageManifestStorage) NewList() runtime.Object {
 func (m *PackageManifestStorage) List(ctx context.Context, options *metainternalversion.ListOptions) (runtime.Object, error) {
 	namespace := genericapirequest.NamespaceValue(ctx)
 
-	labelSelector := labels.Everything()
-	if options != nil && options.LabelSelector != nil {
-		labelSelector = options.LabelSelector
+	if options == nil {
+		options = &metainternalversion.ListOptions{}
+	}
+
+	labelSelector, err := labelSelectorFor(options.LabelSelector)
+	if err != nil {
+		return nil, err
 	}
 
 	name, err := nameFor(options.FieldSelector)
@@ -69,22 +111,71 @@ func (m *PackageManifestStorage) List(ctx context.Context, options *metainternal
 		return nil, err
 	}
 
-	res, err := m.prov.List(namespace)
+	res, err := provider.ListFiltered(m.prov, namespace, provider.ListFilter{Name: name, Labels: pushdownLabelSelector(options.LabelSelector), ProvidedAPI: providedAPIFor(options.FieldSelector), InstallMode: installModeFor(options.LabelSelector)})
 	if err != nil {
-		return &v1alpha1.PackageManifestList{}, err
+		return nil, providerError(m.groupResource, "", err)
+	}
+
+	if err := checkResourceVersion(options.ResourceVersion, res.GetResourceVersion()); err != nil {
+		return nil, err
+	}
+
+	if flagRequested(options.LabelSelector, IncludeInvalidKey) {
+		invalid, err := provider.ListInvalid(m.prov, namespace)
+		if err != nil {
+			return nil, providerError(m.groupResource, "", err)
+		}
+		res.Items = append(res.Items, invalid.Items...)
 	}
 
 	filtered := []v1alpha1.PackageManifest{}
 	for _, manifest := range res.Items {
-		if matches(manifest, name, namespace, labelSelector) {
+		manifest = withCompatibility(inNamespace(manifest, namespace), m.kubeVersion)
+		if matches(manifest, namespace, labelSelector, options.FieldSelector) {
 			filtered = append(filtered, manifest)
 		}
 	}
 
+	if flagRequested(options.LabelSelector, CollapsePackagesKey) {
+		filtered = collapsePackageManifests(filtered)
+	}
+
 	res.Items = filtered
+	if flagRequested(options.LabelSelector, SummarizeProvidersKey) {
+		res.ProviderCounts = providerCounts(filtered)
+	}
 	return res, nil
 }
 
+// providerError returns the API error reported to clients for a provider's failure to serve the named PackageManifest,
+// or to list PackageManifests if name is "". Errors that are already API errors, such as the ServiceUnavailable
+// reported while a provider backs off, are returned as is. Others are reported by their provider.ErrorClass: transient
+// failures as ServiceUnavailable, so that clients retry, missing packages as NotFound, and anything else, including
+// unclassified errors, as an InternalError, so that a failing catalog can't be mistaken for an empty one.
+func providerError(groupResource schema.GroupResource, name string, err error) error {
+	if _, ok := err.(k8serrors.APIStatus); ok {
+		return err
+	}
+	switch provider.ErrorClassOf(err) {
+	case provider.ErrorClassTransient:
+		return k8serrors.NewServiceUnavailable(err.Error())
+	case provider.ErrorClassNotFound:
+		return k8serrors.NewNotFound(groupResource, name)
+	default:
+		return k8serrors.NewInternalError(err)
+	}
+}
+
+// providerCounts returns the number of PackageManifests from each provider, keyed by provider name. PackageManifests
+// without a provider are counted under "".
+func providerCounts(manifests []v1alpha1.PackageManifest) map[string]int {
+	counts := map[string]int{}
+	for _, manifest := range manifests {
+		counts[manifest.Status.Provider.Name]++
+	}
+	return counts
+}
+
 // Getter interface
 func (m *PackageManifestStorage) Get(ctx context.Context, name string, opts *metav1.GetOptions) (runtime.Object, error) {
 	namespace := genericapirequest.NamespaceValue(ctx)
@@ -92,10 +183,14 @@ func (m *PackageManifestStorage) Get(ctx context.Context, name string, opts *met
 
 	pm, err := m.prov.Get(namespace, name)
 	if err != nil {
-		return nil, err
+		return nil, providerError(m.groupResource, name, err)
 	}
 	if pm != nil {
-		manifest = *pm
+		stamped := inNamespace(*pm, namespace)
+		if notModified(opts, &stamped) {
+			return notModifiedManifest(&stamped), nil
+		}
+		manifest = withCompatibility(stamped, m.kubeVersion)
 	} else {
 		return nil, k8serrors.NewNotFound(m.groupResource, name)
 	}
@@ -103,23 +198,72 @@ func (m *PackageManifestStorage) Get(ctx context.Context, name string, opts *met
 	return &manifest, nil
 }
 
+// notModified returns true if a Get with the given options is conditional on the PackageManifest having changed, and
+// it hasn't. PackageManifests without a resourceVersion are always considered changed.
+func notModified(opts *metav1.GetOptions, pm *v1alpha1.PackageManifest) bool {
+	if opts == nil || !strings.HasPrefix(opts.ResourceVersion, IfNoneMatchPrefix) {
+		return false
+	}
+	return pm.GetResourceVersion() != "" && strings.TrimPrefix(opts.ResourceVersion, IfNoneMatchPrefix) == pm.GetResourceVersion()
+}
+
+// notModifiedManifest returns the response to a conditional Get of an unchanged PackageManifest: its identifying
+// metadata, annotated with NotModifiedAnnotationKey
+func notModifiedManifest(pm *v1alpha1.PackageManifest) *v1alpha1.PackageManifest {
+	return &v1alpha1.PackageManifest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              pm.GetName(),
+			Namespace:         pm.GetNamespace(),
+			ResourceVersion:   pm.GetResourceVersion(),
+			CreationTimestamp: pm.GetCreationTimestamp(),
+			Annotations:       map[string]string{NotModifiedAnnotationKey: "true"},
+		},
+	}
+}
+
 // Watcher interface
+// A watch without a namespace streams the changes to PackageManifests in every namespace over a single connection.
 func (m *PackageManifestStorage) Watch(ctx context.Context, options *metainternalversion.ListOptions) (watch.Interface, error) {
 	namespace := genericapirequest.NamespaceValue(ctx)
-	name, err := nameFor(options.FieldSelector)
+
+	if options == nil {
+		options = &metainternalversion.ListOptions{}
+	}
+
+	if _, err := nameFor(options.FieldSelector); err != nil {
+		return nil, err
+	}
+
+	labelSelector, err := labelSelectorFor(options.LabelSelector)
 	if err != nil {
 		return nil, err
 	}
 
-	labelSelector := labels.Everything()
-	if options != nil && options.LabelSelector != nil {
-		labelSelector = options.LabelSelector
+	// a watch can't resume from a snapshot the provider hasn't reached, since the changes up to it would be skipped
+	if current, ok := provider.SnapshotVersion(m.prov); ok {
+		if err := checkResourceVersion(options.ResourceVersion, strconv.FormatUint(current, 10)); err != nil {
+			return nil, err
+		}
+	}
+
+	release, err := m.watchLimiter.acquire(namespace)
+	if err != nil {
+		return nil, err
 	}
 
-	watcher := NewWatcher(namespace, name, options.ResourceVersion, labelSelector, m.prov)
-	go watcher.Run(ctx)
+	// subscribe before returning so that no change made after the watch is established is missed
+	watcher := NewWatcher(namespace, options.FieldSelector, options.ResourceVersion, labelSelector, m.prov, m.kubeVersion, m.watchBacklog, m.watchOverflowPolicy)
+	if err := watcher.Subscribe(); err != nil {
+		release()
+		return nil, providerError(m.groupResource, "", err)
+	}
+	// the slot is released once the watch is stopped or its request ends, whichever comes first
+	go func() {
+		watcher.Run(ctx)
+		release()
+	}()
 
-	return watcher, nil
+	return &limitedWatch{Interface: watcher, release: release}, nil
 }
 
 // Scoper interface
@@ -127,25 +271,98 @@ func (m *PackageManifestStorage) NamespaceScoped() bool {
 	return true
 }
 
+// nameFor returns the name a field selector requires, or "" if it matches any name.
+// Selectable fields are also validated by v1alpha1.PackageManifestFieldLabelConversionFunc before requests reach
+// storage, but selectors are checked again here so that no selector can be misinterpreted.
 func nameFor(fs fields.Selector) (string, error) {
 	if fs == nil {
-		fs = fields.Everything()
+		return "", nil
 	}
-	name := ""
-	if value, found := fs.RequiresExactMatch("metadata.name"); found {
-		name = value
-	} else if !fs.Empty() {
-		return "", fmt.Errorf("field label not supported: %s", fs.Requirements()[0].Field)
+
+	selectable := v1alpha1.PackageManifestSelectableFields(&v1alpha1.PackageManifest{})
+	for _, requirement := range fs.Requirements() {
+		if _, ok := selectable[requirement.Field]; !ok {
+			return "", k8serrors.NewBadRequest(fmt.Sprintf("field label not supported: %s", requirement.Field))
+		}
+		switch requirement.Operator {
+		case selection.Equals, selection.DoubleEquals, selection.NotEquals:
+		default:
+			return "", k8serrors.NewBadRequest(fmt.Sprintf("field selector operator not supported: %s", requirement.Operator))
+		}
+		if requirement.Field == v1alpha1.ProvidedAPIField && strings.Count(requirement.Value, "/") != 2 {
+			return "", k8serrors.NewBadRequest(fmt.Sprintf("%s must be group/version/kind, got %q", v1alpha1.ProvidedAPIField, requirement.Value))
+		}
 	}
+
+	name, _ := fs.RequiresExactMatch("metadata.name")
 	return name, nil
 }
 
-func matches(m v1alpha1.PackageManifest, name, namespace string, ls labels.Selector) bool {
-	if name == "" {
-		name = m.GetName()
+// providedAPIFor returns the API a field selector requires PackageManifests to provide, or "" if it doesn't require one.
+// The selector must already have been validated by nameFor.
+func providedAPIFor(fs fields.Selector) string {
+	if fs == nil {
+		return ""
+	}
+
+	api, _ := fs.RequiresExactMatch(v1alpha1.ProvidedAPIField)
+	return api
+}
+
+// checkResourceVersion returns an error if the provider's snapshot at current can't satisfy a list request for the
+// requested resourceVersion.
+// An empty or "0" requested resourceVersion is satisfied by any snapshot, otherwise the snapshot must not be older
+// than the requested version.
+func checkResourceVersion(requested, current string) error {
+	if requested == "" || requested == "0" {
+		return nil
 	}
+
+	requestedVersion, err := strconv.ParseUint(requested, 10, 64)
+	if err != nil {
+		return k8serrors.NewBadRequest(fmt.Sprintf("invalid resource version %q: %s", requested, err))
+	}
+	currentVersion, err := strconv.ParseUint(current, 10, 64)
+	if err != nil {
+		return k8serrors.NewInternalError(fmt.Errorf("provider returned invalid resource version %q: %s", current, err))
+	}
+
+	if requestedVersion > currentVersion {
+		return k8serrors.NewTimeoutError(fmt.Sprintf("too large resource version: %d, current: %d", requestedVersion, currentVersion), 1)
+	}
+
+	return nil
+}
+
+// inNamespace returns manifest as served in the requested namespace. Providers serve PackageManifests that aren't tied
+// to a namespace, such as those of globally-sourced catalogs, with an empty namespace; they're available in every
+// namespace, so they're served as belonging to the one requested. A request for every namespace has no single namespace
+// to serve them in, so they're left without one.
+func inNamespace(manifest v1alpha1.PackageManifest, namespace string) v1alpha1.PackageManifest {
+	if manifest.GetNamespace() == "" {
+		manifest.SetNamespace(namespace)
+	}
+	return manifest
+}
+
+func matches(m v1alpha1.PackageManifest, namespace string, ls labels.Selector, fs fields.Selector) bool {
 	if namespace == v1.NamespaceAll {
 		namespace = m.GetNamespace()
 	}
-	return ls.Matches(labels.Set(m.GetLabels())) && m.GetName() == name && m.GetNamespace() == namespace
+	if fs == nil {
+		fs = fields.Everything()
+	}
+	if s, ok := ls.(keywordSelector); ok {
+		if !s.hasKeywords(m) {
+			return false
+		}
+		ls = s.Selector
+	}
+	if s, ok := ls.(installModeSelector); ok {
+		if !s.supports(m) {
+			return false
+		}
+		ls = s.Selector
+	}
+	return ls.Matches(labels.Set(m.GetLabels())) && v1alpha1.PackageManifestFieldsMatch(&m, fs) && m.GetNamespace() == namespace
 }
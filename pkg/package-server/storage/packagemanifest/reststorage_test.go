@@ -0,0 +1,1538 @@
+package packagemanifest
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/version"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+
+	operatorsv1alpha1 "github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/queueinformer"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/provider"
+)
+
+func TestListResourceVersion(t *testing.T) {
+	tests := []struct {
+		resourceVersion string
+		expectedErr     func(error) bool
+		description     string
+	}{
+		{
+			resourceVersion: "",
+			description:     "Unset",
+		},
+		{
+			resourceVersion: "0",
+			description:     "Any",
+		},
+		{
+			resourceVersion: "1",
+			description:     "NotOlderThan",
+		},
+		{
+			resourceVersion: "2",
+			description:     "Current",
+		},
+		{
+			resourceVersion: "100",
+			expectedErr:     k8serrors.IsTimeout,
+			description:     "Unknown",
+		},
+		{
+			resourceVersion: "abc",
+			expectedErr:     k8serrors.IsBadRequest,
+			description:     "Invalid",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			prov := provider.NewFakeProvider()
+			prov.Add(packageManifest(packageValue{name: "etcd", namespace: "default"}))
+			prov.Add(packageManifest(packageValue{name: "prometheus", namespace: "default"}))
+			storage := NewStorage(v1alpha1.Resource("packagemanifests"), prov, nil)
+
+			ctx := genericapirequest.WithNamespace(genericapirequest.NewContext(), "default")
+			res, err := storage.List(ctx, &metainternalversion.ListOptions{ResourceVersion: test.resourceVersion})
+			if test.expectedErr != nil {
+				require.True(t, test.expectedErr(err), "unexpected error: %v", err)
+				return
+			}
+
+			require.NoError(t, err)
+			list, ok := res.(*v1alpha1.PackageManifestList)
+			require.True(t, ok)
+			require.Equal(t, "2", list.GetResourceVersion())
+			require.Len(t, list.Items, 2)
+		})
+	}
+}
+
+func manifestWithMinKubeVersions(name string, minKubeVersions ...string) v1alpha1.PackageManifest {
+	manifest := packageManifest(packageValue{name: name, namespace: "default"})
+	for _, minKubeVersion := range minKubeVersions {
+		manifest.Status.Channels = append(manifest.Status.Channels, v1alpha1.PackageChannel{
+			CurrentCSVDesc: v1alpha1.CSVDescription{MinKubeVersion: minKubeVersion},
+		})
+	}
+	return manifest
+}
+
+func TestListCompatibleWithCluster(t *testing.T) {
+	tests := []struct {
+		serverVersion *version.Info
+		selector      string
+		expectedNames []string
+		description   string
+	}{
+		{
+			serverVersion: &version.Info{GitVersion: "v1.10.3+abcdef"},
+			selector:      CompatibleWithClusterLabel + "=true",
+			expectedNames: []string{"any", "older", "same", "mixed"},
+			description:   "Compatible",
+		},
+		{
+			serverVersion: &version.Info{GitVersion: "v1.10.3+abcdef"},
+			selector:      CompatibleWithClusterLabel + "=false",
+			expectedNames: []string{"newer", "invalid"},
+			description:   "Incompatible",
+		},
+		{
+			serverVersion: &version.Info{GitVersion: "v1.12.0"},
+			selector:      CompatibleWithClusterLabel + "=true",
+			expectedNames: []string{"any", "older", "same", "mixed", "newer"},
+			description:   "NewerCluster",
+		},
+		{
+			serverVersion: nil,
+			selector:      CompatibleWithClusterLabel,
+			expectedNames: []string{},
+			description:   "UnknownServerVersion",
+		},
+		{
+			serverVersion: nil,
+			selector:      "",
+			expectedNames: []string{"any", "older", "same", "mixed", "newer", "invalid"},
+			description:   "UnknownServerVersion/NoSelector",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			prov := provider.NewFakeProvider()
+			prov.Add(manifestWithMinKubeVersions("any", ""))
+			prov.Add(manifestWithMinKubeVersions("older", "1.9.0"))
+			prov.Add(manifestWithMinKubeVersions("same", "v1.10"))
+			prov.Add(manifestWithMinKubeVersions("mixed", "1.11.0", "1.8.2"))
+			prov.Add(manifestWithMinKubeVersions("newer", "1.11.0"))
+			prov.Add(manifestWithMinKubeVersions("invalid", "not-a-version"))
+			storage := NewStorage(v1alpha1.Resource("packagemanifests"), prov, test.serverVersion)
+
+			selector, err := labels.Parse(test.selector)
+			require.NoError(t, err)
+
+			ctx := genericapirequest.WithNamespace(genericapirequest.NewContext(), "default")
+			res, err := storage.List(ctx, &metainternalversion.ListOptions{LabelSelector: selector})
+			require.NoError(t, err)
+
+			names := []string{}
+			for _, manifest := range res.(*v1alpha1.PackageManifestList).Items {
+				names = append(names, manifest.GetName())
+			}
+			require.ElementsMatch(t, test.expectedNames, names)
+		})
+	}
+}
+
+// namedProvider records calls to the optional provider.NamedPackageManifestLister interface
+type namedProvider struct {
+	*provider.FakeProvider
+	listNamedCalls int
+}
+
+func (p *namedProvider) ListNamed(namespace, name string) (*v1alpha1.PackageManifestList, error) {
+	p.listNamedCalls++
+	list, err := p.List(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	named := []v1alpha1.PackageManifest{}
+	for _, manifest := range list.Items {
+		if manifest.GetName() == name {
+			named = append(named, manifest)
+		}
+	}
+	list.Items = named
+
+	return list, nil
+}
+
+func TestListNamed(t *testing.T) {
+	tests := []struct {
+		namespace     string
+		fieldSelector string
+		expectedNames []string
+		expectedCalls int
+		description   string
+	}{
+		{
+			namespace:     "default",
+			fieldSelector: "metadata.name=etcd",
+			expectedNames: []string{"etcd"},
+			expectedCalls: 1,
+			description:   "NamePinned",
+		},
+		{
+			namespace:     "default",
+			fieldSelector: "",
+			expectedNames: []string{"etcd", "prometheus"},
+			expectedCalls: 0,
+			description:   "Unpinned",
+		},
+		{
+			namespace:     "",
+			fieldSelector: "metadata.name=etcd",
+			expectedNames: []string{"etcd", "etcd"},
+			expectedCalls: 0,
+			description:   "AllNamespaces",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			prov := &namedProvider{FakeProvider: provider.NewFakeProvider()}
+			for _, value := range []packageValue{{name: "etcd", namespace: "default"}, {name: "prometheus", namespace: "default"}, {name: "etcd", namespace: "local"}} {
+				manifest := packageManifest(value)
+				manifest.Status.CatalogSourceNamespace = value.namespace
+				prov.Add(manifest)
+			}
+			storage := NewStorage(v1alpha1.Resource("packagemanifests"), prov, nil)
+
+			selector, err := fields.ParseSelector(test.fieldSelector)
+			require.NoError(t, err)
+
+			ctx := genericapirequest.WithNamespace(genericapirequest.NewContext(), test.namespace)
+			res, err := storage.List(ctx, &metainternalversion.ListOptions{FieldSelector: selector})
+			require.NoError(t, err)
+
+			names := []string{}
+			for _, manifest := range res.(*v1alpha1.PackageManifestList).Items {
+				names = append(names, manifest.GetName())
+			}
+			require.ElementsMatch(t, test.expectedNames, names)
+			require.Equal(t, test.expectedCalls, prov.listNamedCalls)
+		})
+	}
+}
+
+func TestListFieldSelector(t *testing.T) {
+	tests := []struct {
+		fieldSelector string
+		expectedNames []string
+		description   string
+	}{
+		{
+			fieldSelector: "status.catalogSource=ocs",
+			expectedNames: []string{"etcd", "prometheus"},
+			description:   "CatalogSource",
+		},
+		{
+			fieldSelector: "status.catalogSource!=ocs",
+			expectedNames: []string{"vault"},
+			description:   "NotCatalogSource",
+		},
+		{
+			fieldSelector: "metadata.name=etcd,status.catalogSource=ocs",
+			expectedNames: []string{"etcd"},
+			description:   "NameAndCatalogSource",
+		},
+		{
+			fieldSelector: "metadata.name=vault,status.catalogSource=ocs",
+			expectedNames: []string{},
+			description:   "NoMatch",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			prov := provider.NewFakeProvider()
+			for catalog, names := range map[string][]string{"ocs": {"etcd", "prometheus"}, "community": {"vault"}} {
+				for _, name := range names {
+					manifest := packageManifest(packageValue{name: name, namespace: "default"})
+					manifest.Status.CatalogSourceName = catalog
+					prov.Add(manifest)
+				}
+			}
+			storage := NewStorage(v1alpha1.Resource("packagemanifests"), prov, nil)
+
+			selector, err := fields.ParseSelector(test.fieldSelector)
+			require.NoError(t, err)
+
+			ctx := genericapirequest.WithNamespace(genericapirequest.NewContext(), "default")
+			res, err := storage.List(ctx, &metainternalversion.ListOptions{FieldSelector: selector})
+			require.NoError(t, err)
+
+			names := []string{}
+			for _, manifest := range res.(*v1alpha1.PackageManifestList).Items {
+				names = append(names, manifest.GetName())
+			}
+			require.ElementsMatch(t, test.expectedNames, names)
+		})
+	}
+}
+
+func TestListDisplayName(t *testing.T) {
+	tests := []struct {
+		fieldSelector string
+		expectedNames []string
+		description   string
+	}{
+		{
+			fieldSelector: "status.displayName=etcd",
+			expectedNames: []string{"etcd"},
+			description:   "Exact",
+		},
+		{
+			fieldSelector: "status.displayName=ETCD",
+			expectedNames: []string{},
+			description:   "ExactIsCaseSensitive",
+		},
+		{
+			fieldSelector: "status.displayName!=etcd",
+			expectedNames: []string{"prometheus", "prometheus-community", "vault"},
+			description:   "NotExact",
+		},
+		{
+			fieldSelector: "status.displayNameContains=PROMETHEUS",
+			expectedNames: []string{"prometheus", "prometheus-community"},
+			description:   "ContainsIgnoresCase",
+		},
+		{
+			fieldSelector: "status.displayNameContains=operator",
+			expectedNames: []string{"prometheus"},
+			description:   "ContainsSubstring",
+		},
+		{
+			fieldSelector: "status.displayNameContains!=prometheus",
+			expectedNames: []string{"etcd", "vault"},
+			description:   "NotContains",
+		},
+		{
+			fieldSelector: "status.displayNameContains=prometheus,metadata.name=prometheus-community",
+			expectedNames: []string{"prometheus-community"},
+			description:   "ContainsAndName",
+		},
+		{
+			fieldSelector: "status.displayNameContains=",
+			expectedNames: []string{"etcd", "prometheus", "prometheus-community", "vault"},
+			description:   "ContainsEmpty",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			prov := provider.NewFakeProvider()
+			for name, displayName := range map[string]string{"etcd": "etcd", "prometheus": "Prometheus Operator", "prometheus-community": "prometheus", "vault": ""} {
+				manifest := packageManifest(packageValue{name: name, namespace: "default"})
+				manifest.Status.DefaultChannelName = "stable"
+				manifest.Status.Channels = []v1alpha1.PackageChannel{
+					{Name: "alpha", CurrentCSVDesc: v1alpha1.CSVDescription{DisplayName: "alpha " + name}},
+					{Name: "stable", CurrentCSVDesc: v1alpha1.CSVDescription{DisplayName: displayName}},
+				}
+				prov.Add(manifest)
+			}
+			storage := NewStorage(v1alpha1.Resource("packagemanifests"), prov, nil)
+
+			selector, err := fields.ParseSelector(test.fieldSelector)
+			require.NoError(t, err)
+
+			ctx := genericapirequest.WithNamespace(genericapirequest.NewContext(), "default")
+			res, err := storage.List(ctx, &metainternalversion.ListOptions{FieldSelector: selector})
+			require.NoError(t, err)
+
+			names := []string{}
+			for _, manifest := range res.(*v1alpha1.PackageManifestList).Items {
+				names = append(names, manifest.GetName())
+			}
+			require.ElementsMatch(t, test.expectedNames, names)
+		})
+	}
+}
+
+func TestListProvidedAPI(t *testing.T) {
+	tests := []struct {
+		fieldSelector string
+		expectedNames []string
+		description   string
+	}{
+		{
+			fieldSelector: "status.providedAPI=etcd.database.coreos.com/v1beta2/EtcdCluster",
+			expectedNames: []string{"etcd", "etcd-community"},
+			description:   "CRD",
+		},
+		{
+			fieldSelector: "status.providedAPI=etcd.database.coreos.com/v1beta2/EtcdBackup",
+			expectedNames: []string{"etcd"},
+			description:   "NonDefaultChannel",
+		},
+		{
+			fieldSelector: "status.providedAPI=packages.apps.redhat.com/v1alpha1/PackageManifest",
+			expectedNames: []string{"packageserver"},
+			description:   "APIService",
+		},
+		{
+			fieldSelector: "status.providedAPI=etcd.database.coreos.com/v1beta1/EtcdCluster",
+			expectedNames: []string{},
+			description:   "OtherVersion",
+		},
+		{
+			fieldSelector: "status.providedAPI!=etcd.database.coreos.com/v1beta2/EtcdCluster",
+			expectedNames: []string{"packageserver", "vault"},
+			description:   "NotProvided",
+		},
+		{
+			fieldSelector: "status.providedAPI=etcd.database.coreos.com/v1beta2/EtcdCluster,status.providedAPI=etcd.database.coreos.com/v1beta2/EtcdBackup",
+			expectedNames: []string{"etcd"},
+			description:   "Both",
+		},
+		{
+			fieldSelector: "status.providedAPI=etcd.database.coreos.com/v1beta2/EtcdCluster,metadata.name=etcd-community",
+			expectedNames: []string{"etcd-community"},
+			description:   "APIAndName",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			packageserver := operatorsv1alpha1.ClusterServiceVersion{}
+			packageserver.Spec.APIServiceDefinitions.Owned = []operatorsv1alpha1.APIServiceDescription{{Name: "packages.apps.redhat.com", Version: "v1alpha1", Kind: "PackageManifest"}}
+
+			prov := provider.NewFakeProvider()
+			for name, csvs := range map[string][]operatorsv1alpha1.ClusterServiceVersion{
+				"etcd": {
+					csvOwning("etcdclusters.etcd.database.coreos.com", "v1beta2", "EtcdCluster"),
+					csvOwning("etcdbackups.etcd.database.coreos.com", "v1beta2", "EtcdBackup"),
+				},
+				"etcd-community": {csvOwning("etcdclusters.etcd.database.coreos.com", "v1beta2", "EtcdCluster")},
+				"vault":          {csvOwning("vaultservices.vault.security.coreos.com", "v1alpha1", "VaultService")},
+				"packageserver":  {packageserver},
+			} {
+				manifest := packageManifest(packageValue{name: name, namespace: "default"})
+				manifest.Status.DefaultChannelName = "stable"
+				// the first CSV is the current CSV of the default channel, and any others of other channels
+				for i, csv := range csvs {
+					channel := "stable"
+					if i > 0 {
+						channel = fmt.Sprintf("alpha-%d", i)
+					}
+					manifest.Status.Channels = append(manifest.Status.Channels, v1alpha1.PackageChannel{Name: channel, CurrentCSVDesc: v1alpha1.CreateCSVDescription(&csv)})
+				}
+				prov.Add(manifest)
+			}
+			storage := NewStorage(v1alpha1.Resource("packagemanifests"), prov, nil)
+
+			selector, err := fields.ParseSelector(test.fieldSelector)
+			require.NoError(t, err)
+
+			ctx := genericapirequest.WithNamespace(genericapirequest.NewContext(), "default")
+			res, err := storage.List(ctx, &metainternalversion.ListOptions{FieldSelector: selector})
+			require.NoError(t, err)
+
+			names := []string{}
+			for _, manifest := range res.(*v1alpha1.PackageManifestList).Items {
+				names = append(names, manifest.GetName())
+			}
+			require.ElementsMatch(t, test.expectedNames, names)
+		})
+	}
+}
+
+func csvOwning(crdName, version, kind string) operatorsv1alpha1.ClusterServiceVersion {
+	csv := operatorsv1alpha1.ClusterServiceVersion{}
+	csv.Spec.CustomResourceDefinitions.Owned = []operatorsv1alpha1.CRDDescription{{Name: crdName, Version: version, Kind: kind}}
+	return csv
+}
+
+func TestListUnsupportedFieldSelector(t *testing.T) {
+	tests := []struct {
+		fieldSelector string
+		description   string
+	}{
+		{
+			fieldSelector: "spec.name=etcd",
+			description:   "UnsupportedField",
+		},
+		{
+			fieldSelector: "metadata.name=etcd,status.defaultChannel=alpha",
+			description:   "SupportedAndUnsupportedFields",
+		},
+		{
+			fieldSelector: "status.providedAPI=EtcdCluster",
+			description:   "MalformedProvidedAPI",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			prov := provider.NewFakeProvider()
+			prov.Add(packageManifest(packageValue{name: "etcd", namespace: "default"}))
+			storage := NewStorage(v1alpha1.Resource("packagemanifests"), prov, nil)
+
+			selector, err := fields.ParseSelector(test.fieldSelector)
+			require.NoError(t, err)
+
+			ctx := genericapirequest.WithNamespace(genericapirequest.NewContext(), "default")
+			_, err = storage.List(ctx, &metainternalversion.ListOptions{FieldSelector: selector})
+			require.True(t, k8serrors.IsBadRequest(err), "unexpected error: %v", err)
+
+			_, err = storage.Watch(ctx, &metainternalversion.ListOptions{FieldSelector: selector})
+			require.True(t, k8serrors.IsBadRequest(err), "unexpected error: %v", err)
+		})
+	}
+}
+
+func FuzzNameFor(f *testing.F) {
+	for _, seed := range []string{
+		"",
+		"metadata.name=etcd",
+		"metadata.name==etcd,metadata.namespace=default",
+		"metadata.name!=etcd",
+		"status.catalogSource=ocs,metadata.name=etcd",
+		"spec.name=etcd",
+		"metadata.name",
+		"=",
+		",,",
+		"metadata.name=a\\,b",
+		"metadata.name=",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, selector string) {
+		fs, err := fields.ParseSelector(selector)
+		if err != nil {
+			return
+		}
+
+		name, err := nameFor(fs)
+		if err != nil {
+			if !k8serrors.IsBadRequest(err) {
+				t.Fatalf("selector %q: expected a BadRequest error, got %v", selector, err)
+			}
+			if name != "" {
+				t.Fatalf("selector %q: expected no name with error %v, got %q", selector, err, name)
+			}
+			return
+		}
+
+		if name == "" {
+			return
+		}
+		if required, ok := fs.RequiresExactMatch("metadata.name"); !ok || required != name {
+			t.Fatalf("selector %q: returned name %q isn't required by the selector", selector, name)
+		}
+	})
+}
+
+func TestListExactLabels(t *testing.T) {
+	tests := []struct {
+		labelSelector string
+		expectedNames []string
+		description   string
+	}{
+		{
+			labelSelector: "provider=acme",
+			expectedNames: []string{"etcd", "prometheus"},
+			description:   "Subset",
+		},
+		{
+			labelSelector: ExactLabelsKey + ",provider=acme",
+			expectedNames: []string{"etcd"},
+			description:   "Exact",
+		},
+		{
+			labelSelector: ExactLabelsKey + ",provider in (acme),tier==stable",
+			expectedNames: []string{"prometheus"},
+			description:   "ExactMultipleLabels",
+		},
+		{
+			labelSelector: ExactLabelsKey,
+			expectedNames: []string{"memcached"},
+			description:   "ExactNoLabels",
+		},
+		{
+			labelSelector: ExactLabelsKey + ",tier=beta",
+			expectedNames: []string{},
+			description:   "ExactNoMatch",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			prov := provider.NewFakeProvider()
+			for name, manifestLabels := range map[string]map[string]string{
+				"etcd":       {"provider": "acme"},
+				"prometheus": {"provider": "acme", "tier": "stable"},
+				"vault":      {"tier": "stable"},
+				"memcached":  nil,
+			} {
+				manifest := packageManifest(packageValue{name: name, namespace: "default"})
+				manifest.SetLabels(manifestLabels)
+				prov.Add(manifest)
+			}
+			storage := NewStorage(v1alpha1.Resource("packagemanifests"), prov, nil)
+
+			selector, err := labels.Parse(test.labelSelector)
+			require.NoError(t, err)
+
+			ctx := genericapirequest.WithNamespace(genericapirequest.NewContext(), "default")
+			res, err := storage.List(ctx, &metainternalversion.ListOptions{LabelSelector: selector})
+			require.NoError(t, err)
+
+			names := []string{}
+			for _, manifest := range res.(*v1alpha1.PackageManifestList).Items {
+				names = append(names, manifest.GetName())
+			}
+			require.ElementsMatch(t, test.expectedNames, names)
+		})
+	}
+}
+
+func TestListInvalidExactLabels(t *testing.T) {
+	for _, labelSelector := range []string{
+		ExactLabelsKey + ",provider!=acme",
+		ExactLabelsKey + ",tier in (beta,stable)",
+		ExactLabelsKey + ",!tier",
+		ExactLabelsKey + "=true,provider=acme",
+		CollapsePackagesKey + "=true",
+	} {
+		t.Run(labelSelector, func(t *testing.T) {
+			storage := NewStorage(v1alpha1.Resource("packagemanifests"), provider.NewFakeProvider(), nil)
+
+			selector, err := labels.Parse(labelSelector)
+			require.NoError(t, err)
+
+			ctx := genericapirequest.WithNamespace(genericapirequest.NewContext(), "default")
+			_, err = storage.List(ctx, &metainternalversion.ListOptions{LabelSelector: selector})
+			require.True(t, k8serrors.IsBadRequest(err), "expected BadRequest, got %v", err)
+
+			_, err = storage.Watch(ctx, &metainternalversion.ListOptions{LabelSelector: selector})
+			require.True(t, k8serrors.IsBadRequest(err), "expected BadRequest, got %v", err)
+		})
+	}
+}
+
+func TestListInstallMode(t *testing.T) {
+	tests := []struct {
+		labelSelector string
+		expectedNames []string
+		description   string
+	}{
+		{
+			labelSelector: "olm.installMode=OwnNamespace",
+			expectedNames: []string{"global", "scoped", "split"},
+			description:   "OwnNamespace",
+		},
+		{
+			labelSelector: "olm.installMode=SingleNamespace",
+			expectedNames: []string{"scoped"},
+			description:   "SingleNamespace",
+		},
+		{
+			labelSelector: "olm.installMode=MultiNamespace",
+			expectedNames: []string{"scoped"},
+			description:   "MultiNamespace",
+		},
+		{
+			labelSelector: "olm.installMode==AllNamespaces",
+			expectedNames: []string{"global", "split"},
+			description:   "AllNamespaces",
+		},
+		{
+			labelSelector: "olm.installMode in (MultiNamespace,AllNamespaces)",
+			expectedNames: []string{"global", "scoped", "split"},
+			description:   "EitherMode",
+		},
+		{
+			labelSelector: "olm.installMode=OwnNamespace,olm.installMode=AllNamespaces",
+			expectedNames: []string{"global", "split"},
+			description:   "BothModes",
+		},
+		{
+			labelSelector: "olm.installMode=OwnNamespace,provider=acme",
+			expectedNames: []string{"scoped"},
+			description:   "ModeAndLabels",
+		},
+	}
+
+	modes := func(supported map[operatorsv1alpha1.InstallModeType]bool) operatorsv1alpha1.ClusterServiceVersion {
+		csv := operatorsv1alpha1.ClusterServiceVersion{}
+		for _, mode := range operatorsv1alpha1.InstallModeTypes {
+			if s, ok := supported[mode]; ok {
+				csv.Spec.InstallModes = append(csv.Spec.InstallModes, operatorsv1alpha1.InstallMode{Type: mode, Supported: s})
+			}
+		}
+		return csv
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			prov := provider.NewFakeProvider()
+			manifests := []v1alpha1.PackageManifest{}
+			for name, csvs := range map[string][]operatorsv1alpha1.ClusterServiceVersion{
+				"global": {modes(map[operatorsv1alpha1.InstallModeType]bool{
+					operatorsv1alpha1.InstallModeTypeOwnNamespace:    true,
+					operatorsv1alpha1.InstallModeTypeSingleNamespace: false,
+					operatorsv1alpha1.InstallModeTypeAllNamespaces:   true,
+				})},
+				"scoped": {modes(map[operatorsv1alpha1.InstallModeType]bool{
+					operatorsv1alpha1.InstallModeTypeOwnNamespace:    true,
+					operatorsv1alpha1.InstallModeTypeSingleNamespace: true,
+					operatorsv1alpha1.InstallModeTypeMultiNamespace:  true,
+					operatorsv1alpha1.InstallModeTypeAllNamespaces:   false,
+				})},
+				// only the current CSV of a channel other than the default one supports AllNamespaces
+				"split": {
+					modes(map[operatorsv1alpha1.InstallModeType]bool{operatorsv1alpha1.InstallModeTypeOwnNamespace: true}),
+					modes(map[operatorsv1alpha1.InstallModeType]bool{operatorsv1alpha1.InstallModeTypeAllNamespaces: true}),
+				},
+				"legacy": {{}},
+			} {
+				manifest := packageManifest(packageValue{name: name, namespace: "default"})
+				if name == "scoped" {
+					manifest.SetLabels(map[string]string{"provider": "acme"})
+				}
+				manifest.Status.DefaultChannelName = "stable"
+				for i, csv := range csvs {
+					channel := "stable"
+					if i > 0 {
+						channel = fmt.Sprintf("alpha-%d", i)
+					}
+					manifest.Status.Channels = append(manifest.Status.Channels, v1alpha1.PackageChannel{Name: channel, CurrentCSVDesc: v1alpha1.CreateCSVDescription(&csv)})
+				}
+				prov.Add(manifest)
+				manifests = append(manifests, manifest)
+			}
+			storage := NewStorage(v1alpha1.Resource("packagemanifests"), prov, nil)
+
+			selector, err := labels.Parse(test.labelSelector)
+			require.NoError(t, err)
+
+			ctx := genericapirequest.WithNamespace(genericapirequest.NewContext(), "default")
+			res, err := storage.List(ctx, &metainternalversion.ListOptions{LabelSelector: selector})
+			require.NoError(t, err)
+
+			names := []string{}
+			for _, manifest := range res.(*v1alpha1.PackageManifestList).Items {
+				names = append(names, manifest.GetName())
+			}
+			require.ElementsMatch(t, test.expectedNames, names)
+
+			// watches filter by install mode too
+			labelSelector, err := labelSelectorFor(selector)
+			require.NoError(t, err)
+			watcher := NewWatcher("default", fields.Everything(), "", labelSelector, prov, nil, len(manifests), WatchOverflowDropOldest)
+			for _, manifest := range manifests {
+				watcher.Add(manifest)
+			}
+			names = []string{}
+			for len(watcher.ResultChan()) > 0 {
+				event := <-watcher.ResultChan()
+				names = append(names, event.Object.(*v1alpha1.PackageManifest).GetName())
+			}
+			require.ElementsMatch(t, test.expectedNames, names)
+		})
+	}
+}
+
+func TestListInvalidInstallMode(t *testing.T) {
+	for _, labelSelector := range []string{
+		InstallModeKey + "=EveryNamespace",
+		InstallModeKey + " in (OwnNamespace,EveryNamespace)",
+		InstallModeKey + "!=AllNamespaces",
+		InstallModeKey,
+	} {
+		t.Run(labelSelector, func(t *testing.T) {
+			storage := NewStorage(v1alpha1.Resource("packagemanifests"), provider.NewFakeProvider(), nil)
+
+			selector, err := labels.Parse(labelSelector)
+			require.NoError(t, err)
+
+			ctx := genericapirequest.WithNamespace(genericapirequest.NewContext(), "default")
+			_, err = storage.List(ctx, &metainternalversion.ListOptions{LabelSelector: selector})
+			require.True(t, k8serrors.IsBadRequest(err), "expected BadRequest, got %v", err)
+
+			_, err = storage.Watch(ctx, &metainternalversion.ListOptions{LabelSelector: selector})
+			require.True(t, k8serrors.IsBadRequest(err), "expected BadRequest, got %v", err)
+		})
+	}
+}
+
+func TestListKeywords(t *testing.T) {
+	tests := []struct {
+		labelSelector string
+		expectedNames []string
+		description   string
+	}{
+		{
+			labelSelector: "olm.keywords=database",
+			expectedNames: []string{"etcd", "postgres"},
+			description:   "Single",
+		},
+		{
+			labelSelector: "olm.keywords=Database",
+			expectedNames: []string{"etcd", "postgres"},
+			description:   "IgnoresCase",
+		},
+		{
+			labelSelector: "olm.keywords in (storage,monitoring)",
+			expectedNames: []string{"etcd", "prometheus", "rook"},
+			description:   "Any",
+		},
+		{
+			labelSelector: "olm.matchAllKeywords,olm.keywords in (database,storage)",
+			expectedNames: []string{"etcd"},
+			description:   "All",
+		},
+		{
+			labelSelector: "olm.keywords=database,olm.keywords=storage",
+			expectedNames: []string{"etcd"},
+			description:   "AllByRequirements",
+		},
+		{
+			labelSelector: "olm.matchAllKeywords,olm.keywords in (database,sql,storage)",
+			expectedNames: []string{},
+			description:   "AllNoneMatch",
+		},
+		{
+			labelSelector: "olm.keywords=database,provider=acme",
+			expectedNames: []string{"postgres"},
+			description:   "KeywordsAndLabels",
+		},
+		{
+			labelSelector: "olm.keywords=storage,olm.installMode=AllNamespaces",
+			expectedNames: []string{"rook"},
+			description:   "KeywordsAndInstallMode",
+		},
+	}
+
+	keywords := func(keywords ...string) operatorsv1alpha1.ClusterServiceVersion {
+		csv := operatorsv1alpha1.ClusterServiceVersion{}
+		csv.Spec.Keywords = keywords
+		return csv
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			prov := provider.NewFakeProvider()
+			manifests := []v1alpha1.PackageManifest{}
+			for name, csvs := range map[string][]operatorsv1alpha1.ClusterServiceVersion{
+				"etcd":       {keywords("Database", "storage")},
+				"postgres":   {keywords("database", "sql")},
+				"prometheus": {keywords("monitoring")},
+				// only the current CSV of a channel other than the default one has the storage keyword
+				"rook":  {keywords(), keywords("storage")},
+				"vault": {{}},
+			} {
+				manifest := packageManifest(packageValue{name: name, namespace: "default"})
+				if name == "postgres" {
+					manifest.SetLabels(map[string]string{"provider": "acme"})
+				}
+				manifest.Status.DefaultChannelName = "stable"
+				for i, csv := range csvs {
+					channel := "stable"
+					if i > 0 {
+						channel = fmt.Sprintf("alpha-%d", i)
+						csv.Spec.InstallModes = []operatorsv1alpha1.InstallMode{{Type: operatorsv1alpha1.InstallModeTypeAllNamespaces, Supported: true}}
+					}
+					manifest.Status.Channels = append(manifest.Status.Channels, v1alpha1.PackageChannel{Name: channel, CurrentCSVDesc: v1alpha1.CreateCSVDescription(&csv)})
+				}
+				prov.Add(manifest)
+				manifests = append(manifests, manifest)
+			}
+			storage := NewStorage(v1alpha1.Resource("packagemanifests"), prov, nil)
+
+			selector, err := labels.Parse(test.labelSelector)
+			require.NoError(t, err)
+
+			ctx := genericapirequest.WithNamespace(genericapirequest.NewContext(), "default")
+			res, err := storage.List(ctx, &metainternalversion.ListOptions{LabelSelector: selector})
+			require.NoError(t, err)
+
+			names := []string{}
+			for _, manifest := range res.(*v1alpha1.PackageManifestList).Items {
+				names = append(names, manifest.GetName())
+			}
+			require.ElementsMatch(t, test.expectedNames, names)
+
+			// watches filter by keyword too
+			labelSelector, err := labelSelectorFor(selector)
+			require.NoError(t, err)
+			watcher := NewWatcher("default", fields.Everything(), "", labelSelector, prov, nil, len(manifests), WatchOverflowDropOldest)
+			for _, manifest := range manifests {
+				watcher.Add(manifest)
+			}
+			names = []string{}
+			for len(watcher.ResultChan()) > 0 {
+				event := <-watcher.ResultChan()
+				names = append(names, event.Object.(*v1alpha1.PackageManifest).GetName())
+			}
+			require.ElementsMatch(t, test.expectedNames, names)
+		})
+	}
+}
+
+func TestListInvalidKeywords(t *testing.T) {
+	for _, labelSelector := range []string{
+		KeywordsKey + "!=database",
+		KeywordsKey + " notin (database,storage)",
+		KeywordsKey,
+		MatchAllKeywordsKey + "=true," + KeywordsKey + "=database",
+	} {
+		t.Run(labelSelector, func(t *testing.T) {
+			storage := NewStorage(v1alpha1.Resource("packagemanifests"), provider.NewFakeProvider(), nil)
+
+			selector, err := labels.Parse(labelSelector)
+			require.NoError(t, err)
+
+			ctx := genericapirequest.WithNamespace(genericapirequest.NewContext(), "default")
+			_, err = storage.List(ctx, &metainternalversion.ListOptions{LabelSelector: selector})
+			require.True(t, k8serrors.IsBadRequest(err), "expected BadRequest, got %v", err)
+
+			_, err = storage.Watch(ctx, &metainternalversion.ListOptions{LabelSelector: selector})
+			require.True(t, k8serrors.IsBadRequest(err), "expected BadRequest, got %v", err)
+		})
+	}
+}
+
+// filteringProvider records the filters it's asked to list with, and lists everything regardless
+type filteringProvider struct {
+	*provider.FakeProvider
+	filters []provider.ListFilter
+}
+
+func (f *filteringProvider) ListFiltered(namespace string, filter provider.ListFilter) (*v1alpha1.PackageManifestList, error) {
+	f.filters = append(f.filters, filter)
+	return f.List(namespace)
+}
+
+func TestListPushesDownFilter(t *testing.T) {
+	tests := []struct {
+		fieldSelector  string
+		labelSelector  string
+		expectedName   string
+		expectedLabels string
+		// expectedProvidedAPI is the API the filter is expected to require
+		expectedProvidedAPI string
+		// expectedInstallMode is the install mode the filter is expected to require
+		expectedInstallMode string
+		expectedNames       []string
+		description         string
+	}{
+		{
+			expectedNames: []string{"etcd", "prometheus"},
+			description:   "NoFilter",
+		},
+		{
+			fieldSelector: "metadata.name=etcd",
+			expectedName:  "etcd",
+			expectedNames: []string{"etcd"},
+			description:   "Name",
+		},
+		{
+			labelSelector:  "provider=acme",
+			expectedLabels: "provider=acme",
+			expectedNames:  []string{"etcd"},
+			description:    "Labels",
+		},
+		{
+			fieldSelector:  "metadata.name=prometheus",
+			labelSelector:  "provider=acme",
+			expectedName:   "prometheus",
+			expectedLabels: "provider=acme",
+			expectedNames:  []string{},
+			description:    "NameAndLabels",
+		},
+		{
+			fieldSelector:       "status.providedAPI=etcd.database.coreos.com/v1beta2/EtcdCluster",
+			expectedProvidedAPI: "etcd.database.coreos.com/v1beta2/EtcdCluster",
+			expectedNames:       []string{},
+			description:         "ProvidedAPI",
+		},
+		{
+			labelSelector:       "olm.installMode=AllNamespaces,provider=acme",
+			expectedLabels:      "provider=acme",
+			expectedInstallMode: "AllNamespaces",
+			expectedNames:       []string{},
+			description:         "InstallMode",
+		},
+		{
+			labelSelector: "olm.installMode in (OwnNamespace,AllNamespaces)",
+			expectedNames: []string{},
+			description:   "InstallModesNotPushedDown",
+		},
+		{
+			labelSelector:  "olm.exactLabels,provider=acme",
+			expectedLabels: "provider=acme",
+			expectedNames:  []string{},
+			description:    "ExactLabelsNotPushedDown",
+		},
+		{
+			labelSelector:  "olm.collapsePackages,provider=acme",
+			expectedLabels: "provider=acme",
+			expectedNames:  []string{"etcd"},
+			description:    "CollapsePackagesNotPushedDown",
+		},
+		{
+			labelSelector:  "provider=acme,olm.compatibleWithCluster=false",
+			expectedLabels: "provider=acme",
+			expectedNames:  []string{"etcd"},
+			description:    "StorageLabelsNotPushedDown",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			prov := &filteringProvider{FakeProvider: provider.NewFakeProvider()}
+			etcd := packageManifest(packageValue{name: "etcd", namespace: "default"})
+			etcd.SetLabels(map[string]string{"provider": "acme", "tier": "stable"})
+			prov.Add(etcd)
+			prov.Add(packageManifest(packageValue{name: "prometheus", namespace: "default"}))
+			storage := NewStorage(v1alpha1.Resource("packagemanifests"), prov, &version.Info{GitVersion: "v1.11.0"})
+
+			options := &metainternalversion.ListOptions{}
+			if test.fieldSelector != "" {
+				selector, err := fields.ParseSelector(test.fieldSelector)
+				require.NoError(t, err)
+				options.FieldSelector = selector
+			}
+			if test.labelSelector != "" {
+				selector, err := labels.Parse(test.labelSelector)
+				require.NoError(t, err)
+				options.LabelSelector = selector
+			}
+
+			ctx := genericapirequest.WithNamespace(genericapirequest.NewContext(), "default")
+			res, err := storage.List(ctx, options)
+			require.NoError(t, err)
+
+			require.Len(t, prov.filters, 1)
+			require.Equal(t, test.expectedName, prov.filters[0].Name)
+			require.Equal(t, test.expectedLabels, prov.filters[0].Labels.String())
+			require.Equal(t, test.expectedProvidedAPI, prov.filters[0].ProvidedAPI)
+			require.Equal(t, test.expectedInstallMode, prov.filters[0].InstallMode)
+
+			// the provider ignored the filter, so the storage still has to apply it
+			names := []string{}
+			for _, manifest := range res.(*v1alpha1.PackageManifestList).Items {
+				names = append(names, manifest.GetName())
+			}
+			require.ElementsMatch(t, test.expectedNames, names)
+		})
+	}
+}
+
+// duplicatingProvider lists its manifests along with a fixed set of duplicates
+type duplicatingProvider struct {
+	*provider.FakeProvider
+	duplicates []v1alpha1.PackageManifest
+}
+
+func (d *duplicatingProvider) List(namespace string) (*v1alpha1.PackageManifestList, error) {
+	list, err := d.FakeProvider.List(namespace)
+	if err != nil {
+		return nil, err
+	}
+	list.Items = append(list.Items, d.duplicates...)
+	return list, nil
+}
+
+func TestListCollapsePackages(t *testing.T) {
+	catalogPackageManifest := func(catalog, packageName, defaultChannel string, channels ...string) v1alpha1.PackageManifest {
+		manifest := packageManifest(packageValue{name: packageName, namespace: "default"})
+		manifest.Status = v1alpha1.PackageManifestStatus{
+			CatalogSourceName:      catalog,
+			CatalogSourceNamespace: "default",
+			PackageName:            packageName,
+			DefaultChannelName:     defaultChannel,
+		}
+		for _, channel := range channels {
+			manifest.Status.Channels = append(manifest.Status.Channels, v1alpha1.PackageChannel{Name: channel, CurrentCSVName: packageName + "." + channel})
+		}
+		return manifest
+	}
+
+	tests := []struct {
+		labelSelector    string
+		expectedPackages []string
+		expectedChannels map[string][]string
+		expectedDefaults map[string]string
+		description      string
+	}{
+		{
+			labelSelector:    "",
+			expectedPackages: []string{"a/etcd", "a/prometheus", "b/etcd", "a/etcd", "a/prometheus"},
+			description:      "NotCollapsed",
+		},
+		{
+			labelSelector:    CollapsePackagesKey,
+			expectedPackages: []string{"a/etcd", "a/prometheus", "b/etcd"},
+			expectedChannels: map[string][]string{
+				"a/etcd":       {"alpha", "beta"},
+				"a/prometheus": {"stable", "preview"},
+				"b/etcd":       {"alpha"},
+			},
+			expectedDefaults: map[string]string{
+				"a/etcd":       "beta",
+				"a/prometheus": "stable",
+				"b/etcd":       "alpha",
+			},
+			description: "Collapsed",
+		},
+		{
+			labelSelector:    CollapsePackagesKey + ",provider=acme",
+			expectedPackages: []string{"a/etcd"},
+			expectedChannels: map[string][]string{
+				"a/etcd": {"alpha", "beta"},
+			},
+			expectedDefaults: map[string]string{
+				"a/etcd": "beta",
+			},
+			description: "CollapsedWithLabels",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			prov := &duplicatingProvider{FakeProvider: provider.NewFakeProvider()}
+			etcd := catalogPackageManifest("a", "etcd", "", "alpha")
+			etcd.SetLabels(map[string]string{"provider": "acme"})
+			prov.Add(etcd)
+			prov.Add(catalogPackageManifest("a", "prometheus", "stable", "stable"))
+			prov.Add(catalogPackageManifest("b", "etcd", "alpha", "alpha"))
+
+			// the duplicate etcd adds a channel and the default channel the first entry lacks
+			duplicateEtcd := catalogPackageManifest("a", "etcd", "beta", "alpha", "beta")
+			duplicateEtcd.SetLabels(map[string]string{"provider": "acme"})
+			prov.duplicates = []v1alpha1.PackageManifest{
+				duplicateEtcd,
+				catalogPackageManifest("a", "prometheus", "preview", "preview", "stable"),
+			}
+			storage := NewStorage(v1alpha1.Resource("packagemanifests"), prov, nil)
+
+			options := &metainternalversion.ListOptions{}
+			if test.labelSelector != "" {
+				selector, err := labels.Parse(test.labelSelector)
+				require.NoError(t, err)
+				options.LabelSelector = selector
+			}
+
+			ctx := genericapirequest.WithNamespace(genericapirequest.NewContext(), "default")
+			res, err := storage.List(ctx, options)
+			require.NoError(t, err)
+
+			packages := []string{}
+			for _, manifest := range res.(*v1alpha1.PackageManifestList).Items {
+				key := manifest.Status.CatalogSourceName + "/" + manifest.Status.PackageName
+				packages = append(packages, key)
+				if test.expectedChannels == nil {
+					continue
+				}
+
+				channels := []string{}
+				for _, channel := range manifest.Status.Channels {
+					channels = append(channels, channel.Name)
+				}
+				require.Equal(t, test.expectedChannels[key], channels, key)
+				require.Equal(t, test.expectedDefaults[key], manifest.Status.DefaultChannelName, key)
+			}
+			require.ElementsMatch(t, test.expectedPackages, packages)
+		})
+	}
+}
+
+func TestListEmptyNamespace(t *testing.T) {
+	tests := []struct {
+		namespace          string
+		fieldSelector      string
+		expectedNamespaces map[string]string
+		description        string
+	}{
+		{
+			namespace:          "default",
+			expectedNamespaces: map[string]string{"etcd": "default", "prometheus": "default"},
+			description:        "Namespaced",
+		},
+		{
+			namespace:          "default",
+			fieldSelector:      "metadata.namespace=default",
+			expectedNamespaces: map[string]string{"etcd": "default", "prometheus": "default"},
+			description:        "NamespaceFieldSelector",
+		},
+		{
+			namespace:          "default",
+			fieldSelector:      "metadata.name=prometheus",
+			expectedNamespaces: map[string]string{"prometheus": "default"},
+			description:        "NameFieldSelector",
+		},
+		{
+			namespace:          metav1.NamespaceAll,
+			expectedNamespaces: map[string]string{"etcd": "default", "prometheus": ""},
+			description:        "AllNamespaces",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			// the provider serves prometheus from a globally-sourced catalog, without a namespace
+			prov := &duplicatingProvider{FakeProvider: provider.NewFakeProvider()}
+			prov.Add(packageManifest(packageValue{name: "etcd", namespace: "default"}))
+			prov.duplicates = []v1alpha1.PackageManifest{packageManifest(packageValue{name: "prometheus"})}
+			storage := NewStorage(v1alpha1.Resource("packagemanifests"), prov, nil)
+
+			options := &metainternalversion.ListOptions{}
+			if test.fieldSelector != "" {
+				selector, err := fields.ParseSelector(test.fieldSelector)
+				require.NoError(t, err)
+				options.FieldSelector = selector
+			}
+
+			ctx := genericapirequest.WithNamespace(genericapirequest.NewContext(), test.namespace)
+			res, err := storage.List(ctx, options)
+			require.NoError(t, err)
+
+			namespaces := map[string]string{}
+			for _, manifest := range res.(*v1alpha1.PackageManifestList).Items {
+				namespaces[manifest.GetName()] = manifest.GetNamespace()
+			}
+			require.Equal(t, test.expectedNamespaces, namespaces)
+			require.Empty(t, prov.duplicates[0].GetNamespace(), "provider's manifest was modified")
+		})
+	}
+}
+
+func TestListProviderCounts(t *testing.T) {
+	providerPackageManifest := func(name, providerName string) v1alpha1.PackageManifest {
+		manifest := packageManifest(packageValue{name: name, namespace: "default"})
+		manifest.Status.Provider = v1alpha1.AppLink{Name: providerName}
+		if providerName != "" {
+			manifest.SetLabels(map[string]string{"provider": providerName})
+		}
+		return manifest
+	}
+
+	tests := []struct {
+		labelSelector    string
+		expectedPackages []string
+		expectedCounts   map[string]int
+		description      string
+	}{
+		{
+			labelSelector:    "",
+			expectedPackages: []string{"etcd", "prometheus", "vault", "amq", "local"},
+			expectedCounts:   nil,
+			description:      "NotRequested",
+		},
+		{
+			labelSelector:    SummarizeProvidersKey,
+			expectedPackages: []string{"etcd", "prometheus", "vault", "amq", "local"},
+			expectedCounts:   map[string]int{"Red Hat": 2, "Community": 2, "": 1},
+			description:      "Requested",
+		},
+		{
+			labelSelector:    SummarizeProvidersKey + ",provider=Community",
+			expectedPackages: []string{"vault", "amq"},
+			expectedCounts:   map[string]int{"Community": 2},
+			description:      "RequestedWithLabels",
+		},
+		{
+			labelSelector:    SummarizeProvidersKey + ",provider=Nobody",
+			expectedPackages: []string{},
+			expectedCounts:   map[string]int{},
+			description:      "RequestedNoneListed",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			prov := provider.NewFakeProvider()
+			prov.Add(providerPackageManifest("etcd", "Red Hat"))
+			prov.Add(providerPackageManifest("prometheus", "Red Hat"))
+			prov.Add(providerPackageManifest("vault", "Community"))
+			prov.Add(providerPackageManifest("amq", "Community"))
+			prov.Add(providerPackageManifest("local", ""))
+			storage := NewStorage(v1alpha1.Resource("packagemanifests"), prov, nil)
+
+			options := &metainternalversion.ListOptions{}
+			if test.labelSelector != "" {
+				selector, err := labels.Parse(test.labelSelector)
+				require.NoError(t, err)
+				options.LabelSelector = selector
+			}
+
+			ctx := genericapirequest.WithNamespace(genericapirequest.NewContext(), "default")
+			res, err := storage.List(ctx, options)
+			require.NoError(t, err)
+
+			list := res.(*v1alpha1.PackageManifestList)
+			packages := []string{}
+			for _, manifest := range list.Items {
+				packages = append(packages, manifest.GetName())
+			}
+			require.ElementsMatch(t, test.expectedPackages, packages)
+			require.Equal(t, test.expectedCounts, list.ProviderCounts)
+		})
+	}
+}
+
+func TestListIncludeInvalid(t *testing.T) {
+	invalidPackageManifest := func(name, namespace, reason, message string) v1alpha1.PackageManifest {
+		manifest := packageManifest(packageValue{name: name, namespace: namespace})
+		manifest.Status.Conditions = []v1alpha1.PackageManifestCondition{{
+			Type:    v1alpha1.PackageManifestValid,
+			Status:  corev1.ConditionFalse,
+			Reason:  reason,
+			Message: message,
+		}}
+		return manifest
+	}
+
+	tests := []struct {
+		labelSelector    string
+		expectedPackages []string
+		expectedInvalid  map[string]string
+		description      string
+	}{
+		{
+			labelSelector:    "",
+			expectedPackages: []string{"etcd"},
+			expectedInvalid:  map[string]string{},
+			description:      "NotRequested",
+		},
+		{
+			labelSelector:    IncludeInvalidKey,
+			expectedPackages: []string{"etcd", "vault", "amq"},
+			expectedInvalid: map[string]string{
+				"vault": "default channel beta isn't one of the package's channels",
+				"amq":   "channel alpha references non-existent csv amq.v1.0.0",
+			},
+			description: "Requested",
+		},
+		{
+			labelSelector:    IncludeInvalidKey + ",catalog=ocs",
+			expectedPackages: []string{"vault"},
+			expectedInvalid: map[string]string{
+				"vault": "default channel beta isn't one of the package's channels",
+			},
+			description: "RequestedWithLabels",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			prov := provider.NewFakeProvider()
+			prov.Add(packageManifest(packageValue{name: "etcd", namespace: "default"}))
+			vault := invalidPackageManifest("vault", "default", v1alpha1.PackageManifestReasonDefaultChannelNotFound, "default channel beta isn't one of the package's channels")
+			vault.SetLabels(map[string]string{"catalog": "ocs"})
+			prov.AddInvalid(vault)
+			prov.AddInvalid(invalidPackageManifest("amq", "default", v1alpha1.PackageManifestReasonCSVNotFound, "channel alpha references non-existent csv amq.v1.0.0"))
+			prov.AddInvalid(invalidPackageManifest("prometheus", "other", v1alpha1.PackageManifestReasonCSVNotFound, "channel alpha references non-existent csv prometheus.v1.0.0"))
+			storage := NewStorage(v1alpha1.Resource("packagemanifests"), prov, nil)
+
+			options := &metainternalversion.ListOptions{}
+			if test.labelSelector != "" {
+				selector, err := labels.Parse(test.labelSelector)
+				require.NoError(t, err)
+				options.LabelSelector = selector
+			}
+
+			ctx := genericapirequest.WithNamespace(genericapirequest.NewContext(), "default")
+			res, err := storage.List(ctx, options)
+			require.NoError(t, err)
+
+			packages := []string{}
+			invalid := map[string]string{}
+			for _, manifest := range res.(*v1alpha1.PackageManifestList).Items {
+				packages = append(packages, manifest.GetName())
+				for _, condition := range manifest.Status.Conditions {
+					require.Equal(t, v1alpha1.PackageManifestValid, condition.Type)
+					require.Equal(t, corev1.ConditionFalse, condition.Status)
+					invalid[manifest.GetName()] = condition.Message
+				}
+			}
+			require.ElementsMatch(t, test.expectedPackages, packages)
+			require.Equal(t, test.expectedInvalid, invalid)
+		})
+	}
+}
+
+func TestListIncludeInvalidWithValue(t *testing.T) {
+	storage := NewStorage(v1alpha1.Resource("packagemanifests"), provider.NewFakeProvider(), nil)
+	selector, err := labels.Parse(IncludeInvalidKey + "=true")
+	require.NoError(t, err)
+
+	ctx := genericapirequest.WithNamespace(genericapirequest.NewContext(), "default")
+	_, err = storage.List(ctx, &metainternalversion.ListOptions{LabelSelector: selector})
+	require.True(t, k8serrors.IsBadRequest(err))
+}
+
+func TestGetNotFound(t *testing.T) {
+	providers := map[string]provider.PackageManifestProvider{
+		"Fake":     provider.NewFakeProvider(),
+		"InMemory": provider.NewInMemoryProvider(nil, &queueinformer.Operator{}),
+	}
+
+	for description, prov := range providers {
+		t.Run(description, func(t *testing.T) {
+			storage := NewStorage(v1alpha1.Resource("packagemanifests"), prov, nil)
+			ctx := genericapirequest.WithNamespace(genericapirequest.NewContext(), "default")
+
+			res, err := storage.Get(ctx, "etcd", &metav1.GetOptions{})
+			require.True(t, k8serrors.IsNotFound(err), "unexpected error %v", err)
+			require.Nil(t, res)
+		})
+	}
+}
+
+func TestProviderError(t *testing.T) {
+	prov := provider.NewFakeProvider()
+	prov.Add(packageManifest(packageValue{name: "etcd", namespace: "default"}))
+	storage := NewStorage(v1alpha1.Resource("packagemanifests"), prov, nil)
+	ctx := genericapirequest.WithNamespace(genericapirequest.NewContext(), "default")
+
+	unavailable := errors.New("catalog unavailable")
+	prov.SetError(unavailable)
+
+	res, err := storage.List(ctx, &metainternalversion.ListOptions{})
+	require.True(t, k8serrors.IsInternalError(err))
+	require.Contains(t, err.Error(), unavailable.Error())
+	require.Nil(t, res)
+	_, err = storage.Get(ctx, "etcd", &metav1.GetOptions{})
+	require.True(t, k8serrors.IsInternalError(err))
+	_, err = storage.Watch(ctx, &metainternalversion.ListOptions{})
+	require.True(t, k8serrors.IsInternalError(err))
+
+	// the provider recovers once the error is cleared
+	prov.SetError(nil)
+	res, err = storage.List(ctx, &metainternalversion.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, res.(*v1alpha1.PackageManifestList).Items, 1)
+	manifest, err := storage.Get(ctx, "etcd", &metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "etcd", manifest.(*v1alpha1.PackageManifest).GetName())
+}
+
+func TestProviderErrorClasses(t *testing.T) {
+	tests := []struct {
+		description string
+		err         error
+		reason      metav1.StatusReason
+	}{
+		{
+			description: "Transient",
+			err:         provider.NewTransientError(errors.New("registry timed out")),
+			reason:      metav1.StatusReasonServiceUnavailable,
+		},
+		{
+			description: "Permanent",
+			err:         provider.NewPermanentError(errors.New("catalog can't be parsed")),
+			reason:      metav1.StatusReasonInternalError,
+		},
+		{
+			description: "NotFound",
+			err:         provider.NewNotFoundError(errors.New("catalog source not found")),
+			reason:      metav1.StatusReasonNotFound,
+		},
+		{
+			description: "Unclassified",
+			err:         errors.New("catalog unavailable"),
+			reason:      metav1.StatusReasonInternalError,
+		},
+		{
+			description: "ClassifiedByType",
+			err:         provider.Classify(k8serrors.NewTimeoutError("registry timed out", 1)),
+			reason:      metav1.StatusReasonServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			prov := provider.NewFakeProvider()
+			storage := NewStorage(v1alpha1.Resource("packagemanifests"), prov, nil)
+			ctx := genericapirequest.WithNamespace(genericapirequest.NewContext(), "default")
+			prov.SetError(tt.err)
+
+			_, err := storage.List(ctx, &metainternalversion.ListOptions{})
+			require.Equal(t, tt.reason, k8serrors.ReasonForError(err))
+			_, err = storage.Get(ctx, "etcd", &metav1.GetOptions{})
+			require.Equal(t, tt.reason, k8serrors.ReasonForError(err))
+			_, err = storage.Watch(ctx, &metainternalversion.ListOptions{})
+			require.Equal(t, tt.reason, k8serrors.ReasonForError(err))
+		})
+	}
+}
+
+func TestListProviderAPIError(t *testing.T) {
+	prov := provider.NewFakeProvider()
+	storage := NewStorage(v1alpha1.Resource("packagemanifests"), prov, nil)
+	ctx := genericapirequest.WithNamespace(genericapirequest.NewContext(), "default")
+
+	// API errors from the provider, such as a tripped breaker's, keep their type
+	prov.SetError(k8serrors.NewServiceUnavailable("package manifest provider is being probed after repeated failures"))
+	res, err := storage.List(ctx, &metainternalversion.ListOptions{})
+	require.True(t, k8serrors.IsServiceUnavailable(err))
+	require.Nil(t, res)
+}
+
+func TestGetIfNoneMatch(t *testing.T) {
+	prov := provider.NewFakeProvider()
+	storage := NewStorage(v1alpha1.Resource("packagemanifests"), prov, nil)
+	ctx := genericapirequest.WithNamespace(genericapirequest.NewContext(), "default")
+
+	manifest := packageManifest(packageValue{name: "etcd", namespace: "default"})
+	manifest.Status.PackageName = "etcd"
+	prov.Add(manifest)
+
+	get := func(resourceVersion string) *v1alpha1.PackageManifest {
+		res, err := storage.Get(ctx, "etcd", &metav1.GetOptions{ResourceVersion: resourceVersion})
+		require.NoError(t, err)
+		return res.(*v1alpha1.PackageManifest)
+	}
+	requireNotModified := func(pm *v1alpha1.PackageManifest, resourceVersion string) {
+		require.Equal(t, "true", pm.GetAnnotations()[NotModifiedAnnotationKey])
+		require.Equal(t, resourceVersion, pm.GetResourceVersion())
+		require.Equal(t, "etcd", pm.GetName())
+		require.Empty(t, pm.Status.PackageName)
+	}
+	requireModified := func(pm *v1alpha1.PackageManifest, resourceVersion string) {
+		require.NotContains(t, pm.GetAnnotations(), NotModifiedAnnotationKey)
+		require.Equal(t, resourceVersion, pm.GetResourceVersion())
+		require.Equal(t, "etcd", pm.Status.PackageName)
+	}
+
+	// an unconditional Get returns the whole PackageManifest, whatever its resourceVersion
+	first := get("")
+	requireModified(first, "1")
+	requireModified(get("1"), "1")
+
+	// polling with the resourceVersion last seen returns only metadata while the PackageManifest is unchanged
+	requireNotModified(get(IfNoneMatchPrefix+first.GetResourceVersion()), "1")
+	requireModified(get(IfNoneMatchPrefix+"0"), "1")
+
+	// once it changes, the same poll returns the whole PackageManifest again
+	prov.Modify(manifest)
+	second := get(IfNoneMatchPrefix + first.GetResourceVersion())
+	requireModified(second, "2")
+	requireNotModified(get(IfNoneMatchPrefix+second.GetResourceVersion()), "2")
+}
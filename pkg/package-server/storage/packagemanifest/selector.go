@@ -0,0 +1,333 @@
+package packagemanifest
+
+import (
+	"fmt"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+
+	operatorsv1alpha1 "github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1"
+)
+
+// ExactLabelsKey is a reserved label selector key that switches label matching from subset matching to exact
+// matching. A selector such as "olm.exactLabels,provider=acme,tier=stable" only matches PackageManifests whose labels
+// are exactly provider=acme and tier=stable.
+const ExactLabelsKey = "olm.exactLabels"
+
+// CollapsePackagesKey is a reserved label selector key that makes List return a single PackageManifest for each
+// package in each CatalogSource, merging the channels of any duplicate entries. It has no effect on watches.
+const CollapsePackagesKey = "olm.collapsePackages"
+
+// SummarizeProvidersKey is a reserved label selector key that makes List set the returned list's ProviderCounts to the
+// number of listed PackageManifests from each provider. The listed PackageManifests are unchanged, and it has no effect
+// on watches.
+const SummarizeProvidersKey = "olm.summarizeProviders"
+
+// IncludeInvalidKey is a reserved label selector key that makes List also return the packages providers dropped for
+// failing validation, such as those whose default channel isn't one of their channels, each with a False
+// v1alpha1.PackageManifestValid condition describing why. It's meant for catalog authors debugging their catalogs, and
+// has no effect on Get or watches.
+const IncludeInvalidKey = "olm.includeInvalid"
+
+// InstallModeKey is a reserved label selector key that selects PackageManifests by the install modes they support. A
+// selector such as "olm.installMode=AllNamespaces" only matches PackageManifests with a channel whose current CSV
+// supports the AllNamespaces install mode, and "olm.installMode in (OwnNamespace,SingleNamespace)" those supporting
+// either mode.
+const InstallModeKey = "olm.installMode"
+
+// KeywordsKey is a reserved label selector key that selects PackageManifests by the keywords of their channels'
+// current CSVs, ignoring case. A selector such as "olm.keywords in (database,storage)" only matches PackageManifests
+// with either keyword, and requirements on the key are combined like any others, so "olm.keywords=database,
+// olm.keywords=storage" only matches those with both.
+const KeywordsKey = "olm.keywords"
+
+// MatchAllKeywordsKey is a reserved label selector key that makes each KeywordsKey requirement match only
+// PackageManifests with all of its keywords, rather than any of them. A selector such as "olm.matchAllKeywords,
+// olm.keywords in (database,storage)" only matches PackageManifests with both keywords.
+const MatchAllKeywordsKey = "olm.matchAllKeywords"
+
+// IfNoneMatchPrefix prefixes the resourceVersion of a Get to make it conditional: a Get with the resourceVersion
+// IfNoneMatchPrefix + rv returns a PackageManifest with only its metadata, annotated with NotModifiedAnnotationKey, if
+// the PackageManifest's resourceVersion is still rv. Otherwise, the whole PackageManifest is returned as usual.
+const IfNoneMatchPrefix = "olm.ifNoneMatch="
+
+// NotModifiedAnnotationKey is set to "true" on the PackageManifest returned by a conditional Get whose PackageManifest
+// hasn't changed
+const NotModifiedAnnotationKey = "olm.notModified"
+
+// isListFlag returns true for the reserved label selector keys that change what List returns rather than which
+// PackageManifests match
+func isListFlag(key string) bool {
+	return key == CollapsePackagesKey || key == SummarizeProvidersKey || key == IncludeInvalidKey
+}
+
+// isSelectorKey returns true for the reserved label selector keys that aren't matched against the labels of
+// PackageManifests: the list flags, and the keys that select by install mode or keyword
+func isSelectorKey(key string) bool {
+	return isListFlag(key) || key == InstallModeKey || key == KeywordsKey || key == MatchAllKeywordsKey
+}
+
+// exactLabelSelector matches label sets that are equal to its set
+type exactLabelSelector struct {
+	labels.Selector
+	set labels.Set
+}
+
+// Matches returns true if l is a labels.Set equal to the selector's set.
+// Only complete label sets can be compared exactly, so any other implementation of labels.Labels never matches.
+func (s exactLabelSelector) Matches(l labels.Labels) bool {
+	set, ok := l.(labels.Set)
+	if !ok {
+		return false
+	}
+	return labels.Equals(set, s.set)
+}
+
+// installModeSelector matches PackageManifests that support an install mode of each of its requirements and whose
+// labels match its Selector. Install modes aren't labels, so PackageManifests must be matched by matches rather than
+// Matches.
+type installModeSelector struct {
+	labels.Selector
+	requirements []labels.Requirement
+}
+
+// supports returns true if the manifest supports one of the install modes of each of the selector's requirements
+func (s installModeSelector) supports(manifest v1alpha1.PackageManifest) bool {
+	for _, requirement := range s.requirements {
+		supported := false
+		for _, mode := range requirement.Values().List() {
+			if manifest.SupportsInstallMode(mode) {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			return false
+		}
+	}
+	return true
+}
+
+// keywordSelector matches PackageManifests that have the keywords of each of its requirements and whose labels match
+// its Selector. Like install modes, keywords aren't labels, so PackageManifests must be matched by matches rather than
+// Matches.
+type keywordSelector struct {
+	labels.Selector
+	requirements []labels.Requirement
+	// matchAll requires PackageManifests to have every keyword of each requirement, rather than any of them
+	matchAll bool
+}
+
+// hasKeywords returns true if the manifest has any of the keywords of each of the selector's requirements, or all of
+// them if the selector matches all keywords
+func (s keywordSelector) hasKeywords(manifest v1alpha1.PackageManifest) bool {
+	for _, requirement := range s.requirements {
+		found := 0
+		for _, keyword := range requirement.Values().List() {
+			if manifest.HasKeyword(keyword) {
+				found++
+			}
+		}
+		if found == 0 || s.matchAll && found < requirement.Values().Len() {
+			return false
+		}
+	}
+	return true
+}
+
+// keywordRequirements returns the KeywordsKey requirements of a request's label selector, which must be equality or
+// set inclusion requirements
+func keywordRequirements(ls labels.Selector) ([]labels.Requirement, error) {
+	requirements, _ := ls.Requirements()
+	var keywords []labels.Requirement
+	for _, requirement := range requirements {
+		if requirement.Key() != KeywordsKey {
+			continue
+		}
+		switch requirement.Operator() {
+		case selection.Equals, selection.DoubleEquals, selection.In:
+		default:
+			return nil, k8serrors.NewBadRequest(fmt.Sprintf("label selector key %s only supports equality and set inclusion, got %s", KeywordsKey, requirement.String()))
+		}
+		keywords = append(keywords, requirement)
+	}
+	return keywords, nil
+}
+
+// installModeRequirements returns the InstallModeKey requirements of a request's label selector, which must be equality
+// or set inclusion requirements on known install modes
+func installModeRequirements(ls labels.Selector) ([]labels.Requirement, error) {
+	requirements, _ := ls.Requirements()
+	var modes []labels.Requirement
+	for _, requirement := range requirements {
+		if requirement.Key() != InstallModeKey {
+			continue
+		}
+		switch requirement.Operator() {
+		case selection.Equals, selection.DoubleEquals, selection.In:
+		default:
+			return nil, k8serrors.NewBadRequest(fmt.Sprintf("label selector key %s only supports equality and set inclusion, got %s", InstallModeKey, requirement.String()))
+		}
+		for _, value := range requirement.Values().List() {
+			if !knownInstallMode(value) {
+				return nil, k8serrors.NewBadRequest(fmt.Sprintf("unknown install mode %q", value))
+			}
+		}
+		modes = append(modes, requirement)
+	}
+	return modes, nil
+}
+
+func knownInstallMode(mode string) bool {
+	for _, known := range operatorsv1alpha1.InstallModeTypes {
+		if string(known) == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// installModeFor returns an install mode that every PackageManifest matching a request's label selector supports, or
+// "" if the selector doesn't require a single one
+func installModeFor(ls labels.Selector) string {
+	if ls == nil {
+		return ""
+	}
+	requirements, _ := ls.Requirements()
+	for _, requirement := range requirements {
+		if requirement.Key() == InstallModeKey && requirement.Values().Len() == 1 {
+			return requirement.Values().List()[0]
+		}
+	}
+	return ""
+}
+
+// labelSelectorFor returns the selector to match PackageManifests against for a request's label selector, without the
+// CollapsePackagesKey, SummarizeProvidersKey, IncludeInvalidKey, and MatchAllKeywordsKey requirements.
+// If the selector has the ExactLabelsKey requirement, the returned selector matches only label sets equal to the
+// selector's remaining requirements, which must all be equality requirements.
+// If the selector has InstallModeKey requirements, the returned selector is an installModeSelector, and if it has
+// KeywordsKey requirements, it's a keywordSelector, wrapping the installModeSelector if there's one.
+func labelSelectorFor(ls labels.Selector) (labels.Selector, error) {
+	if ls == nil {
+		return labels.Everything(), nil
+	}
+
+	modes, err := installModeRequirements(ls)
+	if err != nil {
+		return nil, err
+	}
+	keywords, err := keywordRequirements(ls)
+	if err != nil {
+		return nil, err
+	}
+	selector, err := matchingLabelSelector(ls)
+	if err != nil {
+		return nil, err
+	}
+	if len(modes) > 0 {
+		selector = installModeSelector{Selector: selector, requirements: modes}
+	}
+	if len(keywords) > 0 {
+		selector = keywordSelector{Selector: selector, requirements: keywords, matchAll: flagRequested(ls, MatchAllKeywordsKey)}
+	}
+	return selector, nil
+}
+
+// matchingLabelSelector returns the selector to match the labels of PackageManifests against for a request's label
+// selector, as described by labelSelectorFor
+func matchingLabelSelector(ls labels.Selector) (labels.Selector, error) {
+	requirements, _ := ls.Requirements()
+	exact, flagged := false, false
+	for _, requirement := range requirements {
+		switch requirement.Key() {
+		case ExactLabelsKey:
+			exact = true
+		case CollapsePackagesKey, SummarizeProvidersKey, IncludeInvalidKey, MatchAllKeywordsKey:
+			flagged = true
+		case InstallModeKey, KeywordsKey:
+			flagged = true
+			continue
+		default:
+			continue
+		}
+		if requirement.Operator() != selection.Exists {
+			return nil, k8serrors.NewBadRequest(fmt.Sprintf("label selector key %s doesn't take a value", requirement.Key()))
+		}
+	}
+	if !exact && !flagged {
+		return ls, nil
+	}
+	if !exact {
+		// PackageManifests don't carry the flag keys, install modes, or keywords, so they mustn't be matched against
+		// their labels
+		matching := labels.NewSelector()
+		for _, requirement := range requirements {
+			if !isSelectorKey(requirement.Key()) {
+				matching = matching.Add(requirement)
+			}
+		}
+		return matching, nil
+	}
+
+	set := labels.Set{}
+	for _, requirement := range requirements {
+		switch requirement.Key() {
+		case ExactLabelsKey, CollapsePackagesKey, SummarizeProvidersKey, IncludeInvalidKey, InstallModeKey, KeywordsKey, MatchAllKeywordsKey:
+			continue
+		}
+
+		values := requirement.Values().List()
+		switch requirement.Operator() {
+		case selection.Equals, selection.DoubleEquals, selection.In:
+			if len(values) == 1 {
+				set[requirement.Key()] = values[0]
+				continue
+			}
+		}
+		return nil, k8serrors.NewBadRequest(fmt.Sprintf("exact label matching only supports equality requirements, got %s", requirement.String()))
+	}
+
+	return exactLabelSelector{Selector: ls, set: set}, nil
+}
+
+// pushdownLabelSelector returns the part of a request's label selector that providers can filter on: everything but
+// the reserved ExactLabelsKey, CollapsePackagesKey, SummarizeProvidersKey, IncludeInvalidKey, InstallModeKey,
+// KeywordsKey, and MatchAllKeywordsKey, and the labels set by storage, such as CompatibleWithClusterLabel. Install
+// modes are pushed down separately, by installModeFor.
+// Selectors that can't be pushed down select everything, since the storage filters the provider's results again.
+func pushdownLabelSelector(ls labels.Selector) labels.Selector {
+	if ls == nil {
+		return labels.Everything()
+	}
+	requirements, selectable := ls.Requirements()
+	if !selectable {
+		return labels.Everything()
+	}
+
+	pushdown := labels.NewSelector()
+	for _, requirement := range requirements {
+		switch requirement.Key() {
+		case ExactLabelsKey, CollapsePackagesKey, SummarizeProvidersKey, IncludeInvalidKey, InstallModeKey, KeywordsKey, MatchAllKeywordsKey, CompatibleWithClusterLabel:
+			continue
+		}
+		pushdown = pushdown.Add(requirement)
+	}
+	return pushdown
+}
+
+// flagRequested reports whether a request's label selector has a requirement with the given reserved key
+func flagRequested(ls labels.Selector, key string) bool {
+	if ls == nil {
+		return false
+	}
+	requirements, _ := ls.Requirements()
+	for _, requirement := range requirements {
+		if requirement.Key() == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package packagemanifest
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/registry/rest"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1"
+)
+
+// StatusStorage serves the read-only packagemanifests/status subresource, which returns a PackageManifest's metadata
+// and status without its spec
+type StatusStorage struct {
+	manifests *PackageManifestStorage
+}
+
+var _ rest.Storage = &StatusStorage{}
+var _ rest.Getter = &StatusStorage{}
+
+// NewStatusStorage returns storage for the status subresource of the PackageManifests served by the given storage
+func NewStatusStorage(manifests *PackageManifestStorage) *StatusStorage {
+	return &StatusStorage{manifests: manifests}
+}
+
+// Storage interface
+func (s *StatusStorage) New() runtime.Object {
+	return &v1alpha1.PackageManifest{}
+}
+
+// Getter interface
+func (s *StatusStorage) Get(ctx context.Context, name string, opts *metav1.GetOptions) (runtime.Object, error) {
+	obj, err := s.manifests.Get(ctx, name, opts)
+	if err != nil {
+		return nil, err
+	}
+	manifest := obj.(*v1alpha1.PackageManifest)
+
+	// like core resources, the object's metadata is kept so that clients can tell which PackageManifest it belongs to
+	return &v1alpha1.PackageManifest{
+		TypeMeta:   manifest.TypeMeta,
+		ObjectMeta: manifest.ObjectMeta,
+		Status:     manifest.Status,
+	}, nil
+}
@@ -0,0 +1,65 @@
+package packagemanifest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/version"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/provider"
+)
+
+func TestGetStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		expectedErr func(error) bool
+		description string
+	}{
+		{
+			name:        "etcd",
+			description: "Present",
+		},
+		{
+			name:        "missing",
+			expectedErr: k8serrors.IsNotFound,
+			description: "Missing",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			prov := provider.NewFakeProvider()
+			manifest := manifestWithMinKubeVersions("etcd", "1.10.0")
+			manifest.Status.CatalogSourceName = "catalog"
+			manifest.Status.DefaultChannelName = "alpha"
+			manifest.Status.Channels[0].Name = "alpha"
+			manifest.Status.Channels[0].CurrentCSVName = "etcdoperator.v0.9.2"
+			prov.Add(manifest)
+			storage := NewStorage(v1alpha1.Resource("packagemanifests"), prov, &version.Info{GitVersion: "v1.11.0"})
+			status := NewStatusStorage(storage)
+
+			ctx := genericapirequest.WithNamespace(genericapirequest.NewContext(), "default")
+			res, err := status.Get(ctx, test.name, &metav1.GetOptions{})
+			if test.expectedErr != nil {
+				require.True(t, test.expectedErr(err), "unexpected error: %v", err)
+				return
+			}
+			require.NoError(t, err)
+
+			full, err := storage.Get(ctx, test.name, &metav1.GetOptions{})
+			require.NoError(t, err)
+			expected := full.(*v1alpha1.PackageManifest)
+
+			got, ok := res.(*v1alpha1.PackageManifest)
+			require.True(t, ok)
+			require.Equal(t, expected.ObjectMeta, got.ObjectMeta)
+			require.Equal(t, expected.Status, got.Status)
+			require.Equal(t, v1alpha1.PackageManifestSpec{}, got.Spec)
+			require.Equal(t, "true", got.GetLabels()[CompatibleWithClusterLabel])
+		})
+	}
+}
@@ -2,8 +2,13 @@ package packagemanifest
 
 import (
 	"context"
+	"fmt"
+	"strconv"
 	"sync"
 
+	"github.com/coreos/go-semver/semver"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/watch"
 
@@ -11,13 +16,43 @@ import (
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/provider"
 )
 
+// WatchOverflowPolicy determines what a Watcher does when its consumer falls more than the maximum backlog of events
+// behind
+type WatchOverflowPolicy string
+
+const (
+	// WatchOverflowClose ends the watch with a 410 Expired error event, forcing the consumer to relist
+	WatchOverflowClose WatchOverflowPolicy = "close"
+	// WatchOverflowDropOldest discards the oldest undelivered event to make room for the newest one
+	WatchOverflowDropOldest WatchOverflowPolicy = "drop-oldest"
+
+	// DefaultWatchBacklog is the default maximum number of undelivered events buffered for each watch
+	DefaultWatchBacklog = 100
+
+	// DefaultMaxWatchesPerNamespace is the default maximum number of active watches in each namespace; 0 allows any
+	// number
+	DefaultMaxWatchesPerNamespace = 0
+)
+
 type Watcher struct {
 	namespace       string
-	name            string
+	fieldSelector   fields.Selector
 	resourceVersion string
 	labelSelector   labels.Selector
+	overflowPolicy  WatchOverflowPolicy
 
 	source provider.PackageManifestProvider
+	// kubeVersion is the cluster's Kubernetes version that manifests are labeled as compatible with, or nil if unknown
+	kubeVersion *semver.Version
+	// add, modify and delete are the source's changes, once subscribed to
+	add, modify, delete provider.PackageChan
+	// versioned is true if the source versions its snapshots
+	versioned bool
+	// since is the resourceVersion the watch resumed from, if the source versions its snapshots. Changes the source
+	// made at or before it are already known to the consumer, and replayed holds the changes after it that were
+	// replayed, so that neither is delivered again when received from the subscription.
+	since    uint64
+	replayed map[eventKey]struct{}
 
 	stopped bool
 	stop    chan struct{}
@@ -27,35 +62,69 @@ type Watcher struct {
 
 var _ watch.Interface = &Watcher{}
 
-func NewWatcher(namespace, name, resourceVersion string, labelSelector labels.Selector, source provider.PackageManifestProvider) *Watcher {
+// NewWatcher returns a Watcher that buffers up to maxBacklog undelivered events, applying overflowPolicy once the
+// buffer is full. A maxBacklog less than 1 uses DefaultWatchBacklog.
+// If resourceVersion is set and the source implements provider.EventHistory, the watch starts by replaying the events
+// the source recorded after it.
+// If kubeVersion is set, manifests are labeled with CompatibleWithClusterLabel before they're matched against the
+// selectors, as they are by List and Get.
+func NewWatcher(namespace string, fieldSelector fields.Selector, resourceVersion string, labelSelector labels.Selector, source provider.PackageManifestProvider, kubeVersion *semver.Version, maxBacklog int, overflowPolicy WatchOverflowPolicy) *Watcher {
+	if maxBacklog < 1 {
+		maxBacklog = DefaultWatchBacklog
+	}
+
 	return &Watcher{
 		namespace:       namespace,
+		fieldSelector:   fieldSelector,
 		resourceVersion: resourceVersion,
 		labelSelector:   labelSelector,
+		overflowPolicy:  overflowPolicy,
 		source:          source,
+		kubeVersion:     kubeVersion,
 		stopped:         false,
 		stop:            make(chan struct{}),
-		result:          make(chan watch.Event),
+		result:          make(chan watch.Event, maxBacklog),
 		mu:              sync.Mutex{},
 	}
 }
 
-// Run is a blocking method which starts the watch by subscribing to the source.
-// Should run in a goroutine.
-func (w *Watcher) Run(ctx context.Context) {
+// Subscribe subscribes the watch to the source's changes in every namespace, which are filtered by the watch's
+// namespace and selectors as they're delivered. Changes made after Subscribe returns are delivered once Run starts.
+func (w *Watcher) Subscribe() error {
+	// checked before subscribing, since a source may block sending changes until Run receives them
+	_, w.versioned = provider.SnapshotVersion(w.source)
 	add, modify, delete, err := w.source.Subscribe(w.stop)
 	if err != nil {
-		return
+		return err
 	}
+	w.add, w.modify, w.delete = add, modify, delete
+	return nil
+}
+
+// Run is a blocking method which starts the watch, subscribing to the source if Subscribe hasn't been called.
+// Should run in a goroutine.
+func (w *Watcher) Run(ctx context.Context) {
+	if w.add == nil {
+		if err := w.Subscribe(); err != nil {
+			return
+		}
+	}
+	w.replay()
 
 	for {
 		select {
-		case manifest := <-add:
-			w.Add(manifest)
-		case manifest := <-modify:
-			w.Modify(manifest)
-		case manifest := <-delete:
-			w.Delete(manifest)
+		case manifest := <-w.add:
+			if !w.delivered(watch.Added, manifest) {
+				w.Add(manifest)
+			}
+		case manifest := <-w.modify:
+			if !w.delivered(watch.Modified, manifest) {
+				w.Modify(manifest)
+			}
+		case manifest := <-w.delete:
+			if !w.delivered(watch.Deleted, manifest) {
+				w.Delete(manifest)
+			}
 		case <-w.stop:
 		case <-ctx.Done():
 			return
@@ -63,6 +132,95 @@ func (w *Watcher) Run(ctx context.Context) {
 	}
 }
 
+// replay delivers the events the source recorded after the watch's resourceVersion, so that a consumer resuming a
+// watch doesn't have to relist. If the source no longer has all of them, the watch ends with a 410 Expired error event
+// so that the consumer relists.
+// Replay happens after subscribing so that no event is missed. An event made while the watch starts is both replayed
+// and received from the subscription; if the source versions its snapshots, the received copy is dropped, otherwise
+// it's delivered twice.
+func (w *Watcher) replay() {
+	history, ok := w.source.(provider.EventHistory)
+	if !ok || w.resourceVersion == "" || w.resourceVersion == "0" {
+		return
+	}
+	resourceVersion, err := strconv.ParseUint(w.resourceVersion, 10, 64)
+	if err != nil {
+		return
+	}
+
+	events, ok := history.EventsSince(w.namespace, resourceVersion)
+	if !ok {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if !w.stopped {
+			w.expire(fmt.Sprintf("too old resource version: %d", resourceVersion))
+		}
+		return
+	}
+
+	if w.versioned {
+		w.since = resourceVersion
+		w.replayed = map[eventKey]struct{}{}
+		for _, event := range events {
+			w.replayed[keyFor(event.Type, event.Manifest)] = struct{}{}
+		}
+	}
+
+	for _, event := range events {
+		switch event.Type {
+		case watch.Added:
+			w.Add(event.Manifest)
+		case watch.Modified:
+			w.Modify(event.Manifest)
+		case watch.Deleted:
+			w.Delete(event.Manifest)
+		}
+	}
+}
+
+// eventKey identifies a change made by a source that versions its snapshots
+type eventKey struct {
+	eventType              watch.EventType
+	resourceVersion        string
+	namespace              string
+	catalogSourceName      string
+	catalogSourceNamespace string
+	name                   string
+}
+
+func keyFor(eventType watch.EventType, manifest v1alpha1.PackageManifest) eventKey {
+	return eventKey{
+		eventType:              eventType,
+		resourceVersion:        manifest.GetResourceVersion(),
+		namespace:              manifest.GetNamespace(),
+		catalogSourceName:      manifest.Status.CatalogSourceName,
+		catalogSourceNamespace: manifest.Status.CatalogSourceNamespace,
+		name:                   manifest.GetName(),
+	}
+}
+
+// delivered returns true if a change received from the subscription was already known to the consumer when the watch
+// resumed, or has been replayed since. It's only called by Run, so it needs no lock.
+func (w *Watcher) delivered(eventType watch.EventType, manifest v1alpha1.PackageManifest) bool {
+	if w.replayed == nil {
+		return false
+	}
+	resourceVersion, err := strconv.ParseUint(manifest.GetResourceVersion(), 10, 64)
+	if err != nil {
+		return false
+	}
+	if resourceVersion <= w.since {
+		return true
+	}
+
+	key := keyFor(eventType, manifest)
+	if _, ok := w.replayed[key]; ok {
+		delete(w.replayed, key)
+		return true
+	}
+	return false
+}
+
 func (w *Watcher) Stop() {
 	w.stop <- struct{}{}
 	w.mu.Lock()
@@ -80,30 +238,66 @@ func (w *Watcher) ResultChan() <-chan watch.Event {
 }
 
 func (w *Watcher) Add(manifest v1alpha1.PackageManifest) {
-	// TODO: Handle `resourceVersion`
-	if matches(manifest, w.name, w.namespace, w.labelSelector) {
+	manifest = withCompatibility(inNamespace(manifest, w.namespace), w.kubeVersion)
+	if matches(manifest, w.namespace, w.labelSelector, w.fieldSelector) {
 		w.send(watch.Event{Type: watch.Added, Object: &manifest})
 	}
 }
 
 func (w *Watcher) Modify(manifest v1alpha1.PackageManifest) {
-	// TODO: Handle `resourceVersion`
-	if matches(manifest, w.name, w.namespace, w.labelSelector) {
+	manifest = withCompatibility(inNamespace(manifest, w.namespace), w.kubeVersion)
+	if matches(manifest, w.namespace, w.labelSelector, w.fieldSelector) {
 		w.send(watch.Event{Type: watch.Modified, Object: &manifest})
 	}
 }
 
 func (w *Watcher) Delete(lastValue v1alpha1.PackageManifest) {
-	// TODO: Handle `resourceVersion`
-	if matches(lastValue, w.name, w.namespace, w.labelSelector) {
+	lastValue = withCompatibility(inNamespace(lastValue, w.namespace), w.kubeVersion)
+	if matches(lastValue, w.namespace, w.labelSelector, w.fieldSelector) {
 		w.send(watch.Event{Type: watch.Deleted, Object: &lastValue})
 	}
 }
 
+// send delivers an event without blocking, applying the overflow policy if the consumer has fallen too far behind.
+// Since send is the only writer to the result channel and holds the lock, room made by removing an event can't be
+// taken by another writer.
 func (w *Watcher) send(e watch.Event) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
-	if !w.stopped {
+	if w.stopped {
+		return
+	}
+
+	select {
+	case w.result <- e:
+		return
+	default:
+	}
+
+	switch w.overflowPolicy {
+	case WatchOverflowDropOldest:
+		select {
+		case <-w.result:
+		default:
+		}
 		w.result <- e
+	default:
+		w.expire(fmt.Sprintf("watch consumer fell more than %d events behind", cap(w.result)))
+	}
+}
+
+// expire ends the watch with a 410 Expired error event, discarding the backlog so the consumer sees the error next.
+// Callers must hold the lock and check that the watch isn't stopped.
+func (w *Watcher) expire(message string) {
+	for drained := false; !drained; {
+		select {
+		case <-w.result:
+		default:
+			drained = true
+		}
 	}
+	status := k8serrors.NewResourceExpired(message).ErrStatus
+	w.result <- watch.Event{Type: watch.Error, Object: &status}
+	close(w.result)
+	w.stopped = true
 }
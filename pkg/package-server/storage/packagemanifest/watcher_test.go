@@ -1,13 +1,22 @@
 package packagemanifest
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
 	"testing"
+	"time"
 
 	"k8s.io/apimachinery/pkg/labels"
 
 	"github.com/stretchr/testify/require"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/version"
 	"k8s.io/apimachinery/pkg/watch"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
 
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/provider"
@@ -173,3 +182,359 @@ func TestResultChan(t *testing.T) {
 
 	require.True(t, open)
 }
+
+func TestSendOverflow(t *testing.T) {
+	tests := []struct {
+		policy      WatchOverflowPolicy
+		expected    []string
+		expired     bool
+		description string
+	}{
+		{
+			policy:      WatchOverflowClose,
+			expired:     true,
+			description: "CloseWithExpired",
+		},
+		{
+			policy:      "",
+			expired:     true,
+			description: "DefaultsToClose",
+		},
+		{
+			policy:      WatchOverflowDropOldest,
+			expected:    []string{"kafka", "prometheus"},
+			description: "DropOldest",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			watcher := NewWatcher(v1.NamespaceAll, nil, "", labels.Everything(), provider.NewFakeProvider(), nil, 2, test.policy)
+
+			// the consumer is stalled until every manifest has been sent
+			for _, name := range []string{"etcd", "kafka", "prometheus"} {
+				watcher.Add(packageManifest(packageValue{name: name, namespace: "default"}))
+			}
+
+			received := []watch.Event{}
+			for {
+				select {
+				case event, open := <-watcher.ResultChan():
+					if !open {
+						require.True(t, test.expired, "watch closed unexpectedly")
+						require.Len(t, received, 1)
+						require.Equal(t, watch.Error, received[0].Type)
+						status, ok := received[0].Object.(*v1.Status)
+						require.True(t, ok)
+						require.Equal(t, int32(http.StatusGone), status.Code)
+						require.Equal(t, v1.StatusReasonExpired, status.Reason)
+
+						// later events are discarded rather than blocking or panicking
+						watcher.Add(packageManifest(packageValue{name: "vault", namespace: "default"}))
+						return
+					}
+					received = append(received, event)
+				default:
+					require.False(t, test.expired, "watch not closed")
+					names := []string{}
+					for _, event := range received {
+						require.Equal(t, watch.Added, event.Type)
+						names = append(names, event.Object.(*v1alpha1.PackageManifest).GetName())
+					}
+					require.Equal(t, test.expected, names)
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestReplay(t *testing.T) {
+	tests := []struct {
+		resourceVersion string
+		namespace       string
+		expected        []string
+		expired         bool
+		description     string
+	}{
+		{
+			resourceVersion: "2",
+			namespace:       v1.NamespaceAll,
+			expected:        []string{"prometheus", "vault"},
+			description:     "WithinWindow",
+		},
+		{
+			resourceVersion: "3",
+			namespace:       "default",
+			expected:        []string{"vault"},
+			description:     "WithinWindowInNamespace",
+		},
+		{
+			resourceVersion: "4",
+			namespace:       v1.NamespaceAll,
+			expected:        []string{},
+			description:     "Current",
+		},
+		{
+			resourceVersion: "",
+			namespace:       v1.NamespaceAll,
+			expected:        []string{},
+			description:     "NoResourceVersion",
+		},
+		{
+			resourceVersion: "1",
+			namespace:       v1.NamespaceAll,
+			expired:         true,
+			description:     "ExpiredRelists",
+		},
+		{
+			resourceVersion: "1",
+			namespace:       "other",
+			expected:        []string{},
+			description:     "ExpiredInOtherNamespace",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			prov := provider.NewFakeProvider()
+			prov.SetEventHistorySize(2)
+			for _, name := range []string{"etcd", "kafka", "prometheus", "vault"} {
+				prov.Add(packageManifest(packageValue{name: name, namespace: "default"}))
+			}
+
+			watcher := NewWatcher(test.namespace, nil, test.resourceVersion, labels.Everything(), prov, nil, 0, WatchOverflowClose)
+			watcher.replay()
+
+			received := []watch.Event{}
+			for {
+				select {
+				case event, open := <-watcher.ResultChan():
+					if !open {
+						require.True(t, test.expired, "watch closed unexpectedly")
+						require.Len(t, received, 1)
+						require.Equal(t, watch.Error, received[0].Type)
+						status, ok := received[0].Object.(*v1.Status)
+						require.True(t, ok)
+						require.Equal(t, int32(http.StatusGone), status.Code)
+						require.Equal(t, v1.StatusReasonExpired, status.Reason)
+						return
+					}
+					received = append(received, event)
+				default:
+					require.False(t, test.expired, "watch not closed")
+					names := []string{}
+					for _, event := range received {
+						require.Equal(t, watch.Added, event.Type)
+						manifest := event.Object.(*v1alpha1.PackageManifest)
+						require.NotEmpty(t, manifest.GetResourceVersion())
+						names = append(names, manifest.GetName())
+					}
+					require.Equal(t, test.expected, names)
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestWatchNamespaces(t *testing.T) {
+	tests := []struct {
+		namespace   string
+		expected    []string
+		description string
+	}{
+		{
+			namespace:   v1.NamespaceAll,
+			expected:    []string{"default/etcd", "local/prometheus", "default/prometheus", "local/etcd", "/vault"},
+			description: "AllNamespaces",
+		},
+		{
+			namespace:   "local",
+			expected:    []string{"local/prometheus", "local/etcd", "local/vault"},
+			description: "SingleNamespace",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			prov := provider.NewFakeProvider()
+			storage := NewStorage(v1alpha1.Resource("packagemanifests"), prov, nil)
+
+			ctx, cancel := context.WithCancel(genericapirequest.WithNamespace(genericapirequest.NewContext(), test.namespace))
+			defer cancel()
+			watcher, err := storage.Watch(ctx, &metainternalversion.ListOptions{})
+			require.NoError(t, err)
+
+			// the watch is subscribed once established, so none of these changes are missed
+			go func() {
+				prov.Add(packageManifest(packageValue{name: "etcd", namespace: "default"}))
+				prov.Add(packageManifest(packageValue{name: "prometheus", namespace: "local"}))
+				prov.Modify(packageManifest(packageValue{name: "prometheus", namespace: "default"}))
+				prov.Delete(packageManifest(packageValue{name: "etcd", namespace: "local"}))
+				// vault is from a globally-sourced catalog, so it's served in whichever namespace is watched
+				prov.Add(packageManifest(packageValue{name: "vault"}))
+			}()
+
+			received := []string{}
+			for len(received) < len(test.expected) {
+				select {
+				case event := <-watcher.ResultChan():
+					manifest := event.Object.(*v1alpha1.PackageManifest)
+					received = append(received, manifest.GetNamespace()+"/"+manifest.GetName())
+				case <-time.After(time.Second):
+					t.Fatalf("received %v, expected %v", received, test.expected)
+				}
+			}
+			require.Equal(t, test.expected, received)
+		})
+	}
+}
+
+func TestWatchCatalogRefresh(t *testing.T) {
+	catalogPackageManifest := func(name string) v1alpha1.PackageManifest {
+		manifest := packageManifest(packageValue{name: name, namespace: "default"})
+		manifest.Status.CatalogSourceName = "operators"
+		manifest.Status.CatalogSourceNamespace = "default"
+		return manifest
+	}
+
+	prov := provider.NewFakeProvider()
+	prov.Add(catalogPackageManifest("etcd"))
+	prov.Add(catalogPackageManifest("prometheus"))
+	storage := NewStorage(v1alpha1.Resource("packagemanifests"), prov, nil)
+
+	ctx, cancel := context.WithCancel(genericapirequest.WithNamespace(genericapirequest.NewContext(), "default"))
+	defer cancel()
+	watcher, err := storage.Watch(ctx, &metainternalversion.ListOptions{})
+	require.NoError(t, err)
+
+	go prov.Refresh("operators", "default", []v1alpha1.PackageManifest{catalogPackageManifest("prometheus"), catalogPackageManifest("vault")})
+
+	expected := []string{"DELETED etcd", "MODIFIED prometheus", "ADDED vault"}
+	received := []string{}
+	for len(received) < len(expected) {
+		select {
+		case event := <-watcher.ResultChan():
+			manifest := event.Object.(*v1alpha1.PackageManifest)
+			received = append(received, string(event.Type)+" "+manifest.GetName())
+		case <-time.After(time.Second):
+			t.Fatalf("received %v, expected %v", received, expected)
+		}
+	}
+	require.Equal(t, expected, received)
+
+	res, err := storage.List(ctx, &metainternalversion.ListOptions{})
+	require.NoError(t, err)
+	names := []string{}
+	for _, manifest := range res.(*v1alpha1.PackageManifestList).Items {
+		names = append(names, manifest.GetName())
+	}
+	require.ElementsMatch(t, []string{"prometheus", "vault"}, names)
+}
+
+// changedWhileStarting is a provider that makes a change while a watch starts, once the watch has subscribed but
+// before it replays the changes it missed
+type changedWhileStarting struct {
+	*provider.FakeProvider
+	change func()
+	once   sync.Once
+}
+
+func (p *changedWhileStarting) EventsSince(namespace string, resourceVersion uint64) ([]provider.Event, bool) {
+	p.once.Do(func() {
+		before, _ := p.FakeProvider.EventsSince(namespace, resourceVersion)
+		// the change is recorded before it's sent, and it can't be sent until the watch runs
+		go p.change()
+		for {
+			if after, _ := p.FakeProvider.EventsSince(namespace, resourceVersion); len(after) > len(before) {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	})
+	return p.FakeProvider.EventsSince(namespace, resourceVersion)
+}
+
+func TestListWatchHandoff(t *testing.T) {
+	fake := provider.NewFakeProvider()
+	prov := &changedWhileStarting{
+		FakeProvider: fake,
+		change:       func() { fake.Add(packageManifest(packageValue{name: "vault", namespace: "default"})) },
+	}
+	fake.Add(packageManifest(packageValue{name: "etcd", namespace: "default"}))
+	fake.Add(packageManifest(packageValue{name: "kafka", namespace: "default"}))
+	storage := NewStorage(v1alpha1.Resource("packagemanifests"), prov, nil)
+
+	ctx, cancel := context.WithCancel(genericapirequest.WithNamespace(genericapirequest.NewContext(), "default"))
+	defer cancel()
+	res, err := storage.List(ctx, &metainternalversion.ListOptions{})
+	require.NoError(t, err)
+	list := res.(*v1alpha1.PackageManifestList)
+	require.Equal(t, "2", list.GetResourceVersion())
+	require.Len(t, list.Items, 2)
+
+	// a watch can't resume from a snapshot the provider hasn't reached
+	_, err = storage.Watch(ctx, &metainternalversion.ListOptions{ResourceVersion: "3"})
+	require.True(t, k8serrors.IsTimeout(err), "expected Timeout, got %v", err)
+
+	// prometheus is added between the list and the watch, vault while the watch starts, and zookeeper once it's running
+	fake.Add(packageManifest(packageValue{name: "prometheus", namespace: "default"}))
+	watcher, err := storage.Watch(ctx, &metainternalversion.ListOptions{ResourceVersion: list.GetResourceVersion()})
+	require.NoError(t, err)
+
+	expected := []string{"ADDED prometheus 3", "ADDED vault 4", "ADDED zookeeper 5"}
+	received := []string{}
+	for len(received) < len(expected) {
+		select {
+		case event := <-watcher.ResultChan():
+			manifest := event.Object.(*v1alpha1.PackageManifest)
+			received = append(received, fmt.Sprintf("%s %s %s", event.Type, manifest.GetName(), manifest.GetResourceVersion()))
+			if manifest.GetName() == "vault" {
+				go fake.Add(packageManifest(packageValue{name: "zookeeper", namespace: "default"}))
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("received %v, expected %v", received, expected)
+		}
+	}
+	require.Equal(t, expected, received)
+
+	// no change is delivered twice
+	select {
+	case event := <-watcher.ResultChan():
+		t.Fatalf("unexpected event %s %s", event.Type, event.Object.(*v1alpha1.PackageManifest).GetName())
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestWatchCompatibleWithCluster(t *testing.T) {
+	prov := provider.NewFakeProvider()
+	storage := NewStorage(v1alpha1.Resource("packagemanifests"), prov, &version.Info{GitVersion: "v1.10.3+abcdef"})
+	selector, err := labels.Parse(CompatibleWithClusterLabel + "=true")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(genericapirequest.WithNamespace(genericapirequest.NewContext(), "default"))
+	defer cancel()
+	watcher, err := storage.Watch(ctx, &metainternalversion.ListOptions{LabelSelector: selector})
+	require.NoError(t, err)
+	defer watcher.Stop()
+
+	// watch events are labeled, and selected by the label, as List and Get results are
+	prov.Add(manifestWithMinKubeVersions("newer", "1.11.0"))
+	prov.Add(manifestWithMinKubeVersions("older", "1.9.0"))
+	select {
+	case event := <-watcher.ResultChan():
+		manifest := event.Object.(*v1alpha1.PackageManifest)
+		require.Equal(t, watch.Added, event.Type)
+		require.Equal(t, "older", manifest.GetName())
+		require.Equal(t, "true", manifest.GetLabels()[CompatibleWithClusterLabel])
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+	}
+
+	select {
+	case event := <-watcher.ResultChan():
+		t.Fatalf("unexpected event %s %s", event.Type, event.Object.(*v1alpha1.PackageManifest).GetName())
+	case <-time.After(50 * time.Millisecond):
+	}
+}
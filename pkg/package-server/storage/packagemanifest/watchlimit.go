@@ -0,0 +1,65 @@
+package packagemanifest
+
+import (
+	"fmt"
+	"sync"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// watchLimiter caps the number of active watches in each namespace, so that a single client can't subscribe the
+// provider to an unbounded number of watches. Watches of all namespaces are counted under the "" namespace.
+type watchLimiter struct {
+	max    int
+	active map[string]int
+	mu     sync.Mutex
+}
+
+// newWatchLimiter returns a watchLimiter allowing up to max active watches in each namespace. A max less than 1 allows
+// any number of watches.
+func newWatchLimiter(max int) *watchLimiter {
+	return &watchLimiter{
+		max:    max,
+		active: map[string]int{},
+	}
+}
+
+// acquire reserves a watch slot in the namespace, returning a function that releases it. The release function may be
+// called any number of times, but only releases the slot once. If the namespace has no free slot, a TooManyRequests
+// error is returned instead.
+func (l *watchLimiter) acquire(namespace string) (func(), error) {
+	if l.max < 1 {
+		return func() {}, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.active[namespace] >= l.max {
+		return nil, k8serrors.NewTooManyRequests(fmt.Sprintf("namespace %q already has the maximum of %d active watches", namespace, l.max), 1)
+	}
+	l.active[namespace]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.active[namespace]--
+			if l.active[namespace] == 0 {
+				delete(l.active, namespace)
+			}
+		})
+	}, nil
+}
+
+// limitedWatch releases its watch slot when it's stopped
+type limitedWatch struct {
+	watch.Interface
+	release func()
+}
+
+func (w *limitedWatch) Stop() {
+	w.Interface.Stop()
+	w.release()
+}
@@ -0,0 +1,117 @@
+package packagemanifest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	"k8s.io/apimachinery/pkg/watch"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/packagemanifest/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/provider"
+)
+
+func TestWatchLimitPerNamespace(t *testing.T) {
+	prov := provider.NewFakeProvider()
+	storage := NewStorage(v1alpha1.Resource("packagemanifests"), prov, nil)
+	storage.SetMaxWatchesPerNamespace(2)
+
+	ctx, cancel := context.WithCancel(genericapirequest.NewContext())
+	defer cancel()
+	watchIn := func(namespace string) (watch.Interface, error) {
+		return storage.Watch(genericapirequest.WithNamespace(ctx, namespace), &metainternalversion.ListOptions{})
+	}
+
+	first, err := watchIn("default")
+	require.NoError(t, err)
+	_, err = watchIn("default")
+	require.NoError(t, err)
+
+	// the third watch in the namespace is rejected
+	_, err = watchIn("default")
+	require.True(t, k8serrors.IsTooManyRequests(err), "expected TooManyRequests, got %v", err)
+
+	// other namespaces, and watches of all namespaces, have their own slots
+	_, err = watchIn("local")
+	require.NoError(t, err)
+	_, err = watchIn("")
+	require.NoError(t, err)
+
+	// stopping a watch frees its slot
+	first.Stop()
+	_, err = watchIn("default")
+	require.NoError(t, err)
+	_, err = watchIn("default")
+	require.True(t, k8serrors.IsTooManyRequests(err), "expected TooManyRequests, got %v", err)
+}
+
+func TestWatchLimitReleasedWhenRequestEnds(t *testing.T) {
+	prov := provider.NewFakeProvider()
+	storage := NewStorage(v1alpha1.Resource("packagemanifests"), prov, nil)
+	storage.SetMaxWatchesPerNamespace(1)
+
+	ctx, cancel := context.WithCancel(genericapirequest.WithNamespace(genericapirequest.NewContext(), "default"))
+	_, err := storage.Watch(ctx, &metainternalversion.ListOptions{})
+	require.NoError(t, err)
+
+	next := genericapirequest.WithNamespace(genericapirequest.NewContext(), "default")
+	_, err = storage.Watch(next, &metainternalversion.ListOptions{})
+	require.True(t, k8serrors.IsTooManyRequests(err), "expected TooManyRequests, got %v", err)
+
+	// the slot is freed once the watch's request ends, even if it isn't stopped
+	cancel()
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, err = storage.Watch(next, &metainternalversion.ListOptions{}); err == nil || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.NoError(t, err)
+}
+
+func TestWatchLimitReleasedOnSubscribeError(t *testing.T) {
+	prov := provider.NewFakeProvider()
+	storage := NewStorage(v1alpha1.Resource("packagemanifests"), prov, nil)
+	storage.SetMaxWatchesPerNamespace(1)
+	ctx, cancel := context.WithCancel(genericapirequest.WithNamespace(genericapirequest.NewContext(), "default"))
+	defer cancel()
+
+	// watches that fail to subscribe don't hold a slot
+	prov.SetError(provider.NewTransientError(context.DeadlineExceeded))
+	_, err := storage.Watch(ctx, &metainternalversion.ListOptions{})
+	require.True(t, k8serrors.IsServiceUnavailable(err))
+	prov.SetError(nil)
+	_, err = storage.Watch(ctx, &metainternalversion.ListOptions{})
+	require.NoError(t, err)
+}
+
+func TestWatchLimiterUnlimited(t *testing.T) {
+	limiter := newWatchLimiter(0)
+	for i := 0; i < 10; i++ {
+		_, err := limiter.acquire("default")
+		require.NoError(t, err)
+	}
+	require.Empty(t, limiter.active)
+}
+
+func TestWatchLimiterReleaseOnce(t *testing.T) {
+	limiter := newWatchLimiter(2)
+	release, err := limiter.acquire("default")
+	require.NoError(t, err)
+	_, err = limiter.acquire("default")
+	require.NoError(t, err)
+
+	// releasing the same slot twice only frees it once
+	release()
+	release()
+	require.Equal(t, 1, limiter.active["default"])
+	_, err = limiter.acquire("default")
+	require.NoError(t, err)
+	_, err = limiter.acquire("default")
+	require.Error(t, err)
+}